@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestAttachedServices(t *testing.T) {
+	composeFile := &compose.ComposeFile{Services: map[string]*compose.Service{
+		"web":    {Image: "nginx"},
+		"db":     {Image: "postgres"},
+		"worker": {Image: "busybox"},
+	}}
+	tests := []struct {
+		name     string
+		started  []string
+		attach   []string
+		noAttach []string
+		want     string
+	}{
+		{"all started", nil, nil, nil, "db,web,worker"},
+		{"named services", []string{"web", "db"}, nil, nil, "db,web"},
+		{"attach", nil, []string{"web"}, nil, "web"},
+		{"no attach", nil, nil, []string{"db"}, "web,worker"},
+		{"attach minus no attach", nil, []string{"web", "db"}, []string{"db"}, "web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(attachedServices(composeFile, tt.started, tt.attach, tt.noAttach), ",")
+			if got != tt.want {
+				t.Errorf("attached %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
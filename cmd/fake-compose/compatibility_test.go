@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+const compatibilityProject = `version: "3.8"
+services:
+  web:
+    image: nginx
+    deploy:
+      replicas: 3
+      resources:
+        limits:
+          cpu: "0.5"
+          memory: 512m
+`
+
+// createdReplicas counts the containers created for service
+func createdReplicas(hook *test.Hook, service string) int {
+	n := 0
+	for _, entry := range hook.AllEntries() {
+		if strings.HasPrefix(entry.Message, "[STUB] Creating container") && strings.Contains(entry.Message, "for service "+service+" ") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCompatibilityReplicas(t *testing.T) {
+	file := writeProject(t, compatibilityProject)
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"without the flag", []string{"-f", file, "up", "-d"}, 1},
+		{"with the flag", []string{"--compatibility", "-f", file, "up", "-d"}, 3},
+		{"scale wins", []string{"--compatibility", "-f", file, "up", "-d", "--scale", "web=2"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hook *test.Hook
+			var err error
+			captureStdout(t, func() { hook, err = runCLI(t, tt.args...) })
+			if err != nil {
+				t.Fatalf("up: %v", err)
+			}
+			if got := createdReplicas(hook, "web"); got != tt.want {
+				t.Errorf("created %d web containers, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompatibilityLimitsOnlyWithFlag(t *testing.T) {
+	file := writeProject(t, `version: "3.8"
+services:
+  web:
+    image: nginx
+    deploy:
+      resources:
+        limits:
+          cpu: lots
+`)
+	// Deploy limits are ignored without the flag, so the invalid one is
+	// only reported with it
+	if _, err := runCLIOutput(t, "-f", file, "create"); err != nil {
+		t.Errorf("create without --compatibility: %v", err)
+	}
+	_, err := runCLIOutput(t, "--compatibility", "-f", file, "create")
+	if err == nil || !strings.Contains(err.Error(), "--compatibility: service web: deploy.resources.limits.cpu") {
+		t.Errorf("create --compatibility = %v, want the invalid cpu limit reported", err)
+	}
+}
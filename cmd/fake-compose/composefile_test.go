@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/internal/parser"
+)
+
+func TestMissingComposeFile(t *testing.T) {
+	t.Setenv("COMPOSE_FILE", "")
+	chdir(t, t.TempDir())
+
+	_, err := runCLI(t, "config")
+	var notFound *parser.FileNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("config = %v, want a FileNotFoundError", err)
+	}
+	for _, part := range []string{"docker-compose.yml not found", "use -f", "compose.yaml, compose.yml, docker-compose.yaml or docker-compose.yml"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("error %q does not contain %q", err, part)
+		}
+	}
+}
+
+func TestMissingComposeFileFlag(t *testing.T) {
+	_, err := runCLI(t, "-f", "missing.yml", "config")
+	var notFound *parser.FileNotFoundError
+	if !errors.As(err, &notFound) || notFound.Filename != "missing.yml" {
+		t.Errorf("config -f missing.yml = %v, want a FileNotFoundError for missing.yml", err)
+	}
+}
+
+// serviceProject is a compose file with a single service named name
+func serviceProject(name string) string {
+	return `version: "3.8"
+services:
+  ` + name + `:
+    image: nginx
+`
+}
+
+func TestDefaultComposeFilePrecedence(t *testing.T) {
+	t.Setenv("COMPOSE_FILE", "")
+	tests := []struct {
+		present []string
+		want    string
+	}{
+		{[]string{"docker-compose.yml"}, "docker-compose.yml"},
+		{[]string{"docker-compose.yml", "docker-compose.yaml"}, "docker-compose.yaml"},
+		{[]string{"docker-compose.yml", "compose.yml"}, "compose.yml"},
+		{[]string{"docker-compose.yml", "compose.yml", "compose.yaml"}, "compose.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.present, ","), func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.present {
+				// Each file's service is named after the file
+				service := strings.NewReplacer(".", "-").Replace(name)
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(serviceProject(service)), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			chdir(t, dir)
+
+			out, err := runCLIOutput(t, "config")
+			if err != nil {
+				t.Fatalf("config: %v", err)
+			}
+			for _, name := range tt.present {
+				service := strings.NewReplacer(".", "-").Replace(name) + ":"
+				if got := strings.Contains(out, service); got != (name == tt.want) {
+					t.Errorf("service from %s in the output = %v, want only %s used:\n%s", name, got, tt.want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultComposeFileMultipleWarning(t *testing.T) {
+	t.Setenv("COMPOSE_FILE", "")
+	dir := t.TempDir()
+	for _, name := range []string{"compose.yaml", "docker-compose.yml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(serviceProject("web")), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chdir(t, dir)
+
+	hook, err := runCLI(t, "validate")
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	want := "Found multiple compose files with default names: compose.yaml, docker-compose.yml. Using compose.yaml"
+	if !logged(hook, want) {
+		t.Errorf("did not warn %q", want)
+	}
+
+	// An explicit -f is used as given, without probing
+	hook, err = runCLI(t, "-f", "docker-compose.yml", "validate")
+	if err != nil {
+		t.Fatalf("validate -f: %v", err)
+	}
+	if logged(hook, want) {
+		t.Error("warned about default files with -f given")
+	}
+}
+
+func TestDefaultComposeFileSingleNoWarning(t *testing.T) {
+	t.Setenv("COMPOSE_FILE", "")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte(serviceProject("web")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, dir)
+
+	hook, err := runCLI(t, "validate")
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	for _, entry := range hook.AllEntries() {
+		if strings.HasPrefix(entry.Message, "Found multiple compose files") {
+			t.Errorf("warned %q with a single compose file", entry.Message)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertGolden(t *testing.T) {
+	output, err := runCLIOutput(t, "-f", filepath.Join("testdata", "convert.yml"), "--env-file", filepath.Join("testdata", "convert.env"), "-p", "demo", "convert")
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	assertGolden(t, "convert", []byte(output))
+}
+
+func TestConfigNoInterpolate(t *testing.T) {
+	file := writeProject(t, `version: "3.8"
+services:
+  web:
+    image: "nginx:${FOO}"
+`)
+	t.Setenv("FOO", "1.25")
+	for _, command := range []string{"config", "convert"} {
+		output, err := runCLIOutput(t, "-f", file, command, "--no-interpolate")
+		if err != nil {
+			t.Fatalf("%s --no-interpolate: %v", command, err)
+		}
+		if !strings.Contains(output, "nginx:${FOO}") {
+			t.Errorf("%s --no-interpolate output lacks ${FOO}:\n%s", command, output)
+		}
+
+		output, err = runCLIOutput(t, "-f", file, command)
+		if err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+		if !strings.Contains(output, "nginx:1.25") || strings.Contains(output, "${FOO}") {
+			t.Errorf("%s output did not expand ${FOO}:\n%s", command, output)
+		}
+	}
+}
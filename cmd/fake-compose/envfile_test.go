@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// envProject writes a compose file whose images reveal the values of TAG,
+// BASE and PORT, plus the given files next to it
+func envProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	composeFile := writeProject(t, `version: "3.8"
+services:
+  web:
+    image: "nginx:${TAG}"
+  db:
+    image: "postgres:${BASE}"
+  cache:
+    image: "redis:${PORT}"
+`)
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(filepath.Dir(composeFile), name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return composeFile
+}
+
+// parsedImages parses composeFile with the given env files and returns each
+// service's image
+func parsedImages(t *testing.T, composeFile string, envFiles ...string) map[string]string {
+	t.Helper()
+	dir := filepath.Dir(composeFile)
+	for i, envFile := range envFiles {
+		envFiles[i] = filepath.Join(dir, envFile)
+	}
+	p, err := newParser([]string{composeFile}, envFiles)
+	if err != nil {
+		t.Fatalf("newParser: %v", err)
+	}
+	cf, err := p.ParseFile(composeFile)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	images := make(map[string]string)
+	for name, service := range cf.Services {
+		images[name] = service.Image
+	}
+	return images
+}
+
+func TestEnvFilesLaterOverrideEarlier(t *testing.T) {
+	composeFile := envProject(t, map[string]string{
+		"base.env": "TAG=1.24\nBASE=15\n",
+		"prod.env": "TAG=1.25\n",
+	})
+	t.Setenv("PORT", "7")
+
+	images := parsedImages(t, composeFile, "base.env", "prod.env")
+	want := map[string]string{"web": "nginx:1.25", "db": "postgres:15", "cache": "redis:7"}
+	for name, image := range want {
+		if images[name] != image {
+			t.Errorf("%s image = %q, want %q", name, images[name], image)
+		}
+	}
+
+	// Reversing the files reverses which TAG wins
+	if got := parsedImages(t, composeFile, "prod.env", "base.env")["web"]; got != "nginx:1.24" {
+		t.Errorf("web image = %q with prod.env first, want nginx:1.24", got)
+	}
+}
+
+func TestEnvFilesOverrideProcessEnvironment(t *testing.T) {
+	composeFile := envProject(t, map[string]string{"prod.env": "TAG=1.25\n"})
+	t.Setenv("TAG", "latest")
+
+	if got := parsedImages(t, composeFile, "prod.env")["web"]; got != "nginx:1.25" {
+		t.Errorf("web image = %q, want the env file's nginx:1.25", got)
+	}
+}
+
+func TestDefaultDotEnv(t *testing.T) {
+	composeFile := envProject(t, map[string]string{
+		".env":     "TAG=1.23\nBASE=14\n",
+		"prod.env": "TAG=1.25\n",
+	})
+
+	images := parsedImages(t, composeFile)
+	if images["web"] != "nginx:1.23" || images["db"] != "postgres:14" {
+		t.Errorf("images = %v, want the .env values without --env-file", images)
+	}
+
+	// An explicit env file replaces .env rather than layering on it
+	images = parsedImages(t, composeFile, "prod.env")
+	if images["web"] != "nginx:1.25" || images["db"] != "postgres:" {
+		t.Errorf("images = %v, want prod.env only", images)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/container"
+)
+
+func TestWriteEventJSONLines(t *testing.T) {
+	var opts container.EventsOptions
+	if err := container.ParseEventFilters([]string{"type=container", "event=health_status"}, &opts); err != nil {
+		t.Fatalf("ParseEventFilters: %v", err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	events := []container.Event{
+		{Time: now, Type: "container", Action: "create", Service: "web", ContainerID: "abc"},
+		{Time: now, Type: "container", Action: "health_status: healthy", Service: "web", ContainerID: "abc"},
+		{Time: now, Type: "network", Action: "connect"},
+		{Time: now, Type: "container", Action: "health_status: unhealthy", Service: "db", ContainerID: "def"},
+	}
+
+	var out bytes.Buffer
+	for _, event := range events {
+		if opts.Matches(event) {
+			writeEvent(&out, event, true)
+		}
+	}
+
+	var decoded []container.Event
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var event container.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q is not a JSON object: %v", scanner.Text(), err)
+		}
+		decoded = append(decoded, event)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("decoded %d events, want the two health_status ones", len(decoded))
+	}
+	for _, event := range decoded {
+		if event.Type != "container" || !strings.HasPrefix(event.Action, "health_status") {
+			t.Errorf("event %+v passed the filters", event)
+		}
+		if !event.Time.Equal(now) || event.ContainerID == "" {
+			t.Errorf("event %+v lost its time or id", event)
+		}
+	}
+}
+
+func TestWriteEventText(t *testing.T) {
+	var out bytes.Buffer
+	writeEvent(&out, container.Event{Time: time.Now(), Type: "container", Action: "start", Service: "web", ContainerID: "abc"}, false)
+	writeEvent(&out, container.Event{Time: time.Now(), Type: "reconnect"}, false)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("printed %d lines, want 2:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], "container start (abc)") || json.Valid([]byte(lines[0])) {
+		t.Errorf("event line = %q, want plain text", lines[0])
+	}
+	if !strings.Contains(lines[1], "event stream reconnected") {
+		t.Errorf("reconnect line = %q", lines[1])
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/docker/docker/api/types"
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+	"github.com/neomody77/fake-compose/pkg/inspect"
+	"github.com/neomody77/fake-compose/pkg/lifecycle"
+)
+
+// inspectBackend reports one container per service in the given state
+type inspectBackend map[string]string
+
+func (b inspectBackend) Containers(ctx context.Context, services []string, all bool) ([]container.ContainerInfo, error) {
+	var containers []container.ContainerInfo
+	for _, service := range services {
+		if _, ok := b[service]; ok {
+			containers = append(containers, container.ContainerInfo{ID: service + "-id", Service: service})
+		}
+	}
+	return containers, nil
+}
+
+func (b inspectBackend) Inspect(ctx context.Context, containers []container.ContainerInfo) ([]types.ContainerJSON, error) {
+	infos := make([]types.ContainerJSON, len(containers))
+	for i, c := range containers {
+		infos[i] = types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    c.ID,
+			State: &types.ContainerState{Status: b[c.Service]},
+		}}
+	}
+	return infos, nil
+}
+
+func (b inspectBackend) ServiceState(serviceName string) (*lifecycle.ServiceState, bool) {
+	return nil, false
+}
+
+func inspectResults(t *testing.T) []*inspect.ServiceInspect {
+	t.Helper()
+	composeFile := &compose.ComposeFile{Services: map[string]*compose.Service{
+		"db":  {Image: "postgres"},
+		"web": {Image: "nginx"},
+	}}
+	inspector := inspect.New(composeFile, inspectBackend{"db": "running", "web": "exited"})
+
+	var results []*inspect.ServiceInspect
+	for _, name := range []string{"db", "web"} {
+		result, err := inspector.InspectService(context.Background(), name)
+		if err != nil {
+			t.Fatalf("inspect %s: %v", name, err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestWriteInspectJSONArray(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeInspect(&out, inspectResults(t), nil); err != nil {
+		t.Fatalf("writeInspect: %v", err)
+	}
+
+	var decoded []struct {
+		ID      string `json:"Id"`
+		Service string
+		State   struct{ Status string }
+		Health  string
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, out.String())
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("got %d entries, want 2", len(decoded))
+	}
+	for i, want := range []struct{ service, status string }{{"db", "running"}, {"web", "exited"}} {
+		got := decoded[i]
+		if got.Service != want.service || got.ID != want.service+"-id" || got.State.Status != want.status || got.Health != "none" {
+			t.Errorf("entry %d = %+v, want service %s in state %s", i, got, want.service, want.status)
+		}
+	}
+}
+
+func TestWriteInspectFormat(t *testing.T) {
+	tmpl := template.Must(template.New("inspect").Parse("{{.State.Status}}"))
+	var out bytes.Buffer
+	if err := writeInspect(&out, inspectResults(t), tmpl); err != nil {
+		t.Fatalf("writeInspect: %v", err)
+	}
+	if got, want := out.String(), "running\nexited\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestInspectRejectsInvalidFormat(t *testing.T) {
+	_, err := runCLI(t, "-f", "missing.yml", "inspect", "--format", "{{.State")
+	if err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("inspect --format {{.State = %v, want an invalid format error", err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const killProject = `version: "3.8"
+services:
+  web:
+    image: nginx
+  db:
+    image: postgres
+`
+
+func TestKillSignals(t *testing.T) {
+	file := writeProject(t, killProject)
+	tests := []struct {
+		signal string
+		want   string
+	}{
+		{"SIGHUP", "SIGHUP"},
+		{"usr1", "SIGUSR1"},
+		{"9", "SIGKILL"},
+		{"15", "SIGTERM"},
+	}
+	for _, tt := range tests {
+		hook, err := runCLI(t, "-f", file, "kill", "-s", tt.signal)
+		if err != nil {
+			t.Errorf("kill -s %s: %v", tt.signal, err)
+			continue
+		}
+		if msg := "Killing services with signal " + tt.want + "..."; !logged(hook, msg) {
+			t.Errorf("kill -s %s did not log %q", tt.signal, msg)
+		}
+	}
+}
+
+func TestKillRejectsInvalidSignal(t *testing.T) {
+	// The signal is checked before the compose file is loaded
+	hook, err := runCLI(t, "-f", "missing.yml", "kill", "-s", "SIGBOGUS")
+	if err == nil || !strings.Contains(err.Error(), `invalid signal "SIGBOGUS"`) {
+		t.Errorf("kill -s SIGBOGUS = %v, want an invalid signal error", err)
+	}
+	if len(hook.AllEntries()) != 0 {
+		t.Errorf("kill logged %d entries before rejecting the signal", len(hook.AllEntries()))
+	}
+}
+
+func TestKillAll(t *testing.T) {
+	file := writeProject(t, killProject)
+	for _, args := range [][]string{{"kill"}, {"kill", "--all"}} {
+		hook, err := runCLI(t, append([]string{"-f", file}, args...)...)
+		if err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		for _, service := range []string{"web", "db"} {
+			if !logged(hook, "Killing "+service) {
+				t.Errorf("%v did not kill %s", args, service)
+			}
+		}
+	}
+}
+
+func TestKillServices(t *testing.T) {
+	file := writeProject(t, killProject)
+	hook, err := runCLI(t, "-f", file, "kill", "web")
+	if err != nil {
+		t.Fatalf("kill web: %v", err)
+	}
+	if !logged(hook, "Killing web") || logged(hook, "Killing db") {
+		t.Error("kill web should kill web only")
+	}
+
+	if _, err := runCLI(t, "-f", file, "kill", "--all", "web"); err == nil {
+		t.Error("kill --all web succeeded, want an error")
+	}
+}
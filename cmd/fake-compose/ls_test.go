@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/container"
+)
+
+var lsProjects = []container.ProjectInfo{
+	{Name: "blog", Status: "exited(1)", ConfigFiles: "/srv/blog/compose.yaml,/srv/blog/compose.override.yaml"},
+	{Name: "shop", Status: "exited(1), running(2)", ConfigFiles: "/srv/shop/compose.yaml"},
+}
+
+func TestWriteProjectsJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeProjects(&out, lsProjects, "json", false); err != nil {
+		t.Fatalf("writeProjects: %v", err)
+	}
+
+	var got []map[string]string
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, out.String())
+	}
+	want := []map[string]string{
+		{"Name": "blog", "Status": "exited(1)", "ConfigFiles": "/srv/blog/compose.yaml,/srv/blog/compose.override.yaml"},
+		{"Name": "shop", "Status": "exited(1), running(2)", "ConfigFiles": "/srv/shop/compose.yaml"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("json = %v, want %v", got, want)
+	}
+}
+
+func TestWriteProjectsQuiet(t *testing.T) {
+	for _, format := range []string{"table", "json"} {
+		var out bytes.Buffer
+		if err := writeProjects(&out, lsProjects, format, true); err != nil {
+			t.Fatalf("writeProjects: %v", err)
+		}
+		if got := out.String(); got != "blog\nshop\n" {
+			t.Errorf("quiet %s output = %q, want the names only", format, got)
+		}
+	}
+}
+
+func TestWriteProjectsTable(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeProjects(&out, lsProjects, "table", false); err != nil {
+		t.Fatalf("writeProjects: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "NAME") || !strings.HasPrefix(lines[1], "blog") || !strings.HasPrefix(lines[2], "shop") {
+		t.Errorf("table output:\n%s", out.String())
+	}
+}
+
+func TestLsJSONWithoutProjects(t *testing.T) {
+	out, err := runCLIOutput(t, "ls", "--format", "json")
+	if err != nil {
+		t.Fatalf("ls: %v", err)
+	}
+	if strings.TrimSpace(out) != "[]" {
+		t.Errorf("ls --format json = %q, want an empty array", out)
+	}
+}
+
+func TestLsInvalidFormat(t *testing.T) {
+	if _, err := runCLI(t, "ls", "--format", "yaml"); err == nil || !strings.Contains(err.Error(), `invalid format "yaml"`) {
+		t.Errorf("ls --format yaml = %v, want an invalid format error", err)
+	}
+}
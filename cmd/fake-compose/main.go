@@ -1,19 +1,48 @@
 package main
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
+	"github.com/docker/docker/api/types/filters"
+	units "github.com/docker/go-units"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/neomody77/fake-compose/internal/executor"
 	"github.com/neomody77/fake-compose/internal/parser"
+	"github.com/neomody77/fake-compose/internal/state"
 	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
+	"github.com/neomody77/fake-compose/pkg/health"
+	"github.com/neomody77/fake-compose/pkg/hooks"
+	"github.com/neomody77/fake-compose/pkg/inspect"
+	"github.com/neomody77/fake-compose/pkg/lifecycle"
+	"github.com/neomody77/fake-compose/pkg/kubernetes"
+	"github.com/neomody77/fake-compose/pkg/monitoring"
+	hooktemplate "github.com/neomody77/fake-compose/pkg/template"
+	"github.com/neomody77/fake-compose/pkg/term"
 	"gopkg.in/yaml.v3"
 )
 
@@ -24,16 +53,40 @@ var (
 )
 
 func main() {
-	var composeFile string
-	var envFile string
-	var projectName string
-	var verbose bool
-
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp: true,
 	})
 
+	if err := newRootCmd(logger).Execute(); err != nil {
+		var exitErr *cerrors.ServiceExitError
+		if errors.As(err, &exitErr) {
+			logger.Error(err)
+			os.Exit(exitErr.ExitCode)
+		}
+		logger.Fatal(err)
+	}
+}
+
+// newRootCmd builds the command tree, logging through logger
+func newRootCmd(logger *logrus.Logger) *cobra.Command {
+	var composeFiles []string
+	var envFiles []string
+	var projectName string
+	var profiles []string
+	var parallel int
+	var dockerHost string
+	var verbose bool
+	var quiet bool
+	var ansi string
+	var noColor bool
+	var requireDocker bool
+	var tlsVerify bool
+	var tlsCACert, tlsCert, tlsKey string
+	var tlsOptions *container.TLSOptions
+	var pullTimeout time.Duration
+	var compatibility bool
+
 	rootCmd := &cobra.Command{
 		Use:   "fake-compose",
 		Short: "Docker Compose compatible tool with extended features",
@@ -49,15 +102,123 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		Version: fmt.Sprintf("%s (commit: %s, built: %s)", version, commit, date),
 	}
 
-	rootCmd.PersistentFlags().StringVarP(&composeFile, "file", "f", "docker-compose.yml", "Compose file")
-	rootCmd.PersistentFlags().StringVarP(&envFile, "env-file", "", "", "Environment file")
+	rootCmd.PersistentFlags().StringArrayVarP(&composeFiles, "file", "f", nil, "Compose file(s), later files override earlier ones (default: the first of compose.yaml, compose.yml, docker-compose.yaml and docker-compose.yml found)")
+	rootCmd.PersistentFlags().StringArrayVar(&envFiles, "env-file", nil, "Environment file(s), later files override earlier ones (default: .env next to the compose file)")
 	rootCmd.PersistentFlags().StringVarP(&projectName, "project-name", "p", "", "Project name")
+	rootCmd.PersistentFlags().StringArrayVar(&profiles, "profile", nil, "Specify a profile to enable")
+	rootCmd.PersistentFlags().IntVar(&parallel, "parallel", -1, "Control max parallelism, -1 for unlimited")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only print errors and the command's data output")
+	rootCmd.PersistentFlags().StringVar(&ansi, "ansi", "auto", "Control when to print ANSI control characters (never, always, auto)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Produce monochrome output, same as --ansi never")
+	rootCmd.PersistentFlags().BoolVar(&requireDocker, "require-docker", false, "Fail instead of falling back to the stub backend when Docker is unreachable (up, down, build, exec)")
+	rootCmd.PersistentFlags().StringVarP(&dockerHost, "host", "H", "", "Docker daemon address, e.g. tcp://host:2376 or ssh://user@host (default: $DOCKER_HOST)")
+	rootCmd.PersistentFlags().BoolVar(&tlsVerify, "tlsverify", false, "Use TLS and verify the daemon's certificate")
+	rootCmd.PersistentFlags().StringVar(&tlsCACert, "tlscacert", "", "Trust certs signed only by this CA")
+	rootCmd.PersistentFlags().StringVar(&tlsCert, "tlscert", "", "Path to TLS certificate file")
+	rootCmd.PersistentFlags().StringVar(&tlsKey, "tlskey", "", "Path to TLS key file")
+	rootCmd.PersistentFlags().DurationVar(&pullTimeout, "pull-timeout", container.DefaultPullTimeout, "Maximum time for pulling a single image, 0 for no limit")
+	rootCmd.PersistentFlags().BoolVar(&compatibility, "compatibility", false, "Apply deploy resource limits and replicas to plain containers (up, create, run)")
+
+	// connectionOptions returns the container options that select the
+	// Docker daemon
+	connectionOptions := func() container.Options {
+		opts := container.Options{Host: dockerHost, Project: projectName, TLS: tlsOptions, PullTimeout: pullTimeout}
+		if pullTimeout == 0 {
+			opts.PullTimeout = -1
+		}
+		for _, file := range composeFiles {
+			if abs, err := filepath.Abs(file); err == nil {
+				opts.ConfigFiles = append(opts.ConfigFiles, abs)
+			}
+		}
+		return opts
+	}
 
-	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+	// mutatingOptions returns the container options of commands that
+	// change state, which must not run against the stub with
+	// --require-docker
+	mutatingOptions := func() container.Options {
+		opts := connectionOptions()
+		if requireDocker {
+			opts.Backend = container.BackendDocker
+		}
+		return opts
+	}
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// pull, push, images and ls have a --quiet of their own, which
+		// quiets the logs as well
+		quiet, _ = cmd.Flags().GetBool("quiet")
+		if quiet && verbose {
+			return fmt.Errorf("--quiet and --verbose are mutually exclusive")
+		}
 		if verbose {
 			logger.SetLevel(logrus.DebugLevel)
 		}
+		if quiet {
+			logger.SetLevel(logrus.ErrorLevel)
+		}
+
+		// NO_COLOR and TERM=dumb disable colors unless --ansi is set explicitly
+		mode, err := term.ParseMode(ansi)
+		if err != nil {
+			return err
+		}
+		if noColor {
+			if cmd.Flags().Changed("ansi") && mode != term.ModeNever {
+				return fmt.Errorf("--no-color and --ansi %s are mutually exclusive", ansi)
+			}
+			mode = term.ModeNever
+		} else if !cmd.Flags().Changed("ansi") && (os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb") {
+			mode = term.ModeNever
+		}
+		term.SetMode(mode)
+		logger.SetFormatter(&logrus.TextFormatter{DisableColors: mode == term.ModeNever, ForceColors: mode == term.ModeAlways})
+
+		// COMPOSE_* variables provide defaults for flags not set explicitly
+		if value, ok := os.LookupEnv("COMPOSE_FILE"); ok && value != "" && !cmd.Flags().Changed("file") {
+			composeFiles = filepath.SplitList(value)
+		} else if len(composeFiles) == 0 {
+			// Without a compose file, parsing reports docker-compose.yml
+			// missing along with the names looked for
+			composeFiles = []string{parser.DefaultFilenames[len(parser.DefaultFilenames)-1]}
+			if found := parser.DefaultComposeFiles("."); len(found) > 0 {
+				if len(found) > 1 {
+					logger.Warnf("Found multiple compose files with default names: %s. Using %s", strings.Join(found, ", "), found[0])
+				}
+				composeFiles = found[:1]
+			}
+		}
+		if value, ok := os.LookupEnv("COMPOSE_ENV_FILE"); ok && value != "" && !cmd.Flags().Changed("env-file") {
+			envFiles = filepath.SplitList(value)
+		}
+		if value, ok := os.LookupEnv("COMPOSE_PROFILES"); ok && value != "" && !cmd.Flags().Changed("profile") {
+			profiles = strings.Split(value, ",")
+		}
+		if value, ok := os.LookupEnv("COMPOSE_PARALLEL_LIMIT"); ok && !cmd.Flags().Changed("parallel") {
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid COMPOSE_PARALLEL_LIMIT %q: %w", value, err)
+			}
+			parallel = limit
+		}
+		if !cmd.Flags().Changed("host") {
+			dockerHost = os.Getenv("DOCKER_HOST")
+		}
+		// Any TLS flag switches to TLS, like the docker CLI; without
+		// --tlsverify the daemon's certificate is not checked
+		if tlsVerify || tlsCACert != "" || tlsCert != "" || tlsKey != "" {
+			tlsOptions = &container.TLSOptions{CACert: tlsCACert, Cert: tlsCert, Key: tlsKey, Verify: tlsVerify}
+		}
+
+		if projectName == "" {
+			projectName = os.Getenv("COMPOSE_PROJECT_NAME")
+		}
+		if projectName == "" {
+			projectName = defaultProjectName(composeFiles[0])
+		}
+		return nil
 	}
 
 	// Up command
@@ -69,18 +230,54 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		noRecreate bool
 		noStart bool
 		timeout int
+		removeOrphans bool
+		scaleArgs []string
+		noDeps bool
+		cascadeVolumes bool
+		attach []string
+		noAttach []string
+		abortOnExit bool
+		exitCodeFrom string
+		initContainerLogs bool
+		postContainerLogs bool
 	)
 	upCmd := &cobra.Command{
 		Use:   "up [SERVICE...]",
 		Short: "Create and start containers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			if exitCodeFrom != "" && detach {
+				return fmt.Errorf("--exit-code-from and --detach are mutually exclusive")
+			}
+			if exitCodeFrom != "" {
+				abortOnExit = true
+			}
+			if abortOnExit && detach {
+				return fmt.Errorf("--abort-on-container-exit and --detach are mutually exclusive")
+			}
+			if forceRecreate && noRecreate {
+				return fmt.Errorf("--force-recreate and --no-recreate are mutually exclusive")
+			}
+
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
 
-			if projectName == "" {
-				projectName = "fake-compose"
+			scaleMap, err := parseScale(scaleArgs)
+			if err != nil {
+				return err
+			}
+			if compatibility {
+				replicas, err := applyCompatibility(compose)
+				if err != nil {
+					return err
+				}
+				// --scale wins over deploy.replicas
+				for name, n := range replicas {
+					if _, ok := scaleMap[name]; !ok && (len(args) == 0 || contains(args, name)) {
+						scaleMap[name] = n
+					}
+				}
 			}
 
 			ctx, cancel := context.WithCancel(context.Background())
@@ -95,16 +292,42 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 				cancel()
 			}()
 
-			exec, err := executor.New(logger, projectName)
+			execOpts := mutatingOptions()
+			if initContainerLogs {
+				execOpts.InitLogs = os.Stdout
+			}
+			if postContainerLogs {
+				execOpts.PostLogs = os.Stdout
+			}
+			exec, err := executor.New(logger, projectName, execOpts)
 			if err != nil {
 				return fmt.Errorf("failed to create executor: %w", err)
 			}
 			defer exec.Close()
 
-			if err := exec.Up(ctx, compose); err != nil {
+			upOpts := executor.UpOptions{Services: args, NoDeps: noDeps, Parallel: parallel, CascadeVolumes: cascadeVolumes, ExitCodeFrom: exitCodeFrom, QuietPull: quietPull, ForceRecreate: forceRecreate, NoRecreate: noRecreate, Quiet: quiet}
+			stopProgress := renderProgress(exec, logger)
+			err = exec.UpWithOptions(ctx, compose, upOpts)
+			stopProgress()
+			if err != nil {
 				return fmt.Errorf("failed to start services: %w", err)
 			}
 
+			if len(scaleMap) > 0 {
+				if err := exec.Scale(ctx, compose, scaleMap); err != nil {
+					return fmt.Errorf("failed to scale services: %w", err)
+				}
+			}
+
+			if err := exec.CleanupOrphans(ctx, compose, removeOrphans); err != nil {
+				return err
+			}
+			exec.WarnOrphanVolumes(ctx, compose)
+
+			if err := exec.RecordDeployment(ctx, compose); err != nil {
+				logger.Warnf("Failed to record deployment for rollback: %v", err)
+			}
+
 			logger.Info("All services started successfully")
 
 			if detach {
@@ -112,65 +335,418 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 				return nil
 			}
 
-			// Wait for interrupt signal in attached mode
+			// Stream the attached services' logs until interrupted
+			attached := attachedServices(compose, args, attach, noAttach)
+			var printMu sync.Mutex
+			go func() {
+				err := exec.FollowLogs(ctx, attached, func(line container.LogLine) {
+					printMu.Lock()
+					defer printMu.Unlock()
+					printLogLine(line)
+				})
+				if err != nil {
+					logger.Warnf("Log streaming stopped: %v", err)
+				}
+			}()
+
+			// Stop everything as soon as any container this up started exits
+			exited := make(chan error, 1)
+			if abortOnExit {
+				started, err := exec.UpServices(compose, upOpts)
+				if err != nil {
+					return err
+				}
+				for name, n := range scaleMap {
+					if n > 0 && !contains(started, name) {
+						started = append(started, name)
+					}
+				}
+				go func() {
+					exit, err := exec.AbortOnExit(ctx, compose, started, exitCodeFrom, timeout)
+					if ctx.Err() != nil {
+						return
+					}
+					if err != nil {
+						exited <- err
+						cancel()
+						return
+					}
+					if exit.ExitCode != 0 {
+						exited <- &cerrors.ServiceExitError{Service: exit.Service, ExitCode: exit.ExitCode}
+					}
+					cancel()
+				}()
+			}
+
 			<-ctx.Done()
 
 			logger.Info("Shutting down services...")
-			if err := exec.Down(context.Background(), compose); err != nil {
+			stopProgress = renderProgress(exec, logger)
+			err = exec.Down(context.Background(), compose)
+			stopProgress()
+			if err != nil {
 				logger.Errorf("Error during shutdown: %v", err)
 			}
 
-			return nil
+			select {
+			case err := <-exited:
+				return err
+			default:
+				return nil
+			}
 		},
 	}
 	upCmd.Flags().BoolVarP(&detach, "detach", "d", false, "Detached mode: Run containers in the background")
 	upCmd.Flags().BoolVar(&build, "build", false, "Build images before starting containers")
 	upCmd.Flags().BoolVar(&quietPull, "quiet-pull", false, "Pull without printing progress information")
-	upCmd.Flags().BoolVar(&forceRecreate, "force-recreate", false, "Recreate containers even if configuration hasn't changed")
-	upCmd.Flags().BoolVar(&noRecreate, "no-recreate", false, "Don't recreate containers if they already exist")
+	upCmd.Flags().BoolVar(&forceRecreate, "force-recreate", false, "Recreate containers even if their configuration and image haven't changed")
+	upCmd.Flags().BoolVar(&noRecreate, "no-recreate", false, "Don't recreate containers if they already exist, even if their configuration or image changed")
 	upCmd.Flags().BoolVar(&noStart, "no-start", false, "Don't start the services after creating them")
 	upCmd.Flags().IntVarP(&timeout, "timeout", "t", 30, "Shutdown timeout in seconds")
+	upCmd.Flags().BoolVar(&removeOrphans, "remove-orphans", false, "Remove containers for services not defined in the Compose file")
+	upCmd.Flags().BoolVar(&noDeps, "no-deps", false, "Don't start linked services")
+	upCmd.Flags().StringArrayVar(&attach, "attach", nil, "Restrict attaching to the specified services")
+	upCmd.Flags().StringArrayVar(&noAttach, "no-attach", nil, "Do not attach (stream logs) to the specified services")
+	upCmd.Flags().BoolVar(&cascadeVolumes, "cascade-volumes", false, "Mark created volumes for removal by down --cascade-volumes")
+	upCmd.Flags().StringArrayVar(&scaleArgs, "scale", nil, "Scale SERVICE to NUM instances (SERVICE=NUM)")
+	upCmd.Flags().BoolVar(&abortOnExit, "abort-on-container-exit", false, "Stops all containers if any container was stopped. Incompatible with --detach")
+	upCmd.Flags().BoolVar(&initContainerLogs, "init-container-logs", false, "Stream init container output while they run")
+	upCmd.Flags().BoolVar(&postContainerLogs, "post-container-logs", false, "Stream post container output while they run")
+	upCmd.Flags().StringVar(&exitCodeFrom, "exit-code-from", "", "Return the exit code of the selected service container. Implies --abort-on-container-exit")
 
 	// Down command
+	var removeOrphanVolumes, force bool
 	downCmd := &cobra.Command{
 		Use:   "down",
 		Short: "Stop and remove containers, networks",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
 
-			if projectName == "" {
-				projectName = "fake-compose"
-			}
-
-			exec, err := executor.New(logger, projectName)
+			exec, err := executor.New(logger, projectName, mutatingOptions())
 			if err != nil {
 				return fmt.Errorf("failed to create executor: %w", err)
 			}
 			defer exec.Close()
 
-			if err := exec.Down(context.Background(), compose); err != nil {
+			stopProgress := renderProgress(exec, logger)
+			err = exec.DownWithOptions(context.Background(), compose, executor.DownOptions{CascadeVolumes: cascadeVolumes})
+			stopProgress()
+			if err != nil {
 				return fmt.Errorf("failed to stop services: %w", err)
 			}
 
+			if err := exec.CleanupOrphans(context.Background(), compose, removeOrphans); err != nil {
+				return err
+			}
+
+			if removeOrphanVolumes {
+				orphans, err := exec.OrphanVolumes(context.Background(), compose)
+				if err != nil {
+					return err
+				}
+				if len(orphans) > 0 && !force {
+					fmt.Printf("Going to remove orphan volumes %s\n", strings.Join(orphans, ", "))
+					fmt.Print("Are you sure? [yN] ")
+					answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+					if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+						orphans = nil
+					}
+				}
+				if err := exec.RemoveVolumes(context.Background(), orphans); err != nil {
+					return fmt.Errorf("failed to remove orphan volumes: %w", err)
+				}
+			}
+
 			logger.Info("All services stopped successfully")
 			return nil
 		},
 	}
 
+	downCmd.Flags().BoolVar(&removeOrphans, "remove-orphans", false, "Remove containers for services not defined in the Compose file")
+	downCmd.Flags().BoolVar(&cascadeVolumes, "cascade-volumes", false, "Remove volumes created by up --cascade-volumes")
+	downCmd.Flags().BoolVar(&removeOrphanVolumes, "remove-orphan-volumes", false, "Remove project volumes no longer declared in the Compose file")
+	downCmd.Flags().BoolVar(&force, "force", false, "Don't ask to confirm removal of orphan volumes")
+
+	// Rollback command
+	var (
+		rollbackSteps  int
+		rollbackDryRun bool
+	)
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore an earlier deployment of the project",
+		Long: `Stop the current deployment and start an earlier one recorded by up,
+with the configuration and images it ran. Init containers are not run
+again. The last ` + strconv.Itoa(state.HistoryLimit) + ` deployments are kept.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exec, err := executor.New(logger, projectName, mutatingOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			plan, err := exec.Rollback(context.Background(), executor.RollbackOptions{Steps: rollbackSteps, DryRun: rollbackDryRun})
+			if err != nil {
+				return err
+			}
+
+			if rollbackDryRun {
+				fmt.Printf("Would roll back from %s (%s) to %s (%s)\n",
+					executor.ShortHash(plan.From.ConfigHash), plan.From.Time.Format(time.RFC3339),
+					executor.ShortHash(plan.To.ConfigHash), plan.To.Time.Format(time.RFC3339))
+				var names []string
+				for name := range plan.From.Images {
+					names = append(names, name)
+				}
+				for name := range plan.To.Images {
+					if _, ok := plan.From.Images[name]; !ok {
+						names = append(names, name)
+					}
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					from, to := plan.From.Images[name], plan.To.Images[name]
+					switch {
+					case from == to:
+						fmt.Printf("  %s: %s (unchanged)\n", name, to)
+					case from == "":
+						fmt.Printf("  %s: start %s\n", name, to)
+					case to == "":
+						fmt.Printf("  %s: remove\n", name)
+					default:
+						fmt.Printf("  %s: %s -> %s\n", name, from, to)
+					}
+				}
+				return nil
+			}
+
+			logger.Infof("Rolled back to deployment %s from %s", executor.ShortHash(plan.To.ConfigHash), plan.To.Time.Format(time.RFC3339))
+			return nil
+		},
+	}
+	rollbackCmd.Flags().IntVar(&rollbackSteps, "steps", 1, "Number of deployments to go back")
+	rollbackCmd.Flags().BoolVar(&rollbackDryRun, "dry-run", false, "Show what would be rolled back without changing anything")
+
+	// Volume command
+	volumeCmd := &cobra.Command{
+		Use:   "volume",
+		Short: "Manage the project's volumes",
+	}
+	volumeLsCmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List the project's volumes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
+			if err != nil {
+				return err
+			}
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			ctx := context.Background()
+			volumes, err := exec.ProjectVolumes(ctx)
+			if err != nil {
+				return err
+			}
+			orphans, err := exec.OrphanVolumes(ctx, compose)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "VOLUME\tSTATUS")
+			for _, name := range volumes {
+				status := "declared"
+				if slices.Contains(orphans, name) {
+					status = "orphaned"
+				}
+				fmt.Fprintf(w, "%s\t%s\n", name, status)
+			}
+			w.Flush()
+			return nil
+		},
+	}
+	volumeCmd.AddCommand(volumeLsCmd)
+
+	// Plugin command
+	pluginCmd := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage hook plugins",
+	}
+	pluginInstallCmd := &cobra.Command{
+		Use:   "install URL",
+		Short: "Download a hook plugin binary into ~/.fake-compose/plugins",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugin, err := hooks.InstallPlugin(context.Background(), args[0])
+			if err != nil {
+				return err
+			}
+			logger.Infof("Installed plugin %s to %s", plugin.Name, plugin.Path)
+			return nil
+		},
+	}
+	pluginListCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List installed hook plugins",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins, err := hooks.ListPlugins()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tPATH\tSTATUS")
+			for _, plugin := range plugins {
+				status := "ok"
+				if plugin.Err != nil {
+					status = plugin.Err.Error()
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", plugin.Name, plugin.Path, status)
+			}
+			w.Flush()
+			return nil
+		},
+	}
+	pluginCmd.AddCommand(pluginInstallCmd, pluginListCmd)
+
 	// Config command
 	configCmd := &cobra.Command{
 		Use:   "config",
 		Short: "Validate and view the Compose file",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			p, err := newParser(composeFiles, envFiles)
+			if err != nil {
+				return err
+			}
+			noInterpolate, _ := cmd.Flags().GetBool("no-interpolate")
+			merge, _ := cmd.Flags().GetBool("merge")
+			stripExtended, _ := cmd.Flags().GetBool("strip-extended")
+			outputPath, _ := cmd.Flags().GetString("output")
+			if merge && noInterpolate {
+				return fmt.Errorf("--merge and --no-interpolate are mutually exclusive: a merged file has its variables expanded")
+			}
+			p.SetInterpolation(!noInterpolate)
+
+			composeFile, err := parseCompose(logger, p, composeFiles, profiles)
+			if err != nil {
+				return err
+			}
+
+			if pin, _ := cmd.Flags().GetBool("pin-digests"); pin {
+				manager, err := container.NewManagerWithOptions(logger, connectionOptions())
+				if err != nil {
+					return fmt.Errorf("failed to create container manager: %w", err)
+				}
+				defer manager.Close()
+
+				for _, name := range getServiceNames(composeFile, nil) {
+					service := composeFile.Services[name]
+					if service.Image == "" || isPinned(service.Image) {
+						continue
+					}
+					digest, err := manager.ResolveDigest(context.Background(), service.Image)
+					if err != nil {
+						return err
+					}
+					service.Image = pinImage(service.Image, digest)
+					service.Digest = digest
+				}
+			}
+
+			if stripExtended {
+				compose.StripExtensions(composeFile)
+			}
+
+			output, err := yaml.Marshal(composeFile)
+			if err != nil {
+				return fmt.Errorf("failed to marshal compose file: %w", err)
+			}
+			if merge {
+				output = append([]byte(fmt.Sprintf("# Merged from %s by fake-compose\n", strings.Join(composeFiles, ", "))), output...)
+			}
+			return writeOutput(outputPath, output)
+		},
+	}
+
+	configCmd.Flags().Bool("pin-digests", false, "Rewrite image tags to the digests they currently resolve to")
+	configCmd.Flags().Bool("no-interpolate", false, "Don't interpolate environment variables")
+	configCmd.Flags().Bool("merge", false, "Resolve all Compose files into a single standalone file")
+	configCmd.Flags().Bool("strip-extended", false, "Remove fake-compose extensions so stock docker compose accepts the output")
+	configCmd.Flags().StringP("output", "o", "", "Write the configuration to this file instead of stdout")
+
+	// Convert command
+	convertCmd := &cobra.Command{
+		Use:     "convert",
+		Aliases: []string{"normalize"},
+		Short:   "Print the merged, interpolated compose file in canonical form",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := newParser(composeFiles, envFiles)
 			if err != nil {
 				return err
 			}
+			noInterpolate, _ := cmd.Flags().GetBool("no-interpolate")
+			p.SetInterpolation(!noInterpolate)
 
-			output, err := yaml.Marshal(compose)
+			composeFile, err := parseCompose(logger, p, composeFiles, profiles)
+			if err != nil {
+				return err
+			}
+
+			switch to, _ := cmd.Flags().GetString("to"); to {
+			case "compose":
+			case "kubernetes":
+				version, _ := cmd.Flags().GetString("kubernetes-version")
+				manifests, err := kubernetes.Convert(composeFile, projectName, version)
+				if err != nil {
+					return err
+				}
+				output, err := kubernetes.Marshal(manifests)
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(output))
+				return nil
+			case "istio":
+				output, err := kubernetes.Marshal(kubernetes.IstioResources(composeFile))
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(output))
+				return nil
+			case "helm":
+				outputDir, _ := cmd.Flags().GetString("output-dir")
+				if outputDir == "" {
+					outputDir = projectName + "-chart"
+				}
+				files, err := kubernetes.HelmChart(composeFile, projectName)
+				if err != nil {
+					return err
+				}
+				if err := writeFiles(outputDir, files); err != nil {
+					return err
+				}
+				logger.Infof("Wrote Helm chart to %s", outputDir)
+				return nil
+			default:
+				return fmt.Errorf("invalid --to %q: must be compose, kubernetes, istio or helm", to)
+			}
+
+			doc, err := compose.Normalize(composeFile, projectName)
+			if err != nil {
+				return fmt.Errorf("failed to normalize compose file: %w", err)
+			}
+
+			output, err := yaml.Marshal(doc)
 			if err != nil {
 				return fmt.Errorf("failed to marshal compose file: %w", err)
 			}
@@ -179,18 +755,84 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		},
 	}
 
+	convertCmd.Flags().Bool("no-interpolate", false, "Don't interpolate environment variables")
+	convertCmd.Flags().String("to", "compose", "Output format: compose, kubernetes, istio or helm")
+	convertCmd.Flags().String("output-dir", "", "Directory to write the Helm chart to (default PROJECT-chart)")
+	convertCmd.Flags().String("kubernetes-version", kubernetes.DefaultVersion, "Kubernetes version to emit manifests for ("+strings.Join(kubernetes.SupportedVersions(), ", ")+")")
+
 	// Validate command
 	validateCmd := &cobra.Command{
 		Use:   "validate",
 		Short: "Validate compose file",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			p, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
 
+			if strictEnv, _ := cmd.Flags().GetBool("strict-env"); strictEnv {
+				undefined, err := p.UndefinedVars(composeFiles...)
+				if err != nil {
+					return err
+				}
+				if len(undefined) > 0 {
+					return fmt.Errorf("undefined variables: %s", strings.Join(undefined, ", "))
+				}
+			}
+
 			logger.Infof("Compose file is valid")
 			logger.Infof("Found %d services", len(compose.Services))
+
+			warnUnpinned, _ := cmd.Flags().GetBool("warn-unpinned")
+			checkDigests, _ := cmd.Flags().GetBool("check-digests")
+
+			if warnUnpinned {
+				for _, name := range getServiceNames(compose, nil) {
+					if image := compose.Services[name].Image; image != "" && !isPinned(image) {
+						logger.Warnf("Service %s uses unpinned image %s; pin it by digest (config --pin-digests)", name, image)
+					}
+				}
+			}
+
+			if dryRun, _ := cmd.Flags().GetBool("hooks-dry-run"); dryRun {
+				if err := dryRunHooks(logger, projectName, compose); err != nil {
+					return err
+				}
+			}
+
+			if checkDigests {
+				manager, err := container.NewManagerWithOptions(logger, connectionOptions())
+				if err != nil {
+					return fmt.Errorf("failed to create container manager: %w", err)
+				}
+				defer manager.Close()
+
+				var mismatched []string
+				for _, name := range getServiceNames(compose, nil) {
+					service := compose.Services[name]
+					if service.Digest == "" || service.Image == "" {
+						continue
+					}
+					digest, err := manager.ResolveDigest(context.Background(), service.Image)
+					if err != nil {
+						return err
+					}
+					if digest != service.Digest {
+						logger.Errorf("Service %s: %s resolves to %s, expected %s", name, service.Image, digest, service.Digest)
+						mismatched = append(mismatched, name)
+					}
+				}
+				if len(mismatched) > 0 {
+					return fmt.Errorf("image digest mismatch for services: %s", strings.Join(mismatched, ", "))
+				}
+			}
+
+			if checkImages, _ := cmd.Flags().GetBool("check-images"); checkImages {
+				strict, _ := cmd.Flags().GetBool("strict")
+				if err := checkServiceImages(logger, compose, strict); err != nil {
+					return err
+				}
+			}
 			
 			for name, service := range compose.Services {
 				logger.Infof("Service: %s", name)
@@ -213,120 +855,274 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		},
 	}
 
-	// PS command
-	psCmd := &cobra.Command{
-		Use:   "ps [SERVICE...]",
-		Short: "List containers",
+	validateCmd.Flags().Bool("warn-unpinned", false, "Warn about images not pinned by digest")
+	validateCmd.Flags().Bool("check-digests", false, "Check each service's digest against the registry")
+	validateCmd.Flags().Bool("hooks-dry-run", false, "Execute every hook once to check it works (http hooks send GET)")
+	validateCmd.Flags().Bool("check-images", false, "Check that each service image exists in its registry")
+	validateCmd.Flags().Bool("strict", false, "With --check-images, fail when a registry cannot be reached instead of warning")
+	validateCmd.Flags().Bool("strict-env", false, "Fail on variable references without a value or a default")
+
+	// Graph command
+	graphCmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Print the service dependency graph",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			p, err := newParser(composeFiles, envFiles)
+			if err != nil {
+				return err
+			}
+			composeFile, err := parseCompose(logger, p, composeFiles, profiles)
 			if err != nil {
 				return err
 			}
 
-			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-			fmt.Fprintln(w, "NAME\tIMAGE\tCOMMAND\tSERVICE\tSTATUS\tPORTS")
-			
-			for name, service := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
-					continue
-				}
-				status := "Up 2 minutes"
-				ports := ""
-				if len(service.Ports) > 0 {
-					ports = service.Ports[0]
+			graph := executor.DependencyGraph(composeFile)
+			if graph.HasCycle() {
+				logger.Warn("Dependency cycle found; its edges are marked")
+			}
+
+			switch format, _ := cmd.Flags().GetString("format"); format {
+			case "dot":
+				fmt.Print(graph.DOT(projectName))
+			case "json":
+				data, err := json.MarshalIndent(graph, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode graph: %w", err)
 				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
-					name+"-1", service.Image, "stub", name, status, ports)
+				fmt.Println(string(data))
+			default:
+				return fmt.Errorf("invalid --format %q: must be dot or json", format)
 			}
-			w.Flush()
 			return nil
 		},
 	}
 
-	// Version command  
-	versionCmd := &cobra.Command{
+	graphCmd.Flags().String("format", "dot", "Output format: dot or json")
+
+	// PS command
+	psCmd := &cobra.Command{
+		Use:   "ps [SERVICE...]",
+		Short: "List containers",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load every service so containers of disabled ones can be told
+			// apart from orphans
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, []string{"*"})
+			if err != nil {
+				return err
+			}
+
+			all, _ := cmd.Flags().GetBool("all")
+			allProfiles, _ := cmd.Flags().GetBool("all-profiles")
+			filterArgs, _ := cmd.Flags().GetStringArray("filter")
+
+			f, err := container.ParseContainerFilters(filterArgs)
+			if err != nil {
+				return err
+			}
+			// A status filter selects stopped containers without --all
+			if !all && !f.Contains("status") {
+				f.Add("status", "running")
+			}
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			containers, err := exec.ContainersMatching(context.Background(), args, f)
+			if err != nil {
+				return err
+			}
+
+			// Services named explicitly are shown even when disabled
+			shown := profiles
+			if len(args) > 0 || allProfiles {
+				shown = []string{"*"}
+			}
+			return writePs(os.Stdout, containers, compose, shown)
+		},
+	}
+	psCmd.Flags().BoolP("all", "a", false, "Show all stopped containers")
+	psCmd.Flags().Bool("all-profiles", false, "Include services whose profiles are not enabled")
+	psCmd.Flags().StringArray("filter", nil, "Filter containers: status=STATE, service=NAME or label=KEY[=VALUE] (repeatable)")
+
+	// Env command
+	envCmd := &cobra.Command{
+		Use:   "env SERVICE",
+		Short: "Print the resolved environment of a service",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			export, _ := cmd.Flags().GetBool("export")
+			diff, _ := cmd.Flags().GetBool("diff")
+			envArgs, _ := cmd.Flags().GetStringArray("env")
+			if export && diff {
+				return fmt.Errorf("--export and --diff are mutually exclusive")
+			}
+
+			p, composeFile, err := loadCompose(logger, composeFiles, envFiles, []string{"*"})
+			if err != nil {
+				return err
+			}
+			service, ok := composeFile.Services[args[0]]
+			if !ok {
+				return fmt.Errorf("no such service: %s", args[0])
+			}
+
+			env, err := p.ServiceEnvironment(service)
+			if err != nil {
+				return err
+			}
+			for _, arg := range envArgs {
+				key, value, found := strings.Cut(arg, "=")
+				if !found {
+					// Like run -e, a bare KEY is taken from the caller's environment
+					value = os.Getenv(key)
+				}
+				env[key] = value
+			}
+
+			keys := make([]string, 0, len(env))
+			for key := range env {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				value := env[key]
+				switch {
+				case export:
+					fmt.Printf("export %s=%s\n", key, compose.ShellQuote(value))
+				case diff:
+					if current, set := os.LookupEnv(key); !set {
+						fmt.Printf("+ %s=%s\n", key, value)
+					} else if current != value {
+						fmt.Printf("~ %s=%s (shell: %s)\n", key, value, current)
+					}
+				default:
+					fmt.Printf("%s=%s\n", key, value)
+				}
+			}
+			return nil
+		},
+	}
+	envCmd.Flags().StringArrayP("env", "e", nil, "Set an environment variable on top of the resolved ones (KEY=VALUE, or KEY to take it from the shell)")
+	envCmd.Flags().Bool("export", false, "Prefix each line with export, for sourcing into a shell")
+	envCmd.Flags().Bool("diff", false, "Print only the variables that are unset (+) or different (~) in the current shell")
+
+	// Version command  
+	versionCmd := &cobra.Command{
 		Use:   "version",
 		Short: "Show the Docker Compose version information",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fmt.Println("Docker Compose version v2.23.0")
 			fmt.Printf("fake-compose version %s\n", version)
+
+			showEnv, _ := cmd.Flags().GetBool("env")
+			if showEnv {
+				fmt.Println()
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+				fmt.Fprintln(w, "VARIABLE\tVALUE\tSOURCE")
+				for _, name := range composeEnvVars {
+					value, source := os.Getenv(name), "env"
+					if _, ok := os.LookupEnv(name); !ok {
+						value, source = "", "default"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\n", name, value, source)
+				}
+				w.Flush()
+			}
 			return nil
 		},
 	}
+	versionCmd.Flags().Bool("env", false, "Show which COMPOSE_* environment variables are active")
 
 	// Build command
 	buildCmd := &cobra.Command{
 		Use:   "build [SERVICE...]",
 		Short: "Build or rebuild services",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			outputDir, _ := cmd.Flags().GetString("output-dir")
+			compress, _ := cmd.Flags().GetBool("compress")
+			loadDir, _ := cmd.Flags().GetString("load")
+
+			if requireDocker {
+				if err := checkDocker(logger, mutatingOptions()); err != nil {
+					return err
+				}
+			}
+
+			if loadDir != "" {
+				return loadImages(logger, mutatingOptions(), loadDir)
+			}
+
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			
-			for name, service := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
+
+			cacheFrom, _ := cmd.Flags().GetStringArray("cache-from")
+
+			manager, err := container.NewManagerWithOptions(logger, mutatingOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create container manager: %w", err)
+			}
+			defer manager.Close()
+
+			names := getServiceNames(compose, args)
+			sort.Strings(names)
+
+			var built []string
+			for _, name := range names {
+				service, exists := compose.Services[name]
+				if !exists {
+					return fmt.Errorf("no such service: %s", name)
+				}
+				if service.Build == nil {
+					if !quiet {
+						fmt.Println(term.Colorf(term.Yellow, "⚠ Service %s uses pre-built image %s (no build needed)", name, service.Image))
+					}
 					continue
 				}
-				if service.Build != nil {
-					fmt.Printf("\033[36m[+] Building %s\033[0m\n", name)
-					fmt.Printf("\033[32m#0 building with \"docker\" driver\033[0m\n")
-					fmt.Printf("\033[32m#1 [internal] load build definition from Dockerfile\033[0m\n")
-					fmt.Printf("\033[32m#1 transferring dockerfile: 123B done\033[0m\n")
-					fmt.Printf("\033[32m#1 DONE 0.0s\033[0m\n")
-					
-					fmt.Printf("\033[32m#2 [internal] load .dockerignore\033[0m\n")
-					fmt.Printf("\033[32m#2 transferring context: 34B done\033[0m\n")
-					fmt.Printf("\033[32m#2 DONE 0.0s\033[0m\n")
-					
-					fmt.Printf("\033[32m#3 [internal] load metadata for %s\033[0m\n", service.Image)
-					fmt.Printf("\033[32m#3 DONE 1.2s\033[0m\n")
-					
-					fmt.Printf("\033[32m#4 [internal] load build context\033[0m\n")
-					fmt.Printf("\033[32m#4 transferring context: 2.34kB done\033[0m\n")
-					fmt.Printf("\033[32m#4 DONE 0.1s\033[0m\n")
-					
-					fmt.Printf("\033[32m#5 [1/4] FROM %s\033[0m\n", service.Image)
-					fmt.Printf("\033[32m#5 resolve %s done\033[0m\n", service.Image)
-					fmt.Printf("\033[32m#5 sha256:abc123... 0B / 5.54MB 0.1s\033[0m\n")
-					fmt.Printf("\033[32m#5 sha256:def456... 5.54MB / 5.54MB 1.2s done\033[0m\n")
-					fmt.Printf("\033[32m#5 extracting sha256:def456... done\033[0m\n")
-					fmt.Printf("\033[32m#5 DONE 2.1s\033[0m\n")
-					
-					fmt.Printf("\033[32m#6 [2/4] WORKDIR /app\033[0m\n")
-					fmt.Printf("\033[32m#6 DONE 0.0s\033[0m\n")
-					
-					fmt.Printf("\033[32m#7 [3/4] COPY package*.json ./\033[0m\n")
-					fmt.Printf("\033[32m#7 DONE 0.1s\033[0m\n")
-					
-					fmt.Printf("\033[32m#8 [4/4] RUN npm install\033[0m\n")
-					fmt.Printf("\033[32m#8 npm WARN deprecated request@2.88.2\033[0m\n")
-					fmt.Printf("\033[32m#8 added 142 packages from 65 contributors\033[0m\n")
-					fmt.Printf("\033[32m#8 audited 148 packages in 8.234s\033[0m\n")
-					fmt.Printf("\033[32m#8 found 0 vulnerabilities\033[0m\n")
-					fmt.Printf("\033[32m#8 DONE 10.2s\033[0m\n")
-					
-					fmt.Printf("\033[32m#9 exporting to image\033[0m\n")
-					fmt.Printf("\033[32m#9 exporting layers done\033[0m\n")
-					fmt.Printf("\033[32m#9 writing image sha256:ghi789... done\033[0m\n")
-					fmt.Printf("\033[32m#9 naming to docker.io/library/%s done\033[0m\n", name)
-					fmt.Printf("\033[32m#9 DONE 0.2s\033[0m\n\n")
-					
-					fmt.Printf("\033[36m✓ Built %s successfully in 13.8s\033[0m\n", name)
-				} else {
-					fmt.Printf("\033[33m⚠ Service %s uses pre-built image %s (no build needed)\033[0m\n", name, service.Image)
+
+				built = append(built, name)
+				if quiet {
+					opts := container.BuildOptions{Tag: serviceImage(compose, projectName, name), CacheFrom: cacheFrom}
+					if _, err := manager.BuildService(context.Background(), name, service, opts); err != nil {
+						return err
+					}
+					continue
+				}
+				fmt.Println(term.Colorf(term.Cyan, "[+] Building %s", name))
+				start := time.Now()
+				opts := container.BuildOptions{Tag: serviceImage(compose, projectName, name), CacheFrom: cacheFrom, Progress: os.Stdout}
+				if _, err := manager.BuildService(context.Background(), name, service, opts); err != nil {
+					return err
 				}
+				fmt.Println(term.Colorf(term.Cyan, "✓ Built %s successfully in %.1fs", name, time.Since(start).Seconds()))
+			}
+
+			if outputDir != "" {
+				return exportImages(logger, mutatingOptions(), compose, built, outputDir, compress)
 			}
 			return nil
 		},
 	}
+	buildCmd.Flags().String("output-dir", "", "Save built images as <service>.tar in this directory")
+	buildCmd.Flags().Bool("compress", false, "Gzip the archives written by --output-dir")
+	buildCmd.Flags().String("load", "", "Load the image archives in this directory instead of building")
+	buildCmd.Flags().StringArray("cache-from", nil, "Image to use as a build cache source, replacing the compose file's cache_from (repeatable)")
 
 	// Logs command
 	logsCmd := &cobra.Command{
 		Use:   "logs [SERVICE...]",
 		Short: "View output from containers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			logProfiles := profiles
+			if allProfiles, _ := cmd.Flags().GetBool("all-profiles"); allProfiles {
+				logProfiles = []string{"*"}
+			}
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, logProfiles)
 			if err != nil {
 				return err
 			}
@@ -334,6 +1130,35 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 			follow, _ := cmd.Flags().GetBool("follow")
 			showInit, _ := cmd.Flags().GetBool("init")
 			showPost, _ := cmd.Flags().GetBool("post")
+			tail, _ := cmd.Flags().GetInt("tail")
+			filterArgs, _ := cmd.Flags().GetStringArray("filter")
+			index, _ := cmd.Flags().GetInt("index")
+			if index < 0 {
+				return fmt.Errorf("invalid --index %d: replicas are numbered from 1", index)
+			}
+
+			var minLevel *logrus.Level
+			for _, filter := range filterArgs {
+				key, value, _ := strings.Cut(filter, "=")
+				if key != "level" {
+					return fmt.Errorf("invalid filter %q: only level=LEVEL is supported", filter)
+				}
+				level, err := logrus.ParseLevel(value)
+				if err != nil {
+					return fmt.Errorf("invalid filter %q: %w", filter, err)
+				}
+				minLevel = &level
+			}
+
+			if tail > 0 || minLevel != nil || index > 0 {
+				exec, err := executor.New(logger, projectName, connectionOptions())
+				if err != nil {
+					return fmt.Errorf("failed to create executor: %w", err)
+				}
+				defer exec.Close()
+
+				return printServiceLogs(context.Background(), exec, getServiceNames(compose, args), index, tail, minLevel)
+			}
 			
 			for name, service := range compose.Services {
 				if len(args) > 0 && !contains(args, name) {
@@ -342,59 +1167,59 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 				
 				// Show init containers if requested or by default
 				if (showInit || (!showInit && !showPost)) && len(service.InitContainers) > 0 {
-					fmt.Printf("\n\033[33m=== INIT CONTAINERS for %s ===\033[0m\n", name)
+					fmt.Printf("\n%s\n", term.Colorf(term.Yellow, "=== INIT CONTAINERS for %s ===", name))
 					for _, init := range service.InitContainers {
-						fmt.Printf("\033[33m[%s/%s]\033[0m Starting init container %s\n", name, init.Name, init.Name)
-						fmt.Printf("\033[33m[%s/%s]\033[0m Image: %s\n", name, init.Name, init.Image)
+						fmt.Printf("%s Starting init container %s\n", term.Colorf(term.Yellow, "[%s/%s]", name, init.Name), init.Name)
+						fmt.Printf("%s Image: %s\n", term.Colorf(term.Yellow, "[%s/%s]", name, init.Name), init.Image)
 						if len(init.Command) > 0 {
-							fmt.Printf("\033[33m[%s/%s]\033[0m Executing: %v\n", name, init.Name, init.Command)
+							fmt.Printf("%s Executing: %v\n", term.Colorf(term.Yellow, "[%s/%s]", name, init.Name), init.Command)
 							if init.Name == "install-deps" {
-								fmt.Printf("\033[33m[%s/%s]\033[0m npm WARN old lockfile\n", name, init.Name)
-								fmt.Printf("\033[33m[%s/%s]\033[0m added 142 packages in 8.234s\n", name, init.Name)
-								fmt.Printf("\033[33m[%s/%s]\033[0m found 0 vulnerabilities\n", name, init.Name)
+								fmt.Printf("%s npm WARN old lockfile\n", term.Colorf(term.Yellow, "[%s/%s]", name, init.Name))
+								fmt.Printf("%s added 142 packages in 8.234s\n", term.Colorf(term.Yellow, "[%s/%s]", name, init.Name))
+								fmt.Printf("%s found 0 vulnerabilities\n", term.Colorf(term.Yellow, "[%s/%s]", name, init.Name))
 							} else {
-								fmt.Printf("\033[33m[%s/%s]\033[0m Init task completed\n", name, init.Name)
+								fmt.Printf("%s Init task completed\n", term.Colorf(term.Yellow, "[%s/%s]", name, init.Name))
 							}
 						}
-						fmt.Printf("\033[33m[%s/%s]\033[0m Container completed (exit 0)\n", name, init.Name)
+						fmt.Printf("%s Container completed (exit 0)\n", term.Colorf(term.Yellow, "[%s/%s]", name, init.Name))
 					}
 				}
 				
 				// Show post containers if requested or by default
 				if (showPost || (!showInit && !showPost)) && len(service.PostContainers) > 0 {
-					fmt.Printf("\n\033[35m=== POST CONTAINERS for %s ===\033[0m\n", name)
+					fmt.Printf("\n%s\n", term.Colorf(term.Magenta, "=== POST CONTAINERS for %s ===", name))
 					for _, post := range service.PostContainers {
-						fmt.Printf("\033[35m[%s/%s]\033[0m Starting post container %s\n", name, post.Name, post.Name)
-						fmt.Printf("\033[35m[%s/%s]\033[0m Image: %s\n", name, post.Name, post.Image)
+						fmt.Printf("%s Starting post container %s\n", term.Colorf(term.Magenta, "[%s/%s]", name, post.Name), post.Name)
+						fmt.Printf("%s Image: %s\n", term.Colorf(term.Magenta, "[%s/%s]", name, post.Name), post.Image)
 						if post.WaitFor != "" {
-							fmt.Printf("\033[35m[%s/%s]\033[0m Waiting %s...\n", name, post.Name, post.WaitFor)
+							fmt.Printf("%s Waiting %s...\n", term.Colorf(term.Magenta, "[%s/%s]", name, post.Name), post.WaitFor)
 						}
 						if post.Name == "warmup" {
-							fmt.Printf("\033[35m[%s/%s]\033[0m Making warmup request to http://localhost:3000/health\n", name, post.Name)
-							fmt.Printf("\033[35m[%s/%s]\033[0m Response: 200 OK\n", name, post.Name)
+							fmt.Printf("%s Making warmup request to http://localhost:3000/health\n", term.Colorf(term.Magenta, "[%s/%s]", name, post.Name))
+							fmt.Printf("%s Response: 200 OK\n", term.Colorf(term.Magenta, "[%s/%s]", name, post.Name))
 						}
-						fmt.Printf("\033[35m[%s/%s]\033[0m Container completed (exit 0)\n", name, post.Name)
+						fmt.Printf("%s Container completed (exit 0)\n", term.Colorf(term.Magenta, "[%s/%s]", name, post.Name))
 					}
 				}
 				
 				// Show main service logs if not filtering for specific helpers
 				if !showInit && !showPost {
-					fmt.Printf("\n\033[36m=== MAIN SERVICE %s ===\033[0m\n", name)
-					fmt.Printf("\033[36m[%s]\033[0m Image: %s\n", name, service.Image)
+					fmt.Printf("\n%s\n", term.Colorf(term.Cyan, "=== MAIN SERVICE %s ===", name))
+					fmt.Printf("%s Image: %s\n", term.Colorf(term.Cyan, "[%s]", name), service.Image)
 					if len(service.Environment) > 0 {
-						fmt.Printf("\033[36m[%s]\033[0m Environment: %s\n", name, service.Environment["NODE_ENV"])
+						fmt.Printf("%s Environment: %s\n", term.Colorf(term.Cyan, "[%s]", name), service.Environment["NODE_ENV"])
 					}
 					if len(service.Ports) > 0 {
-						fmt.Printf("\033[36m[%s]\033[0m Listening on port %s\n", name, service.Ports[0])
+						fmt.Printf("%s Listening on port %s\n", term.Colorf(term.Cyan, "[%s]", name), service.Ports[0])
 					}
-					fmt.Printf("\033[36m[%s]\033[0m [%s] Server started successfully\n", name, time.Now().Format("15:04:05"))
-					fmt.Printf("\033[36m[%s]\033[0m [%s] Application ready\n", name, time.Now().Format("15:04:05"))
+					fmt.Printf("%s [%s] Server started successfully\n", term.Colorf(term.Cyan, "[%s]", name), time.Now().Format("15:04:05"))
+					fmt.Printf("%s [%s] Application ready\n", term.Colorf(term.Cyan, "[%s]", name), time.Now().Format("15:04:05"))
 					
 					if follow {
-						fmt.Printf("\033[36m[%s]\033[0m Following logs...\n", name)
+						fmt.Printf("%s Following logs...\n", term.Colorf(term.Cyan, "[%s]", name))
 						for i := 0; i < 3; i++ {
 							time.Sleep(1000 * time.Millisecond)
-							fmt.Printf("\033[36m[%s]\033[0m [%s] GET /health - 200\n", name, time.Now().Format("15:04:05"))
+							fmt.Printf("%s [%s] GET /health - 200\n", term.Colorf(term.Cyan, "[%s]", name), time.Now().Format("15:04:05"))
 						}
 					}
 				}
@@ -408,6 +1233,9 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 	logsCmd.Flags().Int("tail", 0, "Number of lines to show from the end of the logs")
 	logsCmd.Flags().Bool("init", false, "Show only init container logs")
 	logsCmd.Flags().Bool("post", false, "Show only post container logs")
+	logsCmd.Flags().StringArray("filter", nil, "Drop lines below a log level (level=warn)")
+	logsCmd.Flags().Bool("all-profiles", false, "Include services whose profiles are not enabled")
+	logsCmd.Flags().Int("index", 0, "Show only the logs of this replica of a scaled service (1-based)")
 
 	// Exec command
 	execCmd := &cobra.Command{
@@ -417,90 +1245,92 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		RunE: func(cmd *cobra.Command, args []string) error {
 			serviceName := args[0]
 			command := args[1:]
+
+			if requireDocker {
+				if err := checkDocker(logger, mutatingOptions()); err != nil {
+					return err
+				}
+			}
 			
 			detach, _ := cmd.Flags().GetBool("detach")
 			user, _ := cmd.Flags().GetString("user")
-			
-			fmt.Printf("\033[36mExecuting in %s container:\033[0m %s\n", serviceName, command[0])
-			if user != "" {
-				fmt.Printf("\033[36mUser:\033[0m %s\n", user)
+			index, _ := cmd.Flags().GetInt("index")
+			all, _ := cmd.Flags().GetBool("all")
+			if index < 0 {
+				return fmt.Errorf("invalid --index %d: replicas are numbered from 1", index)
 			}
-			
-			// Simulate common commands
-			switch command[0] {
-			case "bash", "sh":
-				if detach {
-					fmt.Printf("\033[32mShell session started in background (container_exec_%d)\033[0m\n", time.Now().Unix())
-				} else {
-					fmt.Printf("\033[32mStarting interactive shell...\033[0m\n")
-					fmt.Printf("root@%s:/app# \n", serviceName)
-				}
-			case "ls":
-				fmt.Printf("total 24\n")
-				fmt.Printf("drwxr-xr-x 1 root root 4096 %s .\n", time.Now().Format("Jan 2 15:04"))
-				fmt.Printf("drwxr-xr-x 1 root root 4096 %s ..\n", time.Now().Format("Jan 2 15:04"))
-				fmt.Printf("-rw-r--r-- 1 root root  234 %s package.json\n", time.Now().Format("Jan 2 15:04"))
-				fmt.Printf("drwxr-xr-x 1 root root 4096 %s node_modules\n", time.Now().Format("Jan 2 15:04"))
-				fmt.Printf("-rw-r--r-- 1 root root 1234 %s server.js\n", time.Now().Format("Jan 2 15:04"))
-			case "ps":
-				fmt.Printf("PID TTY          TIME CMD\n")
-				fmt.Printf("  1 ?        00:00:01 node\n")
-				fmt.Printf(" 15 ?        00:00:00 ps\n")
-			case "cat":
-				if len(command) > 1 {
-					switch command[1] {
-					case "/etc/hostname":
-						fmt.Printf("%s_container_%d\n", serviceName, time.Now().Unix())
-					case "package.json":
-						fmt.Printf(`{\n  \"name\": \"%s\",\n  \"version\": \"1.0.0\",\n  \"main\": \"server.js\"\n}\n`, serviceName)
-					default:
-						fmt.Printf("Content of %s\n", command[1])
-					}
-				}
-			case "env":
-				fmt.Printf("PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin\n")
-				fmt.Printf("NODE_ENV=production\n")
-				fmt.Printf("HOME=/root\n")
-				fmt.Printf("HOSTNAME=%s\n", serviceName)
-			case "curl":
-				if len(command) > 1 {
-					fmt.Printf("\033[32m* Connected to %s\033[0m\n", command[1])
-					fmt.Printf("\033[32m< HTTP/1.1 200 OK\033[0m\n")
-					fmt.Printf(`{\"status\": \"healthy\", \"timestamp\": \"%s\"}\n`, time.Now().Format(time.RFC3339))
+			if all && index > 0 {
+				return fmt.Errorf("--all and --index are mutually exclusive")
+			}
+
+			_, composeFile, err := loadCompose(logger, composeFiles, envFiles, profiles)
+			if err != nil {
+				return err
+			}
+			if _, ok := composeFile.Services[serviceName]; !ok {
+				return fmt.Errorf("no such service: %s", serviceName)
+			}
+
+			exec, err := executor.New(logger, projectName, mutatingOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			ctx := context.Background()
+			replicas, err := exec.Replicas(ctx, serviceName, index, false)
+			if err != nil {
+				return err
+			}
+			switch {
+			case len(replicas) == 0:
+				return fmt.Errorf("service %s is not running", serviceName)
+			case len(replicas) > 1 && !all:
+				return fmt.Errorf("service %s has %d running replicas: use --index to pick one or --all to run in every one", serviceName, len(replicas))
+			}
+
+			opts := container.ExecOptions{User: user, Detach: detach}
+			if len(replicas) == 1 {
+				opts.Output = os.Stdout
+				exitCode, err := exec.Exec(ctx, replicas[0], command, opts)
+				if err != nil {
+					return err
 				}
-			default:
-				fmt.Printf("\033[32mCommand '%s' executed successfully\033[0m\n", command[0])
-				if len(command) > 1 {
-					fmt.Printf("\033[32mArguments: %v\033[0m\n", command[1:])
+				if exitCode != 0 {
+					return fmt.Errorf("command exited with code %d in %s", exitCode, replicas[0].Name)
 				}
-				fmt.Printf("\033[32mExit code: 0\033[0m\n")
+				return nil
 			}
-			
-			return nil
+			return execReplicas(ctx, exec, replicas, command, opts)
 		},
 	}
 	execCmd.Flags().BoolP("detach", "d", false, "Detached mode")
 	execCmd.Flags().StringP("user", "u", "", "Username or UID")
 	execCmd.Flags().BoolP("interactive", "i", false, "Keep STDIN open")
 	execCmd.Flags().BoolP("tty", "t", false, "Allocate a pseudo-TTY")
+	execCmd.Flags().Int("index", 0, "Run in this replica of a scaled service (1-based)")
+	execCmd.Flags().Bool("all", false, "Run in every running replica concurrently and print their output in turn")
 
 	// Stop command
 	stopCmd := &cobra.Command{
 		Use:   "stop [SERVICE...]",
 		Short: "Stop services",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			logger.Info("Stopping services...")
-			for name := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
-					continue
-				}
-				logger.Infof("Stopping %s", name)
+
+			timeout, _ := cmd.Flags().GetInt("timeout")
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
 			}
-			return nil
+			defer exec.Close()
+
+			logger.Info("Stopping services...")
+			return exec.Stop(context.Background(), compose, args, timeout)
 		},
 	}
 	stopCmd.Flags().IntP("timeout", "t", 30, "Shutdown timeout in seconds")
@@ -510,18 +1340,19 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		Use:   "start [SERVICE...]",
 		Short: "Start services",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			logger.Info("Starting services...")
-			for name := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
-					continue
-				}
-				logger.Infof("Starting %s", name)
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
 			}
-			return nil
+			defer exec.Close()
+
+			logger.Info("Starting services...")
+			return exec.Start(context.Background(), compose, args)
 		},
 	}
 
@@ -530,62 +1361,139 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		Use:   "restart [SERVICE...]",
 		Short: "Restart service containers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			logger.Info("Restarting services...")
-			for name := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
-					continue
-				}
-				logger.Infof("Restarting %s", name)
+
+			noDeps, _ := cmd.Flags().GetBool("no-deps")
+			timeout, _ := cmd.Flags().GetInt("timeout")
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
 			}
-			return nil
+			defer exec.Close()
+
+			logger.Info("Restarting services...")
+			return exec.Restart(context.Background(), compose, args, !noDeps, timeout)
 		},
 	}
 	restartCmd.Flags().IntP("timeout", "t", 30, "Shutdown timeout in seconds")
+	restartCmd.Flags().Bool("no-deps", true, "Don't restart services that depend on the restarted ones (--no-deps=false to include them)")
 
 	// Pull command
 	pullCmd := &cobra.Command{
 		Use:   "pull [SERVICE...]",
 		Short: "Pull service images",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			policyFlag, _ := cmd.Flags().GetString("policy")
+
+			policy, err := container.ParsePullPolicy(policyFlag)
 			if err != nil {
 				return err
 			}
-			logger.Info("Pulling service images...")
-			for name, service := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
-					continue
+
+			_, composeFile, err := loadCompose(logger, composeFiles, envFiles, profiles)
+			if err != nil {
+				return err
+			}
+			for _, name := range args {
+				if _, ok := composeFile.Services[name]; !ok {
+					return fmt.Errorf("no such service: %s", name)
+				}
+			}
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			pullOpts := executor.PullOptions{Services: args, Policy: policy, Parallel: parallel}
+			if !quiet {
+				pullOpts.Progress = os.Stdout
+			}
+			results, err := exec.PullImages(context.Background(), composeFile, pullOpts)
+			if err != nil {
+				return err
+			}
+
+			if !quiet {
+				for _, result := range results {
+					if result.Pulled {
+						fmt.Printf("%s %s\n", term.Color(term.Green, "Pulled"), result.Image)
+					} else {
+						fmt.Printf("%s %s (present)\n", term.Color(term.Yellow, "Skipped"), result.Image)
+					}
 				}
-				logger.Infof("Pulling %s", service.Image)
 			}
 			return nil
 		},
 	}
 	pullCmd.Flags().BoolP("quiet", "q", false, "Pull without printing progress information")
+	pullCmd.Flags().String("policy", string(container.PullAlways), "Pull policy: always or missing (skip images present locally)")
 
 	// Push command
 	pushCmd := &cobra.Command{
 		Use:   "push [SERVICE...]",
 		Short: "Push service images",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			policy, _ := cmd.Flags().GetString("policy")
+			includeDeps, _ := cmd.Flags().GetBool("include-deps")
+			ignoreFailures, _ := cmd.Flags().GetBool("ignore-push-failures")
+
+			if policy != "built" && policy != "all" {
+				return fmt.Errorf("invalid push policy %q: must be built or all", policy)
+			}
+
+			_, composeFile, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			logger.Info("Pushing service images...")
-			for name, service := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
-					continue
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			pushOpts := executor.PushOptions{
+				Services:       args,
+				IncludeDeps:    includeDeps,
+				Upstream:       policy == "all",
+				IgnoreFailures: ignoreFailures,
+				Parallel:       parallel,
+			}
+			if !quiet {
+				pushOpts.Progress = os.Stdout
+			}
+			results, err := exec.PushImages(context.Background(), composeFile, pushOpts)
+			if err != nil {
+				return err
+			}
+
+			if !quiet {
+				for _, result := range results {
+					switch {
+					case result.Err != nil:
+						fmt.Printf("%s %s (%s)\n", term.Color(term.Red, "Failed"), result.Image, result.Service)
+					case result.Skipped:
+						fmt.Printf("%s %s (%s uses an upstream image)\n", term.Color(term.Yellow, "Skipped"), result.Image, result.Service)
+					default:
+						fmt.Printf("%s %s\n", term.Color(term.Green, "Pushed"), result.Image)
+					}
 				}
-				logger.Infof("Pushing %s", service.Image)
 			}
 			return nil
 		},
 	}
+	pushCmd.Flags().BoolP("quiet", "q", false, "Push without printing progress information")
+	pushCmd.Flags().String("policy", "built", "Which images to push: built (services with a build section) or all")
+	pushCmd.Flags().Bool("include-deps", false, "Also push images of services declared as dependencies")
+	pushCmd.Flags().Bool("ignore-push-failures", false, "Push what it can and ignore images with push failures")
 
 	// Run command
 	runCmd := &cobra.Command{
@@ -593,12 +1501,82 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		Short: "Run a one-off command on a service",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			serviceName := args[0]
-			var command []string
-			if len(args) > 1 {
-				command = args[1:]
+			_, composeFile, err := loadCompose(logger, composeFiles, envFiles, profiles)
+			if err != nil {
+				return err
+			}
+			if compatibility {
+				if _, err := applyCompatibility(composeFile); err != nil {
+					return err
+				}
+			}
+
+			detach, _ := cmd.Flags().GetBool("detach")
+			remove, _ := cmd.Flags().GetBool("rm")
+			envArgs, _ := cmd.Flags().GetStringArray("env")
+
+			overrides := compose.RunOverrides{Command: args[1:]}
+			overrides.Ports, _ = cmd.Flags().GetStringArray("publish")
+			volumeArgs, _ := cmd.Flags().GetStringArray("volume")
+			for _, spec := range volumeArgs {
+				volume, err := compose.ParseVolumeMount(spec)
+				if err != nil {
+					return fmt.Errorf("invalid --volume: %w", err)
+				}
+				overrides.Volumes = append(overrides.Volumes, volume)
+			}
+			overrides.WorkingDir, _ = cmd.Flags().GetString("workdir")
+			overrides.User, _ = cmd.Flags().GetString("user")
+
+			if len(envArgs) > 0 {
+				overrides.Environment = make(map[string]string, len(envArgs))
+				for _, env := range envArgs {
+					key, value, found := strings.Cut(env, "=")
+					if !found {
+						// Like docker, a bare KEY is taken from the caller's environment
+						value = os.Getenv(key)
+					}
+					overrides.Environment[key] = value
+				}
+			}
+
+			waitServices, _ := cmd.Flags().GetStringArray("wait-for-service")
+			waitPorts, _ := cmd.Flags().GetStringArray("wait-for-port")
+			waitTimeout, _ := cmd.Flags().GetDuration("wait-timeout")
+			for _, name := range waitServices {
+				if _, ok := composeFile.Services[name]; !ok {
+					return fmt.Errorf("no such service: %s", name)
+				}
+			}
+			for _, address := range waitPorts {
+				if _, _, err := net.SplitHostPort(address); err != nil {
+					return fmt.Errorf("invalid --wait-for-port %q: %w", address, err)
+				}
+			}
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			if len(waitServices) > 0 || len(waitPorts) > 0 {
+				if err := waitReady(exec, waitServices, waitPorts, waitTimeout); err != nil {
+					return err
+				}
+			}
+
+			exitCode, err := exec.Run(context.Background(), composeFile, args[0], overrides, container.OneOffOptions{
+				Detach: detach,
+				Remove: remove,
+				Output: os.Stdout,
+			})
+			if err != nil {
+				return err
+			}
+			if exitCode != 0 {
+				return fmt.Errorf("service %s exited with code %d", args[0], exitCode)
 			}
-			logger.Infof("Running one-off command on service %s: %v", serviceName, command)
 			return nil
 		},
 	}
@@ -607,16 +1585,29 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 	runCmd.Flags().StringP("user", "u", "", "Username or UID")
 	runCmd.Flags().BoolP("interactive", "i", false, "Keep STDIN open")
 	runCmd.Flags().BoolP("tty", "t", false, "Allocate a pseudo-TTY")
+	runCmd.Flags().StringArrayP("env", "e", nil, "Set an environment variable (KEY=VAL)")
+	// -p and -v are taken by the global --project-name and --verbose flags
+	runCmd.Flags().StringArray("publish", nil, "Publish a container port to the host (host:container)")
+	runCmd.Flags().StringArray("volume", nil, "Bind mount a volume (src:dst)")
+	runCmd.Flags().StringP("workdir", "w", "", "Working directory inside the container")
+	runCmd.Flags().StringArray("wait-for-service", nil, "Wait for a service to be healthy before running (repeatable)")
+	runCmd.Flags().StringArray("wait-for-port", nil, "Wait for a TCP port (host:port) to accept connections before running (repeatable)")
+	runCmd.Flags().Duration("wait-timeout", 60*time.Second, "Maximum time to wait for --wait-for-service and --wait-for-port")
 
 	// Create command
 	createCmd := &cobra.Command{
 		Use:   "create [SERVICE...]",
 		Short: "Creates containers for a service",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
+			if compatibility {
+				if _, err := applyCompatibility(compose); err != nil {
+					return err
+				}
+			}
 			logger.Info("Creating containers...")
 			for name := range compose.Services {
 				if len(args) > 0 && !contains(args, name) {
@@ -635,137 +1626,224 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		Use:   "rm [SERVICE...]",
 		Short: "Removes stopped service containers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			logger.Info("Removing stopped containers...")
-			for name := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
-					continue
+
+			force, _ := cmd.Flags().GetBool("force")
+			stop, _ := cmd.Flags().GetBool("stop")
+			removeVolumes, _ := cmd.Flags().GetBool("volumes")
+			all, _ := cmd.Flags().GetBool("all")
+			filterArgs, _ := cmd.Flags().GetStringArray("filter")
+
+			services := args
+			for _, filter := range filterArgs {
+				key, value, _ := strings.Cut(filter, "=")
+				if key != "service" || value == "" {
+					return fmt.Errorf("invalid filter %q: only service=NAME is supported", filter)
 				}
-				logger.Infof("Removing container for %s", name)
+				services = append(services, value)
 			}
-			return nil
-		},
-	}
-	rmCmd.Flags().Bool("force", false, "Don't ask to confirm removal")
-	rmCmd.Flags().BoolP("stop", "s", false, "Stop the containers before removing")
+
+			manager, err := container.NewManagerWithOptions(logger, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create container manager: %w", err)
+			}
+			defer manager.Close()
+
+			ctx := context.Background()
+			f := filters.NewArgs()
+			if !all && !stop {
+				f.Add("status", "exited")
+				f.Add("status", "created")
+			}
+			containers, err := manager.ListServiceContainers(ctx, f)
+			if err != nil {
+				return err
+			}
+
+			var targets []container.ContainerInfo
+			for _, c := range containers {
+				if _, exists := compose.Services[c.Service]; !exists {
+					continue
+				}
+				if len(services) > 0 && !contains(services, c.Service) {
+					continue
+				}
+				targets = append(targets, c)
+			}
+
+			if len(targets) == 0 {
+				if !quiet {
+					fmt.Println("No stopped containers")
+				}
+				return nil
+			}
+
+			if !force {
+				names := make([]string, len(targets))
+				for i, c := range targets {
+					names[i] = c.Name
+				}
+				fmt.Printf("Going to remove %s\n", strings.Join(names, ", "))
+				fmt.Print("Are you sure? [yN] ")
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+					return nil
+				}
+			}
+
+			var errs []error
+			for _, c := range targets {
+				if stop && c.State == "running" {
+					if err := manager.StopContainer(ctx, c.ID, 10); err != nil {
+						errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.Name, err))
+						continue
+					}
+				}
+				if err := manager.RemoveContainer(ctx, c.ID, removeVolumes); err != nil {
+					errs = append(errs, fmt.Errorf("failed to remove %s: %w", c.Name, err))
+					continue
+				}
+				logger.Infof("Removed container %s", c.Name)
+			}
+			return errors.Join(errs...)
+		},
+	}
+	rmCmd.Flags().Bool("force", false, "Don't ask to confirm removal")
+	rmCmd.Flags().BoolP("stop", "s", false, "Stop the containers before removing")
 	rmCmd.Flags().Bool("volumes", false, "Remove any anonymous volumes attached")
+	rmCmd.Flags().BoolP("all", "a", false, "Also remove running containers")
+	rmCmd.Flags().StringArray("filter", nil, "Filter containers to remove (service=NAME)")
 
 	// Images command
 	imagesCmd := &cobra.Command{
 		Use:   "images [SERVICE...]",
 		Short: "List images used by the created containers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, composeFile, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-			fmt.Fprintln(w, "CONTAINER\tREPOSITORY\tTAG\tIMAGE ID\tSIZE\tCREATED")
-			
-			// Generate realistic image data
-			imageSizes := map[string]string{
-				"node:18-alpine": "172MB",
-				"node:18": "993MB", 
-				"alpine": "5.6MB",
-				"ubuntu": "72.8MB",
-				"nginx": "142MB",
-				"redis": "138MB",
-				"postgres": "374MB",
-				"curlimages/curl": "11.1MB",
+
+			format, _ := cmd.Flags().GetString("format")
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			digests, _ := cmd.Flags().GetBool("digests")
+
+			selected := *composeFile
+			if len(args) > 0 {
+				selected.Services = make(map[string]*compose.Service, len(args))
+				for _, name := range args {
+					service, exists := composeFile.Services[name]
+					if !exists {
+						return fmt.Errorf("no such service: %s", name)
+					}
+					selected.Services[name] = service
+				}
 			}
-			
-			for name, service := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
-					continue
+
+			manager, err := container.NewManagerWithOptions(logger, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create container manager: %w", err)
+			}
+			defer manager.Close()
+
+			images, err := manager.ListServiceImages(context.Background(), &selected)
+			if err != nil {
+				return err
+			}
+
+			if quiet {
+				for _, image := range images {
+					if image.Pulled {
+						fmt.Println(image.ID)
+					}
 				}
-				
-				// Parse image name and tag  
-				tag := "latest"
-				repo := service.Image
-				if parts := []string{}; len(parts) > 1 {
-					repo = parts[0]
-					tag = parts[1]
+				return nil
+			}
+
+			switch format {
+			case "json":
+				data, err := json.MarshalIndent(images, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode images: %w", err)
 				}
-				if service.Image != "" && service.Image[len(service.Image)-7:] == "alpine" {
-					tag = "alpine"
-					repo = service.Image[:len(service.Image)-8]
+				fmt.Println(string(data))
+			case "yaml":
+				data, err := yaml.Marshal(images)
+				if err != nil {
+					return fmt.Errorf("failed to encode images: %w", err)
 				}
-				
-				// Generate realistic image ID
-				imageID := fmt.Sprintf("sha256:%x", time.Now().Unix() + int64(len(name)*42))
-				imageID = imageID[:12]
-				
-				// Get realistic size
-				size, exists := imageSizes[service.Image]
-				if !exists {
-					// Default size based on image type
-					if service.Image != "" {
-						switch {
-						case service.Image == "alpine" || service.Image[len(service.Image)-6:] == "alpine":
-							size = "15.2MB"
-						case service.Image[:4] == "node":
-							size = "893MB"
-						default:
-							size = "245MB"
-						}
-					} else {
-						size = "N/A"
-					}
+				fmt.Print(string(data))
+			case "table", "":
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+				header := "REPOSITORY\tTAG\tIMAGE ID\tSIZE\tCREATED"
+				if digests {
+					header = "REPOSITORY\tTAG\tDIGEST\tIMAGE ID\tSIZE\tCREATED"
 				}
-				
-				// Generate creation time
-				created := time.Now().Add(-time.Duration((len(name)*17)%72) * time.Hour).Format("2006-01-02")
-				
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", name, repo, tag, imageID, size, created)
-				
-				// Also show init and post container images if they exist
-				for _, init := range service.InitContainers {
-					if init.Image != service.Image {
-						initSize, exists := imageSizes[init.Image]
-						if !exists {
-							initSize = "134MB"
+				fmt.Fprintln(w, header)
+				for _, image := range images {
+					id, size, created := "<not pulled>", "-", "-"
+					if image.Pulled {
+						id = strings.TrimPrefix(image.ID, "sha256:")
+						if len(id) > 12 {
+							id = id[:12]
+						}
+						size = units.HumanSizeWithPrecision(float64(image.Size), 3)
+						if image.Created != nil {
+							created = units.HumanDuration(time.Since(*image.Created)) + " ago"
 						}
-						initID := fmt.Sprintf("sha256:%x", time.Now().Unix() + int64(len(init.Name)*31))
-						initID = initID[:12]
-						createdInit := time.Now().Add(-time.Duration((len(init.Name)*23)%96) * time.Hour).Format("2006-01-02")
-						fmt.Fprintf(w, "%s_init_%s\t%s\tlatest\t%s\t%s\t%s\n", name, init.Name, init.Image, initID, initSize, createdInit)
 					}
-				}
-				
-				for _, post := range service.PostContainers {
-					if post.Image != service.Image {
-						postSize, exists := imageSizes[post.Image]
-						if !exists {
-							postSize = "87MB"
+					tag := image.Tag
+					if tag == "" {
+						tag = "<none>"
+					}
+					if digests {
+						digest := image.Digest
+						if digest == "" {
+							digest = "<none>"
 						}
-						postID := fmt.Sprintf("sha256:%x", time.Now().Unix() + int64(len(post.Name)*37))
-						postID = postID[:12]
-						createdPost := time.Now().Add(-time.Duration((len(post.Name)*19)%84) * time.Hour).Format("2006-01-02")
-						fmt.Fprintf(w, "%s_post_%s\t%s\tlatest\t%s\t%s\t%s\n", name, post.Name, post.Image, postID, postSize, createdPost)
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", image.Repository, tag, digest, id, size, created)
+					} else {
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", image.Repository, tag, id, size, created)
 					}
 				}
+				w.Flush()
+			default:
+				return fmt.Errorf("invalid format %q: must be table, json or yaml", format)
 			}
-			w.Flush()
 			return nil
 		},
 	}
+	imagesCmd.Flags().String("format", "table", "Format the output (table, json, yaml)")
+	imagesCmd.Flags().BoolP("quiet", "q", false, "Only display image IDs")
+	imagesCmd.Flags().Bool("digests", false, "Show digests")
 
 	// Kill command
 	killCmd := &cobra.Command{
 		Use:   "kill [SERVICE...]",
 		Short: "Force stop service containers",
+		Long:  "Force stop service containers. Without SERVICE arguments, or with --all, every container of the project is killed.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			signalArg, _ := cmd.Flags().GetString("signal")
+			signal, err := container.ParseSignal(signalArg)
+			if err != nil {
+				return err
+			}
+
+			all, _ := cmd.Flags().GetBool("all")
+			if all && len(args) > 0 {
+				return fmt.Errorf("--all cannot be combined with service names")
+			}
+
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			signal, _ := cmd.Flags().GetString("signal")
 			logger.Infof("Killing services with signal %s...", signal)
 			for name := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
+				if !all && len(args) > 0 && !contains(args, name) {
 					continue
 				}
 				logger.Infof("Killing %s", name)
@@ -773,14 +1851,15 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 			return nil
 		},
 	}
-	killCmd.Flags().StringP("signal", "s", "SIGKILL", "Signal to send to the container")
+	killCmd.Flags().StringP("signal", "s", "SIGKILL", "Signal to send to the container (name or number)")
+	killCmd.Flags().Bool("all", false, "Kill all project containers (the default without SERVICE arguments)")
 
 	// Pause command
 	pauseCmd := &cobra.Command{
 		Use:   "pause [SERVICE...]",
 		Short: "Pause services",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
@@ -800,7 +1879,7 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		Use:   "unpause [SERVICE...]",
 		Short: "Unpause services",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
@@ -830,112 +1909,250 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 	portCmd.Flags().String("protocol", "tcp", "Protocol (tcp or udp)")
 	portCmd.Flags().Int("index", 1, "Container index")
 
+	// Inspect command
+	inspectCmd := &cobra.Command{
+		Use:   "inspect [SERVICE...]",
+		Short: "Display the configuration and runtime state of services",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+
+			var tmpl *template.Template
+			if format != "" {
+				var err error
+				if tmpl, err = template.New("inspect").Parse(format); err != nil {
+					return fmt.Errorf("invalid format: %w", err)
+				}
+			}
+
+			_, composeFile, err := loadCompose(logger, composeFiles, envFiles, profiles)
+			if err != nil {
+				return err
+			}
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			services := getServiceNames(composeFile, args)
+			sort.Strings(services)
+
+			inspector := inspect.New(composeFile, exec)
+			results := make([]*inspect.ServiceInspect, 0, len(services))
+			for _, name := range services {
+				result, err := inspector.InspectService(context.Background(), name)
+				if err != nil {
+					return err
+				}
+				results = append(results, result)
+			}
+
+			return writeInspect(os.Stdout, results, tmpl)
+		},
+	}
+	inspectCmd.Flags().String("format", "", "Format the output using a Go template")
+
+	// Snapshot command
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot OUTPUT_DIR",
+		Short: "Capture the stack's configuration, containers, networks, volumes and logs",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tail, _ := cmd.Flags().GetInt("tail")
+			compress, _ := cmd.Flags().GetBool("compress")
+
+			outputDir := filepath.Clean(args[0])
+			if _, err := os.Stat(outputDir); err == nil {
+				return fmt.Errorf("%s already exists", outputDir)
+			}
+
+			_, composeFile, err := loadCompose(logger, composeFiles, envFiles, profiles)
+			if err != nil {
+				return err
+			}
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			manifest, err := exec.Snapshot(context.Background(), composeFile, outputDir, executor.SnapshotOptions{LogTail: tail})
+			if err != nil {
+				return err
+			}
+
+			output := outputDir
+			if compress {
+				output = outputDir + ".tar.gz"
+				if err := archiveDir(outputDir, output); err != nil {
+					os.Remove(output)
+					return err
+				}
+				if err := os.RemoveAll(outputDir); err != nil {
+					return fmt.Errorf("failed to remove %s: %w", outputDir, err)
+				}
+			}
+
+			if !quiet {
+				fmt.Printf("Snapshot of %d containers, %d networks and %d volumes written to %s\n",
+					len(manifest.Containers), len(manifest.Networks), len(manifest.Volumes), output)
+			}
+			return nil
+		},
+	}
+	snapshotCmd.Flags().Int("tail", 1000, "Number of log lines to keep per service, 0 for all")
+	snapshotCmd.Flags().Bool("compress", false, "Write OUTPUT_DIR.tar.gz instead of a directory")
+
+	// Monitoring command
+	monitoringCmd := &cobra.Command{
+		Use:   "monitoring",
+		Short: "Generate monitoring configuration from cloud_native.prometheus settings",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			genPrometheus, _ := cmd.Flags().GetBool("generate-prometheus-config")
+			genDashboard, _ := cmd.Flags().GetBool("generate-grafana-dashboard")
+			output, _ := cmd.Flags().GetString("output")
+			dashboardOutput, _ := cmd.Flags().GetString("dashboard-output")
+
+			if !genPrometheus && !genDashboard {
+				return fmt.Errorf("nothing to generate: use --generate-prometheus-config or --generate-grafana-dashboard")
+			}
+			if genPrometheus && genDashboard && output == "" && dashboardOutput == "" {
+				return fmt.Errorf("--output or --dashboard-output is required when generating both")
+			}
+
+			_, composeFile, err := loadCompose(logger, composeFiles, envFiles, profiles)
+			if err != nil {
+				return err
+			}
+			if len(monitoring.ScrapedServices(composeFile)) == 0 {
+				logger.Warn("No service has cloud_native.prometheus configured")
+			}
+
+			config, warnings, err := monitoring.GeneratePrometheusConfig(composeFile, projectName)
+			if err != nil {
+				return err
+			}
+			for _, warning := range warnings {
+				logger.Warn(warning)
+			}
+
+			if genPrometheus {
+				data, err := yaml.Marshal(config)
+				if err != nil {
+					return fmt.Errorf("failed to marshal Prometheus config: %w", err)
+				}
+				if err := writeOutput(output, data); err != nil {
+					return err
+				}
+			}
+			if genDashboard {
+				data, err := json.MarshalIndent(monitoring.GenerateGrafanaDashboard(composeFile, projectName, config), "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal Grafana dashboard: %w", err)
+				}
+				if err := writeOutput(dashboardOutput, append(data, '\n')); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	monitoringCmd.Flags().Bool("generate-prometheus-config", false, "Generate Prometheus scrape configs")
+	monitoringCmd.Flags().Bool("generate-grafana-dashboard", false, "Generate a Grafana dashboard for the scraped services")
+	monitoringCmd.Flags().StringP("output", "o", "", "Write the Prometheus config to this file instead of stdout")
+	monitoringCmd.Flags().String("dashboard-output", "", "Write the Grafana dashboard to this file instead of stdout")
+
 	// Top command
 	topCmd := &cobra.Command{
 		Use:   "top [SERVICE...]",
 		Short: "Display the running processes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			format, _ := cmd.Flags().GetString("format")
+			if format != "table" && format != "json" {
+				return fmt.Errorf("invalid format %q: must be table or json", format)
+			}
+
+			_, composeFile, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			for name, service := range compose.Services {
-				if len(args) > 0 && !contains(args, name) {
-					continue
-				}
-				fmt.Printf("\033[36m%s Container Processes:\033[0m\n", name)
-				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-				fmt.Fprintln(w, "UID\tPID\tPPID\tC\tSTIME\tTTY\tTIME\tCMD")
-				
-				// Main process (PID 1)
-				startTime := time.Now().Add(-2 * time.Minute).Format("15:04")
-				runTime := "00:00:02"
-				mainCmd := "node server.js"
-				if service.Command != nil && len(service.Command) > 0 {
-					mainCmd = fmt.Sprintf("%v", service.Command)
-				}
-				fmt.Fprintf(w, "root\t1\t0\t0\t%s\t?\t%s\t%s\n", startTime, runTime, mainCmd)
-				
-				// Worker processes for Node.js apps
-				if service.Image != "" && (service.Image == "node:18-alpine" || service.Image == "node" || 
-					(service.Command != nil && len(service.Command) > 0 && service.Command[0] == "node")) {
-					fmt.Fprintf(w, "root\t15\t1\t0\t%s\t?\t00:00:01\tnode (worker)\n", startTime)
-					fmt.Fprintf(w, "root\t16\t1\t0\t%s\t?\t00:00:01\tnode (worker)\n", startTime)
+			for _, name := range args {
+				if _, ok := composeFile.Services[name]; !ok {
+					return fmt.Errorf("no such service: %s", name)
 				}
-				
-				// System processes
-				fmt.Fprintf(w, "root\t25\t0\t0\t%s\t?\t00:00:00\t[kthreadd]\n", startTime)
-				fmt.Fprintf(w, "root\t26\t25\t0\t%s\t?\t00:00:00\t[ksoftirqd/0]\n", startTime)
-				fmt.Fprintf(w, "root\t27\t25\t0\t%s\t?\t00:00:00\t[rcu_sched]\n", startTime)
-				
-				w.Flush()
-				fmt.Printf("\033[32mTotal processes: %d\033[0m\n\n", 6)
 			}
-			return nil
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			services, err := exec.Top(context.Background(), args)
+			if err != nil {
+				return err
+			}
+
+			return writeTop(os.Stdout, services, format)
 		},
 	}
+	topCmd.Flags().String("format", "table", "Format the output: table or json")
 
 	// Events command
 	eventsCmd := &cobra.Command{
 		Use:   "events [SERVICE...]",
 		Short: "Receive real time events from containers",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, compose, err := loadCompose(composeFile, envFile)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
 			if err != nil {
 				return err
 			}
-			
+
 			jsonOutput, _ := cmd.Flags().GetBool("json")
-			
-			if !jsonOutput {
-				fmt.Printf("\033[36mListening for events from services: %v\033[0m\n", getServiceNames(compose, args))
-				fmt.Printf("\033[36mPress Ctrl+C to exit\033[0m\n\n")
-			}
-			
-			// Simulate real-time events
-			events := []string{
-				"container create",
-				"container start",
-				"network connect",
-				"container health_status: healthy",
-				"container exec_create",
-				"container exec_start",
-				"container resize",
-				"volume mount",
-				"container update",
+			follow, _ := cmd.Flags().GetBool("follow")
+			untilFlag, _ := cmd.Flags().GetString("until")
+
+			opts := container.EventsOptions{Services: getServiceNames(compose, args)}
+			filterArgs, _ := cmd.Flags().GetStringArray("filter")
+			if err := container.ParseEventFilters(filterArgs, &opts); err != nil {
+				return err
 			}
-			
-			for i := 0; i < 15; i++ {
-				time.Sleep(time.Duration(800+i*200) * time.Millisecond)
-				
-				serviceNames := getServiceNames(compose, args)
-				serviceName := serviceNames[i%len(serviceNames)]
-				eventType := events[i%len(events)]
-				timestamp := time.Now()
-				
-				if jsonOutput {
-					fmt.Printf(`{\"time\":\"%s\",\"type\":\"%s\",\"service\":\"%s\",\"id\":\"%s_container_%d\"}\n`,
-						timestamp.Format(time.RFC3339Nano),
-						eventType,
-						serviceName,
-						serviceName,
-						timestamp.Unix())
-				} else {
-					fmt.Printf("\033[32m%s\033[0m \033[36m%s\033[0m %s (%s)\n",
-						timestamp.Format("2006-01-02 15:04:05.000"),
-						serviceName,
-						eventType,
-						fmt.Sprintf("%s_container_%d", serviceName, timestamp.Unix()))
+			if untilFlag != "" {
+				if opts.Until, err = parseTimestamp(untilFlag); err != nil {
+					return fmt.Errorf("invalid --until: %w", err)
 				}
 			}
-			
+
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			if !jsonOutput && !quiet {
+				fmt.Println(term.Colorf(term.Cyan, "Listening for events from services: %v", opts.Services))
+				fmt.Printf("%s\n\n", term.Color(term.Cyan, "Press Ctrl+C to exit"))
+			}
+
+			err = exec.Events(ctx, opts, follow, func(event container.Event) {
+				writeEvent(os.Stdout, event, jsonOutput)
+			})
+
 			if !jsonOutput {
-				fmt.Printf("\n\033[33mEvent stream ended\033[0m\n")
+				fmt.Printf("\n%s\n", term.Color(term.Yellow, "Event stream ended"))
 			}
-			return nil
+			return err
 		},
 	}
 	eventsCmd.Flags().Bool("json", false, "Output events as a stream of JSON objects")
+	eventsCmd.Flags().Bool("follow", false, "Reconnect when the event stream is interrupted, e.g. by a daemon restart")
+	eventsCmd.Flags().String("until", "", "Stop listening at this time (RFC 3339 or Unix timestamp)")
+	eventsCmd.Flags().StringArray("filter", nil, "Filter events: event=ACTION or type=TYPE (repeatable)")
 
 	// Cp command
 	cpCmd := &cobra.Command{
@@ -952,16 +2169,29 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 	cpCmd.Flags().BoolP("archive", "a", false, "Archive mode")
 	cpCmd.Flags().BoolP("follow-link", "L", false, "Always follow symbolic links")
 
-	// Scale command (deprecated but still supported)
+	// Scale command
 	scaleCmd := &cobra.Command{
 		Use:   "scale SERVICE=NUM [SERVICE=NUM...]",
 		Short: "Scale services",
+		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			logger.Warn("scale is deprecated. Use 'up --scale' instead.")
-			for _, arg := range args {
-				logger.Infof("Scaling %s", arg)
+			_, compose, err := loadCompose(logger, composeFiles, envFiles, profiles)
+			if err != nil {
+				return err
 			}
-			return nil
+
+			scaleMap, err := parseScale(args)
+			if err != nil {
+				return err
+			}
+
+			exec, err := executor.New(logger, projectName, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create executor: %w", err)
+			}
+			defer exec.Close()
+
+			return exec.Scale(context.Background(), compose, scaleMap)
 		},
 	}
 
@@ -970,48 +2200,428 @@ Note: Global flags (-f, -p) must come BEFORE the command:
 		Use:   "ls",
 		Short: "List running compose projects",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-			fmt.Fprintln(w, "NAME\tSTATUS\tCONFIG FILES")
-			if projectName != "" {
-				fmt.Fprintf(w, "%s\trunning(1)\t%s\n", projectName, composeFile)
+			all, _ := cmd.Flags().GetBool("all")
+			format, _ := cmd.Flags().GetString("format")
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			if format != "table" && format != "json" {
+				return fmt.Errorf("invalid format %q: must be table or json", format)
 			}
-			w.Flush()
-			return nil
+
+			manager, err := container.NewManagerWithOptions(logger, connectionOptions())
+			if err != nil {
+				return fmt.Errorf("failed to create container manager: %w", err)
+			}
+			defer manager.Close()
+
+			// Projects are found by the labels of their containers
+			projects, err := manager.ListProjects(context.Background(), all)
+			if err != nil {
+				return err
+			}
+
+			return writeProjects(os.Stdout, projects, format, quiet)
 		},
 	}
 	lsCmd.Flags().BoolP("all", "a", false, "Show all stopped projects")
-	lsCmd.Flags().String("format", "table", "Format output")
+	lsCmd.Flags().String("format", "table", "Format the output: table or json")
 	lsCmd.Flags().BoolP("quiet", "q", false, "Only display project names")
 
 	// Add commands
 	rootCmd.AddCommand(
-		upCmd, downCmd, configCmd, validateCmd, psCmd, versionCmd,
+		upCmd, downCmd, rollbackCmd, configCmd, convertCmd, validateCmd, graphCmd, envCmd, psCmd, versionCmd,
 		buildCmd, logsCmd, execCmd, stopCmd, startCmd, restartCmd,
 		pullCmd, pushCmd, runCmd, createCmd, rmCmd, imagesCmd,
 		killCmd, pauseCmd, unpauseCmd, portCmd, topCmd, eventsCmd,
-		cpCmd, scaleCmd, lsCmd,
+		cpCmd, scaleCmd, lsCmd, inspectCmd, volumeCmd, pluginCmd, snapshotCmd, monitoringCmd,
 	)
 
-	if err := rootCmd.Execute(); err != nil {
-		logger.Fatal(err)
+	return rootCmd
+}
+
+// composeEnvVars lists the environment variables consulted for flag
+// defaults and backend selection
+var composeEnvVars = []string{
+	"COMPOSE_FILE",
+	"COMPOSE_PROJECT_NAME",
+	"COMPOSE_PROFILES",
+	"COMPOSE_ENV_FILE",
+	"COMPOSE_PARALLEL_LIMIT",
+	"DOCKER_HOST",
+	container.BackendEnvVar,
+}
+
+func loadCompose(logger *logrus.Logger, composeFiles []string, envFiles []string, profiles []string) (*parser.Parser, *compose.ComposeFile, error) {
+	p, err := newParser(composeFiles, envFiles)
+	if err != nil {
+		return nil, nil, err
 	}
+	compose, err := parseCompose(logger, p, composeFiles, profiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, compose, nil
 }
 
-func loadCompose(composeFile, envFile string) (*parser.Parser, *compose.ComposeFile, error) {
+// newParser creates a parser with the variables of the env files layered in
+// order, later files overriding earlier ones. Without env files the .env
+// file next to the first compose file is used if it exists. Variables from
+// env files take precedence over the process environment.
+func newParser(composeFiles []string, envFiles []string) (*parser.Parser, error) {
 	p := parser.New()
-	
-	if envFile != "" {
-		if err := p.LoadEnvFile(envFile); err != nil {
-			return nil, nil, fmt.Errorf("failed to load env file: %w", err)
+
+	if len(envFiles) == 0 {
+		defaultEnv := filepath.Join(filepath.Dir(composeFiles[0]), ".env")
+		if _, err := os.Stat(defaultEnv); err == nil {
+			envFiles = []string{defaultEnv}
+		}
+	}
+
+	for _, envFile := range envFiles {
+		vars, err := parser.LoadEnvFile(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load env file: %w", err)
 		}
+		p.SetEnvVars(vars)
 	}
+	return p, nil
+}
 
-	compose, err := p.ParseFile(composeFile)
+// parseCompose parses and merges the compose files, logs the parser's
+// warnings and drops services whose profiles are not enabled
+func parseCompose(logger *logrus.Logger, p *parser.Parser, composeFiles []string, profiles []string) (*compose.ComposeFile, error) {
+	composeFile, err := p.ParseFiles(composeFiles...)
+	var notFound *parser.FileNotFoundError
+	if errors.As(err, &notFound) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+
+	for _, warning := range p.Warnings() {
+		logger.Warn(warning)
+	}
+
+	compose.FilterProfiles(composeFile, profiles)
+	return composeFile, nil
+}
+
+// defaultProjectName derives a project name from the directory containing
+// the compose file, normalized the same way Docker Compose does.
+func defaultProjectName(composeFile string) string {
+	absPath, err := filepath.Abs(composeFile)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse compose file: %w", err)
+		return "fake-compose"
 	}
 
-	return p, compose, nil
+	var b strings.Builder
+	for _, r := range strings.ToLower(filepath.Base(filepath.Dir(absPath))) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+
+	name := strings.Trim(b.String(), "-")
+	if name == "" {
+		return "fake-compose"
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "x" + name
+	}
+	return name
+}
+
+// checkDocker fails unless the Docker daemon for opts is reachable
+func checkDocker(logger *logrus.Logger, opts container.Options) error {
+	opts.Backend = container.BackendDocker
+	manager, err := container.NewManagerWithOptions(logger, opts)
+	if err != nil {
+		return err
+	}
+	return manager.Close()
+}
+
+// exportImages saves the images of the given services to
+// <outputDir>/<service>.tar, or .tar.gz when compressing
+func exportImages(logger *logrus.Logger, opts container.Options, composeFile *compose.ComposeFile, services []string, outputDir string, compress bool) error {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	manager, err := container.NewManagerWithOptions(logger, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create container manager: %w", err)
+	}
+	defer manager.Close()
+
+	sort.Strings(services)
+	for _, name := range services {
+		image := serviceImage(composeFile, opts.Project, name)
+
+		path := filepath.Join(outputDir, name+".tar")
+		if compress {
+			path += ".gz"
+		}
+		if err := saveImage(manager, image, path, compress); err != nil {
+			os.Remove(path)
+			return err
+		}
+		logger.Infof("Saved %s to %s", image, path)
+	}
+	return nil
+}
+
+// serviceImage returns the image a service runs or builds: its image
+// field, else <project>-<service>
+func serviceImage(composeFile *compose.ComposeFile, project, name string) string {
+	if image := composeFile.Services[name].Image; image != "" {
+		return image
+	}
+	return project + "-" + name
+}
+
+func saveImage(manager *container.Manager, image, path string, compress bool) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var w io.Writer = file
+	if compress {
+		gz := gzip.NewWriter(file)
+		defer gz.Close()
+		w = gz
+	}
+	return manager.SaveImage(context.Background(), image, w)
+}
+
+// writeOutput writes data to path, or to stdout when path is empty
+func writeOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeFiles writes files, keyed by slash-separated path, under dir
+func writeFiles(dir string, files map[string][]byte) error {
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// archiveDir writes dir as a gzip compressed tar archive to dest. Entries
+// are rooted at the directory's base name, so extracting the archive
+// recreates the directory.
+func archiveDir(dir, dest string) error {
+	file, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+
+	root := filepath.Dir(dir)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// loadImages loads every .tar and .tar.gz archive in dir
+func loadImages(logger *logrus.Logger, opts container.Options, dir string) error {
+	var archives []string
+	for _, pattern := range []string{"*.tar", "*.tar.gz"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return err
+		}
+		archives = append(archives, matches...)
+	}
+	if len(archives) == 0 {
+		return fmt.Errorf("no image archives found in %s", dir)
+	}
+
+	manager, err := container.NewManagerWithOptions(logger, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create container manager: %w", err)
+	}
+	defer manager.Close()
+
+	sort.Strings(archives)
+	for _, path := range archives {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		err = manager.LoadImage(context.Background(), file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		logger.Infof("Loaded %s", path)
+	}
+	return nil
+}
+
+// hookDryRunTimeout bounds each phase's hooks during validate --hooks-dry-run
+const hookDryRunTimeout = 5 * time.Second
+
+// dryRunHooks executes every hook of every service once and prints a summary
+// table. HTTP hooks send a GET without a body instead of their configured
+// request so the dry run has no side effects on the receiving end. Hook
+// templates are rendered as they would be for project.
+func dryRunHooks(logger *logrus.Logger, project string, composeFile *compose.ComposeFile) error {
+	executor := hooks.NewExecutor(logger)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tPHASE\tHOOK\tTYPE\tDURATION\tRESULT\tERROR")
+
+	// Top-level deploy hooks are listed under "-" before the services' hooks
+	owners := []string{"-"}
+	hookSets := []*compose.Hooks{composeFile.GlobalHooks}
+	names := getServiceNames(composeFile, nil)
+	sort.Strings(names)
+	for _, name := range names {
+		owners = append(owners, name)
+		hookSets = append(hookSets, composeFile.Services[name].Hooks)
+	}
+
+	var failed []string
+	for n, name := range owners {
+		if hookSets[n] == nil {
+			continue
+		}
+
+		for _, phase := range hookSets[n].Phases() {
+			if len(phase.Hooks) == 0 {
+				continue
+			}
+
+			probes := make([]compose.Hook, len(phase.Hooks))
+			for i, hook := range phase.Hooks {
+				hook.When = "always"
+				hook.Retries = 0
+				if hook.HTTP != nil {
+					probe := *hook.HTTP
+					probe.Method = http.MethodGet
+					probe.Body = ""
+					hook.HTTP = &probe
+				}
+				probes[i] = hook
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), hookDryRunTimeout)
+			phaseName := strings.ReplaceAll(phase.Name, "_", "-")
+			data := hooktemplate.NewData(project, phaseName, "", nil)
+			if n > 0 {
+				data = hooktemplate.NewData(project, phaseName, name, composeFile.Services[name])
+			}
+			results := executor.ExecuteHooksWithResults(ctx, probes, data)
+			cancel()
+
+			for i, result := range results {
+				status, errLine := "ok", ""
+				if !result.Success {
+					status = "FAIL"
+					errLine, _, _ = strings.Cut(result.Error.Error(), "\n")
+					failed = append(failed, fmt.Sprintf("%s/%s", name, result.HookName))
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name, phase.Name, result.HookName,
+					probes[i].Type, result.EndTime.Sub(result.StartTime).Round(time.Millisecond), status, errLine)
+			}
+		}
+	}
+	w.Flush()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d hook(s) failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// isPinned reports whether an image reference includes a content digest
+func isPinned(image string) bool {
+	return strings.Contains(image, "@sha256:")
+}
+
+// pinImage replaces the tag of an image reference with a digest, turning
+// "nginx:1.25" into "nginx@sha256:..."
+func pinImage(image, digest string) string {
+	name := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name = image[:i]
+	}
+	return name + "@" + digest
+}
+
+// parseScale parses SERVICE=NUM arguments into a map of desired replica
+// counts
+func parseScale(args []string) (map[string]int, error) {
+	scaleMap := make(map[string]int, len(args))
+	for _, arg := range args {
+		name, value, found := strings.Cut(arg, "=")
+		replicas, err := strconv.Atoi(value)
+		if !found || name == "" || err != nil || replicas < 0 {
+			return nil, fmt.Errorf("invalid scale %q: expected SERVICE=NUM", arg)
+		}
+		scaleMap[name] = replicas
+	}
+	return scaleMap, nil
 }
 
 func contains(slice []string, item string) bool {
@@ -1023,6 +2633,282 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+// printServiceLogs prints the logs of every replica of the given services
+// interleaved by timestamp, or of replica index only when it is above
+// zero. With a positive tail only the last tail lines across all services
+// are shown, rather than the last tail lines of each service. Lines below
+// minLevel are dropped when a level can be detected.
+func printServiceLogs(ctx context.Context, exec *executor.Executor, serviceNames []string, index, tail int, minLevel *logrus.Level) error {
+	var lines []container.LogLine
+	for _, name := range serviceNames {
+		replicas, err := exec.Replicas(ctx, name, index, true)
+		if err != nil {
+			return err
+		}
+		// Without known containers the first replica is asked for, which
+		// reports a service that was never started
+		if len(replicas) == 0 {
+			replicas = []container.ContainerInfo{{Service: name, Number: 1}}
+		}
+
+		for _, replica := range replicas {
+			replicaLines, err := exec.Logs(ctx, replica, tail)
+			if err != nil {
+				return err
+			}
+
+			if tail > 0 {
+				buffer := term.NewRingBuffer[container.LogLine](tail)
+				for _, line := range replicaLines {
+					buffer.Push(line)
+				}
+				replicaLines = buffer.Items()
+			}
+
+			for _, line := range replicaLines {
+				if minLevel != nil {
+					if level, ok := logLineLevel(line.Text); ok && level > *minLevel {
+						continue
+					}
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Timestamp.Before(lines[j].Timestamp)
+	})
+
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	for _, line := range lines {
+		printLogLine(line)
+	}
+	return nil
+}
+
+// execReplicas runs a command in several replicas concurrently and prints
+// each one's output, prefixed with the replica, once all have finished. It
+// fails when the command fails in any of them.
+func execReplicas(ctx context.Context, exec *executor.Executor, replicas []container.ContainerInfo, command []string, opts container.ExecOptions) error {
+	outputs := make([]bytes.Buffer, len(replicas))
+	exitCodes := make([]int, len(replicas))
+	errs := make([]error, len(replicas))
+
+	var wg sync.WaitGroup
+	for i, replica := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			replicaOpts := opts
+			replicaOpts.Output = &outputs[i]
+			exitCodes[i], errs[i] = exec.Exec(ctx, replica, command, replicaOpts)
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, replica := range replicas {
+		prefix := term.Colorf(term.Cyan, "[%s-%d]", replica.Service, replica.Number)
+		scanner := bufio.NewScanner(&outputs[i])
+		for scanner.Scan() {
+			fmt.Printf("%s %s\n", prefix, scanner.Text())
+		}
+		switch {
+		case errs[i] != nil:
+			failed++
+			fmt.Printf("%s %s\n", prefix, term.Color(term.Red, errs[i].Error()))
+		case exitCodes[i] != 0:
+			failed++
+			fmt.Printf("%s %s\n", prefix, term.Colorf(term.Red, "exited with code %d", exitCodes[i]))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("command failed in %d of %d replicas", failed, len(replicas))
+	}
+	return nil
+}
+
+// writeTop prints the processes of each service as a table followed by
+// its process count, or all of them as a JSON array
+func writeTop(w io.Writer, services []executor.ServiceProcesses, format string) error {
+	if format == "json" {
+		if services == nil {
+			services = []executor.ServiceProcesses{}
+		}
+		data, err := json.MarshalIndent(services, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode processes: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+		return nil
+	}
+
+	for _, service := range services {
+		fmt.Fprintln(w, term.Colorf(term.Cyan, "%s Container Processes:", service.Service))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(service.Titles, "\t"))
+		for _, process := range service.Processes {
+			fmt.Fprintln(tw, strings.Join(process, "\t"))
+		}
+		tw.Flush()
+		fmt.Fprintf(w, "%s\n\n", term.Colorf(term.Green, "Total processes: %d", len(service.Processes)))
+	}
+	return nil
+}
+
+// writeEvent prints an event on its own line, as a JSON object when
+// jsonOutput is set
+func writeEvent(w io.Writer, event container.Event, jsonOutput bool) {
+	switch {
+	case jsonOutput:
+		data, _ := json.Marshal(event)
+		fmt.Fprintln(w, string(data))
+	case event.Type == "reconnect":
+		fmt.Fprintln(w, term.Colorf(term.Yellow, "%s event stream reconnected, events may have been missed",
+			event.Time.Format("2006-01-02 15:04:05.000")))
+	default:
+		fmt.Fprintf(w, "%s %s %s %s (%s)\n",
+			term.Color(term.Green, event.Time.Format("2006-01-02 15:04:05.000")),
+			term.Color(term.Cyan, event.Service),
+			event.Type,
+			event.Action,
+			event.ContainerID)
+	}
+}
+
+// writeProjects prints the projects as a table or a JSON array, or only
+// their names with quiet set
+func writeProjects(w io.Writer, projects []container.ProjectInfo, format string, quiet bool) error {
+	switch {
+	case quiet:
+		for _, project := range projects {
+			fmt.Fprintln(w, project.Name)
+		}
+	case format == "json":
+		data, err := json.MarshalIndent(projects, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSTATUS\tCONFIG FILES")
+		for _, project := range projects {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", project.Name, project.Status, project.ConfigFiles)
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+// writePs prints the containers as a table, leaving out those of services
+// whose profiles are not in profiles
+func writePs(w io.Writer, containers []container.ContainerInfo, cf *compose.ComposeFile, profiles []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tIMAGE\tCOMMAND\tSERVICE\tSTATUS\tPORTS")
+	for _, c := range containers {
+		var command, ports string
+		if service, exists := cf.Services[c.Service]; exists {
+			if !service.ProfileEnabled(profiles) {
+				continue
+			}
+			command = strings.Join(service.Command, " ")
+			ports = strings.Join(service.Ports, ", ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%q\t%s\t%s\t%s\n",
+			c.Name, c.Image, command, c.Service, c.Status, ports)
+	}
+	return tw.Flush()
+}
+
+// writeInspect prints the inspected services as a JSON array, or each one
+// on its own line through tmpl when it is set
+func writeInspect(w io.Writer, results []*inspect.ServiceInspect, tmpl *template.Template) error {
+	if tmpl != nil {
+		for _, result := range results {
+			if err := tmpl.Execute(w, result); err != nil {
+				return fmt.Errorf("failed to execute format: %w", err)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(results, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode service info: %w", err)
+	}
+	fmt.Fprintln(w, string(data))
+	return nil
+}
+
+// printLogLine prints a log line prefixed with its service, and its
+// replica when known
+func printLogLine(line container.LogLine) {
+	source := line.Service
+	if line.Number > 0 {
+		source = fmt.Sprintf("%s-%d", line.Service, line.Number)
+	}
+	fmt.Printf("%s [%s] %s\n", term.Colorf(term.Cyan, "[%s]", source), line.Timestamp.Format("15:04:05"), line.Text)
+}
+
+// parseTimestamp parses an RFC 3339 time or a Unix timestamp in seconds
+func parseTimestamp(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a Unix timestamp", value)
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), nil
+}
+
+// attachedServices returns the services whose logs an attached up streams:
+// those named by --attach, or otherwise every started service, minus the
+// ones named by --no-attach
+func attachedServices(composeFile *compose.ComposeFile, started, attach, noAttach []string) []string {
+	candidates := attach
+	if len(candidates) == 0 {
+		candidates = getServiceNames(composeFile, started)
+	}
+
+	var result []string
+	for _, name := range candidates {
+		if !contains(noAttach, name) {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// logLineLevel detects the severity of a log line written either in logfmt
+// style (level=warn) or with a bracketed or bare upper-case level word
+func logLineLevel(text string) (logrus.Level, bool) {
+	lower := strings.ToLower(text)
+	if _, rest, found := strings.Cut(lower, "level="); found {
+		word, _, _ := strings.Cut(strings.Trim(rest, `"`), " ")
+		if level, err := logrus.ParseLevel(strings.Trim(word, `"`)); err == nil {
+			return level, true
+		}
+	}
+
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == '[' || r == ']' || r == ':'
+	}) {
+		switch field {
+		case "TRACE", "DEBUG", "INFO", "WARN", "WARNING", "ERROR", "FATAL", "PANIC":
+			level, _ := logrus.ParseLevel(field)
+			return level, true
+		}
+	}
+	return 0, false
+}
+
 func getServiceNames(compose *compose.ComposeFile, args []string) []string {
 	var names []string
 	if len(args) > 0 {
@@ -1032,4 +2918,104 @@ func getServiceNames(compose *compose.ComposeFile, args []string) []string {
 		names = append(names, name)
 	}
 	return names
-}
\ No newline at end of file
+}
+
+// checkServiceImages asks each service image's registry whether the image
+// exists and prints the result per service. Missing images fail the check;
+// registries that cannot be asked only warn unless strict is set.
+func checkServiceImages(logger *logrus.Logger, composeFile *compose.ComposeFile, strict bool) error {
+	names := getServiceNames(composeFile, nil)
+	sort.Strings(names)
+
+	var missing, unchecked []string
+	for _, name := range names {
+		image := composeFile.Services[name].Image
+		if image == "" {
+			continue
+		}
+		auth, err := container.LoadRegistryAuth(image)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			err = container.CheckManifest(ctx, image, auth)
+			cancel()
+		}
+
+		switch {
+		case err == nil:
+			fmt.Printf("%s %s: %s\n", term.Color(term.Green, "Found"), name, image)
+		case errors.Is(err, container.ErrManifestNotFound):
+			fmt.Printf("%s %s: %s\n", term.Color(term.Red, "Missing"), name, image)
+			missing = append(missing, name)
+		default:
+			logger.Warnf("Service %s: could not check %s: %v", name, image, err)
+			unchecked = append(unchecked, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("images not found for services: %s", strings.Join(missing, ", "))
+	}
+	if strict && len(unchecked) > 0 {
+		return fmt.Errorf("could not check images for services: %s", strings.Join(unchecked, ", "))
+	}
+	return nil
+}
+
+// waitReady blocks until the services are healthy and the host:port
+// addresses accept connections, giving up after timeout
+func waitReady(exec *executor.Executor, services, ports []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := exec.WaitForServices(ctx, services)
+	for _, address := range ports {
+		if err != nil {
+			break
+		}
+		err = health.WaitHealthy(ctx, health.TCPCheck(address), time.Second, 0)
+		if err != nil {
+			err = fmt.Errorf("port %s is not accepting connections: %w", address, err)
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s: %w", timeout, err)
+	}
+	return err
+}
+
+// renderProgress shows the lifecycle phases of the services exec starts or
+// stops as a live "[+] Running" summary, hiding info logs meanwhile. Without
+// a terminal, with ANSI output disabled, --verbose or --quiet the logs are
+// printed as usual. The returned function ends the summary.
+func renderProgress(exec *executor.Executor, logger *logrus.Logger) func() {
+	if !term.Enabled() || !term.IsTerminal(os.Stdout) || logger.GetLevel() != logrus.InfoLevel {
+		return func() {}
+	}
+
+	level := logger.GetLevel()
+	logger.SetLevel(logrus.WarnLevel)
+	events := make(chan lifecycle.PhaseEvent)
+	done := make(chan struct{})
+	go func() {
+		lifecycle.NewProgress(os.Stdout).Render(events)
+		close(done)
+	}()
+	exec.NotifyPhases(events)
+
+	return func() {
+		exec.NotifyPhases(nil)
+		close(events)
+		<-done
+		logger.SetLevel(level)
+	}
+}
+
+// applyCompatibility maps deploy resource limits onto the services for
+// --compatibility and returns the replicas each service asks for
+func applyCompatibility(cf *compose.ComposeFile) (map[string]int, error) {
+	replicas, err := compose.ApplyCompatibility(cf)
+	if err != nil {
+		return nil, fmt.Errorf("--compatibility: %w", err)
+	}
+	return replicas, nil
+}
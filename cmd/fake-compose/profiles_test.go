@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+)
+
+const profilesProject = `version: "3.8"
+services:
+  web:
+    image: nginx
+  debug:
+    image: busybox
+    profiles: [debug]
+`
+
+func TestWritePsHidesDisabledProfiles(t *testing.T) {
+	cf := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web":   {Image: "nginx"},
+			"debug": {Image: "busybox", Profiles: []string{"debug"}},
+		},
+	}
+	containers := []container.ContainerInfo{
+		{Name: "demo-web-1", Image: "nginx", Service: "web", Status: "Up"},
+		{Name: "demo-debug-1", Image: "busybox", Service: "debug", Status: "Up"},
+	}
+	tests := []struct {
+		name     string
+		profiles []string
+		debug    bool
+	}{
+		{"default", nil, false},
+		{"profile enabled", []string{"debug"}, true},
+		{"all profiles", []string{"*"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := writePs(&out, containers, cf, tt.profiles); err != nil {
+				t.Fatalf("writePs: %v", err)
+			}
+			if !strings.Contains(out.String(), "demo-web-1") {
+				t.Errorf("web missing:\n%s", out.String())
+			}
+			if got := strings.Contains(out.String(), "demo-debug-1"); got != tt.debug {
+				t.Errorf("debug shown = %v, want %v:\n%s", got, tt.debug, out.String())
+			}
+		})
+	}
+}
+
+func TestLogsHidesDisabledProfiles(t *testing.T) {
+	file := writeProject(t, profilesProject)
+	tests := []struct {
+		name  string
+		args  []string
+		debug bool
+	}{
+		{"default", []string{"-f", file, "logs"}, false},
+		{"profile enabled", []string{"-f", file, "--profile", "debug", "logs"}, true},
+		{"all profiles", []string{"-f", file, "logs", "--all-profiles"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runCLIOutput(t, tt.args...)
+			if err != nil {
+				t.Fatalf("logs: %v", err)
+			}
+			if !strings.Contains(out, "=== MAIN SERVICE web ===") {
+				t.Errorf("web logs missing:\n%s", out)
+			}
+			if got := strings.Contains(out, "=== MAIN SERVICE debug ==="); got != tt.debug {
+				t.Errorf("debug logs shown = %v, want %v:\n%s", got, tt.debug, out)
+			}
+		})
+	}
+}
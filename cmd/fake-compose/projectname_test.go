@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultProjectName(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{"plain", "shop", "shop"},
+		{"upper case", "MyApp", "myapp"},
+		{"spaces", "my shop app", "my-shop-app"},
+		{"underscores and dots", "my_app.v2", "my-app-v2"},
+		{"unicode", "café-ünïcode", "caf---n-code"},
+		{"unicode only", "日本", "fake-compose"},
+		{"leading digit", "2048-game", "x2048-game"},
+		{"leading digit after trim", " 9lives", "x9lives"},
+		{"surrounding symbols", "__app__", "app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			composeFile := filepath.Join(t.TempDir(), tt.dir, "docker-compose.yml")
+			if got := defaultProjectName(composeFile); got != tt.want {
+				t.Errorf("defaultProjectName(%q) = %q, want %q", composeFile, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultProjectNameRelativePath(t *testing.T) {
+	// Tests run in the package directory, cmd/fake-compose
+	if got := defaultProjectName("compose.yaml"); got != "fake-compose" {
+		t.Errorf("defaultProjectName = %q, want fake-compose", got)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// belowError returns the messages logged below the error level
+func belowError(hook *test.Hook) []string {
+	var messages []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Level > logrus.ErrorLevel {
+			messages = append(messages, entry.Level.String()+": "+entry.Message)
+		}
+	}
+	return messages
+}
+
+func TestQuietOnlyLogsErrors(t *testing.T) {
+	file := writeProject(t, `version: "3.8"
+services:
+  web:
+    image: nginx
+    hooks:
+      pre_start:
+        - name: fail
+          type: command
+          command: ["false"]
+`)
+
+	var hook *test.Hook
+	captureStdout(t, func() { hook, _ = runCLI(t, "-f", file, "up", "-d") })
+	if len(belowError(hook)) == 0 {
+		t.Fatal("up logged nothing below error without --quiet")
+	}
+
+	for _, flag := range []string{"--quiet", "-q"} {
+		hook, err := runCLI(t, flag, "-f", file, "up", "-d")
+		if err == nil {
+			t.Fatalf("%s up succeeded with a failing pre_start hook", flag)
+		}
+		if logged := belowError(hook); len(logged) > 0 {
+			t.Errorf("%s up logged below error: %v", flag, logged)
+		}
+		errors := 0
+		for _, entry := range hook.AllEntries() {
+			if entry.Level <= logrus.ErrorLevel {
+				errors++
+			}
+		}
+		if errors == 0 {
+			t.Errorf("%s up did not log the failure", flag)
+		}
+	}
+}
+
+func TestQuietVerboseExclusive(t *testing.T) {
+	file := writeProject(t, killProject)
+	for _, args := range [][]string{
+		{"-q", "-v", "-f", file, "validate"},
+		{"-v", "-f", file, "ls", "-q"},
+	} {
+		_, err := runCLI(t, args...)
+		if err == nil || !strings.Contains(err.Error(), "--quiet and --verbose are mutually exclusive") {
+			t.Errorf("%v = %v, want a mutually exclusive error", args, err)
+		}
+	}
+}
+
+// The global --quiet/-q and the --quiet/-q of images, pull, push and ls
+// must not clash: either position parses, and both quiet the logs
+func TestQuietFlagDoesNotClash(t *testing.T) {
+	file := writeProject(t, killProject)
+	for _, command := range []string{"images", "pull", "push", "ls"} {
+		for _, args := range [][]string{
+			{"-f", file, command, "-q"},
+			{"-f", file, command, "--quiet"},
+			{"-q", "-f", file, command},
+			{"--quiet", "-f", file, command},
+		} {
+			var hook *test.Hook
+			var err error
+			captureStdout(t, func() { hook, err = runCLI(t, args...) })
+			if err != nil {
+				t.Errorf("%v: %v", args, err)
+				continue
+			}
+			if logged := belowError(hook); len(logged) > 0 {
+				t.Errorf("%v logged below error: %v", args, logged)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/internal/executor"
+)
+
+func topServices() []executor.ServiceProcesses {
+	titles := []string{"UID", "PID", "CMD"}
+	return []executor.ServiceProcesses{
+		{Service: "db", Titles: titles, Processes: [][]string{{"999", "1", "postgres"}}},
+		{Service: "web", Titles: titles, Processes: [][]string{
+			{"root", "1", "nginx: master"},
+			{"nginx", "7", "nginx: worker"},
+			{"nginx", "8", "nginx: worker"},
+		}},
+	}
+}
+
+func TestWriteTopJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeTop(&out, topServices(), "json"); err != nil {
+		t.Fatalf("writeTop: %v", err)
+	}
+
+	var decoded []struct {
+		Service   string     `json:"service"`
+		Titles    []string   `json:"titles"`
+		Processes [][]string `json:"processes"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, out.String())
+	}
+	if len(decoded) != 2 || decoded[0].Service != "db" || decoded[1].Service != "web" {
+		t.Fatalf("decoded %+v, want db and web", decoded)
+	}
+	if len(decoded[1].Processes) != 3 || strings.Join(decoded[1].Titles, ",") != "UID,PID,CMD" {
+		t.Errorf("web = %+v, want three processes under the titles", decoded[1])
+	}
+
+	out.Reset()
+	if err := writeTop(&out, nil, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "[]" {
+		t.Errorf("no services printed %q, want []", got)
+	}
+}
+
+func TestWriteTopTotals(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeTop(&out, topServices(), "table"); err != nil {
+		t.Fatalf("writeTop: %v", err)
+	}
+	output := out.String()
+
+	db, web, found := strings.Cut(output, "web Container Processes:")
+	if !found {
+		t.Fatalf("no web section in:\n%s", output)
+	}
+	if !strings.Contains(db, "Total processes: 1") {
+		t.Errorf("db section lacks a total of 1:\n%s", db)
+	}
+	if !strings.Contains(web, "Total processes: 3") || strings.Count(web, "nginx: worker") != 2 {
+		t.Errorf("web section lacks its processes or a total of 3:\n%s", web)
+	}
+}
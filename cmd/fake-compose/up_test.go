@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpAbortOnExitExcludesDetach(t *testing.T) {
+	for _, flag := range []string{"--abort-on-container-exit", "--exit-code-from=web"} {
+		_, err := runCLI(t, "-f", "missing.yml", "up", "-d", flag)
+		if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+			t.Errorf("up -d %s = %v, want a mutually exclusive error", flag, err)
+		}
+	}
+}
+
+func TestUpRequireDocker(t *testing.T) {
+	const host = "tcp://127.0.0.1:1"
+	file := writeProject(t, `version: "3.8"
+services:
+  web:
+    image: nginx
+`)
+	t.Setenv("FAKE_COMPOSE_BACKEND", "")
+	t.Setenv("DOCKER_TLS_VERIFY", "")
+
+	_, err := runCLI(t, "-f", file, "-H", host, "--require-docker", "up", "-d")
+	if err == nil || !strings.Contains(err.Error(), "docker backend required") || !strings.Contains(err.Error(), host) {
+		t.Errorf("up --require-docker -H %s = %v, want an error naming the host", host, err)
+	}
+
+	// The daemon address may come from DOCKER_HOST as well
+	t.Setenv("DOCKER_HOST", host)
+	_, err = runCLI(t, "-f", file, "--require-docker", "up", "-d")
+	if err == nil || !strings.Contains(err.Error(), host) {
+		t.Errorf("up --require-docker with DOCKER_HOST=%s = %v, want an error naming the host", host, err)
+	}
+
+	// Without the flag up falls back to the stub
+	if _, err := runCLIOutput(t, "-f", file, "up", "-d"); err != nil {
+		t.Errorf("up without --require-docker: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeRegistry serves a manifest for app:1.0 only and returns its host
+func fakeRegistry(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead && r.URL.Path == "/v2/app/manifests/1.0" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func imageProject(t *testing.T, image string) string {
+	t.Helper()
+	return writeProject(t, `version: "3.8"
+services:
+  web:
+    image: `+image+`
+`)
+}
+
+func TestValidateCheckImages(t *testing.T) {
+	registry := fakeRegistry(t)
+
+	out, err := runCLIOutput(t, "-f", imageProject(t, registry+"/app:1.0"), "validate", "--check-images")
+	if err != nil {
+		t.Fatalf("validate found image: %v", err)
+	}
+	if !strings.Contains(out, "Found") || !strings.Contains(out, "web: "+registry+"/app:1.0") {
+		t.Errorf("found image not reported:\n%s", out)
+	}
+
+	out, err = runCLIOutput(t, "-f", imageProject(t, registry+"/app:2.0"), "validate", "--check-images")
+	if err == nil || !strings.Contains(err.Error(), "images not found for services: web") {
+		t.Errorf("validate missing tag = %v, want an images not found error", err)
+	}
+	if !strings.Contains(out, "Missing") {
+		t.Errorf("missing image not reported:\n%s", out)
+	}
+}
+
+func TestValidateCheckImagesUnreachable(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	file := imageProject(t, strings.TrimPrefix(closed.URL, "http://")+"/app:1.0")
+
+	hook, err := runCLI(t, "-f", file, "validate", "--check-images")
+	if err != nil {
+		t.Errorf("validate with an unreachable registry = %v, want only a warning", err)
+	}
+	warned := false
+	for _, entry := range hook.AllEntries() {
+		warned = warned || strings.HasPrefix(entry.Message, "Service web: could not check")
+	}
+	if !warned {
+		t.Error("unreachable registry was not warned about")
+	}
+
+	if _, err := runCLI(t, "-f", file, "validate", "--check-images", "--strict"); err == nil || !strings.Contains(err.Error(), "could not check images for services: web") {
+		t.Errorf("validate --strict = %v, want a could not check error", err)
+	}
+}
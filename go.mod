@@ -5,10 +5,13 @@ go 1.23.0
 toolchain go1.23.11
 
 require (
+	github.com/docker/distribution v2.8.3+incompatible
 	github.com/docker/docker v20.10.27+incompatible
 	github.com/docker/go-connections v0.4.0
+	github.com/docker/go-units v0.5.0
 	github.com/sirupsen/logrus v1.9.3
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/sync v0.16.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -16,8 +19,6 @@ replace github.com/docker/distribution => github.com/distribution/distribution v
 
 require (
 	github.com/Microsoft/go-winio v0.6.2 // indirect
-	github.com/docker/distribution v2.8.3+incompatible // indirect
-	github.com/docker/go-units v0.5.0 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/moby/term v0.5.2 // indirect
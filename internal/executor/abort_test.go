@@ -0,0 +1,182 @@
+package executor
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// upAbortProject starts web, db and worker and returns the executor with
+// the ID of each service's container
+func upAbortProject(t *testing.T) (*Executor, *fakeManager, *compose.ComposeFile, map[string]string) {
+	t.Helper()
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web":    {Image: "nginx"},
+			"db":     {Image: "postgres"},
+			"worker": {Image: "busybox"},
+		},
+	}
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	fake.takeLifecycle()
+
+	ids := make(map[string]string)
+	for name := range project.Services {
+		ids[name] = e.runningServices[name][0]
+	}
+	return e, fake, project, ids
+}
+
+func TestAbortOnContainerExit(t *testing.T) {
+	e, fake, project, ids := upAbortProject(t)
+	ctx := context.Background()
+
+	// worker's container exits on its own
+	fake.SetExitCode(ids["worker"], 3)
+	if err := fake.StubManager.StopContainer(ctx, ids["worker"], 0); err != nil {
+		t.Fatal(err)
+	}
+
+	exit, err := e.AbortOnExit(ctx, project, []string{"db", "web", "worker"}, "", 10)
+	if err != nil {
+		t.Fatalf("abort on exit: %v", err)
+	}
+	if exit.Service != "worker" || exit.ContainerID != ids["worker"] || exit.ExitCode != 3 {
+		t.Errorf("exit = %+v, want worker with code 3", exit)
+	}
+
+	// up then shuts the project down
+	if err := e.Down(ctx, project); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	stopped, _ := fake.takeLifecycle()
+	sort.Strings(stopped)
+	if got := strings.Join(stopped, ","); got != "db,web" {
+		t.Errorf("stopped %s, want the remaining db and web", got)
+	}
+}
+
+func TestExitCodeFrom(t *testing.T) {
+	e, fake, project, ids := upAbortProject(t)
+	ctx := context.Background()
+
+	// worker exits first, but db decides the result
+	fake.SetExitCode(ids["worker"], 3)
+	fake.SetExitCode(ids["db"], 5)
+	if err := fake.StubManager.StopContainer(ctx, ids["worker"], 0); err != nil {
+		t.Fatal(err)
+	}
+
+	exit, err := e.AbortOnExit(ctx, project, []string{"db", "web", "worker"}, "db", 10)
+	if err != nil {
+		t.Fatalf("abort on exit: %v", err)
+	}
+	if exit.Service != "db" || exit.ExitCode != 5 {
+		t.Errorf("exit = %+v, want db with code 5", exit)
+	}
+}
+
+func TestExitCodeFromExitingFirst(t *testing.T) {
+	e, fake, project, ids := upAbortProject(t)
+	ctx := context.Background()
+
+	fake.SetExitCode(ids["db"], 4)
+	if err := fake.StubManager.StopContainer(ctx, ids["db"], 0); err != nil {
+		t.Fatal(err)
+	}
+
+	exit, err := e.AbortOnExit(ctx, project, []string{"db", "web", "worker"}, "db", 10)
+	if err != nil {
+		t.Fatalf("abort on exit: %v", err)
+	}
+	if exit.Service != "db" || exit.ExitCode != 4 {
+		t.Errorf("exit = %+v, want db with code 4", exit)
+	}
+}
+
+func TestExitCodeFromMustBeStarted(t *testing.T) {
+	e := newTestExecutor(t, newFakeManager())
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web":   {Image: "nginx"},
+			"tests": {Image: "busybox"},
+		},
+	}
+
+	err := e.UpWithOptions(context.Background(), project, UpOptions{Services: []string{"web"}, ExitCodeFrom: "tests", Quiet: true})
+	if err == nil || !strings.Contains(err.Error(), "tests requested, but it is not being started") {
+		t.Errorf("up = %v, want an error about tests not being started", err)
+	}
+}
+
+func TestUpServices(t *testing.T) {
+	e := newTestExecutor(t, newFakeManager())
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web":    {Image: "nginx", DependsOn: map[string]compose.DependsOn{"db": {}}},
+			"db":     {Image: "postgres"},
+			"worker": {Image: "busybox"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts UpOptions
+		want string
+	}{
+		{"every service", UpOptions{}, "db,web,worker"},
+		{"with dependencies", UpOptions{Services: []string{"web"}}, "db,web"},
+		{"without dependencies", UpOptions{Services: []string{"web"}, NoDeps: true}, "web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.UpServices(project, tt.opts)
+			if err != nil {
+				t.Fatalf("UpServices: %v", err)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("UpServices = %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := e.UpServices(project, UpOptions{Services: []string{"cache"}}); err == nil {
+		t.Error("UpServices with an unknown service succeeded")
+	}
+}
+
+func TestAbortOnExitIgnoresServicesNotStarted(t *testing.T) {
+	e, fake, project, ids := upAbortProject(t)
+	ctx := context.Background()
+
+	// worker is left stopped from an earlier run while up starts only web
+	fake.SetExitCode(ids["worker"], 3)
+	if err := fake.StubManager.StopContainer(ctx, ids["worker"], 0); err != nil {
+		t.Fatal(err)
+	}
+	started, err := e.UpServices(project, UpOptions{Services: []string{"web"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		fake.SetExitCode(ids["web"], 7)
+		fake.StubManager.StopContainer(ctx, ids["web"], 0)
+	}()
+	exit, err := e.AbortOnExit(ctx, project, started, "", 10)
+	if err != nil {
+		t.Fatalf("abort on exit: %v", err)
+	}
+	if exit.Service != "web" || exit.ExitCode != 7 {
+		t.Errorf("exit = %+v, want web with code 7 rather than the unrelated worker", exit)
+	}
+}
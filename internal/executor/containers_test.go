@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// psRows returns the name and state of each container ps lists
+func psRows(t *testing.T, e *Executor, services []string, all bool) []string {
+	t.Helper()
+	f := filters.NewArgs()
+	if !all {
+		f.Add("status", "running")
+	}
+	containers, err := e.ContainersMatching(context.Background(), services, f)
+	if err != nil {
+		t.Fatalf("ContainersMatching: %v", err)
+	}
+	rows := make([]string, len(containers))
+	for i, c := range containers {
+		rows[i] = c.Name + " " + c.State
+	}
+	return rows
+}
+
+func equalRows(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("rows = %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPsListsEveryReplica(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := webProject()
+	project.Services["db"] = &compose.Service{Image: "postgres"}
+	ids := scaledWeb(t, e, fake, project)
+
+	equalRows(t, psRows(t, e, nil, false), []string{
+		"demo_db_1 running",
+		"demo_web_1 running",
+		"demo_web_2 running",
+		"demo_web_3 running",
+	})
+	equalRows(t, psRows(t, e, []string{"web"}, false), []string{
+		"demo_web_1 running",
+		"demo_web_2 running",
+		"demo_web_3 running",
+	})
+
+	// Each replica keeps its own state
+	if err := fake.StopContainer(context.Background(), ids[1], 10); err != nil {
+		t.Fatal(err)
+	}
+	equalRows(t, psRows(t, e, []string{"web"}, false), []string{
+		"demo_web_1 running",
+		"demo_web_3 running",
+	})
+	equalRows(t, psRows(t, e, []string{"web"}, true), []string{
+		"demo_web_1 running",
+		"demo_web_2 exited",
+		"demo_web_3 running",
+	})
+}
@@ -2,108 +2,1361 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"github.com/neomody77/fake-compose/internal/state"
 	"github.com/neomody77/fake-compose/pkg/compose"
 	"github.com/neomody77/fake-compose/pkg/container"
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
+	"github.com/neomody77/fake-compose/pkg/health"
+	"github.com/neomody77/fake-compose/pkg/hooks"
 	"github.com/neomody77/fake-compose/pkg/lifecycle"
+	"github.com/neomody77/fake-compose/pkg/template"
 )
 
+// dependencyHealthTimeout bounds how long a service waits for a
+// service_healthy dependency
+const dependencyHealthTimeout = 2 * time.Minute
+
 type Executor struct {
 	projectName       string
 	logger           *logrus.Logger
 	containerManager *container.Manager
 	lifecycleManager *lifecycle.Manager
-	runningServices  map[string]string
+	hookExecutor     *hooks.Executor
+	store            *state.Store
+	runningServices  map[string][]string
 	mu               sync.RWMutex
 }
 
-func New(logger *logrus.Logger, projectName string) (*Executor, error) {
-	containerManager, err := container.NewManager(logger)
+func New(logger *logrus.Logger, projectName string, opts container.Options) (*Executor, error) {
+	opts.Project = projectName
+	containerManager, err := container.NewManagerWithOptions(logger, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container manager: %w", err)
+	}
+	return newWithManager(logger, projectName, containerManager)
+}
+
+// newWithManager creates an executor driving the given container manager
+func newWithManager(logger *logrus.Logger, projectName string, containerManager *container.Manager) (*Executor, error) {
+	store, err := state.NewStore(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Executor{
+		projectName:      projectName,
+		logger:          logger,
+		containerManager: containerManager,
+		lifecycleManager: lifecycle.NewManager(logger, projectName),
+		hookExecutor:     hooks.NewExecutor(logger),
+		store:            store,
+		runningServices:  make(map[string][]string),
+	}, nil
+}
+
+// UpOptions controls which services Up starts and how
+type UpOptions struct {
+	// Services limits startup to the named services and, unless NoDeps is
+	// set, their dependencies. Empty means all services.
+	Services []string
+	// NoDeps starts only the named services, without starting or waiting
+	// for their dependencies
+	NoDeps bool
+	// Parallel bounds how many services start at once; zero or a negative
+	// value means no limit
+	Parallel int
+	// CascadeVolumes marks the volumes created by this run so a later
+	// `down --cascade-volumes` removes them
+	CascadeVolumes bool
+	// ExitCodeFrom names a service that must be among the started ones,
+	// for callers that report its exit code
+	ExitCodeFrom string
+	// QuietPull reports each pulled image with a summary line only
+	QuietPull bool
+	// ForceRecreate recreates running services even when they are up to
+	// date
+	ForceRecreate bool
+	// NoRecreate leaves running services alone even when their
+	// configuration or image changed
+	NoRecreate bool
+	// Quiet pulls missing images without printing any progress
+	Quiet bool
+}
+
+// DownOptions controls what Down removes besides the service containers
+type DownOptions struct {
+	// CascadeVolumes removes the volumes created by `up --cascade-volumes`
+	CascadeVolumes bool
+}
+
+func (e *Executor) Up(ctx context.Context, compose *compose.ComposeFile) error {
+	return e.UpWithOptions(ctx, compose, UpOptions{})
+}
+
+// UpWithOptions starts services concurrently, each one as soon as the
+// dependencies it was started with are up
+func (e *Executor) UpWithOptions(ctx context.Context, compose *compose.ComposeFile, opts UpOptions) error {
+	e.logger.Info("Starting services...")
+
+	selected, err := e.selectServices(compose.Services, opts.Services, opts.NoDeps)
+	if err != nil {
+		return err
+	}
+	if opts.ExitCodeFrom != "" && !selected[opts.ExitCodeFrom] {
+		return fmt.Errorf("exit code from service %s requested, but it is not being started", opts.ExitCodeFrom)
+	}
+
+	if compose.GlobalHooks != nil && len(compose.GlobalHooks.PreDeploy) > 0 {
+		e.logger.Info("Running pre-deploy hooks")
+		if err := e.hookExecutor.ExecuteHooks(ctx, compose.GlobalHooks.PreDeploy, compose.GlobalHooks.ListTimeout, template.NewData(e.projectName, "pre-deploy", "", nil)); err != nil {
+			return fmt.Errorf("pre-deploy hooks failed: %w", err)
+		}
+	}
+
+	services := make([]string, 0, len(selected))
+	for name := range selected {
+		services = append(services, name)
+	}
+	pullOpts := PullOptions{Services: services, Policy: container.PullMissing, Parallel: opts.Parallel, Progress: os.Stdout, Quiet: opts.QuietPull}
+	if opts.Quiet {
+		pullOpts.Progress = nil
+	}
+	if _, err := e.PullImages(ctx, compose, pullOpts); err != nil {
+		return err
+	}
+
+	if err := e.ensureNetworks(ctx, compose); err != nil {
+		return err
+	}
+
+	if err := e.ensureVolumes(ctx, compose, opts.CascadeVolumes); err != nil {
+		return err
+	}
+
+	e.refreshRunning(ctx)
+
+	// Only dependencies ordered before a service are waited for, which
+	// breaks dependency cycles the same way sequential startup does
+	ordered := orderServices(compose.Services)
+	done := make(map[string]chan struct{}, len(ordered))
+	position := make(map[string]int, len(ordered))
+	for i, name := range ordered {
+		done[name] = make(chan struct{})
+		position[name] = i
+	}
+
+	// started records the services this call starts, the only ones a
+	// rollback tears down
+	started := make(map[string]bool, len(selected))
+	var startedMu sync.Mutex
+
+	g, gctx := errgroup.WithContext(ctx)
+	if opts.Parallel > 0 {
+		g.SetLimit(opts.Parallel)
+	}
+
+	for _, serviceName := range ordered {
+		if !selected[serviceName] {
+			continue
+		}
+		service := compose.Services[serviceName]
+
+		var deps []string
+		if !opts.NoDeps {
+			for dep := range service.DependsOn {
+				if selected[dep] && position[dep] < position[serviceName] {
+					deps = append(deps, dep)
+				}
+			}
+		}
+
+		g.Go(func() error {
+			for _, dep := range deps {
+				select {
+				case <-done[dep]:
+				case <-gctx.Done():
+					return gctx.Err()
+				}
+			}
+
+			resolved := e.resolveNetworks(compose, service)
+			e.mu.RLock()
+			_, running := e.runningServices[serviceName]
+			e.mu.RUnlock()
+			var aside []asideContainer
+			if running {
+				reason := ""
+				switch {
+				case opts.NoRecreate:
+				case opts.ForceRecreate:
+					reason = "recreation forced"
+				default:
+					var err error
+					if reason, err = e.recreateReason(gctx, serviceName, resolved); err != nil {
+						return &cerrors.ServiceStartError{Service: serviceName, Cause: err}
+					}
+				}
+				if reason == "" {
+					e.logger.Infof("Service %s is already running", serviceName)
+					close(done[serviceName])
+					return nil
+				}
+
+				// The old containers are kept aside until their
+				// replacements are up, to fall back on if they are not
+				e.logger.Infof("Recreating service %s: %s", serviceName, reason)
+				var err error
+				if aside, err = e.stopService(gctx, serviceName, resolved, true); err != nil {
+					return &cerrors.ServiceStartError{Service: serviceName, Cause: err}
+				}
+			}
+
+			if err := e.recreateService(gctx, serviceName, resolved, !opts.NoDeps, aside); err != nil {
+				e.logger.Errorf("Failed to start service %s: %v", serviceName, err)
+				return &cerrors.ServiceStartError{Service: serviceName, Cause: err}
+			}
+			startedMu.Lock()
+			started[serviceName] = true
+			startedMu.Unlock()
+			close(done[serviceName])
+			return nil
+		})
+	}
+
+	err = g.Wait()
+	if err == nil && compose.GlobalHooks != nil && len(compose.GlobalHooks.PostDeploy) > 0 {
+		err = e.postDeploy(ctx, compose, selected)
+	}
+	if err != nil {
+		e.logger.Info("Rolling back started services...")
+		e.rollback(context.Background(), compose, started)
+	}
+
+	e.saveRunning()
+	return err
+}
+
+// recreateReason tells why a running service's containers are out of date:
+// they were created from a different configuration, or the service's image
+// tag now points to another image, e.g. after a pull. It returns an empty
+// reason for up to date containers.
+func (e *Executor) recreateReason(ctx context.Context, serviceName string, service *compose.Service) (string, error) {
+	resolved, err := e.resolveNetworkMode(service)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err = e.resolveVolumesFrom(resolved); err != nil {
+		return "", err
+	}
+	configHash := container.ConfigHash(resolved)
+
+	imageID := ""
+	if service.Image != "" {
+		if imageID, err = e.containerManager.ImageID(ctx, service.Image); err != nil {
+			return "", err
+		}
+	}
+
+	replicas, err := e.serviceReplicas(ctx, serviceName, false)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range replicas {
+		if c.Labels[container.LabelConfigHash] != configHash {
+			return "configuration changed", nil
+		}
+		if imageID != "" && c.Labels[container.LabelImage] != imageID {
+			return fmt.Sprintf("image %s changed", service.Image), nil
+		}
+	}
+	return "", nil
+}
+
+// postDeploy waits for the started services that define a healthcheck to
+// become healthy, then runs the post_deploy hooks
+func (e *Executor) postDeploy(ctx context.Context, compose *compose.ComposeFile, selected map[string]bool) error {
+	for serviceName := range selected {
+		if compose.Services[serviceName].HealthCheck == nil {
+			continue
+		}
+		e.mu.RLock()
+		containerIDs := e.runningServices[serviceName]
+		e.mu.RUnlock()
+		if err := e.waitHealthy(ctx, containerIDs, dependencyHealthTimeout); err != nil {
+			return fmt.Errorf("service %s did not become healthy: %w", serviceName, err)
+		}
+	}
+
+	e.logger.Info("Running post-deploy hooks")
+	if err := e.hookExecutor.ExecuteHooks(ctx, compose.GlobalHooks.PostDeploy, compose.GlobalHooks.ListTimeout, template.NewData(e.projectName, "post-deploy", "", nil)); err != nil {
+		return fmt.Errorf("post-deploy hooks failed: %w", err)
+	}
+	return nil
+}
+
+// ensureNetworks creates the compose file's networks that do not exist yet,
+// skipping external ones. Networks are scoped to the project the way Docker
+// Compose names them, <project>_<network>.
+func (e *Executor) ensureNetworks(ctx context.Context, compose *compose.ComposeFile) error {
+	for name, network := range compose.Networks {
+		var userLabels map[string]string
+		if network != nil {
+			if network.External {
+				continue
+			}
+			userLabels = network.Labels
+		}
+
+		labels := container.MergeLabels(userLabels, map[string]string{
+			container.LabelProject: e.projectName,
+			container.LabelNetwork: name,
+		})
+		if _, err := e.containerManager.EnsureNetwork(ctx, e.projectName+"_"+name, network, labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureVolumes creates the compose file's named volumes that do not exist
+// yet. With cascade set the volumes created here are labeled and recorded in
+// the state file so removeCascadeVolumes can find them again.
+func (e *Executor) ensureVolumes(ctx context.Context, compose *compose.ComposeFile, cascade bool) error {
+	var created []string
+	for name, volume := range compose.Volumes {
+		if volume != nil && volume.External {
+			continue
+		}
+
+		var userLabels map[string]string
+		if volume != nil {
+			userLabels = volume.Labels
+		}
+		labels := container.MergeLabels(userLabels, map[string]string{
+			container.LabelProject: e.projectName,
+			container.LabelVolume:  name,
+		})
+		if cascade {
+			labels[container.LabelCascadeProject] = e.projectName
+		}
+
+		isNew, err := e.containerManager.EnsureVolume(ctx, name, volume, labels)
+		if err != nil {
+			return err
+		}
+		if isNew && cascade {
+			created = append(created, name)
+		}
+	}
+
+	if len(created) == 0 {
+		return nil
+	}
+
+	st, err := e.store.Load()
+	if err != nil {
+		return err
+	}
+	for _, name := range created {
+		if !slices.Contains(st.Volumes, name) {
+			st.Volumes = append(st.Volumes, name)
+		}
+	}
+	return e.store.Save(st)
+}
+
+// removeCascadeVolumes removes the volumes labeled by `up --cascade-volumes`
+// along with those recorded in the state file. Volumes the compose file
+// declares external are never removed.
+func (e *Executor) removeCascadeVolumes(ctx context.Context, compose *compose.ComposeFile) error {
+	st, err := e.store.Load()
+	if err != nil {
+		return err
+	}
+
+	f := filters.NewArgs()
+	f.Add("label", container.LabelCascadeProject+"="+e.projectName)
+	labeled, err := e.containerManager.ListVolumes(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	var kept []string
+	for _, name := range slices.Compact(slices.Sorted(slices.Values(append(labeled, st.Volumes...)))) {
+		if volume, exists := compose.Volumes[name]; exists && volume != nil && volume.External {
+			e.logger.Warnf("Not removing external volume %s", name)
+			continue
+		}
+		if err := e.containerManager.RemoveVolume(ctx, name); err != nil {
+			errs = append(errs, err)
+			kept = append(kept, name)
+		}
+	}
+
+	st.Volumes = kept
+	if err := e.store.Save(st); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// selectServices resolves the services to start: the named ones plus,
+// unless noDeps is set, everything they transitively depend on
+// UpServices returns the services UpWithOptions starts for opts, in name
+// order: those named in opts.Services and, unless opts.NoDeps is set, their
+// dependencies, or every service when none are named
+func (e *Executor) UpServices(compose *compose.ComposeFile, opts UpOptions) ([]string, error) {
+	selected, err := e.selectServices(compose.Services, opts.Services, opts.NoDeps)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(selected))
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (e *Executor) selectServices(services map[string]*compose.Service, names []string, noDeps bool) (map[string]bool, error) {
+	selected := make(map[string]bool, len(services))
+	if len(names) == 0 {
+		for name := range services {
+			selected[name] = true
+		}
+		return selected, nil
+	}
+
+	var visit func(string)
+	visit = func(name string) {
+		if selected[name] {
+			return
+		}
+		service, exists := services[name]
+		if !exists {
+			return
+		}
+		selected[name] = true
+		if noDeps {
+			return
+		}
+		for dep := range service.DependsOn {
+			visit(dep)
+		}
+	}
+
+	for _, name := range names {
+		if _, exists := services[name]; !exists {
+			return nil, fmt.Errorf("no such service: %s", name)
+		}
+		visit(name)
+	}
+	return selected, nil
+}
+
+func (e *Executor) Down(ctx context.Context, compose *compose.ComposeFile) error {
+	return e.DownWithOptions(ctx, compose, DownOptions{})
+}
+
+func (e *Executor) DownWithOptions(ctx context.Context, compose *compose.ComposeFile, opts DownOptions) error {
+	e.logger.Info("Stopping services...")
+
+	e.refreshRunning(ctx)
+	defer e.saveRunning()
+
+	ordered := orderServices(compose.Services)
+	
+	for i := len(ordered) - 1; i >= 0; i-- {
+		serviceName := ordered[i]
+		service := compose.Services[serviceName]
+		
+		if _, err := e.stopService(ctx, serviceName, service, false); err != nil {
+			e.logger.Errorf("Failed to stop service %s: %v", serviceName, err)
+		}
+	}
+
+	if opts.CascadeVolumes {
+		return e.removeCascadeVolumes(ctx, compose)
+	}
+	return nil
+}
+
+// Scale brings each service in scaleMap to the requested number of running
+// replicas. Missing replicas are created with the next free container
+// numbers; excess replicas are stopped and removed, highest number first.
+func (e *Executor) Scale(ctx context.Context, compose *compose.ComposeFile, scaleMap map[string]int) error {
+	names := make([]string, 0, len(scaleMap))
+	for name := range scaleMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, serviceName := range names {
+		service, exists := compose.Services[serviceName]
+		if !exists {
+			return fmt.Errorf("no such service: %s", serviceName)
+		}
+
+		desired := scaleMap[serviceName]
+		if desired < 0 {
+			return fmt.Errorf("invalid scale %d for service %s", desired, serviceName)
+		}
+
+		replicas, err := e.serviceReplicas(ctx, serviceName, false)
+		if err != nil {
+			return err
+		}
+
+		e.logger.Infof("Scaling service %s from %d to %d", serviceName, len(replicas), desired)
+
+		for len(replicas) > desired {
+			last := replicas[len(replicas)-1]
+			if err := e.containerManager.StopContainer(ctx, last.ID, 10); err != nil {
+				return fmt.Errorf("failed to stop %s: %w", last.Name, err)
+			}
+			if err := e.containerManager.RemoveContainer(ctx, last.ID, false); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", last.Name, err)
+			}
+			replicas = replicas[:len(replicas)-1]
+		}
+
+		next := 1
+		if len(replicas) > 0 {
+			next = replicas[len(replicas)-1].Number + 1
+		}
+		for len(replicas) < desired {
+			containerID, err := e.startReplica(ctx, serviceName, e.resolveNetworks(compose, service), next)
+			if err != nil {
+				return err
+			}
+			replicas = append(replicas, container.ContainerInfo{ID: containerID, Service: serviceName, Number: next})
+			next++
+		}
+
+		containerIDs := make([]string, len(replicas))
+		for i, replica := range replicas {
+			containerIDs[i] = replica.ID
+		}
+
+		e.mu.Lock()
+		if len(containerIDs) > 0 {
+			e.runningServices[serviceName] = containerIDs
+		} else {
+			delete(e.runningServices, serviceName)
+		}
+		e.mu.Unlock()
+	}
+
+	e.refreshRunning(ctx)
+	e.saveRunning()
+	return nil
+}
+
+// Stop stops the running containers of the given services, or of all
+// services when none are named, dependents before their dependencies. The
+// containers are kept so Start can resume them.
+func (e *Executor) Stop(ctx context.Context, compose *compose.ComposeFile, services []string, timeout int) error {
+	targets, err := e.selectServices(compose.Services, services, true)
+	if err != nil {
+		return err
+	}
+
+	e.refreshRunning(ctx)
+	defer e.saveRunning()
+
+	ordered := orderServices(compose.Services)
+	var errs []error
+	for i := len(ordered) - 1; i >= 0; i-- {
+		serviceName := ordered[i]
+		if !targets[serviceName] {
+			continue
+		}
+
+		e.mu.RLock()
+		containerIDs := e.runningServices[serviceName]
+		e.mu.RUnlock()
+
+		for _, containerID := range containerIDs {
+			if err := e.containerManager.StopContainer(ctx, containerID, timeout); err != nil {
+				errs = append(errs, fmt.Errorf("failed to stop %s: %w", serviceName, err))
+			}
+		}
+
+		e.mu.Lock()
+		delete(e.runningServices, serviceName)
+		e.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Start starts the existing stopped containers of the given services, or of
+// all services when none are named, dependencies first
+func (e *Executor) Start(ctx context.Context, compose *compose.ComposeFile, services []string) error {
+	targets, err := e.selectServices(compose.Services, services, true)
+	if err != nil {
+		return err
+	}
+
+	for _, serviceName := range orderServices(compose.Services) {
+		if !targets[serviceName] {
+			continue
+		}
+
+		replicas, err := e.serviceReplicas(ctx, serviceName, true)
+		if err != nil {
+			return err
+		}
+		if len(replicas) == 0 {
+			e.logger.Warnf("Service %s has no containers to start; run up to create them", serviceName)
+			continue
+		}
+
+		for _, c := range replicas {
+			if c.State == "running" {
+				continue
+			}
+			e.logger.Infof("Starting %s", c.Name)
+			if err := e.containerManager.StartContainer(ctx, c.ID); err != nil {
+				return fmt.Errorf("failed to start %s: %w", c.Name, err)
+			}
+		}
+	}
+
+	e.refreshRunning(ctx)
+	e.saveRunning()
+	return nil
+}
+
+// Containers returns the project's containers for the given services, or
+// for all services when none are named. Stopped containers are included
+// when all is set.
+func (e *Executor) Containers(ctx context.Context, services []string, all bool) ([]container.ContainerInfo, error) {
+	f := filters.NewArgs()
+	if !all {
+		f.Add("status", "running")
+	}
+	return e.ContainersMatching(ctx, services, f)
+}
+
+// ContainersMatching lists the project's containers that match f, as built
+// by container.ParseContainerFilters, restricted to the given services
+// unless none are named
+func (e *Executor) ContainersMatching(ctx context.Context, services []string, f filters.Args) ([]container.ContainerInfo, error) {
+	containers, err := e.containerManager.ListServiceContainers(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	result := containers[:0]
+	for _, c := range containers {
+		if len(services) == 0 || slices.Contains(services, c.Service) {
+			result = append(result, c)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Service != result[j].Service {
+			return result[i].Service < result[j].Service
+		}
+		return result[i].Number < result[j].Number
+	})
+	return result, nil
+}
+
+// FollowLogs streams the output of the running containers of the given
+// services to fn until ctx is cancelled. fn may be called concurrently.
+func (e *Executor) FollowLogs(ctx context.Context, services []string, fn func(container.LogLine)) error {
+	g, gctx := errgroup.WithContext(ctx)
+	for _, serviceName := range services {
+		e.mu.RLock()
+		containerIDs := e.runningServices[serviceName]
+		e.mu.RUnlock()
+
+		for _, containerID := range containerIDs {
+			g.Go(func() error {
+				return e.containerManager.FollowLogs(gctx, containerID, serviceName, fn)
+			})
+		}
+	}
+	return g.Wait()
+}
+
+// Events streams the project's container events to fn. With follow set, a
+// stream that ends unexpectedly, e.g. because the daemon restarted, is
+// reopened after a second from the last event seen, and a "reconnect"
+// event marks the possible gap.
+func (e *Executor) Events(ctx context.Context, opts container.EventsOptions, follow bool, fn func(container.Event)) error {
+	lastSeen := time.Now()
+	for {
+		err := e.containerManager.Events(ctx, opts, func(event container.Event) {
+			lastSeen = event.Time
+			fn(event)
+		})
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		if !follow {
+			return err
+		}
+		if !opts.Until.IsZero() && !time.Now().Before(opts.Until) {
+			return nil
+		}
+
+		e.logger.Warnf("Event stream lost, reconnecting: %v", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+
+		// Since is inclusive, so skip the last event already delivered
+		opts.Since = lastSeen.Add(time.Nanosecond)
+		fn(container.Event{Time: time.Now(), Type: "reconnect"})
+	}
+}
+
+// ContainerExit describes a service container that stopped
+type ContainerExit struct {
+	Service     string
+	ContainerID string
+	ExitCode    int
+}
+
+// WaitFirstExit watches the running containers of the given services and
+// returns as soon as any of them stops. The remaining watches are cancelled.
+func (e *Executor) WaitFirstExit(ctx context.Context, services []string) (ContainerExit, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		exit ContainerExit
+		err  error
+	}
+	results := make(chan result)
+	watching := 0
+	for _, serviceName := range services {
+		e.mu.RLock()
+		containerIDs := e.runningServices[serviceName]
+		e.mu.RUnlock()
+
+		for _, containerID := range containerIDs {
+			watching++
+			go func() {
+				code, err := e.containerManager.Wait(ctx, containerID)
+				select {
+				case results <- result{ContainerExit{Service: serviceName, ContainerID: containerID, ExitCode: int(code)}, err}:
+				case <-ctx.Done():
+				}
+			}()
+		}
+	}
+	if watching == 0 {
+		return ContainerExit{}, fmt.Errorf("no running containers to watch")
+	}
+
+	select {
+	case r := <-results:
+		return r.exit, r.err
+	case <-ctx.Done():
+		return ContainerExit{}, ctx.Err()
+	}
+}
+
+// AbortOnExit waits for the first of the given services' containers to
+// exit and returns the exit that decides the result of an aborting up: that
+// container's, or with exitCodeFrom set the named service's, which is
+// stopped to read its exit code
+func (e *Executor) AbortOnExit(ctx context.Context, compose *compose.ComposeFile, services []string, exitCodeFrom string, timeout int) (ContainerExit, error) {
+	exit, err := e.WaitFirstExit(ctx, services)
+	if err != nil {
+		return ContainerExit{}, fmt.Errorf("failed to watch containers: %w", err)
+	}
+	e.logger.Infof("Service %s exited with code %d, aborting", exit.Service, exit.ExitCode)
+
+	if exitCodeFrom == "" || exit.Service == exitCodeFrom {
+		return exit, nil
+	}
+	code, err := e.ServiceExitCode(ctx, compose, exitCodeFrom, timeout)
+	if err != nil {
+		return ContainerExit{}, fmt.Errorf("failed to get exit code of %s: %w", exitCodeFrom, err)
+	}
+	return ContainerExit{Service: exitCodeFrom, ExitCode: code}, nil
+}
+
+// ServiceExitCode stops the given service and returns the exit code of its
+// first container
+func (e *Executor) ServiceExitCode(ctx context.Context, compose *compose.ComposeFile, serviceName string, timeout int) (int, error) {
+	if err := e.Stop(ctx, compose, []string{serviceName}, timeout); err != nil {
+		return -1, err
+	}
+
+	containers, err := e.Containers(ctx, []string{serviceName}, true)
+	if err != nil {
+		return -1, err
+	}
+	if len(containers) == 0 {
+		return -1, fmt.Errorf("no containers found for service %s", serviceName)
+	}
+
+	info, err := e.containerManager.InspectContainer(ctx, containers[0].ID)
+	if err != nil {
+		return -1, fmt.Errorf("failed to inspect %s: %w", containers[0].Name, err)
+	}
+	if info.ContainerJSONBase == nil || info.State == nil {
+		return -1, fmt.Errorf("no state reported for %s", containers[0].Name)
+	}
+	return info.State.ExitCode, nil
+}
+
+// Inspect returns the detailed view of the given containers
+func (e *Executor) Inspect(ctx context.Context, containers []container.ContainerInfo) ([]types.ContainerJSON, error) {
+	result := make([]types.ContainerJSON, 0, len(containers))
+	for _, c := range containers {
+		info, err := e.containerManager.InspectContainer(ctx, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect %s: %w", c.Name, err)
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// ServiceProcesses lists the processes of all running containers of a
+// service
+type ServiceProcesses struct {
+	Service   string     `json:"service"`
+	Titles    []string   `json:"titles"`
+	Processes [][]string `json:"processes"`
+}
+
+// Top lists the processes of the running containers of the given
+// services, or of all services when none are named. Services without a
+// running container are left out. Results are sorted by service.
+func (e *Executor) Top(ctx context.Context, services []string) ([]ServiceProcesses, error) {
+	containers, err := e.Containers(ctx, services, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ServiceProcesses
+	for _, c := range containers {
+		top, err := e.containerManager.Top(ctx, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list processes of %s: %w", c.Name, err)
+		}
+
+		// Containers come sorted by service, so replicas are adjacent
+		if len(result) == 0 || result[len(result)-1].Service != c.Service {
+			result = append(result, ServiceProcesses{Service: c.Service, Titles: top.Titles, Processes: [][]string{}})
+		}
+		last := &result[len(result)-1]
+		last.Processes = append(last.Processes, top.Processes...)
+	}
+	return result, nil
+}
+
+// Replicas returns a service's containers ordered by replica number, only
+// running ones unless all is set. An index above zero selects the replica
+// of that number, which must exist.
+func (e *Executor) Replicas(ctx context.Context, service string, index int, all bool) ([]container.ContainerInfo, error) {
+	containers, err := e.serviceReplicas(ctx, service, all)
+	if err != nil {
+		return nil, err
+	}
+	if index <= 0 {
+		return containers, nil
+	}
+
+	for _, c := range containers {
+		if c.Number == index {
+			return []container.ContainerInfo{c}, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s has no replica %d (container %s)", service, index, container.ContainerName(e.projectName, service, index))
+}
+
+// Logs returns the output of a service container, only the last tail
+// lines when tail is above zero
+func (e *Executor) Logs(ctx context.Context, c container.ContainerInfo, tail int) ([]container.LogLine, error) {
+	return e.containerManager.ServiceLogs(ctx, c.Service, c.Number, tail)
+}
+
+// Exec runs a command in a service container and returns its exit code
+func (e *Executor) Exec(ctx context.Context, c container.ContainerInfo, command []string, opts container.ExecOptions) (int, error) {
+	code, err := e.containerManager.Exec(ctx, c.ID, command, opts)
+	if err != nil {
+		return code, fmt.Errorf("failed to execute in %s: %w", c.Name, err)
+	}
+	return code, nil
+}
+
+// NotifyPhases sends the lifecycle phase changes of services started or
+// stopped by this executor to events, until called again with nil
+func (e *Executor) NotifyPhases(events chan<- lifecycle.PhaseEvent) {
+	e.lifecycleManager.Notify(events)
+}
+
+// ServiceState returns the lifecycle state of a service started by this
+// executor
+func (e *Executor) ServiceState(serviceName string) (*lifecycle.ServiceState, bool) {
+	return e.lifecycleManager.GetServiceState(serviceName)
+}
+
+// refreshRunning rebuilds the service to container mapping from the
+// project's running containers so commands see containers started by an
+// earlier invocation. When the backend cannot be queried the state file's
+// cache is used instead.
+func (e *Executor) refreshRunning(ctx context.Context) {
+	f := filters.NewArgs()
+	f.Add("status", "running")
+
+	running := make(map[string][]string)
+	containers, err := e.containerManager.ListServiceContainers(ctx, f)
+	if err != nil {
+		e.logger.Warnf("Failed to list project containers, using cached state: %v", err)
+		st, loadErr := e.store.Load()
+		if loadErr != nil {
+			e.logger.Warnf("Failed to load cached state: %v", loadErr)
+			return
+		}
+		for name, ids := range st.Containers {
+			running[name] = ids
+		}
+	} else {
+		sort.Slice(containers, func(i, j int) bool {
+			return containers[i].Number < containers[j].Number
+		})
+		for _, c := range containers {
+			if c.Labels[container.LabelOneOff] == "True" {
+				continue
+			}
+			running[c.Service] = append(running[c.Service], c.ID)
+		}
+	}
+
+	e.mu.Lock()
+	e.runningServices = running
+	e.mu.Unlock()
+}
+
+// saveRunning records the current service to container mapping in the
+// state file cache
+func (e *Executor) saveRunning() {
+	st, err := e.store.Load()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create container manager: %w", err)
+		e.logger.Warnf("Failed to load state: %v", err)
+		return
 	}
 
-	return &Executor{
-		projectName:      projectName,
-		logger:          logger,
-		containerManager: containerManager,
-		lifecycleManager: lifecycle.NewManager(logger),
-		runningServices:  make(map[string]string),
-	}, nil
+	e.mu.RLock()
+	st.Containers = make(map[string][]string, len(e.runningServices))
+	for name, ids := range e.runningServices {
+		st.Containers[name] = append([]string(nil), ids...)
+	}
+	e.mu.RUnlock()
+
+	if err := e.store.Save(st); err != nil {
+		e.logger.Warnf("Failed to save state: %v", err)
+	}
 }
 
-func (e *Executor) Up(ctx context.Context, compose *compose.ComposeFile) error {
-	e.logger.Info("Starting services...")
+// serviceReplicas returns the running containers of a service, or all of
+// them including stopped ones when all is set, ordered by container number
+func (e *Executor) serviceReplicas(ctx context.Context, serviceName string, all bool) ([]container.ContainerInfo, error) {
+	f := filters.NewArgs()
+	if !all {
+		f.Add("status", "running")
+	}
+	f.Add("label", container.LabelService+"="+serviceName)
 
-	ordered := e.orderServices(compose.Services)
+	containers, err := e.containerManager.ListServiceContainers(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers for %s: %w", serviceName, err)
+	}
 
-	for _, serviceName := range ordered {
-		service := compose.Services[serviceName]
-		
-		if err := e.startService(ctx, serviceName, service); err != nil {
-			e.logger.Errorf("Failed to start service %s: %v", serviceName, err)
-			
-			e.logger.Info("Rolling back started services...")
-			e.rollback(context.Background(), compose)
-			
-			return fmt.Errorf("failed to start service %s: %w", serviceName, err)
+	replicas := containers[:0]
+	for _, c := range containers {
+		if c.Labels[container.LabelOneOff] != "True" {
+			replicas = append(replicas, c)
 		}
 	}
+	sort.Slice(replicas, func(i, j int) bool {
+		return replicas[i].Number < replicas[j].Number
+	})
+	return replicas, nil
+}
 
-	return nil
+// startReplica creates and starts replica number of a service, removing the
+// container again if it fails to start
+func (e *Executor) startReplica(ctx context.Context, serviceName string, service *compose.Service, number int) (string, error) {
+	service, err := e.resolveNetworkMode(service)
+	if err != nil {
+		return "", err
+	}
+	if service, err = e.resolveVolumesFrom(service); err != nil {
+		return "", err
+	}
+
+	containerID, err := e.containerManager.CreateService(ctx, serviceName, service, number)
+	if err != nil {
+		return "", fmt.Errorf("failed to create service container: %w", err)
+	}
+
+	if err := e.containerManager.StartContainer(ctx, containerID); err != nil {
+		e.containerManager.RemoveContainer(ctx, containerID, false)
+		return "", fmt.Errorf("failed to start service container: %w", err)
+	}
+	return containerID, nil
 }
 
-func (e *Executor) Down(ctx context.Context, compose *compose.ComposeFile) error {
-	e.logger.Info("Stopping services...")
+// Restart stops and starts the containers of the given services, or of all
+// services when none are named. With withDependents set, every service that
+// transitively depends on a target is restarted too: dependents are stopped
+// before their dependencies and started after them.
+func (e *Executor) Restart(ctx context.Context, compose *compose.ComposeFile, services []string, withDependents bool, timeout int) error {
+	targets, err := e.selectServices(compose.Services, services, true)
+	if err != nil {
+		return err
+	}
+	// Dependents that asked for it with depends_on restart: true are
+	// restarted along with their dependency even without withDependents
+	for _, name := range e.dependents(compose.Services, targets, !withDependents) {
+		targets[name] = true
+	}
 
-	ordered := e.orderServices(compose.Services)
-	
-	for i := len(ordered) - 1; i >= 0; i-- {
-		serviceName := ordered[i]
-		service := compose.Services[serviceName]
-		
-		if err := e.stopService(ctx, serviceName, service); err != nil {
-			e.logger.Errorf("Failed to stop service %s: %v", serviceName, err)
+	var order []string
+	for _, name := range orderServices(compose.Services) {
+		if targets[name] {
+			order = append(order, name)
+		}
+	}
+
+	replicas := make(map[string][]container.ContainerInfo, len(order))
+	for _, name := range order {
+		if replicas[name], err = e.serviceReplicas(ctx, name, true); err != nil {
+			return err
+		}
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		for _, c := range replicas[order[i]] {
+			if c.State != "running" {
+				continue
+			}
+			e.logger.Infof("Stopping %s", c.Name)
+			if err := e.containerManager.StopContainer(ctx, c.ID, timeout); err != nil {
+				return fmt.Errorf("failed to stop %s: %w", c.Name, err)
+			}
 		}
 	}
 
+	for _, name := range order {
+		for _, c := range replicas[name] {
+			e.logger.Infof("Starting %s", c.Name)
+			if err := e.containerManager.StartContainer(ctx, c.ID); err != nil {
+				return fmt.Errorf("failed to start %s: %w", c.Name, err)
+			}
+		}
+	}
 	return nil
 }
 
-func (e *Executor) startService(ctx context.Context, serviceName string, service *compose.Service) error {
+// dependents returns the services that transitively depend on any of the
+// targets, excluding the targets themselves. With restartOnly set only
+// depends_on entries marked restart: true are followed.
+func (e *Executor) dependents(services map[string]*compose.Service, targets map[string]bool, restartOnly bool) []string {
+	reverse := make(map[string][]string)
+	for name, service := range services {
+		for dep, dependsOn := range service.DependsOn {
+			if !restartOnly || dependsOn.Restart {
+				reverse[dep] = append(reverse[dep], name)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	var visit func(string)
+	visit = func(name string) {
+		for _, dependent := range reverse[name] {
+			if seen[dependent] || targets[dependent] {
+				continue
+			}
+			seen[dependent] = true
+			result = append(result, dependent)
+			visit(dependent)
+		}
+	}
+	for name := range targets {
+		visit(name)
+	}
+	return result
+}
+
+// Orphans returns the project's containers whose service is no longer
+// defined in the compose file. One-off run containers are not orphans.
+func (e *Executor) Orphans(ctx context.Context, compose *compose.ComposeFile) ([]container.ContainerInfo, error) {
+	containers, err := e.containerManager.ListServiceContainers(ctx, filters.NewArgs())
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []container.ContainerInfo
+	for _, c := range containers {
+		if c.Labels[container.LabelOneOff] == "True" {
+			continue
+		}
+		if _, exists := compose.Services[c.Service]; !exists {
+			orphans = append(orphans, c)
+		}
+	}
+	return orphans, nil
+}
+
+// CleanupOrphans removes orphaned containers when remove is set and
+// otherwise only warns about them
+func (e *Executor) CleanupOrphans(ctx context.Context, compose *compose.ComposeFile, remove bool) error {
+	orphans, err := e.Orphans(ctx, compose)
+	if err != nil {
+		return fmt.Errorf("failed to find orphan containers: %w", err)
+	}
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	if !remove {
+		names := make([]string, len(orphans))
+		for i, c := range orphans {
+			names[i] = c.Name
+		}
+		e.logger.Warnf("Found orphan containers (%s) for this project. If you removed or renamed this service in your compose file, you can run this command with the --remove-orphans flag to clean it up.", strings.Join(names, ", "))
+		return nil
+	}
+
+	var errs []error
+	for _, c := range orphans {
+		e.logger.Infof("Removing orphan container %s", c.Name)
+		if c.State == "running" {
+			if err := e.containerManager.StopContainer(ctx, c.ID, 10); err != nil {
+				errs = append(errs, fmt.Errorf("failed to stop orphan %s: %w", c.Name, err))
+				continue
+			}
+		}
+		if err := e.containerManager.RemoveContainer(ctx, c.ID, false); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove orphan %s: %w", c.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProjectVolumes returns the names of the volumes labeled with the project,
+// sorted
+func (e *Executor) ProjectVolumes(ctx context.Context) ([]string, error) {
+	f := filters.NewArgs()
+	f.Add("label", container.LabelProject+"="+e.projectName)
+	names, err := e.containerManager.ListVolumes(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// OrphanVolumes returns the project's volumes that the compose file no
+// longer declares. Declared volumes, external ones included, are never
+// orphans.
+func (e *Executor) OrphanVolumes(ctx context.Context, compose *compose.ComposeFile) ([]string, error) {
+	names, err := e.ProjectVolumes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project volumes: %w", err)
+	}
+
+	var orphans []string
+	for _, name := range names {
+		if _, declared := compose.Volumes[name]; !declared {
+			orphans = append(orphans, name)
+		}
+	}
+	return orphans, nil
+}
+
+// WarnOrphanVolumes logs the project's orphaned volumes, if any
+func (e *Executor) WarnOrphanVolumes(ctx context.Context, compose *compose.ComposeFile) {
+	orphans, err := e.OrphanVolumes(ctx, compose)
+	if err != nil {
+		e.logger.Debugf("Skipping orphan volume check: %v", err)
+		return
+	}
+	if len(orphans) > 0 {
+		e.logger.Warnf("Found orphan volumes (%s) for this project. If you removed them from your compose file, you can run down with the --remove-orphan-volumes flag to clean them up.", strings.Join(orphans, ", "))
+	}
+}
+
+// RemoveVolumes removes the named volumes, continuing past failures
+func (e *Executor) RemoveVolumes(ctx context.Context, names []string) error {
+	var errs []error
+	for _, name := range names {
+		e.logger.Infof("Removing volume %s", name)
+		if err := e.containerManager.RemoveVolume(ctx, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run runs a one-off container for a service with the given overrides
+// applied on top of its definition and returns the container's exit code.
+// The service in the compose file is not modified.
+func (e *Executor) Run(ctx context.Context, compose *compose.ComposeFile, serviceName string, overrides compose.RunOverrides, opts container.OneOffOptions) (int, error) {
+	service, exists := compose.Services[serviceName]
+	if !exists {
+		return -1, fmt.Errorf("no such service: %s", serviceName)
+	}
+
+	if _, ok := service.NetworkModeService(); ok || len(service.VolumesFromServices()) > 0 {
+		e.refreshRunning(ctx)
+	}
+	service, err := e.resolveNetworkMode(e.resolveNetworks(compose, service.WithRunOverrides(overrides)))
+	if err != nil {
+		return -1, err
+	}
+	if service, err = e.resolveVolumesFrom(service); err != nil {
+		return -1, err
+	}
+
+	e.logger.Infof("Running one-off container for service %s", serviceName)
+	return e.containerManager.RunOneOff(ctx, serviceName, service, opts)
+}
+
+// resolveNetworks returns a copy of service with its networks keyed by
+// their Docker names: project-scoped for networks defined by the compose
+// file, unchanged for external ones
+func (e *Executor) resolveNetworks(composeFile *compose.ComposeFile, service *compose.Service) *compose.Service {
+	if len(service.Networks) == 0 {
+		return service
+	}
+
+	resolved := *service
+	resolved.Networks = make(compose.ServiceNetworks, len(service.Networks))
+	for name, settings := range service.Networks {
+		if network, defined := composeFile.Networks[name]; defined && (network == nil || !network.External) {
+			name = e.projectName + "_" + name
+		}
+		resolved.Networks[name] = settings
+	}
+	return &resolved
+}
+
+// resolveNetworkMode translates a "service:<name>" network_mode into the
+// "container:<id>" form Docker understands, using the target service's
+// first running container. Other modes are returned unchanged.
+func (e *Executor) resolveNetworkMode(service *compose.Service) (*compose.Service, error) {
+	target, ok := service.NetworkModeService()
+	if !ok {
+		return service, nil
+	}
+
+	e.mu.RLock()
+	containerIDs := e.runningServices[target]
+	e.mu.RUnlock()
+	if len(containerIDs) == 0 {
+		return nil, fmt.Errorf("network_mode service:%s: service %s has no running container", target, target)
+	}
+
+	resolved := *service
+	resolved.NetworkMode = "container:" + containerIDs[0]
+	return &resolved, nil
+}
+
+// resolveVolumesFrom translates volumes_from entries into the
+// "<container>[:mode]" form Docker understands, using each referenced
+// service's first running container
+func (e *Executor) resolveVolumesFrom(service *compose.Service) (*compose.Service, error) {
+	if len(service.VolumesFrom) == 0 {
+		return service, nil
+	}
+
+	resolved := *service
+	resolved.VolumesFrom = make([]string, 0, len(service.VolumesFrom))
+	for _, entry := range service.VolumesFrom {
+		ref, err := compose.ParseVolumesFrom(entry)
+		if err != nil {
+			return nil, err
+		}
+		source := ref.Container
+		if ref.Service != "" {
+			e.mu.RLock()
+			containerIDs := e.runningServices[ref.Service]
+			e.mu.RUnlock()
+			if len(containerIDs) == 0 {
+				return nil, fmt.Errorf("volumes_from %s: service %s has no running container", entry, ref.Service)
+			}
+			source = containerIDs[0]
+		}
+		if ref.Mode != "" {
+			source += ":" + ref.Mode
+		}
+		resolved.VolumesFrom = append(resolved.VolumesFrom, source)
+	}
+	return &resolved, nil
+}
+
+func (e *Executor) startService(ctx context.Context, serviceName string, service *compose.Service, waitDeps bool) error {
 	e.logger.Infof("Starting service: %s", serviceName)
 
+	if waitDeps {
+		if err := e.waitForDependencies(ctx, serviceName, service); err != nil {
+			return err
+		}
+	}
+
 	if err := e.lifecycleManager.StartService(ctx, serviceName, service); err != nil {
 		return err
 	}
 
 	for _, init := range service.InitContainers {
 		if err := e.containerManager.RunInitContainer(ctx, serviceName, &init); err != nil {
-			return fmt.Errorf("init container %s failed: %w", init.Name, err)
+			initErr := &cerrors.InitContainerError{Service: serviceName, Container: init.Name, ExitCode: -1, Cause: err}
+			var runErr *cerrors.ContainerRunError
+			if errors.As(err, &runErr) {
+				initErr.ExitCode = runErr.ExitCode
+				initErr.Logs = runErr.Logs
+			}
+			return initErr
 		}
 	}
 
-	containerID, err := e.containerManager.CreateService(ctx, serviceName, service)
+	containerID, err := e.startReplica(ctx, serviceName, service, 1)
 	if err != nil {
-		return fmt.Errorf("failed to create service container: %w", err)
-	}
-
-	if err := e.containerManager.StartContainer(ctx, containerID); err != nil {
-		e.containerManager.RemoveContainer(ctx, containerID)
-		return fmt.Errorf("failed to start service container: %w", err)
+		return err
 	}
 
 	e.mu.Lock()
-	e.runningServices[serviceName] = containerID
+	e.runningServices[serviceName] = []string{containerID}
 	e.mu.Unlock()
 
 	for _, post := range service.PostContainers {
 		if post.OnSuccess {
-			if err := e.containerManager.RunPostContainer(ctx, serviceName, &post); err != nil {
-				e.logger.Warnf("Post container %s failed: %v", post.Name, err)
+			if err := e.waitForPostContainer(ctx, &post); err != nil {
+				e.logger.Warnf("Post container %s not run: %v", post.Name, err)
+				continue
+			}
+			data := template.NewData(e.projectName, string(lifecycle.PhasePostStart), serviceName, service)
+			if err := e.containerManager.RunPostContainer(ctx, serviceName, &post, data); err != nil {
+				e.warnPostContainerFailure(post.Name, err)
 			}
 		}
 	}
@@ -112,34 +1365,146 @@ func (e *Executor) startService(ctx context.Context, serviceName string, service
 	return nil
 }
 
-func (e *Executor) stopService(ctx context.Context, serviceName string, service *compose.Service) error {
+// waitForDependencies blocks until every dependency declared with the
+// service_healthy condition reports healthy
+func (e *Executor) waitForDependencies(ctx context.Context, serviceName string, service *compose.Service) error {
+	for dep, dependsOn := range service.DependsOn {
+		if dependsOn.Condition != "service_healthy" {
+			continue
+		}
+
+		e.mu.RLock()
+		containerIDs, exists := e.runningServices[dep]
+		e.mu.RUnlock()
+
+		if !exists {
+			return &cerrors.DependencyError{Service: serviceName, Dependency: dep, Reason: "is not running"}
+		}
+
+		e.logger.Infof("Waiting for dependency %s to be healthy", dep)
+		if err := e.waitHealthy(ctx, containerIDs, dependencyHealthTimeout); err != nil {
+			return &cerrors.DependencyError{Service: serviceName, Dependency: dep, Reason: "did not become healthy", Cause: err}
+		}
+	}
+	return nil
+}
+
+// WaitForServices blocks until every replica of the named services is
+// healthy, or ctx is done. It gates one-off containers the way the
+// service_healthy condition gates services.
+func (e *Executor) WaitForServices(ctx context.Context, services []string) error {
+	e.refreshRunning(ctx)
+
+	for _, serviceName := range services {
+		e.mu.RLock()
+		containerIDs, exists := e.runningServices[serviceName]
+		e.mu.RUnlock()
+
+		if !exists {
+			return fmt.Errorf("service %s is not running", serviceName)
+		}
+
+		e.logger.Infof("Waiting for service %s to be healthy", serviceName)
+		if err := e.waitHealthy(ctx, containerIDs, 0); err != nil {
+			return fmt.Errorf("service %s did not become healthy: %w", serviceName, err)
+		}
+	}
+	return nil
+}
+
+// waitForPostContainer blocks until the service named by a post container's
+// "<service>:healthy" wait_for condition is healthy. Duration waits are left
+// to the container manager.
+func (e *Executor) waitForPostContainer(ctx context.Context, post *compose.PostContainer) error {
+	_, target, err := post.WaitCondition()
+	if err != nil || target == "" {
+		return err
+	}
+
+	e.mu.RLock()
+	containerIDs, exists := e.runningServices[target]
+	e.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("service %s is not running", target)
+	}
+
+	e.logger.Infof("Waiting for service %s to be healthy before running post container %s", target, post.Name)
+	return e.waitHealthy(ctx, containerIDs, dependencyHealthTimeout)
+}
+
+// waitHealthy blocks until every given replica of a service is healthy. A
+// zero timeout waits until ctx is done.
+func (e *Executor) waitHealthy(ctx context.Context, containerIDs []string, timeout time.Duration) error {
+	for _, containerID := range containerIDs {
+		check := func() (bool, error) {
+			return e.containerManager.IsHealthy(ctx, containerID)
+		}
+		if err := health.WaitHealthy(ctx, check, time.Second, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Executor) warnPostContainerFailure(name string, err error) {
+	var runErr *cerrors.ContainerRunError
+	if errors.As(err, &runErr) {
+		e.logger.Warnf("Post container %s exited with code %d, last output:\n%s", name, runErr.ExitCode, cerrors.LogTail(runErr.Logs, 10))
+		return
+	}
+	e.logger.Warnf("Post container %s failed: %v", name, err)
+}
+
+// stopService runs a service's stop lifecycle and stops its containers.
+// They are removed, or with aside set renamed out of the way of their
+// replacements and returned, see moveAside.
+func (e *Executor) stopService(ctx context.Context, serviceName string, service *compose.Service, aside bool) ([]asideContainer, error) {
 	e.logger.Infof("Stopping service: %s", serviceName)
 
 	e.mu.RLock()
-	containerID, exists := e.runningServices[serviceName]
+	containerIDs, exists := e.runningServices[serviceName]
 	e.mu.RUnlock()
 
 	if !exists {
 		e.logger.Warnf("Service %s not found in running services", serviceName)
-		return nil
+		return nil, nil
 	}
 
 	if err := e.lifecycleManager.StopService(ctx, serviceName, service); err != nil {
 		e.logger.Warnf("Lifecycle stop failed for %s: %v", serviceName, err)
 	}
 
-	if err := e.containerManager.StopContainer(ctx, containerID, 30); err != nil {
-		e.logger.Warnf("Failed to stop container for %s: %v", serviceName, err)
-	}
+	var moved []asideContainer
+	data := template.NewData(e.projectName, string(lifecycle.PhasePostStop), serviceName, service)
+	for i, containerID := range containerIDs {
+		if err := e.containerManager.StopContainer(ctx, containerID, 30); err != nil {
+			e.logger.Warnf("Failed to stop container for %s: %v", serviceName, err)
+		}
+		// Post containers see the exit code of the first replica
+		if i == 0 {
+			if info, err := e.containerManager.InspectContainer(ctx, containerID); err == nil && info.ContainerJSONBase != nil && info.State != nil {
+				data.ExitCode = info.State.ExitCode
+			}
+		}
 
-	if err := e.containerManager.RemoveContainer(ctx, containerID); err != nil {
-		e.logger.Warnf("Failed to remove container for %s: %v", serviceName, err)
+		if aside {
+			old, err := e.moveAside(ctx, containerID)
+			if err == nil {
+				moved = append(moved, old)
+				continue
+			}
+			e.logger.Warnf("Failed to move aside container for %s, removing it: %v", serviceName, err)
+		}
+		if err := e.containerManager.RemoveContainer(ctx, containerID, false); err != nil {
+			e.logger.Warnf("Failed to remove container for %s: %v", serviceName, err)
+		}
 	}
 
 	for _, post := range service.PostContainers {
 		if post.OnFailure {
-			if err := e.containerManager.RunPostContainer(ctx, serviceName, &post); err != nil {
-				e.logger.Warnf("Post container %s failed: %v", post.Name, err)
+			if err := e.containerManager.RunPostContainer(ctx, serviceName, &post, data); err != nil {
+				e.warnPostContainerFailure(post.Name, err)
 			}
 		}
 	}
@@ -149,36 +1514,149 @@ func (e *Executor) stopService(ctx context.Context, serviceName string, service
 	e.mu.Unlock()
 
 	e.logger.Infof("Service %s stopped", serviceName)
+	return moved, nil
+}
+
+// asideContainer is a stopped container renamed out of the way of its
+// replacement
+type asideContainer struct {
+	ID string
+	// Name is the container's own name, to restore it by
+	Name string
+}
+
+// moveAside renames a stopped container to <name>_old, freeing its name
+// for the container replacing it
+func (e *Executor) moveAside(ctx context.Context, containerID string) (asideContainer, error) {
+	info, err := e.containerManager.InspectContainer(ctx, containerID)
+	if err != nil {
+		return asideContainer{}, err
+	}
+	name := strings.TrimPrefix(info.Name, "/")
+	if err := e.containerManager.RenameContainer(ctx, containerID, name+"_old"); err != nil {
+		return asideContainer{}, err
+	}
+	return asideContainer{ID: containerID, Name: name}, nil
+}
+
+// restoreAside undoes a recreate whose new containers failed to start: the
+// old containers get their names back and are started again
+func (e *Executor) restoreAside(ctx context.Context, serviceName string, aside []asideContainer) {
+	var restored []string
+	for _, old := range aside {
+		if err := e.containerManager.RenameContainer(ctx, old.ID, old.Name); err != nil {
+			e.logger.Warnf("Failed to restore container %s for %s: %v", old.Name, serviceName, err)
+			continue
+		}
+		if err := e.containerManager.StartContainer(ctx, old.ID); err != nil {
+			e.logger.Warnf("Failed to restart container %s for %s: %v", old.Name, serviceName, err)
+			continue
+		}
+		restored = append(restored, old.ID)
+	}
+	if len(restored) == 0 {
+		return
+	}
+	e.mu.Lock()
+	e.runningServices[serviceName] = restored
+	e.mu.Unlock()
+	e.logger.Infof("Restored the previous containers of service %s", serviceName)
+}
+
+// recreateService starts a service and, when it replaces containers moved
+// aside, as many replicas as there were old containers. The old containers
+// are removed once all replacements run; if one fails the replacements are
+// removed instead and the old containers restored.
+func (e *Executor) recreateService(ctx context.Context, serviceName string, service *compose.Service, waitDeps bool, aside []asideContainer) error {
+	if err := e.startService(ctx, serviceName, service, waitDeps); err != nil {
+		e.restoreAside(context.Background(), serviceName, aside)
+		return err
+	}
+	for number := 2; number <= len(aside); number++ {
+		containerID, err := e.startReplica(ctx, serviceName, service, number)
+		if err != nil {
+			e.removeReplacements(context.Background(), serviceName)
+			e.restoreAside(context.Background(), serviceName, aside)
+			return err
+		}
+		e.mu.Lock()
+		e.runningServices[serviceName] = append(e.runningServices[serviceName], containerID)
+		e.mu.Unlock()
+	}
+	e.removeAside(ctx, serviceName, aside)
 	return nil
 }
 
-func (e *Executor) rollback(ctx context.Context, compose *compose.ComposeFile) {
-	e.mu.RLock()
-	services := make(map[string]string)
-	for k, v := range e.runningServices {
-		services[k] = v
+// removeReplacements stops and removes the containers started to replace
+// a service's old ones, freeing their names to restore the old ones under
+func (e *Executor) removeReplacements(ctx context.Context, serviceName string) {
+	e.mu.Lock()
+	containerIDs := e.runningServices[serviceName]
+	delete(e.runningServices, serviceName)
+	e.mu.Unlock()
+
+	for _, containerID := range containerIDs {
+		if err := e.containerManager.StopContainer(ctx, containerID, 10); err != nil {
+			e.logger.Warnf("Failed to stop container for %s: %v", serviceName, err)
+		}
+		if err := e.containerManager.RemoveContainer(ctx, containerID, false); err != nil {
+			e.logger.Warnf("Failed to remove container for %s: %v", serviceName, err)
+		}
 	}
-	e.mu.RUnlock()
+}
+
+// removeAside removes the old containers of a recreated service
+func (e *Executor) removeAside(ctx context.Context, serviceName string, aside []asideContainer) {
+	for _, old := range aside {
+		if err := e.containerManager.RemoveContainer(ctx, old.ID, false); err != nil {
+			e.logger.Warnf("Failed to remove old container %s for %s: %v", old.Name, serviceName, err)
+		}
+	}
+}
+
+// rollback stops and removes the started services in reverse dependency
+// order, so dependents go before what they depend on, running their
+// pre-stop and post-stop hooks. Services that were already running before
+// the failed up are left alone.
+func (e *Executor) rollback(ctx context.Context, compose *compose.ComposeFile, started map[string]bool) {
+	ordered := orderServices(compose.Services)
 
-	for serviceName, containerID := range services {
+	for i := len(ordered) - 1; i >= 0; i-- {
+		serviceName := ordered[i]
+		if !started[serviceName] {
+			continue
+		}
 		service := compose.Services[serviceName]
 		e.logger.Infof("Rolling back service %s", serviceName)
-		
-		if err := e.containerManager.StopContainer(ctx, containerID, 10); err != nil {
-			e.logger.Warnf("Failed to stop container during rollback: %v", err)
-		}
-		
-		if err := e.containerManager.RemoveContainer(ctx, containerID); err != nil {
-			e.logger.Warnf("Failed to remove container during rollback: %v", err)
+
+		if err := e.lifecycleManager.StopService(ctx, serviceName, service); err != nil {
+			e.logger.Warnf("Lifecycle stop failed for %s during rollback: %v", serviceName, err)
 		}
-		
-		if service != nil {
-			e.lifecycleManager.StopService(ctx, serviceName, service)
+
+		e.mu.RLock()
+		containerIDs := e.runningServices[serviceName]
+		e.mu.RUnlock()
+
+		for _, containerID := range containerIDs {
+			if err := e.containerManager.StopContainer(ctx, containerID, 10); err != nil {
+				e.logger.Warnf("Failed to stop container during rollback: %v", err)
+			}
+
+			if err := e.containerManager.RemoveContainer(ctx, containerID, false); err != nil {
+				e.logger.Warnf("Failed to remove container during rollback: %v", err)
+			}
 		}
+
+		e.mu.Lock()
+		delete(e.runningServices, serviceName)
+		e.mu.Unlock()
 	}
 }
 
-func (e *Executor) orderServices(services map[string]*compose.Service) []string {
+// orderServices returns the services in start order, each after the
+// services it depends on. Services are visited by name so the order is
+// stable; a dependency cycle is broken at the edge that closes it.
+func orderServices(services map[string]*compose.Service) []string {
 	visited := make(map[string]bool)
 	result := make([]string, 0, len(services))
 	
@@ -190,7 +1668,7 @@ func (e *Executor) orderServices(services map[string]*compose.Service) []string
 		visited[name] = true
 		
 		if service, exists := services[name]; exists {
-			for dep := range service.DependsOn {
+			for _, dep := range sortedDeps(service) {
 				visit(dep)
 			}
 		}
@@ -198,13 +1676,28 @@ func (e *Executor) orderServices(services map[string]*compose.Service) []string
 		result = append(result, name)
 	}
 	
+	names := make([]string, 0, len(services))
 	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
 		visit(name)
 	}
 	
 	return result
 }
 
+// sortedDeps returns the names of a service's depends_on entries in order
+func sortedDeps(service *compose.Service) []string {
+	deps := make([]string, 0, len(service.DependsOn))
+	for dep := range service.DependsOn {
+		deps = append(deps, dep)
+	}
+	sort.Strings(deps)
+	return deps
+}
+
 func (e *Executor) Close() error {
 	return e.containerManager.Close()
 }
\ No newline at end of file
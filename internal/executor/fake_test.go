@@ -0,0 +1,292 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+	"github.com/neomody77/fake-compose/pkg/template"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeManager is a stub container manager recording renames, health checks
+// and post container runs, which can be told to fail creating a given
+// replica or to report a service unhealthy for a number of checks
+type fakeManager struct {
+	*container.StubManager
+
+	mu      sync.Mutex
+	renames []string
+	// failNumber makes CreateService fail for that replica number
+	failNumber int
+	// failService makes CreateService fail for every replica of that
+	// service
+	failService string
+	// unhealthyChecks is how many health checks of each service's
+	// containers report unhealthy before they turn healthy
+	unhealthyChecks map[string]int
+	healthChecks    []string
+	postRuns        []postRun
+	starts          []postRun
+	stops           []string
+	// initErr is returned by every init container run
+	initErr error
+	// listErr makes listing containers by label fail, as when the daemon
+	// is unreachable
+	listErr error
+	oneOffs []*compose.Service
+	pushes  map[string]container.PushOptions
+	// pulling and maxPulling track concurrent image pulls
+	pulling    int
+	maxPulling int
+	// created holds the configuration each service's containers were
+	// last created with
+	created map[string]*compose.Service
+	// imageIDs overrides the image ID an image tag resolves to, as when a
+	// pull moved the tag
+	imageIDs map[string]string
+}
+
+// postRun records a post container run, or a container start, and when it
+// started and ended
+type postRun struct {
+	Service string
+	Name    string
+	At      time.Time
+	Done    time.Time
+}
+
+func newFakeManager() *fakeManager {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &fakeManager{
+		StubManager:     container.NewStubManager(logger, "demo"),
+		unhealthyChecks: make(map[string]int),
+		created:         make(map[string]*compose.Service),
+		pushes:          make(map[string]container.PushOptions),
+		imageIDs:        make(map[string]string),
+	}
+}
+
+func newTestExecutor(t *testing.T, impl container.ContainerImplementation) *Executor {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	e, err := newWithManager(logger, "demo", container.NewManagerWithImplementation(logger, impl))
+	if err != nil {
+		t.Fatalf("newWithManager: %v", err)
+	}
+	return e
+}
+
+func webProject() *compose.ComposeFile {
+	return &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web": {Image: "nginx"},
+		},
+	}
+}
+
+func (f *fakeManager) CreateService(ctx context.Context, serviceName string, service *compose.Service, number int) (string, error) {
+	f.mu.Lock()
+	fail := f.failNumber == number || f.failService == serviceName
+	f.created[serviceName] = service
+	f.mu.Unlock()
+	if fail {
+		return "", fmt.Errorf("create %s_%d failed", serviceName, number)
+	}
+	return f.StubManager.CreateService(ctx, serviceName, service, number)
+}
+
+func (f *fakeManager) ImageID(ctx context.Context, image string) (string, error) {
+	f.mu.Lock()
+	id, moved := f.imageIDs[image]
+	f.mu.Unlock()
+	if moved {
+		return id, nil
+	}
+	return f.StubManager.ImageID(ctx, image)
+}
+
+func (f *fakeManager) setImageID(image, id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.imageIDs[image] = id
+}
+
+func (f *fakeManager) ListServiceContainers(ctx context.Context, args filters.Args) ([]container.ContainerInfo, error) {
+	f.mu.Lock()
+	err := f.listErr
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return f.StubManager.ListServiceContainers(ctx, args)
+}
+
+func (f *fakeManager) RenameContainer(ctx context.Context, containerID, newName string) error {
+	f.mu.Lock()
+	f.renames = append(f.renames, newName)
+	f.mu.Unlock()
+	return f.StubManager.RenameContainer(ctx, containerID, newName)
+}
+
+// IsHealthy reports a container unhealthy while its service has unhealthy
+// checks left. Stub container IDs start with their service's name.
+func (f *fakeManager) IsHealthy(ctx context.Context, containerID string) (bool, error) {
+	service, _, _ := strings.Cut(containerID, "_")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.healthChecks = append(f.healthChecks, service)
+	if f.unhealthyChecks[service] > 0 {
+		f.unhealthyChecks[service]--
+		return false, nil
+	}
+	return true, nil
+}
+
+// StartContainer records when each container start began and ended, with
+// the container ID as the run's name
+func (f *fakeManager) StartContainer(ctx context.Context, containerID string) error {
+	service, _, _ := strings.Cut(containerID, "_")
+	start := postRun{Service: service, Name: containerID, At: time.Now()}
+	err := f.StubManager.StartContainer(ctx, containerID)
+	start.Done = time.Now()
+
+	f.mu.Lock()
+	f.starts = append(f.starts, start)
+	f.mu.Unlock()
+	return err
+}
+
+func (f *fakeManager) StopContainer(ctx context.Context, containerID string, timeout int) error {
+	service, _, _ := strings.Cut(containerID, "_")
+	f.mu.Lock()
+	f.stops = append(f.stops, service)
+	f.mu.Unlock()
+	return f.StubManager.StopContainer(ctx, containerID, timeout)
+}
+
+// FollowLogs is a fake log source writing one line naming the container
+func (f *fakeManager) FollowLogs(ctx context.Context, containerID, serviceName string, fn func(container.LogLine)) error {
+	fn(container.LogLine{Service: serviceName, Timestamp: time.Now(), Text: "hello from " + containerID})
+	return nil
+}
+
+func (f *fakeManager) PullImage(ctx context.Context, ref string, opts container.PullOptions) (bool, error) {
+	f.mu.Lock()
+	f.pulling++
+	f.maxPulling = max(f.maxPulling, f.pulling)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.pulling--
+		f.mu.Unlock()
+	}()
+	return f.StubManager.PullImage(ctx, ref, opts)
+}
+
+func (f *fakeManager) PushImage(ctx context.Context, ref string, opts container.PushOptions) error {
+	f.mu.Lock()
+	f.pushes[ref] = opts
+	f.mu.Unlock()
+	return f.StubManager.PushImage(ctx, ref, opts)
+}
+
+func (f *fakeManager) RunInitContainer(ctx context.Context, serviceName string, initContainer *compose.InitContainer) error {
+	f.mu.Lock()
+	err := f.initErr
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return f.StubManager.RunInitContainer(ctx, serviceName, initContainer)
+}
+
+func (f *fakeManager) RunPostContainer(ctx context.Context, serviceName string, postContainer *compose.PostContainer, data template.Data) error {
+	run := postRun{Service: serviceName, Name: postContainer.Name, At: time.Now()}
+	err := f.StubManager.RunPostContainer(ctx, serviceName, postContainer, data)
+	run.Done = time.Now()
+
+	f.mu.Lock()
+	f.postRuns = append(f.postRuns, run)
+	f.mu.Unlock()
+	return err
+}
+
+func (f *fakeManager) RunOneOff(ctx context.Context, serviceName string, service *compose.Service, opts container.OneOffOptions) (int, error) {
+	f.mu.Lock()
+	f.oneOffs = append(f.oneOffs, service)
+	f.mu.Unlock()
+	return f.StubManager.RunOneOff(ctx, serviceName, service, opts)
+}
+
+func (f *fakeManager) setFailNumber(number int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failNumber = number
+}
+
+func (f *fakeManager) setUnhealthyChecks(service string, checks int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unhealthyChecks[service] = checks
+}
+
+func (f *fakeManager) takeRenames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	renames := f.renames
+	f.renames = nil
+	sort.Strings(renames)
+	return renames
+}
+
+// checksOf returns how many health checks were made of a service
+func (f *fakeManager) checksOf(service string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, checked := range f.healthChecks {
+		if checked == service {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeManager) posts() []postRun {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]postRun(nil), f.postRuns...)
+}
+
+func (f *fakeManager) started() []postRun {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]postRun(nil), f.starts...)
+}
+
+// takeLifecycle returns and forgets the services of the containers stopped
+// and started so far, in order
+func (f *fakeManager) takeLifecycle() (stopped, started []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stopped = f.stops
+	for _, start := range f.starts {
+		started = append(started, start.Service)
+	}
+	f.stops, f.starts = nil, nil
+	return stopped, started
+}
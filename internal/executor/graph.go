@@ -0,0 +1,168 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// Kinds of edge in a dependency graph
+const (
+	EdgeDependsOn   = "depends_on"
+	EdgeNetworkMode = "network_mode"
+	EdgeVolumesFrom = "volumes_from"
+	EdgeWaitFor     = "wait_for"
+)
+
+// Graph is the service dependency graph that start order is derived from
+type Graph struct {
+	// Services lists every service, in start order
+	Services []string    `json:"services"`
+	Edges    []GraphEdge `json:"edges"`
+}
+
+// GraphEdge points from a service to a service it needs
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	// Condition is the depends_on condition, such as service_healthy
+	Condition string `json:"condition,omitempty"`
+	// Cycle marks edges that are part of a dependency cycle. Startup breaks
+	// such a cycle instead of waiting on it forever.
+	Cycle bool `json:"cycle,omitempty"`
+}
+
+// DependencyGraph builds the dependency graph of a compose file. Besides
+// depends_on it has edges for network_mode: service:<name> and
+// volumes_from, which the parser turns into dependencies, and for post
+// containers waiting for another service to become healthy.
+func DependencyGraph(composeFile *compose.ComposeFile) *Graph {
+	graph := &Graph{Services: orderServices(composeFile.Services)}
+
+	for _, name := range graph.Services {
+		service, exists := composeFile.Services[name]
+		if !exists {
+			continue
+		}
+		networkTarget, _ := service.NetworkModeService()
+		volumesFrom := service.VolumesFromServices()
+		for _, dep := range sortedDeps(service) {
+			edge := GraphEdge{From: name, To: dep, Kind: EdgeDependsOn, Condition: service.DependsOn[dep].Condition}
+			switch {
+			case dep == networkTarget:
+				edge.Kind = EdgeNetworkMode
+				edge.Condition = ""
+			case slices.Contains(volumesFrom, dep):
+				edge.Kind = EdgeVolumesFrom
+				edge.Condition = ""
+			}
+			graph.Edges = append(graph.Edges, edge)
+		}
+
+		waited := make(map[string]bool)
+		for _, post := range service.PostContainers {
+			_, target, err := post.WaitCondition()
+			if err != nil || target == "" || waited[target] {
+				continue
+			}
+			waited[target] = true
+			graph.Edges = append(graph.Edges, GraphEdge{From: name, To: target, Kind: EdgeWaitFor, Condition: "service_healthy"})
+		}
+	}
+
+	// An ordering edge is on a cycle when its target leads back to its
+	// source
+	needs := make(map[string][]string)
+	for _, edge := range graph.Edges {
+		if edge.Kind != EdgeWaitFor {
+			needs[edge.From] = append(needs[edge.From], edge.To)
+		}
+	}
+	for i, edge := range graph.Edges {
+		if edge.Kind != EdgeWaitFor {
+			graph.Edges[i].Cycle = reaches(needs, edge.To, edge.From)
+		}
+	}
+
+	return graph
+}
+
+// reaches reports whether to can be reached from from
+func reaches(needs map[string][]string, from, to string) bool {
+	seen := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if name == to {
+			return true
+		}
+		for _, next := range needs[name] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// HasCycle reports whether any edge of the graph is part of a cycle
+func (g *Graph) HasCycle() bool {
+	for _, edge := range g.Edges {
+		if edge.Cycle {
+			return true
+		}
+	}
+	return false
+}
+
+// DOT renders the graph in Graphviz DOT format. network_mode and
+// volumes_from edges are dashed, wait_for edges dotted, and edges on a cycle drawn in red.
+func (g *Graph) DOT(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotID(name))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	services := append([]string(nil), g.Services...)
+	sort.Strings(services)
+	for _, service := range services {
+		fmt.Fprintf(&b, "  %s;\n", dotID(service))
+	}
+
+	for _, edge := range g.Edges {
+		var attrs []string
+		switch edge.Kind {
+		case EdgeNetworkMode, EdgeVolumesFrom:
+			attrs = append(attrs, fmt.Sprintf("label=%s", dotID(edge.Kind)), "style=dashed")
+		case EdgeWaitFor:
+			attrs = append(attrs, `label="wait_for"`, "style=dotted")
+		default:
+			if edge.Condition != "" && edge.Condition != "service_started" {
+				attrs = append(attrs, fmt.Sprintf("label=%s", dotID(edge.Condition)))
+			}
+		}
+		if edge.Cycle {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+
+		fmt.Fprintf(&b, "  %s -> %s", dotID(edge.From), dotID(edge.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotID quotes a name for use as a DOT identifier
+func dotID(name string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"`
+}
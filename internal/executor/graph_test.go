@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares got with testdata/<name>.golden, rewriting the
+// file instead when run with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// graphProject has an edge of every kind, with the dependencies the
+// parser adds for network_mode and volumes_from filled in
+func graphProject() *compose.ComposeFile {
+	return &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web": {
+				Image:          "nginx",
+				DependsOn:      map[string]compose.DependsOn{"api": {Condition: "service_healthy"}},
+				PostContainers: []compose.PostContainer{{Name: "warmup", Image: "curl", WaitFor: "db:healthy"}},
+			},
+			"api": {Image: "api", DependsOn: map[string]compose.DependsOn{"db": {Condition: "service_started"}}},
+			"db":  {Image: "postgres"},
+			"proxy": {
+				Image:       "envoy",
+				NetworkMode: "service:api",
+				DependsOn:   map[string]compose.DependsOn{"api": {}},
+			},
+			"backup": {
+				Image:       "restic",
+				VolumesFrom: []string{"db:ro"},
+				DependsOn:   map[string]compose.DependsOn{"db": {}},
+			},
+		},
+	}
+}
+
+func TestDependencyGraphDOT(t *testing.T) {
+	graph := DependencyGraph(graphProject())
+	if graph.HasCycle() {
+		t.Error("acyclic stack reported a cycle")
+	}
+	assertGolden(t, "graph", []byte(graph.DOT("demo")))
+}
+
+func TestDependencyGraphCycle(t *testing.T) {
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"a": {Image: "a", DependsOn: map[string]compose.DependsOn{"b": {}}},
+			"b": {Image: "b", DependsOn: map[string]compose.DependsOn{"a": {}}},
+			"c": {Image: "c", DependsOn: map[string]compose.DependsOn{"a": {}}},
+		},
+	}
+	graph := DependencyGraph(project)
+	if !graph.HasCycle() {
+		t.Fatal("cycle not detected")
+	}
+	for _, edge := range graph.Edges {
+		if want := edge.From != "c"; edge.Cycle != want {
+			t.Errorf("edge %s -> %s cycle = %v, want %v", edge.From, edge.To, edge.Cycle, want)
+		}
+	}
+	assertGolden(t, "graph_cycle", []byte(graph.DOT("demo")))
+}
@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/container"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestUpRecreatesOnImageChange(t *testing.T) {
+	tests := []struct {
+		name      string
+		moved     bool
+		opts      UpOptions
+		recreated bool
+	}{
+		{"unchanged", false, UpOptions{}, false},
+		{"moved tag", true, UpOptions{}, true},
+		{"moved tag with no-recreate", true, UpOptions{NoRecreate: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeManager()
+			e := newTestExecutor(t, fake)
+			hook := test.NewLocal(e.logger)
+			project := webProject()
+			ctx := context.Background()
+			if err := e.UpWithOptions(ctx, project, UpOptions{Quiet: true}); err != nil {
+				t.Fatalf("up: %v", err)
+			}
+			before := slices.Clone(e.runningServices["web"])
+			replicas, err := e.serviceReplicas(ctx, "web", false)
+			if err != nil {
+				t.Fatalf("serviceReplicas: %v", err)
+			}
+			for _, c := range replicas {
+				if want, _ := fake.ImageID(ctx, "nginx"); c.Labels[container.LabelImage] != want {
+					t.Errorf("%s image label = %q, want %q", c.Name, c.Labels[container.LabelImage], want)
+				}
+			}
+
+			// Only the image the tag points to changes, not the config hash
+			if tt.moved {
+				fake.setImageID("nginx", "sha256:moved")
+			}
+			tt.opts.Quiet = true
+			if err := e.UpWithOptions(ctx, project, tt.opts); err != nil {
+				t.Fatalf("second up: %v", err)
+			}
+
+			after := e.runningServices["web"]
+			if recreated := !slices.Equal(before, after); recreated != tt.recreated {
+				t.Errorf("recreated = %v (containers %v -> %v), want %v", recreated, before, after, tt.recreated)
+			}
+			reasonLogged := false
+			for _, entry := range hook.AllEntries() {
+				reasonLogged = reasonLogged || entry.Message == "Recreating service web: image nginx changed"
+			}
+			if reasonLogged != tt.recreated {
+				t.Errorf("image change reason logged = %v, want %v", reasonLogged, tt.recreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
+)
+
+func TestInitContainerFailureReportsExitCodeAndLogTail(t *testing.T) {
+	fake := newFakeManager()
+	var logs strings.Builder
+	for i := 1; i <= 15; i++ {
+		fmt.Fprintf(&logs, "migration step %d\n", i)
+	}
+	fake.initErr = &cerrors.ContainerRunError{Name: "migrate", ExitCode: 2, Logs: logs.String()}
+	e := newTestExecutor(t, fake)
+	project := webProject()
+	project.Services["web"].InitContainers = []compose.InitContainer{{Name: "migrate", Image: "migrate"}}
+
+	err := e.UpWithOptions(context.Background(), project, UpOptions{Quiet: true})
+
+	var initErr *cerrors.InitContainerError
+	if !errors.As(err, &initErr) {
+		t.Fatalf("up error = %v, want an InitContainerError", err)
+	}
+	if initErr.Service != "web" || initErr.Container != "migrate" || initErr.ExitCode != 2 || initErr.Logs != logs.String() {
+		t.Errorf("init error = %+v", initErr)
+	}
+	var runErr *cerrors.ContainerRunError
+	if !errors.As(err, &runErr) || runErr.ExitCode != 2 {
+		t.Errorf("the ContainerRunError is not in the chain of %v", err)
+	}
+
+	msg := err.Error()
+	if !strings.Contains(msg, "init container migrate for service web exited with code 2") {
+		t.Errorf("message %q lacks the exit code", msg)
+	}
+	// Only the last ten lines are shown
+	if !strings.Contains(msg, "migration step 6\n") || !strings.HasSuffix(msg, "migration step 15") {
+		t.Errorf("message %q lacks the log tail", msg)
+	}
+	if strings.Contains(msg, "migration step 5\n") {
+		t.Errorf("message %q has more than the log tail", msg)
+	}
+}
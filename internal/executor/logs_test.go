@@ -0,0 +1,43 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+)
+
+func TestFollowLogsStreamsOnlyAttachedServices(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web":    {Image: "nginx"},
+			"db":     {Image: "postgres"},
+			"worker": {Image: "busybox"},
+		},
+	}
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	var mu sync.Mutex
+	lines := make(map[string]int)
+	err := e.FollowLogs(context.Background(), []string{"web", "worker"}, func(line container.LogLine) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines[line.Service]++
+	})
+	if err != nil {
+		t.Fatalf("follow logs: %v", err)
+	}
+
+	if lines["web"] != 1 || lines["worker"] != 1 {
+		t.Errorf("got lines %v, want one each from web and worker", lines)
+	}
+	if lines["db"] != 0 {
+		t.Errorf("streamed %d lines from the detached db", lines["db"])
+	}
+}
@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestNetworkModeResolution(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"db":      {Image: "postgres"},
+			"web":     {Image: "nginx", NetworkMode: "service:db", DependsOn: map[string]compose.DependsOn{"db": {Condition: "service_started"}}},
+			"metrics": {Image: "exporter", NetworkMode: "host"},
+			"batch":   {Image: "busybox", NetworkMode: "none"},
+		},
+	}
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	want := map[string]string{
+		"web":     "container:" + e.runningServices["db"][0],
+		"metrics": "host",
+		"batch":   "none",
+		"db":      "",
+	}
+	for name, mode := range want {
+		if got := fake.created[name].NetworkMode; got != mode {
+			t.Errorf("%s created with network_mode %q, want %q", name, got, mode)
+		}
+	}
+	if project.Services["web"].NetworkMode != "service:db" {
+		t.Errorf("the compose file's network_mode changed to %q", project.Services["web"].NetworkMode)
+	}
+}
+
+func TestNetworkModeServiceNotRunning(t *testing.T) {
+	e := newTestExecutor(t, newFakeManager())
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"db":  {Image: "postgres"},
+			"web": {Image: "nginx", NetworkMode: "service:db"},
+		},
+	}
+
+	err := e.UpWithOptions(context.Background(), project, UpOptions{Services: []string{"web"}, NoDeps: true, Quiet: true})
+	if err == nil || !strings.Contains(err.Error(), "service db has no running container") {
+		t.Errorf("up = %v, want an error about db not running", err)
+	}
+}
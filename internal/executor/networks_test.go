@@ -0,0 +1,40 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestServiceNetworkSettingsKeepAliases(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"api": {
+				Image: "api",
+				Networks: compose.ServiceNetworks{
+					"backend": {Aliases: []string{"api.internal"}, IPv4Address: "172.28.0.10"},
+					"shared":  nil,
+				},
+			},
+		},
+		Networks: map[string]*compose.Network{
+			"backend": {},
+			"shared":  {External: true},
+		},
+	}
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	networks := fake.created["api"].Networks
+	backend, ok := networks["demo_backend"]
+	if !ok || backend == nil || len(backend.Aliases) != 1 || backend.Aliases[0] != "api.internal" || backend.IPv4Address != "172.28.0.10" {
+		t.Errorf("demo_backend settings = %+v, want the alias and static address", backend)
+	}
+	if settings, ok := networks["shared"]; !ok || settings != nil {
+		t.Errorf("networks = %v, want the external shared network under its own name", networks)
+	}
+}
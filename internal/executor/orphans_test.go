@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// upWithOrphan brings web up next to a worker container the compose file no
+// longer defines
+func upWithOrphan(t *testing.T) (*Executor, *compose.ComposeFile) {
+	t.Helper()
+	ctx := context.Background()
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	id, err := fake.CreateService(ctx, "worker", &compose.Service{Image: "busybox"}, 1)
+	if err != nil {
+		t.Fatalf("create worker: %v", err)
+	}
+	if err := fake.StartContainer(ctx, id); err != nil {
+		t.Fatalf("start worker: %v", err)
+	}
+
+	project := webProject()
+	if err := e.UpWithOptions(ctx, project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	return e, project
+}
+
+func TestUpWarnsAboutOrphans(t *testing.T) {
+	e, project := upWithOrphan(t)
+	hook := test.NewLocal(e.logger)
+
+	if err := e.CleanupOrphans(context.Background(), project, false); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+
+	warned := false
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel && strings.Contains(entry.Message, "demo_worker_1") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Error("no warning names the orphan demo_worker_1")
+	}
+	orphans, err := e.Orphans(context.Background(), project)
+	if err != nil {
+		t.Fatalf("orphans: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].Service != "worker" {
+		t.Errorf("orphans = %v, want the worker container kept", orphans)
+	}
+}
+
+func TestUpRemovesOrphans(t *testing.T) {
+	e, project := upWithOrphan(t)
+
+	if err := e.CleanupOrphans(context.Background(), project, true); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+
+	orphans, err := e.Orphans(context.Background(), project)
+	if err != nil {
+		t.Fatalf("orphans: %v", err)
+	}
+	if len(orphans) != 0 {
+		t.Errorf("orphans = %v, want none", orphans)
+	}
+	if ids := replicaIDs(t, e); len(ids) != 1 {
+		t.Errorf("web has %d containers, want 1", len(ids))
+	}
+}
@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// postProject has web, depending on db, run a post container waiting for
+// waitFor
+func postProject(waitFor string) *compose.ComposeFile {
+	return &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"db": {Image: "postgres"},
+			"web": {
+				Image:     "nginx",
+				DependsOn: map[string]compose.DependsOn{"db": {Condition: "service_started"}},
+				PostContainers: []compose.PostContainer{
+					{Name: "warmup", Image: "curlimages/curl", WaitFor: waitFor, OnSuccess: true},
+				},
+			},
+		},
+	}
+}
+
+func TestPostContainerWaitsForDuration(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+
+	if err := e.UpWithOptions(context.Background(), postProject("300ms"), UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	posts := fake.posts()
+	if len(posts) != 1 || posts[0].Name != "warmup" {
+		t.Fatalf("post containers run = %+v, want warmup", posts)
+	}
+	if took := posts[0].Done.Sub(posts[0].At); took < 300*time.Millisecond {
+		t.Errorf("post container ran after %s, want a 300ms wait", took)
+	}
+	if n := fake.checksOf("db"); n != 0 {
+		t.Errorf("a duration wait checked db's health %d times", n)
+	}
+}
+
+func TestPostContainerWaitsForServiceHealth(t *testing.T) {
+	fake := newFakeManager()
+	fake.setUnhealthyChecks("db", 1)
+	e := newTestExecutor(t, fake)
+
+	if err := e.UpWithOptions(context.Background(), postProject("db:healthy"), UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	posts := fake.posts()
+	if len(posts) != 1 || posts[0].Name != "warmup" {
+		t.Fatalf("post containers run = %+v, want warmup", posts)
+	}
+	// The first check reports unhealthy, so the post container waits for
+	// a second one
+	if n := fake.checksOf("db"); n != 2 {
+		t.Errorf("db's health was checked %d times, want 2", n)
+	}
+}
+
+func TestPostContainerSkippedWhenServiceNotRunning(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := postProject("cache:healthy")
+
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if posts := fake.posts(); len(posts) != 0 {
+		t.Errorf("post containers run = %+v, want none while cache is not running", posts)
+	}
+}
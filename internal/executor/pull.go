@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+)
+
+// PullOptions controls which images PullImages fetches and how
+type PullOptions struct {
+	// Services limits pulling to the images of the named services. Empty
+	// means all services.
+	Services []string
+	Policy   container.PullPolicy
+	// Parallel bounds how many images are pulled at once; zero or a
+	// negative value means no limit
+	Parallel int
+	// Progress receives the pull progress of every image; nil discards it
+	Progress io.Writer
+	// Quiet limits the progress to a summary line per image
+	Quiet bool
+}
+
+// PullResult reports what PullImages did for one image
+type PullResult struct {
+	Image string
+	// Pulled is false when the missing policy skipped a present image
+	Pulled bool
+}
+
+// PullImages pulls the images of the selected services, including those of
+// their init and post containers. Each image is pulled once even when
+// several services share it. Results are sorted by image.
+func (e *Executor) PullImages(ctx context.Context, composeFile *compose.ComposeFile, opts PullOptions) ([]PullResult, error) {
+	images := serviceImages(composeFile, opts.Services)
+
+	progress := opts.Progress
+	if progress != nil {
+		progress = &lockedWriter{w: progress}
+	}
+
+	results := make([]PullResult, len(images))
+	g, gctx := errgroup.WithContext(ctx)
+	if opts.Parallel > 0 {
+		g.SetLimit(opts.Parallel)
+	}
+	for i, image := range images {
+		g.Go(func() error {
+			pulled, err := e.containerManager.PullImage(gctx, image, container.PullOptions{Policy: opts.Policy, Progress: progress, Quiet: opts.Quiet})
+			if err != nil {
+				return err
+			}
+			results[i] = PullResult{Image: image, Pulled: pulled}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// serviceImages returns the unique, sorted image references of the named
+// services, or of all services when none are named. Services without an
+// image, which are built locally, contribute only their init and post
+// container images.
+func serviceImages(composeFile *compose.ComposeFile, services []string) []string {
+	seen := make(map[string]bool)
+	add := func(image string) {
+		if image != "" {
+			seen[image] = true
+		}
+	}
+
+	for name, service := range composeFile.Services {
+		if len(services) > 0 && !slices.Contains(services, name) {
+			continue
+		}
+		add(service.Image)
+		for _, init := range service.InitContainers {
+			add(init.Image)
+		}
+		for _, post := range service.PostContainers {
+			add(post.Image)
+		}
+	}
+
+	images := make([]string, 0, len(seen))
+	for image := range seen {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// lockedWriter serializes writes so the progress lines of concurrent pulls
+// do not interleave
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+)
+
+// imagesProject has n services, each with its own image
+func imagesProject(n int) *compose.ComposeFile {
+	project := &compose.ComposeFile{Services: make(map[string]*compose.Service)}
+	for i := 0; i < n; i++ {
+		project.Services[fmt.Sprintf("svc%d", i)] = &compose.Service{Image: fmt.Sprintf("image%d", i)}
+	}
+	return project
+}
+
+func TestPullImagesBoundsConcurrency(t *testing.T) {
+	for _, parallel := range []int{1, 2} {
+		fake := newFakeManager()
+		e := newTestExecutor(t, fake)
+
+		results, err := e.PullImages(context.Background(), imagesProject(6), PullOptions{Policy: container.PullAlways, Parallel: parallel})
+		if err != nil {
+			t.Fatalf("pull --parallel %d: %v", parallel, err)
+		}
+		if len(results) != 6 {
+			t.Errorf("pull --parallel %d pulled %d images, want 6", parallel, len(results))
+		}
+		if fake.maxPulling != parallel {
+			t.Errorf("pull --parallel %d ran %d pulls at once", parallel, fake.maxPulling)
+		}
+	}
+}
+
+func TestPullImagesMissingSkipsPresent(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := imagesProject(3)
+	if _, err := fake.PullImage(context.Background(), "image1", container.PullOptions{Policy: container.PullAlways}); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := e.PullImages(context.Background(), project, PullOptions{Policy: container.PullMissing})
+	if err != nil {
+		t.Fatalf("pull --policy missing: %v", err)
+	}
+	want := []PullResult{{"image0", true}, {"image1", false}, {"image2", true}}
+	if fmt.Sprint(results) != fmt.Sprint(want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+
+	results, err = e.PullImages(context.Background(), project, PullOptions{Policy: container.PullAlways})
+	if err != nil {
+		t.Fatalf("pull --policy always: %v", err)
+	}
+	for _, r := range results {
+		if !r.Pulled {
+			t.Errorf("always policy skipped %s", r.Image)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+)
+
+// PushOptions controls which service images PushImages uploads and how
+type PushOptions struct {
+	// Services limits pushing to the named services. Empty means all
+	// services.
+	Services []string
+	// IncludeDeps also pushes the images of the named services'
+	// dependencies
+	IncludeDeps bool
+	// Upstream also pushes the images of services without a build
+	// section, e.g. upstream images retagged for a private registry
+	Upstream bool
+	// IgnoreFailures records failed pushes in the results instead of
+	// stopping at the first one
+	IgnoreFailures bool
+	// Parallel bounds how many images are pushed at once; zero or a
+	// negative value means no limit
+	Parallel int
+	// Progress receives the push progress of every image; nil discards it
+	Progress io.Writer
+}
+
+// PushResult reports what PushImages did for one service
+type PushResult struct {
+	Service string
+	Image   string
+	// Skipped is set for services that use an upstream image
+	Skipped bool
+	// Err is the push failure when failures are ignored
+	Err error
+}
+
+// PushImages pushes the images the selected services are built into,
+// with the credentials of each image's registry. Results are sorted by
+// service.
+func (e *Executor) PushImages(ctx context.Context, composeFile *compose.ComposeFile, opts PushOptions) ([]PushResult, error) {
+	selected, err := e.selectServices(composeFile.Services, opts.Services, !opts.IncludeDeps)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(selected))
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	progress := opts.Progress
+	if progress != nil {
+		progress = &lockedWriter{w: progress}
+	}
+
+	results := make([]PushResult, len(names))
+	g, gctx := errgroup.WithContext(ctx)
+	if opts.Parallel > 0 {
+		g.SetLimit(opts.Parallel)
+	}
+	for i, name := range names {
+		service := composeFile.Services[name]
+		results[i] = PushResult{Service: name, Image: e.serviceImage(name, service)}
+		if service.Build == nil && !opts.Upstream {
+			results[i].Skipped = true
+			continue
+		}
+
+		g.Go(func() error {
+			err := e.pushImage(gctx, results[i].Image, progress)
+			if err != nil && opts.IgnoreFailures {
+				e.logger.Warnf("Ignoring push failure for service %s: %v", name, err)
+				results[i].Err = err
+				return nil
+			}
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (e *Executor) pushImage(ctx context.Context, image string, progress io.Writer) error {
+	auth, err := container.LoadRegistryAuth(image)
+	if err != nil {
+		return fmt.Errorf("failed to load credentials for %s: %w", image, err)
+	}
+	return e.containerManager.PushImage(ctx, image, container.PushOptions{Auth: auth, Progress: progress})
+}
+
+// serviceImage returns the reference a service's image is tagged with: its
+// image, or <project>-<service> for services that are only built
+func (e *Executor) serviceImage(name string, service *compose.Service) string {
+	if service.Image != "" {
+		return service.Image
+	}
+	return e.projectName + "-" + name
+}
@@ -0,0 +1,95 @@
+package executor
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// pushProject has a service built into a private registry image, one only
+// built, and one using an upstream image
+func pushProject() *compose.ComposeFile {
+	return &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"app":   {Image: "registry.example.com/shop/app:1.0", Build: &compose.BuildConfig{Context: "."}},
+			"tools": {Build: &compose.BuildConfig{Context: "tools"}},
+			"redis": {Image: "redis:7"},
+		},
+	}
+}
+
+// writeDockerConfig stores credentials for registry.example.com in a
+// Docker config file that LoadRegistryAuth reads
+func writeDockerConfig(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	auth := base64.StdEncoding.EncodeToString([]byte("deployer:s3cret"))
+	config := `{"auths": {"registry.example.com": {"auth": "` + auth + `"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+}
+
+func TestPushImagesBuiltServices(t *testing.T) {
+	writeDockerConfig(t)
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+
+	results, err := e.PushImages(context.Background(), pushProject(), PushOptions{})
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+
+	want := []PushResult{
+		{Service: "app", Image: "registry.example.com/shop/app:1.0"},
+		{Service: "redis", Image: "redis:7", Skipped: true},
+		{Service: "tools", Image: "demo-tools"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("results = %+v, want %+v", results, want)
+	}
+	for i, r := range results {
+		if r != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, r, want[i])
+		}
+	}
+
+	if len(fake.pushes) != 2 {
+		t.Errorf("pushed %d images, want app and tools", len(fake.pushes))
+	}
+	app, ok := fake.pushes["registry.example.com/shop/app:1.0"]
+	if !ok {
+		t.Fatal("app was not pushed under its image reference")
+	}
+	if app.Auth.Username != "deployer" || app.Auth.Password != "s3cret" || app.Auth.ServerAddress != "registry.example.com" {
+		t.Errorf("app pushed with auth %+v, want the registry.example.com credentials", app.Auth)
+	}
+	if tools, ok := fake.pushes["demo-tools"]; !ok || tools.Auth.Username != "" {
+		t.Errorf("tools pushed = %v with auth %+v, want pushed anonymously", ok, tools.Auth)
+	}
+	if _, ok := fake.pushes["redis:7"]; ok {
+		t.Error("the upstream redis image was pushed")
+	}
+}
+
+func TestPushImagesUpstream(t *testing.T) {
+	writeDockerConfig(t)
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+
+	results, err := e.PushImages(context.Background(), pushProject(), PushOptions{Services: []string{"redis"}, Upstream: true})
+	if err != nil {
+		t.Fatalf("push --policy all redis: %v", err)
+	}
+	if len(results) != 1 || results[0].Skipped {
+		t.Errorf("results = %+v, want redis pushed", results)
+	}
+	if _, ok := fake.pushes["redis:7"]; !ok || len(fake.pushes) != 1 {
+		t.Errorf("pushed %v, want redis:7 only", fake.pushes)
+	}
+}
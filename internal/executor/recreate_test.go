@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+)
+
+// scaledWeb starts web and scales it to three replicas, returning their ids
+func scaledWeb(t *testing.T, e *Executor, fake *fakeManager, project *compose.ComposeFile) []string {
+	t.Helper()
+	ctx := context.Background()
+	if err := e.UpWithOptions(ctx, project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := e.Scale(ctx, project, map[string]int{"web": 3}); err != nil {
+		t.Fatalf("scale: %v", err)
+	}
+	fake.takeRenames()
+	return replicaIDs(t, e)
+}
+
+func replicaIDs(t *testing.T, e *Executor) []string {
+	t.Helper()
+	replicas, err := e.serviceReplicas(context.Background(), "web", false)
+	if err != nil {
+		t.Fatalf("serviceReplicas: %v", err)
+	}
+	ids := make([]string, len(replicas))
+	for i, replica := range replicas {
+		ids[i] = replica.ID
+	}
+	return ids
+}
+
+func TestRecreateReplacesEveryReplica(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := webProject()
+	old := scaledWeb(t, e, fake, project)
+	if len(old) != 3 {
+		t.Fatalf("got %d replicas before recreate, want 3", len(old))
+	}
+
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{ForceRecreate: true, Quiet: true}); err != nil {
+		t.Fatalf("recreate: %v", err)
+	}
+
+	want := []string{"demo_web_1_old", "demo_web_2_old", "demo_web_3_old"}
+	if got := fake.takeRenames(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("renames = %v, want %v", got, want)
+	}
+
+	all, err := e.serviceReplicas(context.Background(), "web", true)
+	if err != nil {
+		t.Fatalf("serviceReplicas: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("got %d containers after recreate, want 3", len(all))
+	}
+	for i, c := range all {
+		if c.State != "running" {
+			t.Errorf("replica %d is %s, want running", c.Number, c.State)
+		}
+		if want := container.ContainerName("demo", "web", i+1); c.Name != want {
+			t.Errorf("replica %d is named %s, want %s", c.Number, c.Name, want)
+		}
+		for _, id := range old {
+			if c.ID == id {
+				t.Errorf("old container %s was not removed", id)
+			}
+		}
+	}
+}
+
+func TestRecreateRestoresOldContainersOnFailure(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := webProject()
+	old := scaledWeb(t, e, fake, project)
+
+	// Replica 1 is replaced before replica 2 fails, so the restore also
+	// covers removing a replacement that did start
+	fake.setFailNumber(2)
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{ForceRecreate: true, Quiet: true}); err == nil {
+		t.Fatal("recreate succeeded, want an error")
+	}
+
+	want := []string{"demo_web_1", "demo_web_1_old", "demo_web_2", "demo_web_2_old", "demo_web_3", "demo_web_3_old"}
+	if got := fake.takeRenames(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("renames = %v, want %v", got, want)
+	}
+
+	all, err := e.serviceReplicas(context.Background(), "web", true)
+	if err != nil {
+		t.Fatalf("serviceReplicas: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("got %d containers after failed recreate, want 3", len(all))
+	}
+	for i, c := range all {
+		if c.ID != old[i] {
+			t.Errorf("replica %d is %s, want the old container %s", c.Number, c.ID, old[i])
+		}
+		if c.State != "running" {
+			t.Errorf("replica %d is %s, want running", c.Number, c.State)
+		}
+		if want := container.ContainerName("demo", "web", i+1); c.Name != want {
+			t.Errorf("replica %d is named %s, want %s", c.Number, c.Name, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// chainProject is web depending on api depending on db
+func chainProject() *compose.ComposeFile {
+	return &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web": {Image: "nginx", DependsOn: map[string]compose.DependsOn{"api": {}}},
+			"api": {Image: "api", DependsOn: map[string]compose.DependsOn{"db": {}}},
+			"db":  {Image: "postgres"},
+		},
+	}
+}
+
+func TestRestartOrder(t *testing.T) {
+	tests := []struct {
+		name           string
+		services       []string
+		withDependents bool
+		stopped        string
+		started        string
+	}{
+		{"no deps", []string{"db"}, false, "db", "db"},
+		{"dependents", []string{"db"}, true, "web,api,db", "db,api,web"},
+		{"middle of the chain", []string{"api"}, true, "web,api", "api,web"},
+		{"all", nil, false, "web,api,db", "db,api,web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeManager()
+			e := newTestExecutor(t, fake)
+			project := chainProject()
+			if err := e.UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err != nil {
+				t.Fatalf("up: %v", err)
+			}
+			fake.takeLifecycle()
+
+			if err := e.Restart(context.Background(), project, tt.services, tt.withDependents, 10); err != nil {
+				t.Fatalf("restart: %v", err)
+			}
+
+			stopped, started := fake.takeLifecycle()
+			if got := strings.Join(stopped, ","); got != tt.stopped {
+				t.Errorf("stopped %s, want %s", got, tt.stopped)
+			}
+			if got := strings.Join(started, ","); got != tt.started {
+				t.Errorf("started %s, want %s", got, tt.started)
+			}
+		})
+	}
+}
+
+// restartChain is chainProject with depends_on restart: true set on the
+// api -> db and web -> api edges as given
+func restartChain(apiOnDB, webOnAPI bool) *compose.ComposeFile {
+	project := chainProject()
+	project.Services["api"].DependsOn["db"] = compose.DependsOn{Restart: apiOnDB}
+	project.Services["web"].DependsOn["api"] = compose.DependsOn{Restart: webOnAPI}
+	return project
+}
+
+func TestRestartPropagation(t *testing.T) {
+	tests := []struct {
+		name     string
+		project  *compose.ComposeFile
+		services []string
+		stopped  string
+		started  string
+	}{
+		{"whole chain", restartChain(true, true), []string{"db"}, "web,api,db", "db,api,web"},
+		{"first link only", restartChain(true, false), []string{"db"}, "api,db", "db,api"},
+		{"broken first link", restartChain(false, true), []string{"db"}, "db", "db"},
+		{"no restart", restartChain(false, false), []string{"db"}, "db", "db"},
+		{"from the middle", restartChain(true, true), []string{"api"}, "web,api", "api,web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeManager()
+			e := newTestExecutor(t, fake)
+			if err := e.UpWithOptions(context.Background(), tt.project, UpOptions{Quiet: true}); err != nil {
+				t.Fatalf("up: %v", err)
+			}
+			fake.takeLifecycle()
+
+			if err := e.Restart(context.Background(), tt.project, tt.services, false, 10); err != nil {
+				t.Fatalf("restart: %v", err)
+			}
+
+			stopped, started := fake.takeLifecycle()
+			if got := strings.Join(stopped, ","); got != tt.stopped {
+				t.Errorf("stopped %s, want %s", got, tt.stopped)
+			}
+			if got := strings.Join(started, ","); got != tt.started {
+				t.Errorf("started %s, want %s", got, tt.started)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/neomody77/fake-compose/internal/state"
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"gopkg.in/yaml.v3"
+)
+
+// RollbackOptions controls Rollback
+type RollbackOptions struct {
+	// Steps is how many deployments to go back; 1 returns to the one
+	// before the current deployment
+	Steps int
+	// DryRun only returns the plan, changing nothing
+	DryRun bool
+}
+
+// RollbackPlan describes a rollback from the current deployment to an
+// earlier one
+type RollbackPlan struct {
+	From state.Deployment
+	To   state.Deployment
+}
+
+// RecordDeployment adds the running stack to the project's rollback
+// history after a successful up. Nothing is recorded when neither the
+// configuration nor the images changed since the last deployment.
+func (e *Executor) RecordDeployment(ctx context.Context, composeFile *compose.ComposeFile) error {
+	config, err := yaml.Marshal(composeFile)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	sum := sha256.Sum256(config)
+
+	deployment := state.Deployment{
+		ConfigHash: hex.EncodeToString(sum[:]),
+		Time:       time.Now(),
+		Config:     string(config),
+		Images:     make(map[string]string),
+		Containers: make(map[string][]string),
+	}
+
+	e.mu.RLock()
+	for name, ids := range e.runningServices {
+		deployment.Containers[name] = append([]string(nil), ids...)
+	}
+	e.mu.RUnlock()
+
+	for name, ids := range deployment.Containers {
+		if len(ids) == 0 {
+			continue
+		}
+		info, err := e.containerManager.InspectContainer(ctx, ids[0])
+		if err != nil {
+			return fmt.Errorf("failed to inspect service %s: %w", name, err)
+		}
+		deployment.Images[name] = info.Image
+	}
+
+	store, err := state.NewHistoryStore(e.projectName)
+	if err != nil {
+		return err
+	}
+	history, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if n := len(history.Deployments); n > 0 {
+		last := history.Deployments[n-1]
+		if last.ConfigHash == deployment.ConfigHash && reflect.DeepEqual(last.Images, deployment.Images) {
+			return nil
+		}
+	}
+	history.Record(deployment)
+	return store.Save(history)
+}
+
+// Rollback brings down the current deployment and starts the one opts.Steps
+// deployments earlier, with the configuration and image IDs recorded for
+// it. Init containers are not run again, since the earlier version's
+// migrations may no longer apply to the data. The rolled back deployments
+// are dropped from the history, so the restored one becomes current.
+func (e *Executor) Rollback(ctx context.Context, opts RollbackOptions) (*RollbackPlan, error) {
+	if opts.Steps < 1 {
+		return nil, fmt.Errorf("rollback steps must be at least 1")
+	}
+
+	store, err := state.NewHistoryStore(e.projectName)
+	if err != nil {
+		return nil, err
+	}
+	history, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	n := len(history.Deployments)
+	if n == 0 {
+		return nil, fmt.Errorf("no deployments recorded for project %s", e.projectName)
+	}
+	if opts.Steps >= n {
+		return nil, fmt.Errorf("cannot roll back %d deployment(s): only %d earlier deployment(s) recorded", opts.Steps, n-1)
+	}
+
+	target := n - 1 - opts.Steps
+	plan := &RollbackPlan{From: history.Deployments[n-1], To: history.Deployments[target]}
+	if opts.DryRun {
+		return plan, nil
+	}
+
+	current, err := deploymentConfig(plan.From)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := deploymentConfig(plan.To)
+	if err != nil {
+		return nil, err
+	}
+	for name, service := range previous.Services {
+		if image := plan.To.Images[name]; image != "" {
+			service.Image = image
+		}
+		service.InitContainers = nil
+	}
+
+	if err := e.Down(ctx, current); err != nil {
+		return nil, fmt.Errorf("failed to stop current deployment: %w", err)
+	}
+	if err := e.Up(ctx, previous); err != nil {
+		return nil, fmt.Errorf("failed to start deployment %s: %w", ShortHash(plan.To.ConfigHash), err)
+	}
+
+	history.Deployments = history.Deployments[:target+1]
+	if err := store.Save(history); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// deploymentConfig parses the configuration recorded for a deployment
+func deploymentConfig(d state.Deployment) (*compose.ComposeFile, error) {
+	var composeFile compose.ComposeFile
+	if err := yaml.Unmarshal([]byte(d.Config), &composeFile); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration of deployment %s: %w", ShortHash(d.ConfigHash), err)
+	}
+	return &composeFile, nil
+}
+
+// ShortHash abbreviates a configuration hash for display
+func ShortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// stopHooks has the pre-stop and post-stop hooks of service append their
+// phase and the service name to the file at path
+func stopHooks(service, path string) *compose.Hooks {
+	record := func(name string) compose.Hook {
+		return compose.Hook{Name: name, Type: "command", Command: []string{"sh", "-c", "echo " + name + " >> " + path}}
+	}
+	return &compose.Hooks{
+		PreStop:  []compose.Hook{record("pre_stop_" + service)},
+		PostStop: []compose.Hook{record("post_stop_" + service)},
+	}
+}
+
+func TestUpRollbackReversesStartedServices(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "hooks")
+	project := chainProject()
+	for name, service := range project.Services {
+		service.Hooks = stopHooks(name, log)
+	}
+
+	fake := newFakeManager()
+	fake.failService = "web"
+	e := newTestExecutor(t, fake)
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err == nil {
+		t.Fatal("up succeeded with web failing to start")
+	}
+
+	stopped, _ := fake.takeLifecycle()
+	if got := strings.Join(stopped, ","); got != "api,db" {
+		t.Errorf("stopped %s, want api,db", got)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pre_stop_api post_stop_api pre_stop_db post_stop_db"
+	if got := strings.Join(strings.Fields(string(data)), " "); got != want {
+		t.Errorf("hooks ran %q, want %q", got, want)
+	}
+
+	for _, name := range []string{"db", "api", "web"} {
+		if ids := e.runningServices[name]; len(ids) > 0 {
+			t.Errorf("%s still tracked as running: %v", name, ids)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+)
+
+func TestRunOverridesLeaveServiceUnchanged(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web": {
+				Image:       "nginx",
+				Environment: map[string]string{"MODE": "prod", "KEEP": "yes"},
+				Ports:       []string{"80:80"},
+			},
+		},
+	}
+	overrides := compose.RunOverrides{
+		Environment: map[string]string{"MODE": "debug"},
+		Ports:       []string{"8080:8080"},
+		WorkingDir:  "/src",
+	}
+
+	if _, err := e.Run(context.Background(), project, "web", overrides, container.OneOffOptions{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(fake.oneOffs) != 1 {
+		t.Fatalf("got %d one-off runs, want 1", len(fake.oneOffs))
+	}
+	run := fake.oneOffs[0]
+	if run.Environment["MODE"] != "debug" || run.Environment["KEEP"] != "yes" {
+		t.Errorf("one-off env = %v, want MODE=debug and KEEP=yes", run.Environment)
+	}
+	if len(run.Ports) != 2 || run.Ports[1] != "8080:8080" {
+		t.Errorf("one-off ports = %v, want 80:80 and 8080:8080", run.Ports)
+	}
+	if run.WorkingDir != "/src" {
+		t.Errorf("one-off workdir = %q, want /src", run.WorkingDir)
+	}
+
+	stored := project.Services["web"]
+	if stored.Environment["MODE"] != "prod" || len(stored.Environment) != 2 {
+		t.Errorf("stored env changed to %v", stored.Environment)
+	}
+	if len(stored.Ports) != 1 || stored.WorkingDir != "" {
+		t.Errorf("stored service changed: ports %v, workdir %q", stored.Ports, stored.WorkingDir)
+	}
+}
@@ -0,0 +1,177 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"gopkg.in/yaml.v3"
+)
+
+// SnapshotVersion is the layout version recorded in a snapshot's manifest.
+// A restore must refuse snapshots with a version it does not know.
+const SnapshotVersion = 1
+
+// SnapshotManifest describes the contents of a snapshot directory. It is
+// written last, so a snapshot without one is incomplete.
+type SnapshotManifest struct {
+	Version    int       `json:"version"`
+	Project    string    `json:"project"`
+	Created    time.Time `json:"created"`
+	Containers []string  `json:"containers"`
+	Networks   []string  `json:"networks"`
+	Volumes    []string  `json:"volumes"`
+}
+
+// SnapshotOptions controls what a snapshot captures
+type SnapshotOptions struct {
+	// LogTail is the number of log lines kept per service; 0 keeps all
+	LogTail int
+}
+
+// Snapshot captures the state of the project into dir:
+//
+//	manifest.json            SnapshotManifest
+//	config.yml               the resolved compose file
+//	containers/<name>.json   container inspect output
+//	networks/<name>.json     network inspect output
+//	volumes/<name>.tar       volume contents
+//	logs/<service>.log       recent log output
+//
+// Networks the compose file declares but that do not exist are skipped with
+// a warning.
+func (e *Executor) Snapshot(ctx context.Context, composeFile *compose.ComposeFile, dir string, opts SnapshotOptions) (*SnapshotManifest, error) {
+	for _, sub := range []string{"containers", "networks", "volumes", "logs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+	}
+
+	manifest := &SnapshotManifest{
+		Version:    SnapshotVersion,
+		Project:    e.projectName,
+		Created:    time.Now().UTC(),
+		Containers: []string{},
+		Networks:   []string{},
+		Volumes:    []string{},
+	}
+
+	config, err := yaml.Marshal(composeFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal compose file: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), config, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write config.yml: %w", err)
+	}
+
+	containers, err := e.Containers(ctx, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	infos, err := e.Inspect(ctx, containers)
+	if err != nil {
+		return nil, err
+	}
+	services := make(map[string]bool)
+	for i, c := range containers {
+		if err := writeJSON(filepath.Join(dir, "containers", c.Name+".json"), infos[i]); err != nil {
+			return nil, err
+		}
+		manifest.Containers = append(manifest.Containers, c.Name)
+		services[c.Service] = true
+	}
+
+	networkNames := make([]string, 0, len(composeFile.Networks))
+	for name, network := range composeFile.Networks {
+		if network == nil || !network.External {
+			name = e.projectName + "_" + name
+		}
+		networkNames = append(networkNames, name)
+	}
+	sort.Strings(networkNames)
+	for _, name := range networkNames {
+		info, err := e.containerManager.InspectNetwork(ctx, name)
+		if err != nil {
+			e.logger.Warnf("Skipping network %s: %v", name, err)
+			continue
+		}
+		if err := writeJSON(filepath.Join(dir, "networks", name+".json"), info); err != nil {
+			return nil, err
+		}
+		manifest.Networks = append(manifest.Networks, name)
+	}
+
+	volumes, err := e.ProjectVolumes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project volumes: %w", err)
+	}
+	for _, name := range volumes {
+		if err := e.snapshotVolume(ctx, name, filepath.Join(dir, "volumes", name+".tar")); err != nil {
+			return nil, err
+		}
+		manifest.Volumes = append(manifest.Volumes, name)
+	}
+
+	serviceNames := make([]string, 0, len(services))
+	for name := range services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, name := range serviceNames {
+		if err := e.snapshotLogs(ctx, name, opts.LogTail, filepath.Join(dir, "logs", name+".log")); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeJSON(filepath.Join(dir, "manifest.json"), manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (e *Executor) snapshotVolume(ctx context.Context, name, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	return e.containerManager.CopyVolume(ctx, name, file)
+}
+
+func (e *Executor) snapshotLogs(ctx context.Context, service string, tail int, path string) error {
+	lines, err := e.containerManager.ServiceLogs(ctx, service, 1, tail)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	for _, line := range lines {
+		fmt.Fprintf(w, "%s %s\n", line.Timestamp.Format(time.RFC3339Nano), strings.TrimRight(line.Text, "\n"))
+	}
+	return w.Flush()
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
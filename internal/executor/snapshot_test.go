@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestSnapshotInspectsNetworks(t *testing.T) {
+	e := newTestExecutor(t, newFakeManager())
+	project := webProject()
+	project.Networks = map[string]*compose.Network{"backend": {}}
+	ctx := context.Background()
+	if err := e.UpWithOptions(ctx, project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	// A declared network that was never created is skipped
+	project.Networks["ghost"] = &compose.Network{}
+	dir := filepath.Join(t.TempDir(), "snap")
+	manifest, err := e.Snapshot(ctx, project, dir, SnapshotOptions{})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	if want := []string{"demo_backend"}; !reflect.DeepEqual(manifest.Networks, want) {
+		t.Errorf("manifest networks = %v, want %v", manifest.Networks, want)
+	}
+	if want := []string{"demo_web_1"}; !reflect.DeepEqual(manifest.Containers, want) {
+		t.Errorf("manifest containers = %v, want %v", manifest.Containers, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "networks", "demo_backend.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var network types.NetworkResource
+	if err := json.Unmarshal(data, &network); err != nil {
+		t.Fatalf("network JSON: %v", err)
+	}
+	if network.Name != "demo_backend" {
+		t.Errorf("network name = %q", network.Name)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "networks", "demo_ghost.json")); !os.IsNotExist(err) {
+		t.Error("a missing network was written to the snapshot")
+	}
+
+	for _, name := range []string{"config.yml", "manifest.json", "containers/demo_web_1.json", "logs/web.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("snapshot lacks %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/container"
+	"github.com/sirupsen/logrus"
+)
+
+// secondExecutor returns a fresh executor for the same project and HOME,
+// as a later CLI invocation would build
+func secondExecutor(t *testing.T, impl container.ContainerImplementation) *Executor {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	e, err := newWithManager(logger, "demo", container.NewManagerWithImplementation(logger, impl))
+	if err != nil {
+		t.Fatalf("newWithManager: %v", err)
+	}
+	return e
+}
+
+func TestSecondExecutorFindsContainersByLabel(t *testing.T) {
+	fake := newFakeManager()
+	project := webProject()
+	if err := newTestExecutor(t, fake).UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	fake.takeLifecycle()
+
+	e := secondExecutor(t, fake)
+	if err := e.Stop(context.Background(), project, nil, 10); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	if stopped, _ := fake.takeLifecycle(); len(stopped) != 1 || stopped[0] != "web" {
+		t.Errorf("stopped %v, want web", stopped)
+	}
+	replicas, err := e.serviceReplicas(context.Background(), "web", true)
+	if err != nil {
+		t.Fatalf("serviceReplicas: %v", err)
+	}
+	if len(replicas) != 1 || replicas[0].State != "exited" {
+		t.Errorf("web replicas = %v, want one exited container", replicas)
+	}
+}
+
+func TestSecondExecutorFallsBackToCachedState(t *testing.T) {
+	fake := newFakeManager()
+	project := webProject()
+	if err := newTestExecutor(t, fake).UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	fake.takeLifecycle()
+
+	fake.listErr = errors.New("daemon unreachable")
+	e := secondExecutor(t, fake)
+	if err := e.Stop(context.Background(), project, nil, 10); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	if stopped, _ := fake.takeLifecycle(); len(stopped) != 1 || stopped[0] != "web" {
+		t.Errorf("stopped %v, want web from the cached state", stopped)
+	}
+}
@@ -0,0 +1,27 @@
+package executor
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTopCountsEveryReplica(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := webProject()
+	scaledWeb(t, e, fake, project)
+
+	services, err := e.Top(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("top: %v", err)
+	}
+	if len(services) != 1 || services[0].Service != "web" {
+		t.Fatalf("top = %+v, want web only", services)
+	}
+	if n := len(services[0].Processes); n != 3 {
+		t.Errorf("web has %d processes, want one per replica", n)
+	}
+	if len(services[0].Titles) == 0 {
+		t.Error("web has no titles")
+	}
+}
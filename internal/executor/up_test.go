@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestUpNoDepsStartsOnlyNamedService(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"web": {Image: "nginx", DependsOn: map[string]compose.DependsOn{"db": {Condition: "service_healthy"}}},
+			"db":  {Image: "postgres"},
+		},
+	}
+
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{Services: []string{"web"}, NoDeps: true, Quiet: true}); err != nil {
+		t.Fatalf("up --no-deps web: %v", err)
+	}
+
+	starts := fake.started()
+	if len(starts) != 1 || starts[0].Service != "web" {
+		t.Errorf("started %v, want web only", starts)
+	}
+	if n := fake.checksOf("db"); n != 0 {
+		t.Errorf("waited on db with %d health checks, want none", n)
+	}
+}
+
+func TestUpParallelOneIsSequential(t *testing.T) {
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"a": {Image: "busybox"},
+			"b": {Image: "busybox"},
+			"c": {Image: "busybox"},
+		},
+	}
+	overlapping := func(parallel int) bool {
+		fake := newFakeManager()
+		e := newTestExecutor(t, fake)
+		if err := e.UpWithOptions(context.Background(), project, UpOptions{Parallel: parallel, Quiet: true}); err != nil {
+			t.Fatalf("up --parallel %d: %v", parallel, err)
+		}
+		starts := fake.started()
+		if len(starts) != 3 {
+			t.Fatalf("up --parallel %d started %d containers, want 3", parallel, len(starts))
+		}
+		for i, s := range starts {
+			for _, other := range starts[i+1:] {
+				if s.At.Before(other.Done) && other.At.Before(s.Done) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if overlapping(1) {
+		t.Error("--parallel 1 started containers concurrently")
+	}
+	if !overlapping(0) {
+		t.Error("unbounded up started containers one at a time")
+	}
+}
@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestVolumesFromResolution(t *testing.T) {
+	fake := newFakeManager()
+	e := newTestExecutor(t, fake)
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"db": {Image: "postgres"},
+			"backup": {
+				Image:       "restic",
+				VolumesFrom: []string{"db:ro", "container:legacy"},
+				DependsOn:   map[string]compose.DependsOn{"db": {Condition: "service_started"}},
+			},
+		},
+	}
+	if err := e.UpWithOptions(context.Background(), project, UpOptions{Quiet: true}); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	want := []string{e.runningServices["db"][0] + ":ro", "legacy"}
+	if got := fake.created["backup"].VolumesFrom; !slices.Equal(got, want) {
+		t.Errorf("backup created with volumes_from %v, want %v", got, want)
+	}
+	if got := project.Services["backup"].VolumesFrom; !slices.Equal(got, []string{"db:ro", "container:legacy"}) {
+		t.Errorf("the compose file's volumes_from changed to %v", got)
+	}
+
+	_, started := fake.takeLifecycle()
+	if db, backup := slices.Index(started, "db"), slices.Index(started, "backup"); db < 0 || backup < db {
+		t.Errorf("started %v, want db before backup", started)
+	}
+}
+
+func TestVolumesFromServiceNotRunning(t *testing.T) {
+	e := newTestExecutor(t, newFakeManager())
+	project := &compose.ComposeFile{
+		Services: map[string]*compose.Service{
+			"db":     {Image: "postgres"},
+			"backup": {Image: "restic", VolumesFrom: []string{"db"}},
+		},
+	}
+
+	err := e.UpWithOptions(context.Background(), project, UpOptions{Services: []string{"backup"}, NoDeps: true, Quiet: true})
+	if err == nil || !strings.Contains(err.Error(), "service db has no running container") {
+		t.Errorf("up = %v, want an error about db not running", err)
+	}
+}
@@ -0,0 +1,33 @@
+package parser
+
+import "testing"
+
+func TestParseDependsOnRestart(t *testing.T) {
+	path := writeCompose(t, `
+version: "3.8"
+services:
+  web:
+    image: nginx
+    depends_on:
+      api:
+        condition: service_started
+        restart: true
+      db:
+        condition: service_healthy
+  api:
+    image: api
+  db:
+    image: postgres
+`)
+	cf, err := New().ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	deps := cf.Services["web"].DependsOn
+	if !deps["api"].Restart {
+		t.Errorf("api dependency restart = false, want true")
+	}
+	if deps["db"].Restart {
+		t.Errorf("db dependency restart = true, want the false default")
+	}
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# database settings
+export DB_HOST=db.internal
+  export   DB_PORT = 5432
+
+PLAIN=value # trailing comment
+HASH=a#b
+EMPTY=
+SINGLE='literal $HOME \n # kept'
+DOUBLE="line1\nline2\t\"quoted\" \\ # kept" # comment
+UNKNOWN_ESCAPE="a\qb"
+QUOTED_COMMENT="x" ignored
+NO_EQUALS
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := LoadEnvFile(path)
+	if err != nil {
+		t.Fatalf("LoadEnvFile: %v", err)
+	}
+	want := map[string]string{
+		"DB_HOST":        "db.internal",
+		"DB_PORT":        "5432",
+		"PLAIN":          "value",
+		"HASH":           "a#b",
+		"EMPTY":          "",
+		"SINGLE":         `literal $HOME \n # kept`,
+		"DOUBLE":         "line1\nline2\t\"quoted\" \\ # kept",
+		"UNKNOWN_ESCAPE": `a\qb`,
+		"QUOTED_COMMENT": "x",
+	}
+	for key, value := range want {
+		if got, ok := vars[key]; !ok || got != value {
+			t.Errorf("%s = %q (set %v), want %q", key, got, ok, value)
+		}
+	}
+	if len(vars) != len(want) {
+		t.Errorf("got %d variables, want %d: %v", len(vars), len(want), vars)
+	}
+}
+
+func TestLoadEnvFileUnterminatedQuotes(t *testing.T) {
+	for _, line := range []string{`A='open`, `A="open`, `A="escaped\"`} {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte("OK=1\n"+line+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadEnvFile(path)
+		if err == nil || !strings.Contains(err.Error(), ".env:2: unterminated") {
+			t.Errorf("LoadEnvFile with %s = %v, want an unterminated quote error on line 2", line, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultFilenames are the compose files looked for, in order, when none is
+// given with -f
+var DefaultFilenames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// FileNotFoundError reports that a compose file does not exist
+type FileNotFoundError struct {
+	// Filename is the missing file. It is empty when none of the
+	// DefaultFilenames exists in Dir.
+	Filename string
+	Dir      string
+}
+
+func (e *FileNotFoundError) Error() string {
+	names := strings.Join(DefaultFilenames[:len(DefaultFilenames)-1], ", ") + " or " + DefaultFilenames[len(DefaultFilenames)-1]
+	if e.Filename == "" {
+		return fmt.Sprintf("no compose file found in %s: looked for %s; use -f to specify one", e.Dir, names)
+	}
+	return fmt.Sprintf("compose file %s not found: use -f to specify one, or name it %s to have it found by default", e.Filename, names)
+}
+
+// FindComposeFile returns the first of the DefaultFilenames that exists in
+// dir
+func FindComposeFile(dir string) (string, error) {
+	found := DefaultComposeFiles(dir)
+	if len(found) == 0 {
+		return "", &FileNotFoundError{Dir: dir}
+	}
+	return found[0], nil
+}
+
+// DefaultComposeFiles returns the DefaultFilenames that exist in dir, in
+// order of precedence
+func DefaultComposeFiles(dir string) []string {
+	var found []string
+	for _, name := range DefaultFilenames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			found = append(found, path)
+		}
+	}
+	return found
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// touch creates empty files named names in dir
+func touch(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindComposeFileOrder(t *testing.T) {
+	tests := []struct {
+		present []string
+		want    string
+	}{
+		{[]string{"docker-compose.yml"}, "docker-compose.yml"},
+		{[]string{"docker-compose.yml", "docker-compose.yaml"}, "docker-compose.yaml"},
+		{[]string{"docker-compose.yaml", "compose.yml"}, "compose.yml"},
+		{[]string{"docker-compose.yml", "compose.yml", "compose.yaml"}, "compose.yaml"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		touch(t, dir, tt.present...)
+		got, err := FindComposeFile(dir)
+		if err != nil {
+			t.Errorf("FindComposeFile with %v: %v", tt.present, err)
+			continue
+		}
+		if got != filepath.Join(dir, tt.want) {
+			t.Errorf("FindComposeFile with %v = %s, want %s", tt.present, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultComposeFilesSkipsDirectories(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "compose.yaml"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	touch(t, dir, "docker-compose.yml", "compose.yml")
+
+	found := DefaultComposeFiles(dir)
+	want := []string{filepath.Join(dir, "compose.yml"), filepath.Join(dir, "docker-compose.yml")}
+	if strings.Join(found, ",") != strings.Join(want, ",") {
+		t.Errorf("DefaultComposeFiles = %v, want %v", found, want)
+	}
+}
+
+func TestFindComposeFileNotFound(t *testing.T) {
+	dir := t.TempDir()
+	_, err := FindComposeFile(dir)
+	var notFound *FileNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("FindComposeFile = %v, want a FileNotFoundError", err)
+	}
+	want := "no compose file found in " + dir + ": looked for compose.yaml, compose.yml, docker-compose.yaml or docker-compose.yml; use -f to specify one"
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
+
+func TestParseFilesNotFound(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "docker-compose.yml")
+	_, err := New().ParseFiles(missing)
+	var notFound *FileNotFoundError
+	if !errors.As(err, &notFound) || notFound.Filename != missing {
+		t.Fatalf("ParseFiles = %v, want a FileNotFoundError for %s", err, missing)
+	}
+	for _, part := range []string{"compose file " + missing + " not found", "use -f", "compose.yaml, compose.yml, docker-compose.yaml or docker-compose.yml"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("error %q does not contain %q", err, part)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package parser
+
+import "testing"
+
+const interpolateCompose = `
+version: "3.8"
+services:
+  web:
+    image: "nginx:${FOO}"
+    environment:
+      GREETING: "hello $${NAME}"
+`
+
+func TestParseInterpolation(t *testing.T) {
+	t.Setenv("FOO", "1.25")
+	path := writeCompose(t, interpolateCompose)
+
+	cf, err := New().ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if got := cf.Services["web"].Image; got != "nginx:1.25" {
+		t.Errorf("image = %q, want nginx:1.25", got)
+	}
+	if got := cf.Services["web"].Environment["GREETING"]; got != "hello ${NAME}" {
+		t.Errorf("GREETING = %q, want the escaped reference kept", got)
+	}
+}
+
+func TestParseNoInterpolation(t *testing.T) {
+	t.Setenv("FOO", "1.25")
+	path := writeCompose(t, interpolateCompose)
+
+	p := New()
+	p.SetInterpolation(false)
+	cf, err := p.ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if got := cf.Services["web"].Image; got != "nginx:${FOO}" {
+		t.Errorf("image = %q, want the reference kept", got)
+	}
+	if got := cf.Services["web"].Environment["GREETING"]; got != "hello $${NAME}" {
+		t.Errorf("GREETING = %q, want the text unchanged", got)
+	}
+}
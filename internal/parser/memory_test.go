@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMemorySettingsNilVersusZero(t *testing.T) {
+	path := writeCompose(t, `
+version: "3.8"
+services:
+  unset:
+    image: nginx
+  zero:
+    image: nginx
+    mem_swappiness: 0
+    oom_kill_disable: false
+  set:
+    image: nginx
+    mem_swappiness: 60
+    oom_kill_disable: true
+`)
+	cf, err := New().ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+
+	unset := cf.Services["unset"]
+	if unset.MemSwappiness != nil || unset.OomKillDisable != nil {
+		t.Errorf("unset service has mem_swappiness %v, oom_kill_disable %v, want both nil", unset.MemSwappiness, unset.OomKillDisable)
+	}
+	zero := cf.Services["zero"]
+	if zero.MemSwappiness == nil || *zero.MemSwappiness != 0 {
+		t.Errorf("mem_swappiness: 0 parsed as %v, want a pointer to 0", zero.MemSwappiness)
+	}
+	if zero.OomKillDisable == nil || *zero.OomKillDisable {
+		t.Errorf("oom_kill_disable: false parsed as %v, want a pointer to false", zero.OomKillDisable)
+	}
+	set := cf.Services["set"]
+	if set.MemSwappiness == nil || *set.MemSwappiness != 60 || set.OomKillDisable == nil || !*set.OomKillDisable {
+		t.Errorf("set service has mem_swappiness %v, oom_kill_disable %v, want 60 and true", set.MemSwappiness, set.OomKillDisable)
+	}
+}
+
+func TestParseMemorySettingsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "lowest swappiness", extra: "mem_swappiness: 0"},
+		{name: "highest swappiness", extra: "mem_swappiness: 100"},
+		{name: "negative swappiness", extra: "mem_swappiness: -1", wantErr: "invalid swappiness -1"},
+		{name: "swappiness above 100", extra: "mem_swappiness: 101", wantErr: "invalid swappiness 101"},
+		{name: "oom kill disabled", extra: "oom_kill_disable: true"},
+		{name: "oom kill disabled for one replica", extra: "oom_kill_disable: true\n    deploy:\n      replicas: 1"},
+		{name: "oom kill enabled for replicas", extra: "oom_kill_disable: false\n    deploy:\n      replicas: 3"},
+		{name: "oom kill disabled for replicas", extra: "oom_kill_disable: true\n    deploy:\n      replicas: 3", wantErr: "cannot disable the OOM killer for 3 replicas"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCompose(t, `
+version: "3.8"
+services:
+  web:
+    image: nginx
+    `+tt.extra+`
+`)
+			_, err := New().ParseFile(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseFile: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseFile error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
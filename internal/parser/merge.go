@@ -0,0 +1,39 @@
+package parser
+
+import "gopkg.in/yaml.v3"
+
+// mergeNodes merges the compose file src into dst, as ParseFiles does for
+// each file after the first: mappings are merged key by key, anything else
+// in src replaces its counterpart in dst
+func mergeNodes(dst, src *yaml.Node) {
+	if dst.Kind == yaml.DocumentNode && src.Kind == yaml.DocumentNode {
+		if len(dst.Content) == 0 {
+			dst.Content = src.Content
+			return
+		}
+		if len(src.Content) > 0 {
+			mergeNodes(dst.Content[0], src.Content[0])
+		}
+		return
+	}
+
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		*dst = *src
+		return
+	}
+
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		found := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				mergeNodes(dst.Content[j+1], value)
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+}
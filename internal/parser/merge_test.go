@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilesMerges(t *testing.T) {
+	base := writeCompose(t, `
+version: "3.8"
+services:
+  web:
+    image: nginx:1.25
+    command: ["nginx", "-g", "daemon off;"]
+    environment:
+      LOG_LEVEL: info
+      PORT: "80"
+  db:
+    image: postgres
+`)
+	override := filepath.Join(t.TempDir(), "compose.override.yaml")
+	if err := os.WriteFile(override, []byte(`
+services:
+  web:
+    image: nginx:1.27
+    command: ["nginx-debug"]
+    environment:
+      LOG_LEVEL: debug
+    build:
+      context: ./web
+  cache:
+    image: redis
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cf, err := New().ParseFiles(base, override)
+	if err != nil {
+		t.Fatalf("ParseFiles: %v", err)
+	}
+
+	if len(cf.Services) != 3 {
+		t.Errorf("got %d services, want web, db and cache", len(cf.Services))
+	}
+	web := cf.Services["web"]
+	// Scalars and sequences are replaced
+	if web.Image != "nginx:1.27" {
+		t.Errorf("image = %q, want the override's", web.Image)
+	}
+	if want := []string{"nginx-debug"}; !reflect.DeepEqual([]string(web.Command), want) {
+		t.Errorf("command = %v, want %v", web.Command, want)
+	}
+	// Mappings are merged key by key
+	if web.Environment["LOG_LEVEL"] != "debug" || web.Environment["PORT"] != "80" {
+		t.Errorf("environment = %v, want LOG_LEVEL from the override and PORT from the base", web.Environment)
+	}
+	// Relative paths resolve against the first file's directory, even
+	// when set by a later file
+	if web.Build == nil {
+		t.Fatal("build from the override is missing")
+	}
+	if want := filepath.Join(filepath.Dir(base), "web"); web.Build.Context != want {
+		t.Errorf("build context = %q, want %q", web.Build.Context, want)
+	}
+}
+
+func TestParseFilesRequiresAFile(t *testing.T) {
+	if _, err := New().ParseFiles(); err == nil {
+		t.Error("ParseFiles without files succeeded")
+	}
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNetworkMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		networks string
+		wantErr  string
+	}{
+		{name: "host", mode: "host"},
+		{name: "none", mode: "none"},
+		{name: "service", mode: "service:db"},
+		{name: "container", mode: "container:abc123"},
+		{name: "with networks", mode: "host", networks: "\n    networks: [backend]", wantErr: "cannot be combined with networks"},
+		{name: "unknown kind", mode: "pod:db", wantErr: `invalid network_mode "pod:db"`},
+		{name: "missing name", mode: "service:", wantErr: "requires a name"},
+		{name: "own namespace", mode: "service:web", wantErr: "cannot join its own network namespace"},
+		{name: "undefined service", mode: "service:cache", wantErr: "undefined service cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCompose(t, `
+version: "3.8"
+services:
+  web:
+    image: nginx
+    network_mode: "`+tt.mode+`"`+tt.networks+`
+  db:
+    image: postgres
+networks:
+  backend: {}
+`)
+			cf, err := New().ParseFile(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseFile: %v", err)
+				}
+				if got := cf.Services["web"].NetworkMode; got != tt.mode {
+					t.Errorf("network_mode = %q, want %q", got, tt.mode)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "network_mode") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseFile error = %v, want a network_mode error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseNetworkModeServiceDependsOnTarget(t *testing.T) {
+	path := writeCompose(t, `
+version: "3.8"
+services:
+  web:
+    image: nginx
+    network_mode: service:db
+  db:
+    image: postgres
+`)
+	cf, err := New().ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if dep, ok := cf.Services["web"].DependsOn["db"]; !ok || dep.Condition != "service_started" {
+		t.Errorf("web depends_on = %v, want db started first", cf.Services["web"].DependsOn)
+	}
+}
@@ -3,16 +3,26 @@ package parser
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+	"github.com/neomody77/fake-compose/pkg/hooks"
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
 )
 
 type Parser struct {
-	envVars map[string]string
+	envVars       map[string]string
+	warnings      []string
+	noInterpolate bool
 }
 
 func New() *Parser {
@@ -21,32 +31,129 @@ func New() *Parser {
 	}
 }
 
+// SetInterpolation controls whether ${VAR} references are expanded. With
+// interpolation disabled they are kept literally, e.g. to print a template.
+func (p *Parser) SetInterpolation(enabled bool) {
+	p.noInterpolate = !enabled
+}
+
 func (p *Parser) ParseFile(filename string) (*compose.ComposeFile, error) {
-	data, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	return p.ParseFiles(filename)
+}
+
+// ParseFiles parses one or more compose files and merges them in order, with
+// later files overriding earlier ones. Environment variables are expanded in
+// scalar values after the YAML is parsed, so anchors and aliases are never
+// affected by substitution. Mappings are merged key by key while
+// scalars and sequences are replaced. Relative paths resolve against the
+// directory of the first file.
+func (p *Parser) ParseFiles(filenames ...string) (*compose.ComposeFile, error) {
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("no compose file specified")
 	}
 
-	expanded := p.expandEnvVars(string(data))
+	var merged *yaml.Node
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if os.IsNotExist(err) {
+			return nil, &FileNotFoundError{Filename: filename}
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML in %s: %w", filename, err)
+		}
+
+		if !p.noInterpolate {
+			p.interpolateNode(&doc)
+		}
+
+		if merged == nil {
+			merged = &doc
+		} else {
+			mergeNodes(merged, &doc)
+		}
+	}
 
 	var composeFile compose.ComposeFile
-	if err := yaml.Unmarshal([]byte(expanded), &composeFile); err != nil {
+	if err := merged.Decode(&composeFile); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
-	if err := p.resolveRelativePaths(&composeFile, filepath.Dir(filename)); err != nil {
+	if err := p.resolveRelativePaths(&composeFile, filepath.Dir(filenames[0])); err != nil {
 		return nil, fmt.Errorf("failed to resolve paths: %w", err)
 	}
 
+	p.warnings = nil
 	if err := p.validateComposeFile(&composeFile); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	compose.JoinPods(composeFile.Services)
+	compose.ResolveSecrets(&composeFile)
+
+	// A service joining another service's network namespace or mounting
+	// its volumes needs that service started first
+	for _, service := range composeFile.Services {
+		targets := service.VolumesFromServices()
+		if target, ok := service.NetworkModeService(); ok {
+			targets = append(targets, target)
+		}
+		for _, target := range targets {
+			if _, exists := service.DependsOn[target]; !exists {
+				if service.DependsOn == nil {
+					service.DependsOn = make(map[string]compose.DependsOn)
+				}
+				service.DependsOn[target] = compose.DependsOn{Condition: "service_started"}
+			}
+		}
+	}
+
 	return &composeFile, nil
 }
 
+// Warnings returns non-fatal issues found while validating the last parsed
+// compose file
+func (p *Parser) Warnings() []string {
+	return p.warnings
+}
+
+func (p *Parser) warnf(format string, args ...interface{}) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
+// interpolateNode expands environment variables in every scalar of the
+// tree. Alias nodes are skipped since they share the anchored node, which is
+// expanded where it is defined.
+func (p *Parser) interpolateNode(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		expanded := p.expandEnvVars(node.Value)
+		if expanded != node.Value {
+			node.Value = expanded
+			// Let plain scalars re-resolve their type, e.g. "${PORT}" -> int
+			if node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+	case yaml.AliasNode:
+		return
+	default:
+		for _, child := range node.Content {
+			p.interpolateNode(child)
+		}
+	}
+}
+
+// expandEnvVars substitutes variables in content. As in Docker Compose,
+// $$ stands for a literal $, e.g. to pass $${VAR} on to a hook's env.
 func (p *Parser) expandEnvVars(content string) string {
 	return os.Expand(content, func(key string) string {
+		if key == "$" {
+			return "$"
+		}
 		if val, ok := p.envVars[key]; ok {
 			return val
 		}
@@ -75,10 +182,19 @@ func (p *Parser) resolveRelativePaths(cf *compose.ComposeFile, baseDir string) e
 		}
 	}
 
-	for _, secret := range cf.Secrets {
-		if secret.File != "" && !filepath.IsAbs(secret.File) {
+	for name, secret := range cf.Secrets {
+		if secret == nil || secret.File == "" {
+			continue
+		}
+		if !filepath.IsAbs(secret.File) {
 			secret.File = filepath.Join(baseDir, secret.File)
 		}
+		// Fail now rather than when a container mounting it is created
+		if info, err := os.Stat(secret.File); err != nil {
+			return &cerrors.ValidationError{Field: "secrets." + name + ".file", Message: fmt.Sprintf("secret file %s not found", secret.File)}
+		} else if info.IsDir() {
+			return &cerrors.ValidationError{Field: "secrets." + name + ".file", Message: fmt.Sprintf("secret file %s is a directory", secret.File)}
+		}
 	}
 
 	return nil
@@ -86,93 +202,430 @@ func (p *Parser) resolveRelativePaths(cf *compose.ComposeFile, baseDir string) e
 
 func (p *Parser) validateComposeFile(cf *compose.ComposeFile) error {
 	if cf.Version == "" {
-		return fmt.Errorf("version is required")
+		return &cerrors.ValidationError{Field: "version", Message: "version is required"}
 	}
 
 	if len(cf.Services) == 0 {
-		return fmt.Errorf("at least one service is required")
+		return &cerrors.ValidationError{Field: "services", Message: "at least one service is required"}
 	}
 
 	for name, service := range cf.Services {
 		if err := p.validateService(name, service); err != nil {
-			return fmt.Errorf("service %s: %w", name, err)
+			return err
+		}
+		if err := validateNetworkMode(name, service, cf.Services); err != nil {
+			return err
+		}
+		if err := validateVolumesFrom(name, service, cf.Services); err != nil {
+			return err
+		}
+		if err := validateSecrets(name, service, cf.Secrets); err != nil {
+			return err
+		}
+	}
+	if err := validatePods(cf.Services); err != nil {
+		return err
+	}
+
+	if cf.GlobalHooks != nil {
+		if err := p.validateHooks("hooks", cf.GlobalHooks); err != nil {
+			return err
+		}
+		for _, phase := range cf.GlobalHooks.Phases() {
+			if len(phase.Hooks) > 0 && phase.Name != "pre_deploy" && phase.Name != "post_deploy" {
+				return &cerrors.ValidationError{Field: "hooks." + phase.Name, Message: "only pre_deploy and post_deploy hooks can be set at the top level"}
+			}
+		}
+	}
+
+	for name, network := range cf.Networks {
+		if network == nil {
+			continue
+		}
+		if err := validateLabels("networks."+name+".labels", network.Labels); err != nil {
+			return err
+		}
+	}
+
+	for name, volume := range cf.Volumes {
+		if volume == nil {
+			continue
+		}
+		if err := validateLabels("volumes."+name+".labels", volume.Labels); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// validateBlkio checks a service's block I/O weights and that its device
+// throttles have a path and a rate of the right kind
+func validateBlkio(field string, service *compose.Service) error {
+	weightRange := fmt.Sprintf("must be between %d and %d", compose.MinBlkioWeight, compose.MaxBlkioWeight)
+	if service.BlkioWeight != 0 && (service.BlkioWeight < compose.MinBlkioWeight || service.BlkioWeight > compose.MaxBlkioWeight) {
+		return &cerrors.ValidationError{Field: field + ".blkio_weight", Message: fmt.Sprintf("invalid weight %d: %s", service.BlkioWeight, weightRange)}
+	}
+	for i, device := range service.BlkioWeightDevice {
+		deviceField := fmt.Sprintf("%s.blkio_weight_device[%d]", field, i)
+		if device.Path == "" {
+			return &cerrors.ValidationError{Field: deviceField, Message: "path is required"}
+		}
+		if device.Weight < compose.MinBlkioWeight || device.Weight > compose.MaxBlkioWeight {
+			return &cerrors.ValidationError{Field: deviceField, Message: fmt.Sprintf("invalid weight %d for %s: %s", device.Weight, device.Path, weightRange)}
+		}
+	}
+
+	throttles := []struct {
+		key     string
+		devices []compose.ThrottleDevice
+		parse   func(compose.ThrottleDevice) (uint64, error)
+	}{
+		{"device_read_bps", service.DeviceReadBps, compose.ThrottleDevice.BytesPerSecond},
+		{"device_write_bps", service.DeviceWriteBps, compose.ThrottleDevice.BytesPerSecond},
+		{"device_read_iops", service.DeviceReadIOps, compose.ThrottleDevice.OpsPerSecond},
+		{"device_write_iops", service.DeviceWriteIOps, compose.ThrottleDevice.OpsPerSecond},
+	}
+	for _, throttle := range throttles {
+		for i, device := range throttle.devices {
+			deviceField := fmt.Sprintf("%s.%s[%d]", field, throttle.key, i)
+			if device.Path == "" {
+				return &cerrors.ValidationError{Field: deviceField, Message: "path is required"}
+			}
+			if _, err := throttle.parse(device); err != nil {
+				return &cerrors.ValidationError{Field: deviceField, Message: err.Error()}
+			}
+		}
+	}
+	return nil
+}
+
+// validateCPURealtime checks the real-time scheduling budget fits in its
+// period
+func (p *Parser) validateCPURealtime(name, field string, service *compose.Service) error {
+	if service.CPURtRuntime < 0 {
+		return &cerrors.ValidationError{Field: field + ".cpu_rt_runtime", Message: fmt.Sprintf("invalid runtime %d: must be positive", service.CPURtRuntime)}
+	}
+	if service.CPURtPeriod < 0 {
+		return &cerrors.ValidationError{Field: field + ".cpu_rt_period", Message: fmt.Sprintf("invalid period %d: must be positive", service.CPURtPeriod)}
+	}
+	if service.CPURtRuntime > 0 && service.CPURtPeriod > 0 && service.CPURtRuntime > service.CPURtPeriod {
+		return &cerrors.ValidationError{Field: field + ".cpu_rt_runtime", Message: fmt.Sprintf("runtime %dus exceeds cpu_rt_period %dus", service.CPURtRuntime, service.CPURtPeriod)}
+	}
+	if service.CPURtRuntime > 0 || service.CPURtPeriod > 0 {
+		p.warnf("service %s: cpu_rt_runtime and cpu_rt_period need a Docker daemon started with --cpu-rt-runtime", name)
+	}
+	return nil
+}
+
+// validatePods checks that the services of a pod can share a network
+// namespace: none sets a network_mode of its own, and no two bind the same
+// container port or publish the same host port
+func validatePods(services map[string]*compose.Service) error {
+	pods := compose.Pods(services)
+	names := make([]string, 0, len(pods))
+	for pod := range pods {
+		names = append(names, pod)
+	}
+	sort.Strings(names)
+
+	for _, pod := range names {
+		targets := make(map[string]string)
+		published := make(map[string]string)
+		for _, name := range pods[pod] {
+			service := services[name]
+			// Already joined, as in the output of config
+			joined := name != pods[pod][0] && service.NetworkMode == "service:"+pods[pod][0]
+			if service.NetworkMode != "" && !joined {
+				return &cerrors.ValidationError{Field: "services." + name + ".network_mode", Message: fmt.Sprintf("network_mode cannot be set on a service of pod %s", pod)}
+			}
+
+			field := "services." + name + ".ports"
+			for _, spec := range service.Ports {
+				port, err := compose.ParsePort(spec)
+				if err != nil {
+					return &cerrors.ValidationError{Field: field, Message: err.Error()}
+				}
+				target := fmt.Sprintf("%d/%s", port.Target, port.Protocol)
+				if other, exists := targets[target]; exists && other != name {
+					return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("port %s is also used by service %s in pod %s", target, other, pod)}
+				}
+				targets[target] = name
+				if port.Published == "" {
+					continue
+				}
+				host := fmt.Sprintf("%s:%s/%s", port.HostIP, port.Published, port.Protocol)
+				if other, exists := published[host]; exists && other != name {
+					return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("host port %s/%s is also published by service %s in pod %s", port.Published, port.Protocol, other, pod)}
+				}
+				published[host] = name
+			}
+		}
+	}
+	return nil
+}
+
+// validateNetworkMode checks a service's network_mode. host, none and
+// bridge are passed to Docker as is, container:<id> joins an existing
+// container and service:<name> joins another service's container.
+func validateNetworkMode(name string, service *compose.Service, services map[string]*compose.Service) error {
+	if service.NetworkMode == "" {
+		return nil
+	}
+	field := "services." + name + ".network_mode"
+	if len(service.Networks) > 0 {
+		return &cerrors.ValidationError{Field: field, Message: "network_mode cannot be combined with networks"}
+	}
+
+	kind, target, hasTarget := strings.Cut(service.NetworkMode, ":")
+	switch {
+	case !hasTarget:
+		return nil
+	case kind != "container" && kind != "service":
+		return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("invalid network_mode %q", service.NetworkMode)}
+	case target == "":
+		return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("network_mode %s: requires a name", kind)}
+	case kind == "service" && target == name:
+		return &cerrors.ValidationError{Field: field, Message: "a service cannot join its own network namespace"}
+	case kind == "service" && services[target] == nil:
+		return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("network_mode refers to undefined service %s", target)}
+	}
+	return nil
+}
+
+// validateVolumesFrom checks that a service's volumes_from entries are well
+// formed and refer to other services defined in the file
+func validateVolumesFrom(name string, service *compose.Service, services map[string]*compose.Service) error {
+	field := "services." + name + ".volumes_from"
+	for _, entry := range service.VolumesFrom {
+		ref, err := compose.ParseVolumesFrom(entry)
+		switch {
+		case err != nil:
+			return &cerrors.ValidationError{Field: field, Message: err.Error()}
+		case ref.Service == name:
+			return &cerrors.ValidationError{Field: field, Message: "a service cannot mount its own volumes"}
+		case ref.Service != "" && services[ref.Service] == nil:
+			return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("volumes_from refers to undefined service %s", ref.Service)}
+		}
+	}
+	return nil
+}
+
+// validateSecrets checks that a service's secrets are file backed secrets
+// defined at the top level. External secrets are swarm secrets, which
+// Docker only attaches to swarm services and never to the plain containers
+// fake-compose creates, so they are not supported.
+func validateSecrets(name string, service *compose.Service, secrets map[string]*compose.Secret) error {
+	field := "services." + name + ".secrets"
+	for _, ref := range service.Secrets {
+		secret := secrets[ref.Source]
+		switch {
+		case ref.Source == "":
+			return &cerrors.ValidationError{Field: field, Message: "secret source is required"}
+		case secret == nil:
+			return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("refers to undefined secret %s", ref.Source)}
+		case secret.External:
+			return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("external secret %s is not supported: swarm secrets can only be used by swarm services, use a file secret", ref.Source)}
+		case secret.File == "":
+			return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("secret %s has no file", ref.Source)}
+		}
+	}
+	return nil
+}
+
+// validateLabels rejects label keys Docker cannot store
+func validateLabels(field string, labels map[string]string) error {
+	for key := range labels {
+		if key == "" {
+			return &cerrors.ValidationError{Field: field, Message: "label key must not be empty"}
+		}
+		if strings.ContainsRune(key, 0) {
+			return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf("label key %q must not contain null bytes", key)}
+		}
+	}
+	return nil
+}
+
 func (p *Parser) validateService(name string, service *compose.Service) error {
+	field := "services." + name
 	if service.Image == "" && service.Build == nil {
-		return fmt.Errorf("either image or build must be specified")
+		return &cerrors.ValidationError{Field: field, Message: "either image or build must be specified"}
 	}
 
-	for _, initContainer := range service.InitContainers {
+	if err := validateLabels(field+".labels", service.Labels); err != nil {
+		return err
+	}
+
+	for i, initContainer := range service.InitContainers {
 		if initContainer.Name == "" {
-			return fmt.Errorf("init container name is required")
+			return &cerrors.ValidationError{Field: fmt.Sprintf("%s.init_containers[%d].name", field, i), Message: "init container name is required"}
 		}
 		if initContainer.Image == "" {
-			return fmt.Errorf("init container %s: image is required", initContainer.Name)
+			return &cerrors.ValidationError{Field: fmt.Sprintf("%s.init_containers[%d].image", field, i), Message: fmt.Sprintf("init container %s: image is required", initContainer.Name)}
+		}
+		if initContainer.Retries < 0 {
+			return &cerrors.ValidationError{Field: fmt.Sprintf("%s.init_containers[%d].retries", field, i), Message: fmt.Sprintf("init container %s: retries must not be negative", initContainer.Name)}
+		}
+		if initContainer.RetryDelay != "" {
+			if _, err := time.ParseDuration(initContainer.RetryDelay); err != nil {
+				return &cerrors.ValidationError{Field: fmt.Sprintf("%s.init_containers[%d].retry_delay", field, i), Message: fmt.Sprintf("init container %s: invalid duration %q", initContainer.Name, initContainer.RetryDelay)}
+			}
+		}
+		if initContainer.WorkingDir != "" && !path.IsAbs(initContainer.WorkingDir) {
+			return &cerrors.ValidationError{Field: fmt.Sprintf("%s.init_containers[%d].working_dir", field, i), Message: fmt.Sprintf("init container %s: working_dir %q must be an absolute path", initContainer.Name, initContainer.WorkingDir)}
 		}
 	}
 
-	for _, postContainer := range service.PostContainers {
+	for i, postContainer := range service.PostContainers {
 		if postContainer.Name == "" {
-			return fmt.Errorf("post container name is required")
+			return &cerrors.ValidationError{Field: fmt.Sprintf("%s.post_containers[%d].name", field, i), Message: "post container name is required"}
 		}
 		if postContainer.Image == "" {
-			return fmt.Errorf("post container %s: image is required", postContainer.Name)
+			return &cerrors.ValidationError{Field: fmt.Sprintf("%s.post_containers[%d].image", field, i), Message: fmt.Sprintf("post container %s: image is required", postContainer.Name)}
+		}
+		if _, _, err := postContainer.WaitCondition(); err != nil {
+			return &cerrors.ValidationError{Field: fmt.Sprintf("%s.post_containers[%d].wait_for", field, i), Message: fmt.Sprintf("post container %s: %v", postContainer.Name, err)}
+		}
+		if postContainer.WorkingDir != "" && !path.IsAbs(postContainer.WorkingDir) {
+			return &cerrors.ValidationError{Field: fmt.Sprintf("%s.post_containers[%d].working_dir", field, i), Message: fmt.Sprintf("post container %s: working_dir %q must be an absolute path", postContainer.Name, postContainer.WorkingDir)}
+		}
+	}
+
+	if err := validateRestart(service.Restart); err != nil {
+		return &cerrors.ValidationError{Field: field + ".restart", Message: err.Error()}
+	}
+	switch service.LogLevel {
+	case "", "trace", "debug", "info", "warn", "error":
+	default:
+		return &cerrors.ValidationError{Field: field + ".log_level", Message: fmt.Sprintf("invalid log level %q: must be one of trace, debug, info, warn, error", service.LogLevel)}
+	}
+
+	for network, settings := range service.Networks {
+		if settings == nil || settings.IPv4Address == "" {
+			continue
+		}
+		if ip := net.ParseIP(settings.IPv4Address); ip == nil || ip.To4() == nil {
+			return &cerrors.ValidationError{Field: field + ".networks." + network + ".ipv4_address", Message: fmt.Sprintf("invalid IPv4 address %q", settings.IPv4Address)}
+		}
+	}
+
+	if service.HealthCheck != nil {
+		if err := p.validateHealthCheck(name, field+".healthcheck", service.HealthCheck); err != nil {
+			return err
+		}
+	}
+
+	if err := validateBlkio(field, service); err != nil {
+		return err
+	}
+
+	if swappiness := service.MemSwappiness; swappiness != nil && (*swappiness < 0 || *swappiness > 100) {
+		return &cerrors.ValidationError{Field: field + ".mem_swappiness", Message: fmt.Sprintf("invalid swappiness %d: must be between 0 and 100", *swappiness)}
+	}
+	// Without the OOM killer, replicas exhausting memory take the host down
+	// with them
+	if service.OomKillDisable != nil && *service.OomKillDisable && service.Deploy != nil && service.Deploy.Replicas > 1 {
+		return &cerrors.ValidationError{Field: field + ".oom_kill_disable", Message: fmt.Sprintf("cannot disable the OOM killer for %d replicas", service.Deploy.Replicas)}
+	}
+
+	if service.CPUs != "" {
+		if _, err := compose.ParseCPUs(service.CPUs); err != nil {
+			return &cerrors.ValidationError{Field: field + ".cpus", Message: err.Error()}
+		}
+	}
+	if service.MemLimit != "" {
+		if _, err := compose.ParseMemory(service.MemLimit); err != nil {
+			return &cerrors.ValidationError{Field: field + ".mem_limit", Message: err.Error()}
+		}
+	}
+	if service.MemReservation != "" {
+		if _, err := compose.ParseMemory(service.MemReservation); err != nil {
+			return &cerrors.ValidationError{Field: field + ".mem_reservation", Message: err.Error()}
+		}
+	}
+
+	if err := p.validateCPURealtime(name, field, service); err != nil {
+		return err
+	}
+
+	if service.StopSignal != "" {
+		if _, err := container.ParseSignal(service.StopSignal); err != nil {
+			return &cerrors.ValidationError{Field: field + ".stop_signal", Message: err.Error()}
+		}
+	}
+
+	if service.Restart == "always" && len(service.InitContainers) > 0 {
+		p.warnf("service %s: restart: always re-runs init containers on every restart", name)
+	}
+
+	if (service.Init != nil && *service.Init) || service.InitProcess != "" {
+		if signal := strings.TrimPrefix(strings.ToUpper(service.StopSignal), "SIG"); signal == "KILL" || signal == "9" {
+			p.warnf("service %s: stop_signal SIGKILL cannot be forwarded by the init process", name)
 		}
 	}
 
 	if service.Hooks != nil {
-		if err := p.validateHooks(service.Hooks); err != nil {
-			return fmt.Errorf("hooks validation failed: %w", err)
+		if err := p.validateHooks(field+".hooks", service.Hooks); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (p *Parser) validateHooks(hooks *compose.Hooks) error {
-	allHooks := [][]compose.Hook{
-		hooks.PreStart,
-		hooks.PostStart,
-		hooks.PreStop,
-		hooks.PostStop,
-		hooks.PreBuild,
-		hooks.PostBuild,
-		hooks.PreDeploy,
-		hooks.PostDeploy,
+// validateHealthCheck checks that a healthcheck uses exactly one of test,
+// http_get and tcp_socket, and notes that the probe forms are extensions
+func (p *Parser) validateHealthCheck(name, field string, hc *compose.HealthCheck) error {
+	forms := 0
+	if len(hc.Test) > 0 {
+		forms++
 	}
-
-	for _, hookList := range allHooks {
-		for _, hook := range hookList {
-			if hook.Name == "" {
-				return fmt.Errorf("hook name is required")
-			}
-			if hook.Type == "" {
-				return fmt.Errorf("hook %s: type is required", hook.Name)
+	if hc.HTTPGet != nil {
+		forms++
+		if hc.HTTPGet.Port < 1 || hc.HTTPGet.Port > 65535 {
+			return &cerrors.ValidationError{Field: field + ".http_get.port", Message: fmt.Sprintf("invalid port %d", hc.HTTPGet.Port)}
+		}
+		for i, header := range hc.HTTPGet.HTTPHeaders {
+			if header.Name == "" {
+				return &cerrors.ValidationError{Field: fmt.Sprintf("%s.http_get.http_headers[%d].name", field, i), Message: "header name is required"}
 			}
-			switch hook.Type {
-			case "command":
-				if len(hook.Command) == 0 {
-					return fmt.Errorf("hook %s: command is required for command type", hook.Name)
-				}
-			case "script":
-				if hook.Script == "" {
-					return fmt.Errorf("hook %s: script is required for script type", hook.Name)
-				}
-			case "http":
-				if hook.HTTP == nil || hook.HTTP.URL == "" {
-					return fmt.Errorf("hook %s: http configuration with URL is required for http type", hook.Name)
-				}
-			case "exec":
-				if hook.Exec == nil || hook.Exec.Container == "" || len(hook.Exec.Command) == 0 {
-					return fmt.Errorf("hook %s: exec configuration with container and command is required for exec type", hook.Name)
-				}
-			default:
-				return fmt.Errorf("hook %s: invalid type %s", hook.Name, hook.Type)
+		}
+	}
+	if hc.TCPSocket != nil {
+		forms++
+		if hc.TCPSocket.Port < 1 || hc.TCPSocket.Port > 65535 {
+			return &cerrors.ValidationError{Field: field + ".tcp_socket.port", Message: fmt.Sprintf("invalid port %d", hc.TCPSocket.Port)}
+		}
+	}
+	if forms > 1 {
+		return &cerrors.ValidationError{Field: field, Message: "only one of test, http_get and tcp_socket may be set"}
+	}
+
+	if hc.HTTPGet != nil || hc.TCPSocket != nil {
+		p.warnf("service %s: healthcheck probes are translated to CMD-SHELL tests needing curl or nc in the image; test is the canonical form", name)
+	}
+	return nil
+}
+
+func validateRestart(restart string) error {
+	switch restart {
+	case "", "no", "always", "on-failure", "unless-stopped":
+		return nil
+	}
+	if retries, ok := strings.CutPrefix(restart, "on-failure:"); ok {
+		if n, err := strconv.Atoi(retries); err == nil && n >= 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid restart policy %q: must be one of no, always, on-failure[:<max-retries>], unless-stopped", restart)
+}
+
+func (p *Parser) validateHooks(field string, hooks *compose.Hooks) error {
+	for _, phase := range hooks.Phases() {
+		for i, hook := range phase.Hooks {
+			hookField := fmt.Sprintf("%s.%s[%d]", field, phase.Name, i)
+			if err := p.validateHook(hookField, hook); err != nil {
+				return err
 			}
 		}
 	}
@@ -180,31 +633,177 @@ func (p *Parser) validateHooks(hooks *compose.Hooks) error {
 	return nil
 }
 
+func (p *Parser) validateHook(field string, hook compose.Hook) error {
+	invalid := func(format string, args ...interface{}) error {
+		return &cerrors.ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if hook.Name == "" {
+		return invalid("hook name is required")
+	}
+	if hook.Type == "" {
+		return invalid("hook %s: type is required", hook.Name)
+	}
+	switch hook.Type {
+	case "command":
+		if len(hook.Command) == 0 {
+			return invalid("hook %s: command is required for command type", hook.Name)
+		}
+	case "script":
+		if hook.Script == "" {
+			return invalid("hook %s: script is required for script type", hook.Name)
+		}
+	case "http":
+		if hook.HTTP == nil || hook.HTTP.URL == "" {
+			return invalid("hook %s: http configuration with URL is required for http type", hook.Name)
+		}
+	case "exec":
+		if hook.Exec == nil || hook.Exec.Container == "" || len(hook.Exec.Command) == 0 {
+			return invalid("hook %s: exec configuration with container and command is required for exec type", hook.Name)
+		}
+	case "plugin":
+		if hook.Plugin == nil || hook.Plugin.Name == "" {
+			return invalid("hook %s: plugin configuration with name is required for plugin type", hook.Name)
+		}
+		if strings.ContainsAny(hook.Plugin.Name, `/\`) {
+			return invalid("hook %s: plugin name %s must not be a path", hook.Name, hook.Plugin.Name)
+		}
+	default:
+		return invalid("hook %s: invalid type %s", hook.Name, hook.Type)
+	}
+	switch hook.When {
+	case "", "always", "on-success", "on-failure":
+	default:
+		return invalid("hook %s: invalid when condition %s", hook.Name, hook.When)
+	}
+	if hook.RetryWhen != "" {
+		if _, err := hooks.ParseRetryCondition(hook.RetryWhen); err != nil {
+			return invalid("hook %s: %v", hook.Name, err)
+		}
+	}
+	for key := range hook.Env {
+		if !isIdentifier(key) {
+			return invalid("hook %s: invalid env variable name %q", hook.Name, key)
+		}
+	}
+
+	return nil
+}
+
+// isIdentifier reports whether s is a valid environment variable name:
+// letters, digits and underscores, not starting with a digit
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func (p *Parser) SetEnvVar(key, value string) {
 	p.envVars[key] = value
 }
 
-func (p *Parser) LoadEnvFile(filename string) error {
+// SetEnvVars sets every variable in vars, overriding earlier values
+func (p *Parser) SetEnvVars(vars map[string]string) {
+	for key, value := range vars {
+		p.envVars[key] = value
+	}
+}
+
+// LoadEnvFile parses a file of KEY=VALUE lines. Blank lines and lines
+// starting with # are skipped, and an optional "export " prefix is ignored.
+// See parseEnvValue for how values are unquoted.
+func LoadEnvFile(filename string) (map[string]string, error) {
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to read env file: %w", err)
+		return nil, fmt.Errorf("failed to read env file: %w", err)
 	}
 
+	vars := make(map[string]string)
 	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
+	for i, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
+		if rest, ok := strings.CutPrefix(line, "export "); ok {
+			line = strings.TrimSpace(rest)
+		}
 
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			value = strings.Trim(value, "\"'")
-			p.envVars[key] = value
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, i+1, err)
 		}
+		vars[strings.TrimSpace(key)] = value
 	}
 
-	return nil
-}
\ No newline at end of file
+	return vars, nil
+}
+
+// parseEnvValue unquotes an env file value. Single-quoted values are taken
+// literally. Double-quoted values support the escapes \n, \t, \r, \" and
+// \\. Anything after the closing quote is ignored, so # inside quotes is
+// kept. In unquoted values a # preceded by whitespace starts a comment.
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return value[1 : end+1], nil
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				case '"', '\\':
+					b.WriteByte(value[i])
+				default:
+					b.WriteByte('\\')
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value")
+	}
+
+	if strings.HasPrefix(value, "#") {
+		return "", nil
+	}
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(value), nil
+}
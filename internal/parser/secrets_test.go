@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
+)
+
+// writeCompose writes a compose file into a new directory and returns its
+// path
+func writeCompose(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "compose.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const secretsCompose = `
+version: "3.8"
+services:
+  db:
+    image: postgres
+    secrets:
+      - db_password
+      - source: db_password
+        target: /etc/db/password
+secrets:
+  db_password:
+    file: ./db_password.txt
+`
+
+func TestParseSecretsResolvesFiles(t *testing.T) {
+	path := writeCompose(t, secretsCompose)
+	secretFile := filepath.Join(filepath.Dir(path), "db_password.txt")
+	if err := os.WriteFile(secretFile, []byte("hunter2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cf, err := New().ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	for _, ref := range cf.Services["db"].Secrets {
+		if ref.File != secretFile {
+			t.Errorf("secret %s file = %q, want %q", ref.Source, ref.File, secretFile)
+		}
+	}
+}
+
+func TestParseSecretsMissingFile(t *testing.T) {
+	path := writeCompose(t, secretsCompose)
+
+	_, err := New().ParseFile(path)
+	var validationErr *cerrors.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("ParseFile error = %v, want a validation error", err)
+	}
+	if validationErr.Field != "secrets.db_password.file" || !strings.Contains(validationErr.Message, "not found") {
+		t.Errorf("validation error = %+v", validationErr)
+	}
+}
+
+func TestParseSecretsRejectsExternal(t *testing.T) {
+	path := writeCompose(t, `
+version: "3.8"
+services:
+  db:
+    image: postgres
+    secrets: [db_password]
+secrets:
+  db_password:
+    external: true
+`)
+
+	_, err := New().ParseFile(path)
+	if err == nil || !strings.Contains(err.Error(), "external secret db_password is not supported") {
+		t.Errorf("ParseFile error = %v, want the external secret to be rejected", err)
+	}
+}
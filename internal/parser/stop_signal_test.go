@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStopSignal(t *testing.T) {
+	tests := []struct {
+		signal  string
+		wantErr string
+	}{
+		{signal: "SIGINT"},
+		{signal: "SIGUSR1"},
+		{signal: "term"},
+		{signal: "2"},
+		{signal: "64"},
+		{signal: "SIGBOGUS", wantErr: `invalid signal "SIGBOGUS"`},
+		{signal: "0", wantErr: "number must be between 1 and 64"},
+		{signal: "65", wantErr: "number must be between 1 and 64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.signal, func(t *testing.T) {
+			path := writeCompose(t, `
+version: "3.8"
+services:
+  web:
+    image: gunicorn
+    stop_signal: "`+tt.signal+`"
+`)
+			cf, err := New().ParseFile(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseFile: %v", err)
+				}
+				if got := cf.Services["web"].StopSignal; got != tt.signal {
+					t.Errorf("stop_signal = %q, want %q", got, tt.signal)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "stop_signal") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseFile error = %v, want a stop_signal error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseStopSignalKillWarns(t *testing.T) {
+	path := writeCompose(t, `
+version: "3.8"
+services:
+  web:
+    image: gunicorn
+    init: true
+    stop_signal: SIGKILL
+`)
+	p := New()
+	if _, err := p.ParseFile(path); err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if len(p.Warnings()) == 0 {
+		t.Error("no warning for stop_signal SIGKILL")
+	}
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// varRefPattern matches $VAR and ${VAR} references, with an optional
+// modifier such as ${VAR:-default}. $$ is matched so an escaped dollar is
+// not taken for the start of a reference.
+var varRefPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)([^}]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ExtractVarRefs returns the unique names of the variables referenced in
+// content, sorted
+func ExtractVarRefs(content string) []string {
+	seen := make(map[string]bool)
+	for _, ref := range varRefs(content) {
+		seen[ref.name] = true
+	}
+	return sortedKeys(seen)
+}
+
+// UndefinedVars returns the variables referenced in the compose files'
+// values that are set neither in the env files nor in the environment,
+// sorted. References with a default, ${VAR:-default} or ${VAR-default},
+// are not reported. Comments are not scanned.
+func (p *Parser) UndefinedVars(filenames ...string) ([]string, error) {
+	undefined := make(map[string]bool)
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+		}
+
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML in %s: %w", filename, err)
+		}
+
+		walkScalars(&doc, func(value string) {
+			for _, ref := range varRefs(value) {
+				if ref.hasDefault() {
+					continue
+				}
+				if _, ok := p.envVars[ref.name]; ok {
+					continue
+				}
+				if _, ok := os.LookupEnv(ref.name); ok {
+					continue
+				}
+				undefined[ref.name] = true
+			}
+		})
+	}
+	return sortedKeys(undefined), nil
+}
+
+// varRef is one variable reference and its modifier, e.g. ":-default"
+type varRef struct {
+	name     string
+	modifier string
+}
+
+func (r varRef) hasDefault() bool {
+	return strings.HasPrefix(r.modifier, ":-") || strings.HasPrefix(r.modifier, "-")
+}
+
+func varRefs(content string) []varRef {
+	var refs []varRef
+	for _, m := range varRefPattern.FindAllStringSubmatch(content, -1) {
+		switch {
+		case m[1] != "":
+			refs = append(refs, varRef{name: m[1], modifier: m[2]})
+		case m[3] != "":
+			refs = append(refs, varRef{name: m[3]})
+		}
+	}
+	return refs
+}
+
+// walkScalars calls fn with the value of every scalar in the tree. Aliases
+// are skipped, as in interpolateNode.
+func walkScalars(node *yaml.Node, fn func(string)) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		fn(node.Value)
+	case yaml.AliasNode:
+		return
+	default:
+		for _, child := range node.Content {
+			walkScalars(child, fn)
+		}
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ServiceEnvironment resolves a service's environment from, in increasing
+// precedence, the variables of the project's env files (.env or
+// --env-file), the service's env_file entries in order and its environment
+// map
+func (p *Parser) ServiceEnvironment(service *compose.Service) (map[string]string, error) {
+	env := make(map[string]string, len(p.envVars)+len(service.Environment))
+	for key, value := range p.envVars {
+		env[key] = value
+	}
+	for _, envFile := range service.EnvFile {
+		vars, err := LoadEnvFile(envFile)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range vars {
+			env[key] = value
+		}
+	}
+	for key, value := range service.Environment {
+		env[key] = value
+	}
+	return env, nil
+}
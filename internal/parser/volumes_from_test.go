@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVolumesFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		wantErr string
+	}{
+		{name: "service", entry: "db"},
+		{name: "read only", entry: "db:ro"},
+		{name: "read write", entry: "db:rw"},
+		{name: "container", entry: "container:abc123:ro"},
+		{name: "bad mode", entry: "db:rx", wantErr: "mode must be ro or rw"},
+		{name: "missing name", entry: "container:", wantErr: "requires a name"},
+		{name: "own volumes", entry: "web", wantErr: "cannot mount its own volumes"},
+		{name: "undefined service", entry: "cache", wantErr: "undefined service cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCompose(t, `
+version: "3.8"
+services:
+  web:
+    image: nginx
+    volumes_from: ["`+tt.entry+`"]
+  db:
+    image: postgres
+`)
+			cf, err := New().ParseFile(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseFile: %v", err)
+				}
+				if got := cf.Services["web"].VolumesFrom; len(got) != 1 || got[0] != tt.entry {
+					t.Errorf("volumes_from = %v, want [%s]", got, tt.entry)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "volumes_from") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseFile error = %v, want a volumes_from error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseVolumesFromDependsOnTarget(t *testing.T) {
+	path := writeCompose(t, `
+version: "3.8"
+services:
+  web:
+    image: nginx
+    volumes_from: ["db:ro", "container:legacy"]
+  db:
+    image: postgres
+`)
+	cf, err := New().ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	deps := cf.Services["web"].DependsOn
+	if dep, ok := deps["db"]; !ok || dep.Condition != "service_started" {
+		t.Errorf("web depends_on = %v, want db started first", deps)
+	}
+	if len(deps) != 1 {
+		t.Errorf("web depends_on = %v, want only the db service", deps)
+	}
+}
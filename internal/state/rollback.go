@@ -0,0 +1,72 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// HistoryLimit is the number of deployments kept in a project's rollback
+// history; recording one more drops the oldest
+const HistoryLimit = 10
+
+// Deployment records a successful `up` that `rollback` can return to
+type Deployment struct {
+	// ConfigHash identifies the deployed configuration
+	ConfigHash string    `json:"config_hash"`
+	Time       time.Time `json:"time"`
+	// Config is the deployed configuration, as printed by `config`
+	Config string `json:"config"`
+	// Images maps each running service to the ID of the image its
+	// containers were created from, so later pulls of the same tag do not
+	// change what a rollback starts
+	Images map[string]string `json:"images,omitempty"`
+	// Containers maps each running service to its container IDs
+	Containers map[string][]string `json:"containers,omitempty"`
+}
+
+// History is a project's rollback history, oldest deployment first
+type History struct {
+	Deployments []Deployment `json:"deployments"`
+}
+
+// Record appends a deployment, dropping the oldest ones beyond
+// HistoryLimit
+func (h *History) Record(d Deployment) {
+	h.Deployments = append(h.Deployments, d)
+	if extra := len(h.Deployments) - HistoryLimit; extra > 0 {
+		h.Deployments = append([]Deployment(nil), h.Deployments[extra:]...)
+	}
+}
+
+// HistoryStore persists a project's History as JSON in
+// ~/.fake-compose/<project>/rollback.json
+type HistoryStore struct {
+	path string
+}
+
+// NewHistoryStore returns the rollback history store for a project
+func NewHistoryStore(project string) (*HistoryStore, error) {
+	path, err := projectFile(project, "rollback.json")
+	if err != nil {
+		return nil, err
+	}
+	return &HistoryStore{path: path}, nil
+}
+
+// Load reads the history. A project that was never deployed has an empty
+// history.
+func (s *HistoryStore) Load() (*History, error) {
+	var h History
+	if err := readJSON(s.path, &h); err != nil {
+		return nil, fmt.Errorf("failed to read rollback history: %w", err)
+	}
+	return &h, nil
+}
+
+// Save writes the history, replacing the previous file atomically
+func (s *HistoryStore) Save(h *History) error {
+	if err := writeJSON(s.path, h); err != nil {
+		return fmt.Errorf("failed to write rollback history: %w", err)
+	}
+	return nil
+}
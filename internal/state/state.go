@@ -0,0 +1,95 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// State is what fake-compose remembers about a project between invocations
+type State struct {
+	// Volumes lists the volumes created by `up --cascade-volumes` that the
+	// paired `down --cascade-volumes` removes
+	Volumes []string `json:"volumes,omitempty"`
+	// Containers caches the container IDs of each service's replicas as of
+	// the last command that changed them. The container backend stays the
+	// source of truth; the cache is used when it cannot be queried.
+	Containers map[string][]string `json:"containers,omitempty"`
+}
+
+// Store persists a project's State as JSON under ~/.fake-compose/<project>
+type Store struct {
+	path string
+}
+
+// NewStore returns the store for a project. Nothing is written until Save.
+func NewStore(project string) (*Store, error) {
+	path, err := projectFile(project, "state.json")
+	if err != nil {
+		return nil, err
+	}
+	return &Store{path: path}, nil
+}
+
+// Load reads the stored state. A project without a state file has an empty
+// state.
+func (s *Store) Load() (*State, error) {
+	var st State
+	if err := readJSON(s.path, &st); err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	return &st, nil
+}
+
+// Save writes the state, replacing the previous file atomically
+func (s *Store) Save(st *State) error {
+	if err := writeJSON(s.path, st); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// projectFile returns the path of a file in the project's state directory
+func projectFile(project, name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate state directory: %w", err)
+	}
+	return filepath.Join(home, ".fake-compose", project, name), nil
+}
+
+// readJSON decodes the JSON file at path into v, leaving v untouched when
+// the file does not exist
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSON encodes v to path, replacing the previous file atomically
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
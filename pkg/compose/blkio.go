@@ -0,0 +1,53 @@
+package compose
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/docker/go-units"
+)
+
+// Block I/O weights, relative to other containers, range from 10 to 1000
+const (
+	MinBlkioWeight = 10
+	MaxBlkioWeight = 1000
+)
+
+// WeightDevice sets the block I/O weight of one device
+type WeightDevice struct {
+	Path   string `yaml:"path"`
+	Weight int    `yaml:"weight"`
+}
+
+// ThrottleDevice caps the I/O rate of one device. For device_read_bps and
+// device_write_bps Rate is a byte size such as 100mb, for device_read_iops
+// and device_write_iops a number of operations.
+type ThrottleDevice struct {
+	Path string `yaml:"path"`
+	Rate string `yaml:"rate"`
+}
+
+// BytesPerSecond parses Rate as a byte size
+func (d ThrottleDevice) BytesPerSecond() (uint64, error) {
+	rate, err := units.RAMInBytes(d.Rate)
+	if err != nil || rate < 0 {
+		return 0, fmt.Errorf("invalid rate %q for %s: must be a byte size such as 100mb", d.Rate, d.Path)
+	}
+	return uint64(rate), nil
+}
+
+// OpsPerSecond parses Rate as a number of operations
+func (d ThrottleDevice) OpsPerSecond() (uint64, error) {
+	rate, err := strconv.ParseUint(d.Rate, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q for %s: must be a number of operations", d.Rate, d.Path)
+	}
+	return rate, nil
+}
+
+// HasBlkio reports whether the service sets any block I/O weight or limit
+func (s *Service) HasBlkio() bool {
+	return s.BlkioWeight != 0 || len(s.BlkioWeightDevice) > 0 ||
+		len(s.DeviceReadBps) > 0 || len(s.DeviceWriteBps) > 0 ||
+		len(s.DeviceReadIOps) > 0 || len(s.DeviceWriteIOps) > 0
+}
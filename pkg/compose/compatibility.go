@@ -0,0 +1,105 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+)
+
+// binaryMemorySuffixes are the Kubernetes style memory suffixes, which
+// units.RAMInBytes does not know
+var binaryMemorySuffixes = map[string]int64{
+	"Ki": units.KiB,
+	"Mi": units.MiB,
+	"Gi": units.GiB,
+	"Ti": units.TiB,
+}
+
+// ParseCPUs parses a number of CPUs, such as 1.5 or the millicores 500m,
+// into nano CPUs
+func ParseCPUs(value string) (int64, error) {
+	cpus, err := strconv.ParseFloat(value, 64)
+	if millis, ok := strings.CutSuffix(value, "m"); ok {
+		cpus, err = strconv.ParseFloat(millis, 64)
+		cpus /= 1000
+	}
+	if err != nil || cpus <= 0 {
+		return 0, fmt.Errorf("invalid cpus %q: must be a positive number such as 0.5 or 500m", value)
+	}
+	return int64(cpus * 1e9), nil
+}
+
+// ParseMemory parses a memory size into bytes. Both Docker sizes such as
+// 512m and Kubernetes sizes such as 512Mi are accepted.
+func ParseMemory(value string) (int64, error) {
+	for suffix, unit := range binaryMemorySuffixes {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.ParseInt(number, 10, 64)
+			if err != nil || n <= 0 {
+				break
+			}
+			return n * unit, nil
+		}
+	}
+	size, err := units.RAMInBytes(value)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid memory size %q: must be a size such as 512m or 512Mi", value)
+	}
+	return size, nil
+}
+
+// ApplyCompatibility maps each service's deploy section onto the settings
+// a plain container understands, as --compatibility does:
+//
+//	deploy.resources.limits.cpu      -> cpus
+//	deploy.resources.limits.memory   -> mem_limit
+//	deploy.resources.requests.memory -> mem_reservation
+//	deploy.replicas                  -> --scale
+//
+// Settings given on the service itself win over deploy. The returned map
+// holds the scale of each service that sets replicas.
+func ApplyCompatibility(cf *ComposeFile) (map[string]int, error) {
+	// Sorted so the first invalid service reported is always the same
+	names := make([]string, 0, len(cf.Services))
+	for name := range cf.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	scale := make(map[string]int)
+	for _, name := range names {
+		service := cf.Services[name]
+		if service.Deploy == nil {
+			continue
+		}
+		if service.Deploy.Replicas > 0 {
+			scale[name] = service.Deploy.Replicas
+		}
+		resources := service.Deploy.Resources
+		if resources == nil {
+			continue
+		}
+		if service.CPUs == "" && resources.Limits.CPU != "" {
+			if _, err := ParseCPUs(resources.Limits.CPU); err != nil {
+				return nil, fmt.Errorf("service %s: deploy.resources.limits.cpu: %w", name, err)
+			}
+			service.CPUs = resources.Limits.CPU
+		}
+		if service.MemLimit == "" && resources.Limits.Memory != "" {
+			if _, err := ParseMemory(resources.Limits.Memory); err != nil {
+				return nil, fmt.Errorf("service %s: deploy.resources.limits.memory: %w", name, err)
+			}
+			service.MemLimit = resources.Limits.Memory
+		}
+		if service.MemReservation == "" && resources.Requests.Memory != "" {
+			if _, err := ParseMemory(resources.Requests.Memory); err != nil {
+				return nil, fmt.Errorf("service %s: deploy.resources.requests.memory: %w", name, err)
+			}
+			service.MemReservation = resources.Requests.Memory
+		}
+	}
+	return scale, nil
+}
@@ -0,0 +1,101 @@
+package compose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyCompatibility(t *testing.T) {
+	cf := &ComposeFile{
+		Services: map[string]*Service{
+			"web": {
+				Image: "nginx",
+				Deploy: &DeployConfig{
+					Replicas: 3,
+					Resources: &Resources{
+						Limits:   ResourceSpec{CPU: "0.5", Memory: "512m"},
+						Requests: ResourceSpec{Memory: "256Mi"},
+					},
+				},
+			},
+			// Settings on the service itself win over deploy
+			"db": {
+				Image:    "postgres",
+				CPUs:     "2",
+				MemLimit: "1g",
+				Deploy: &DeployConfig{
+					Resources: &Resources{Limits: ResourceSpec{CPU: "1", Memory: "2g"}},
+				},
+			},
+			"cache": {Image: "redis"},
+		},
+	}
+
+	scale, err := ApplyCompatibility(cf)
+	if err != nil {
+		t.Fatalf("ApplyCompatibility: %v", err)
+	}
+	if want := map[string]int{"web": 3}; !reflect.DeepEqual(scale, want) {
+		t.Errorf("scale = %v, want %v", scale, want)
+	}
+
+	web := cf.Services["web"]
+	if web.CPUs != "0.5" || web.MemLimit != "512m" || web.MemReservation != "256Mi" {
+		t.Errorf("web cpus %q, mem_limit %q, mem_reservation %q, want 0.5, 512m and 256Mi", web.CPUs, web.MemLimit, web.MemReservation)
+	}
+	db := cf.Services["db"]
+	if db.CPUs != "2" || db.MemLimit != "1g" {
+		t.Errorf("db cpus %q, mem_limit %q, want its own 2 and 1g", db.CPUs, db.MemLimit)
+	}
+	cache := cf.Services["cache"]
+	if cache.CPUs != "" || cache.MemLimit != "" || cache.MemReservation != "" {
+		t.Errorf("cache without deploy got limits %q, %q, %q", cache.CPUs, cache.MemLimit, cache.MemReservation)
+	}
+}
+
+func TestApplyCompatibilityInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources Resources
+		want      string
+	}{
+		{"cpu", Resources{Limits: ResourceSpec{CPU: "lots"}}, "service web: deploy.resources.limits.cpu"},
+		{"memory limit", Resources{Limits: ResourceSpec{Memory: "-1"}}, "service web: deploy.resources.limits.memory"},
+		{"memory request", Resources{Requests: ResourceSpec{Memory: "big"}}, "service web: deploy.resources.requests.memory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := tt.resources
+			cf := &ComposeFile{Services: map[string]*Service{
+				"web": {Image: "nginx", Deploy: &DeployConfig{Resources: &resources}},
+			}}
+			if _, err := ApplyCompatibility(cf); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ApplyCompatibility = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResources(t *testing.T) {
+	cpus := map[string]int64{"0.5": 5e8, "2": 2e9, "500m": 5e8}
+	for value, want := range cpus {
+		if got, err := ParseCPUs(value); err != nil || got != want {
+			t.Errorf("ParseCPUs(%q) = %d, %v, want %d", value, got, err, want)
+		}
+	}
+	memory := map[string]int64{"512m": 512 << 20, "512Mi": 512 << 20, "1g": 1 << 30, "2Gi": 2 << 30}
+	for value, want := range memory {
+		if got, err := ParseMemory(value); err != nil || got != want {
+			t.Errorf("ParseMemory(%q) = %d, %v, want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"0", "-1", "abc"} {
+		if _, err := ParseCPUs(value); err == nil {
+			t.Errorf("ParseCPUs(%q) succeeded", value)
+		}
+		if _, err := ParseMemory(value); err == nil {
+			t.Errorf("ParseMemory(%q) succeeded", value)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TestCommand returns the Docker healthcheck test for the check. The
+// http_get and tcp_socket probes are translated to CMD-SHELL commands using
+// curl and nc, so those tools have to exist in the image.
+func (h *HealthCheck) TestCommand() []string {
+	switch {
+	case h.HTTPGet != nil:
+		path := h.HTTPGet.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		cmd := "curl -fsS"
+		for _, header := range h.HTTPGet.HTTPHeaders {
+			cmd += " -H " + ShellQuote(header.Name+": "+header.Value)
+		}
+		cmd += " " + ShellQuote(fmt.Sprintf("http://localhost:%d%s", h.HTTPGet.Port, path))
+		return []string{"CMD-SHELL", cmd + " || exit 1"}
+	case h.TCPSocket != nil:
+		return []string{"CMD-SHELL", fmt.Sprintf("nc -z localhost %d || exit 1", h.TCPSocket.Port)}
+	default:
+		return h.Test
+	}
+}
@@ -0,0 +1,22 @@
+package compose
+
+// HookPhase is the list of hooks configured for one lifecycle phase
+type HookPhase struct {
+	Name  string
+	Hooks []Hook
+}
+
+// Phases returns every phase's hooks, named by their compose keys, in
+// lifecycle order. Phases without hooks are included.
+func (h *Hooks) Phases() []HookPhase {
+	return []HookPhase{
+		{"pre_start", h.PreStart},
+		{"post_start", h.PostStart},
+		{"pre_stop", h.PreStop},
+		{"post_stop", h.PostStop},
+		{"pre_build", h.PreBuild},
+		{"post_build", h.PostBuild},
+		{"pre_deploy", h.PreDeploy},
+		{"post_deploy", h.PostDeploy},
+	}
+}
@@ -0,0 +1,10 @@
+package compose
+
+import "strings"
+
+// NetworkModeService returns the service whose network namespace a
+// "service:<name>" network_mode joins
+func (s *Service) NetworkModeService() (string, bool) {
+	name, ok := strings.CutPrefix(s.NetworkMode, "service:")
+	return name, ok && name != ""
+}
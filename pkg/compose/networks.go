@@ -0,0 +1,43 @@
+package compose
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ServiceNetwork holds a service's settings on one of its networks
+type ServiceNetwork struct {
+	Aliases     []string `yaml:"aliases,omitempty"`
+	IPv4Address string   `yaml:"ipv4_address,omitempty"`
+}
+
+// ServiceNetworks maps the networks a service attaches to onto their
+// settings. Networks given in the short list form have nil settings.
+type ServiceNetworks map[string]*ServiceNetwork
+
+// UnmarshalYAML accepts both `networks: [a, b]` and the long form
+// `networks: {a: {aliases: [...], ipv4_address: ...}}`
+func (n *ServiceNetworks) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		*n = make(ServiceNetworks, len(names))
+		for _, name := range names {
+			(*n)[name] = nil
+		}
+		return nil
+	case yaml.MappingNode:
+		var networks map[string]*ServiceNetwork
+		if err := node.Decode(&networks); err != nil {
+			return err
+		}
+		*n = networks
+		return nil
+	default:
+		return fmt.Errorf("line %d: networks must be a list or a map", node.Line)
+	}
+}
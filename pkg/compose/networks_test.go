@@ -0,0 +1,42 @@
+package compose
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestServiceNetworksUnmarshal(t *testing.T) {
+	var short struct{ Networks ServiceNetworks }
+	if err := yaml.Unmarshal([]byte("networks: [front, back]"), &short); err != nil {
+		t.Fatalf("short form: %v", err)
+	}
+	if len(short.Networks) != 2 || short.Networks["front"] != nil || short.Networks["back"] != nil {
+		t.Errorf("short form = %v, want front and back without settings", short.Networks)
+	}
+
+	var long struct{ Networks ServiceNetworks }
+	err := yaml.Unmarshal([]byte(`
+networks:
+  back:
+    aliases: [api, api.internal]
+    ipv4_address: 172.28.0.10
+  front:
+`), &long)
+	if err != nil {
+		t.Fatalf("long form: %v", err)
+	}
+	back := long.Networks["back"]
+	if back == nil || strings.Join(back.Aliases, ",") != "api,api.internal" || back.IPv4Address != "172.28.0.10" {
+		t.Errorf("back = %+v, want aliases api, api.internal and address 172.28.0.10", back)
+	}
+	if settings, ok := long.Networks["front"]; !ok || settings != nil {
+		t.Errorf("front = %+v, %v, want attached without settings", settings, ok)
+	}
+
+	var bad struct{ Networks ServiceNetworks }
+	if err := yaml.Unmarshal([]byte("networks: front"), &bad); err == nil || !strings.Contains(err.Error(), "must be a list or a map") {
+		t.Errorf("scalar networks error = %v, want a list or map error", err)
+	}
+}
@@ -0,0 +1,180 @@
+package compose
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PortConfig is the long form of a service port
+type PortConfig struct {
+	Target    int    `yaml:"target"`
+	Published string `yaml:"published,omitempty"`
+	HostIP    string `yaml:"host_ip,omitempty"`
+	Protocol  string `yaml:"protocol"`
+	Mode      string `yaml:"mode"`
+}
+
+// ParsePort expands a "[[host_ip:]published:]target[/protocol]" port
+func ParsePort(spec string) (PortConfig, error) {
+	port := PortConfig{Protocol: "tcp", Mode: "ingress"}
+
+	rest := spec
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		rest, port.Protocol = rest[:i], rest[i+1:]
+	}
+
+	// The host IP may be a bracketed IPv6 address containing colons
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]:")
+		if end < 0 {
+			return PortConfig{}, fmt.Errorf("invalid port %q", spec)
+		}
+		port.HostIP, rest = rest[1:end], rest[end+2:]
+	}
+
+	parts := strings.Split(rest, ":")
+	switch len(parts) {
+	case 1:
+	case 2:
+		port.Published = parts[0]
+	case 3:
+		if port.HostIP != "" {
+			return PortConfig{}, fmt.Errorf("invalid port %q", spec)
+		}
+		port.HostIP, port.Published = parts[0], parts[1]
+	default:
+		return PortConfig{}, fmt.Errorf("invalid port %q", spec)
+	}
+
+	target, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil || target < 1 || target > 65535 {
+		return PortConfig{}, fmt.Errorf("invalid port %q: bad container port", spec)
+	}
+	port.Target = target
+	return port, nil
+}
+
+// VolumeConfig is the long form of a service volume
+type VolumeConfig struct {
+	Type        string       `yaml:"type"`
+	Source      string       `yaml:"source,omitempty"`
+	Target      string       `yaml:"target"`
+	ReadOnly    bool         `yaml:"read_only,omitempty"`
+	Bind        *BindOptions `yaml:"bind,omitempty"`
+	Consistency string       `yaml:"consistency,omitempty"`
+}
+
+// ParseVolume expands a "[source:]target[:mode]" volume. Sources that look
+// like paths are bind mounts, other sources are named volumes and a bare
+// target is an anonymous volume.
+func ParseVolume(spec string) (VolumeConfig, error) {
+	parts := strings.Split(spec, ":")
+	volume := VolumeConfig{Type: "volume"}
+
+	switch len(parts) {
+	case 1:
+		volume.Target = parts[0]
+	case 2, 3:
+		volume.Source, volume.Target = parts[0], parts[1]
+		if len(parts) == 3 {
+			for _, opt := range strings.Split(parts[2], ",") {
+				if opt == "ro" {
+					volume.ReadOnly = true
+				}
+			}
+		}
+		if strings.HasPrefix(volume.Source, ".") || strings.HasPrefix(volume.Source, "/") || strings.HasPrefix(volume.Source, "~") {
+			volume.Type = "bind"
+		}
+	default:
+		return VolumeConfig{}, fmt.Errorf("invalid volume %q", spec)
+	}
+
+	if volume.Target == "" {
+		return VolumeConfig{}, fmt.Errorf("invalid volume %q: missing target", spec)
+	}
+	return volume, nil
+}
+
+// Normalize renders the compose file in canonical form, the way other tools
+// expect to consume it: the project name is recorded, depends_on conditions
+// default to service_started, and ports and volumes use their long forms.
+func Normalize(cf *ComposeFile, project string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := doc.Encode(cf); err != nil {
+		return nil, err
+	}
+	doc.Content = append([]*yaml.Node{scalarNode("name"), scalarNode(project)}, doc.Content...)
+
+	services := mappingValue(&doc, "services")
+	if services == nil {
+		return &doc, nil
+	}
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name, service := services.Content[i].Value, services.Content[i+1]
+		if err := normalizeService(name, service); err != nil {
+			return nil, err
+		}
+	}
+	return &doc, nil
+}
+
+func normalizeService(name string, service *yaml.Node) error {
+	if dependsOn := mappingValue(service, "depends_on"); dependsOn != nil {
+		for i := 1; i < len(dependsOn.Content); i += 2 {
+			dep := dependsOn.Content[i]
+			if mappingValue(dep, "condition") == nil {
+				dep.Kind, dep.Tag, dep.Style = yaml.MappingNode, "!!map", 0
+				dep.Content = append(dep.Content, scalarNode("condition"), scalarNode("service_started"))
+			}
+		}
+	}
+
+	if ports := mappingValue(service, "ports"); ports != nil {
+		for i, item := range ports.Content {
+			port, err := ParsePort(item.Value)
+			if err != nil {
+				return fmt.Errorf("service %s: %w", name, err)
+			}
+			if err := ports.Content[i].Encode(port); err != nil {
+				return err
+			}
+		}
+	}
+
+	if volumes := mappingValue(service, "volumes"); volumes != nil {
+		for i, item := range volumes.Content {
+			if item.Kind != yaml.ScalarNode {
+				continue
+			}
+			volume, err := ParseVolume(item.Value)
+			if err != nil {
+				return fmt.Errorf("service %s: %w", name, err)
+			}
+			if err := volumes.Content[i].Encode(volume); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,42 @@
+package compose
+
+// RunOverrides holds the per-invocation settings of a one-off `run` that
+// take precedence over the service definition
+type RunOverrides struct {
+	Command     []string
+	Environment map[string]string
+	Ports       []string
+	Volumes     []VolumeMount
+	WorkingDir  string
+	User        string
+}
+
+// WithRunOverrides returns a copy of the service with the overrides applied.
+// Environment entries replace variables of the same name while ports and
+// volumes are added to the service's own. The receiver is left untouched so
+// the parsed compose file can be reused.
+func (s *Service) WithRunOverrides(o RunOverrides) *Service {
+	merged := *s
+
+	merged.Environment = make(map[string]string, len(s.Environment)+len(o.Environment))
+	for key, value := range s.Environment {
+		merged.Environment[key] = value
+	}
+	for key, value := range o.Environment {
+		merged.Environment[key] = value
+	}
+
+	merged.Ports = append(append([]string(nil), s.Ports...), o.Ports...)
+	merged.Volumes = append(append([]VolumeMount(nil), s.Volumes...), o.Volumes...)
+
+	if len(o.Command) > 0 {
+		merged.Command = o.Command
+	}
+	if o.WorkingDir != "" {
+		merged.WorkingDir = o.WorkingDir
+	}
+	if o.User != "" {
+		merged.User = o.User
+	}
+	return &merged
+}
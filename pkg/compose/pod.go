@@ -0,0 +1,46 @@
+package compose
+
+import "sort"
+
+// Pods groups services by their x-pod value, like containers of a
+// Kubernetes pod. Each group is sorted by name; its first service holds
+// the pod's network namespace and the others join it.
+func Pods(services map[string]*Service) map[string][]string {
+	pods := make(map[string][]string)
+	for name, service := range services {
+		if service.Pod != "" {
+			pods[service.Pod] = append(pods[service.Pod], name)
+		}
+	}
+	for _, members := range pods {
+		sort.Strings(members)
+	}
+	return pods
+}
+
+// JoinPods makes every service of a pod but the first join the first one's
+// network namespace through a service:<name> network_mode. Their ports and
+// networks move to the first service, since a container sharing another's
+// namespace can neither publish ports nor attach to networks itself.
+func JoinPods(services map[string]*Service) {
+	for _, members := range Pods(services) {
+		holder := services[members[0]]
+		for _, name := range members[1:] {
+			service := services[name]
+			service.NetworkMode = "service:" + members[0]
+
+			holder.Ports = append(holder.Ports, service.Ports...)
+			service.Ports = nil
+
+			for network, settings := range service.Networks {
+				if holder.Networks == nil {
+					holder.Networks = make(ServiceNetworks)
+				}
+				if _, exists := holder.Networks[network]; !exists {
+					holder.Networks[network] = settings
+				}
+			}
+			service.Networks = nil
+		}
+	}
+}
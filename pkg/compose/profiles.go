@@ -0,0 +1,28 @@
+package compose
+
+import "slices"
+
+// ProfileEnabled reports whether a service is active with the given
+// profiles enabled. Services without profiles are always enabled, and the
+// profile "*" enables every service.
+func (s *Service) ProfileEnabled(active []string) bool {
+	if len(s.Profiles) == 0 || slices.Contains(active, "*") {
+		return true
+	}
+	for _, profile := range s.Profiles {
+		if profile == "*" || slices.Contains(active, profile) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterProfiles drops the services that are not enabled by the given
+// profiles
+func FilterProfiles(cf *ComposeFile, active []string) {
+	for name, service := range cf.Services {
+		if !service.ProfileEnabled(active) {
+			delete(cf.Services, name)
+		}
+	}
+}
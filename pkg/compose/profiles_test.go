@@ -0,0 +1,52 @@
+package compose
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestServiceProfileEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []string
+		active   []string
+		want     bool
+	}{
+		{"no profiles", nil, nil, true},
+		{"no profiles with active", nil, []string{"debug"}, true},
+		{"inactive profile", []string{"debug"}, nil, false},
+		{"active profile", []string{"debug"}, []string{"debug"}, true},
+		{"one of several", []string{"debug", "tools"}, []string{"tools"}, true},
+		{"other profile", []string{"debug"}, []string{"tools"}, false},
+		{"all profiles", []string{"debug"}, []string{"*"}, true},
+		{"service in every profile", []string{"*"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{Profiles: tt.profiles}
+			if got := service.ProfileEnabled(tt.active); got != tt.want {
+				t.Errorf("ProfileEnabled(%v) with profiles %v = %v, want %v", tt.active, tt.profiles, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterProfiles(t *testing.T) {
+	cf := &ComposeFile{Services: map[string]*Service{
+		"web":     {},
+		"debug":   {Profiles: []string{"debug"}},
+		"adminer": {Profiles: []string{"tools"}},
+	}}
+
+	FilterProfiles(cf, []string{"tools"})
+
+	names := make([]string, 0, len(cf.Services))
+	for name := range cf.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "adminer,web" {
+		t.Errorf("services = %s, want adminer,web", got)
+	}
+}
@@ -0,0 +1,79 @@
+package compose
+
+import (
+	"fmt"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretsDir is where secrets are mounted in a container unless their
+// target says otherwise
+const SecretsDir = "/run/secrets"
+
+// SecretRef grants a service one of the top-level secrets, given either by
+// name or as a mapping with a source and target
+type SecretRef struct {
+	Source string `yaml:"source"`
+	// Target is where the secret is mounted, /run/secrets/<source> by
+	// default. A relative target is taken inside /run/secrets.
+	Target string `yaml:"target,omitempty"`
+	// File is the secret's file on the host, set from the top-level
+	// definition by ResolveSecrets
+	File string `yaml:"-"`
+}
+
+// UnmarshalYAML accepts both `- db_password` and the long form
+// `- {source: db_password, target: /etc/db/password}`
+func (r *SecretRef) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*r = SecretRef{Source: node.Value}
+		return nil
+	case yaml.MappingNode:
+		// The alias has no UnmarshalYAML, so decoding into it does not
+		// recurse
+		type secretRef SecretRef
+		var ref secretRef
+		if err := node.Decode(&ref); err != nil {
+			return err
+		}
+		*r = SecretRef(ref)
+		return nil
+	default:
+		return fmt.Errorf("line %d: a secret must be a string or a map", node.Line)
+	}
+}
+
+// MarshalYAML writes a secret without a target in the short form
+func (r SecretRef) MarshalYAML() (interface{}, error) {
+	if r.Target == "" {
+		return r.Source, nil
+	}
+	type secretRef SecretRef
+	return secretRef(r), nil
+}
+
+// TargetPath returns the path the secret is mounted at in the container
+func (r SecretRef) TargetPath() string {
+	switch {
+	case r.Target == "":
+		return path.Join(SecretsDir, r.Source)
+	case path.IsAbs(r.Target):
+		return r.Target
+	default:
+		return path.Join(SecretsDir, r.Target)
+	}
+}
+
+// ResolveSecrets points each service's secret references at the files of
+// the top-level secrets they name
+func ResolveSecrets(cf *ComposeFile) {
+	for _, service := range cf.Services {
+		for i, ref := range service.Secrets {
+			if secret := cf.Secrets[ref.Source]; secret != nil {
+				service.Secrets[i].File = secret.File
+			}
+		}
+	}
+}
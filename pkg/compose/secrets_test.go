@@ -0,0 +1,105 @@
+package compose
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSecretRefUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []SecretRef
+	}{
+		{
+			name: "short form",
+			yaml: "[db_password]",
+			want: []SecretRef{{Source: "db_password"}},
+		},
+		{
+			name: "long form",
+			yaml: "[{source: db_password, target: /etc/db/password}]",
+			want: []SecretRef{{Source: "db_password", Target: "/etc/db/password"}},
+		},
+		{
+			name: "mixed",
+			yaml: "[api_key, {source: tls_key, target: tls.key}]",
+			want: []SecretRef{{Source: "api_key"}, {Source: "tls_key", Target: "tls.key"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []SecretRef
+			if err := yaml.Unmarshal([]byte(tt.yaml), &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("secret %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSecretRefUnmarshalYAMLRejectsSequence(t *testing.T) {
+	var got []SecretRef
+	if err := yaml.Unmarshal([]byte("[[db_password]]"), &got); err == nil {
+		t.Errorf("Unmarshal accepted a nested list: %+v", got)
+	}
+}
+
+func TestSecretRefMarshalYAML(t *testing.T) {
+	out, err := yaml.Marshal([]SecretRef{{Source: "api_key"}, {Source: "tls_key", Target: "tls.key"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "- api_key\n- source: tls_key\n  target: tls.key\n"
+	if string(out) != want {
+		t.Errorf("Marshal = %q, want %q", out, want)
+	}
+}
+
+func TestSecretRefTargetPath(t *testing.T) {
+	tests := []struct {
+		ref  SecretRef
+		want string
+	}{
+		{SecretRef{Source: "db_password"}, "/run/secrets/db_password"},
+		{SecretRef{Source: "db_password", Target: "/etc/db/password"}, "/etc/db/password"},
+		{SecretRef{Source: "tls_key", Target: "tls/server.key"}, "/run/secrets/tls/server.key"},
+	}
+	for _, tt := range tests {
+		if got := tt.ref.TargetPath(); got != tt.want {
+			t.Errorf("%+v.TargetPath() = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	cf := &ComposeFile{
+		Secrets: map[string]*Secret{
+			"db_password": {File: "/srv/secrets/db_password.txt"},
+		},
+		Services: map[string]*Service{
+			"db":  {Secrets: []SecretRef{{Source: "db_password"}}},
+			"web": {Secrets: []SecretRef{{Source: "db_password", Target: "/etc/db"}, {Source: "undefined"}}},
+		},
+	}
+
+	ResolveSecrets(cf)
+
+	if got := cf.Services["db"].Secrets[0].File; got != "/srv/secrets/db_password.txt" {
+		t.Errorf("db secret file = %q", got)
+	}
+	if got := cf.Services["web"].Secrets[0].File; got != "/srv/secrets/db_password.txt" {
+		t.Errorf("web secret file = %q", got)
+	}
+	if got := cf.Services["web"].Secrets[1].File; got != "" {
+		t.Errorf("undefined secret resolved to %q", got)
+	}
+}
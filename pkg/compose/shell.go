@@ -0,0 +1,12 @@
+package compose
+
+import "strings"
+
+// ShellQuote quotes s for use as a single sh word, leaving words that sh
+// reads literally as they are
+func ShellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@%+,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
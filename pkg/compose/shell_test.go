@@ -0,0 +1,41 @@
+package compose
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"nginx", "nginx"},
+		{"http://localhost:8080/health", "http://localhost:8080/health"},
+		{"KEY=a,b+c@d%e", "KEY=a,b+c@d%e"},
+		{"", "''"},
+		{"two words", "'two words'"},
+		{"$HOME", "'$HOME'"},
+		{"it's", `'it'\''s'`},
+		{"a;rm -rf /", "'a;rm -rf /'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := ShellQuote(tt.in); got != tt.want {
+				t.Errorf("ShellQuote(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthCheckHTTPGetCommand(t *testing.T) {
+	check := &HealthCheck{HTTPGet: &HTTPProbe{
+		Path:        "health",
+		Port:        8080,
+		HTTPHeaders: []Header{{Name: "Authorization", Value: "Bearer it's"}},
+	}}
+	want := []string{"CMD-SHELL", `curl -fsS -H 'Authorization: Bearer it'\''s' http://localhost:8080/health || exit 1`}
+	if got := check.TestCommand(); !slices.Equal(got, want) {
+		t.Errorf("TestCommand = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+package compose
+
+// StripExtensions removes the fake-compose extensions from the compose
+// file so stock Docker Compose accepts it: init and post containers, hooks,
+// cloud_native settings, digest, init_process and log_level. Healthcheck
+// probes are rewritten to the equivalent test command, and deploy
+// resources, which use Kubernetes-style requests, are dropped.
+func StripExtensions(cf *ComposeFile) {
+	cf.GlobalHooks = nil
+	for _, service := range cf.Services {
+		service.InitContainers = nil
+		service.PostContainers = nil
+		service.Hooks = nil
+		service.CloudNative = nil
+		service.Digest = ""
+		service.InitProcess = ""
+		service.LogLevel = ""
+
+		if hc := service.HealthCheck; hc != nil && (hc.HTTPGet != nil || hc.TCPSocket != nil) {
+			hc.Test = hc.TestCommand()
+			hc.HTTPGet = nil
+			hc.TCPSocket = nil
+		}
+		if service.Deploy != nil {
+			service.Deploy.Resources = nil
+		}
+	}
+}
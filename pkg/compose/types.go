@@ -11,19 +11,31 @@ type ComposeFile struct {
 	Volumes  map[string]*Volume     `yaml:"volumes,omitempty"`
 	Configs  map[string]*Config     `yaml:"configs,omitempty"`
 	Secrets  map[string]*Secret     `yaml:"secrets,omitempty"`
+	// GlobalHooks are deployment-level hooks; only pre_deploy and
+	// post_deploy apply
+	GlobalHooks *Hooks              `yaml:"hooks,omitempty"`
 	Extensions map[string]interface{} `yaml:"x-,inline"`
 }
 
 type Service struct {
 	Image           string                 `yaml:"image,omitempty"`
+	Digest          string                `yaml:"digest,omitempty"`
 	Build           *BuildConfig          `yaml:"build,omitempty"`
 	Command         []string              `yaml:"command,omitempty"`
 	Entrypoint      []string              `yaml:"entrypoint,omitempty"`
+	WorkingDir      string                `yaml:"working_dir,omitempty"`
+	User            string                `yaml:"user,omitempty"`
+	Init            *bool                 `yaml:"init,omitempty"`
+	InitProcess     string                `yaml:"init_process,omitempty"`
+	StopSignal      string                `yaml:"stop_signal,omitempty"`
 	Environment     map[string]string     `yaml:"environment,omitempty"`
 	EnvFile         []string              `yaml:"env_file,omitempty"`
 	Ports           []string              `yaml:"ports,omitempty"`
-	Volumes         []string              `yaml:"volumes,omitempty"`
-	Networks        []string              `yaml:"networks,omitempty"`
+	Volumes         []VolumeMount         `yaml:"volumes,omitempty"`
+	VolumesFrom     []string              `yaml:"volumes_from,omitempty"`
+	Networks        ServiceNetworks       `yaml:"networks,omitempty"`
+	NetworkMode     string                `yaml:"network_mode,omitempty"`
+	ExtraHosts      []string              `yaml:"extra_hosts,omitempty"`
 	DependsOn       map[string]DependsOn  `yaml:"depends_on,omitempty"`
 	Deploy          *DeployConfig         `yaml:"deploy,omitempty"`
 	HealthCheck     *HealthCheck          `yaml:"healthcheck,omitempty"`
@@ -33,21 +45,54 @@ type Service struct {
 	PostContainers  []PostContainer       `yaml:"post_containers,omitempty"`
 	Hooks           *Hooks                `yaml:"hooks,omitempty"`
 	CloudNative     *CloudNativeConfig    `yaml:"cloud_native,omitempty"`
+	Profiles        []string              `yaml:"profiles,omitempty"`
+	LogLevel        string                `yaml:"log_level,omitempty"`
+	// Pod groups services sharing a network namespace, see Pods
+	Pod             string                `yaml:"x-pod,omitempty"`
+	// Block I/O weights and throttles, applied through the daemon's blkio
+	// cgroup
+	BlkioWeight       int              `yaml:"blkio_weight,omitempty"`
+	BlkioWeightDevice []WeightDevice   `yaml:"blkio_weight_device,omitempty"`
+	DeviceReadBps     []ThrottleDevice `yaml:"device_read_bps,omitempty"`
+	DeviceWriteBps    []ThrottleDevice `yaml:"device_write_bps,omitempty"`
+	DeviceReadIOps    []ThrottleDevice `yaml:"device_read_iops,omitempty"`
+	DeviceWriteIOps   []ThrottleDevice `yaml:"device_write_iops,omitempty"`
+	// MemSwappiness (0 to 100) and OomKillDisable are pointers so an
+	// explicit 0 or false is told apart from the daemon default
+	MemSwappiness  *int  `yaml:"mem_swappiness,omitempty"`
+	OomKillDisable *bool `yaml:"oom_kill_disable,omitempty"`
+	// CPU and memory limits of the container, set by --compatibility from
+	// deploy.resources when not given here
+	CPUs           string `yaml:"cpus,omitempty"`
+	MemLimit       string `yaml:"mem_limit,omitempty"`
+	MemReservation string `yaml:"mem_reservation,omitempty"`
+	// Real-time scheduling: microseconds of each period the container's
+	// real-time tasks may run for, and the period itself
+	CPURtRuntime int64 `yaml:"cpu_rt_runtime,omitempty"`
+	CPURtPeriod  int64 `yaml:"cpu_rt_period,omitempty"`
+	// Secrets are mounted read-only, see SecretRef
+	Secrets []SecretRef `yaml:"secrets,omitempty"`
 }
 
 type InitContainer struct {
 	Name        string            `yaml:"name"`
 	Image       string            `yaml:"image"`
 	Command     []string          `yaml:"command,omitempty"`
+	Entrypoint  []string          `yaml:"entrypoint,omitempty"`
+	WorkingDir  string            `yaml:"working_dir,omitempty"`
 	Environment map[string]string `yaml:"environment,omitempty"`
 	Volumes     []string          `yaml:"volumes,omitempty"`
 	Resources   *Resources        `yaml:"resources,omitempty"`
+	Retries     int               `yaml:"retries,omitempty"`
+	RetryDelay  string            `yaml:"retry_delay,omitempty"`
 }
 
 type PostContainer struct {
 	Name        string            `yaml:"name"`
 	Image       string            `yaml:"image"`
 	Command     []string          `yaml:"command,omitempty"`
+	Entrypoint  []string          `yaml:"entrypoint,omitempty"`
+	WorkingDir  string            `yaml:"working_dir,omitempty"`
 	Environment map[string]string `yaml:"environment,omitempty"`
 	Volumes     []string          `yaml:"volumes,omitempty"`
 	WaitFor     string            `yaml:"wait_for,omitempty"`
@@ -64,17 +109,31 @@ type Hooks struct {
 	PostBuild   []Hook `yaml:"post_build,omitempty"`
 	PreDeploy   []Hook `yaml:"pre_deploy,omitempty"`
 	PostDeploy  []Hook `yaml:"post_deploy,omitempty"`
+	// ListTimeout bounds each list of hooks as a whole, retries included,
+	// where a hook's own timeout bounds a single attempt
+	ListTimeout time.Duration `yaml:"list_timeout,omitempty"`
 }
 
 type Hook struct {
-	Name    string            `yaml:"name"`
-	Type    string            `yaml:"type"`
-	Command []string          `yaml:"command,omitempty"`
-	Script  string            `yaml:"script,omitempty"`
-	HTTP    *HTTPHook         `yaml:"http,omitempty"`
-	Exec    *ExecHook         `yaml:"exec,omitempty"`
-	Timeout time.Duration     `yaml:"timeout,omitempty"`
-	Retries int               `yaml:"retries,omitempty"`
+	Name     string            `yaml:"name"`
+	Type     string            `yaml:"type"`
+	Command  []string          `yaml:"command,omitempty"`
+	Script   string            `yaml:"script,omitempty"`
+	HTTP     *HTTPHook         `yaml:"http,omitempty"`
+	Exec     *ExecHook         `yaml:"exec,omitempty"`
+	// Env adds variables to the environment of command and script hooks,
+	// and is substituted into ${VAR} references in http hook headers and
+	// bodies
+	Env      map[string]string `yaml:"env,omitempty"`
+	Timeout  time.Duration     `yaml:"timeout,omitempty"`
+	Retries  int               `yaml:"retries,omitempty"`
+	Parallel bool              `yaml:"parallel,omitempty"`
+	When     string            `yaml:"when,omitempty"`
+	// RetryWhen is a Go template evaluated against the failed attempt's
+	// hooks.HookResult, such as '{{eq .ExitCode 1}}'. When set, a failure
+	// is retried only if it renders "true".
+	RetryWhen string           `yaml:"retry_when,omitempty"`
+	Plugin    *PluginHook      `yaml:"plugin,omitempty"`
 }
 
 type HTTPHook struct {
@@ -89,6 +148,13 @@ type ExecHook struct {
 	Command   []string `yaml:"command"`
 }
 
+// PluginHook runs an external plugin binary, such as
+// fake-compose-hook-vault, passing it Config as JSON
+type PluginHook struct {
+	Name   string                 `yaml:"name"`
+	Config map[string]interface{} `yaml:"config,omitempty"`
+}
+
 type CloudNativeConfig struct {
 	Kubernetes  *KubernetesConfig  `yaml:"kubernetes,omitempty"`
 	Helm        *HelmConfig        `yaml:"helm,omitempty"`
@@ -126,6 +192,11 @@ type BuildConfig struct {
 	Dockerfile string            `yaml:"dockerfile,omitempty"`
 	Args       map[string]string `yaml:"args,omitempty"`
 	Target     string            `yaml:"target,omitempty"`
+	// CacheFrom lists images whose layers the build may reuse
+	CacheFrom  []string          `yaml:"cache_from,omitempty"`
+	// CacheTo exports the build cache; only "inline" is supported, which
+	// embeds it in the built image for later cache_from use
+	CacheTo    string            `yaml:"cache_to,omitempty"`
 }
 
 type DeployConfig struct {
@@ -150,10 +221,32 @@ type HealthCheck struct {
 	Timeout     time.Duration `yaml:"timeout,omitempty"`
 	Retries     int           `yaml:"retries,omitempty"`
 	StartPeriod time.Duration `yaml:"start_period,omitempty"`
+	// HTTPGet and TCPSocket are fake-compose extensions modelled on
+	// Kubernetes probes, used instead of test
+	HTTPGet     *HTTPProbe    `yaml:"http_get,omitempty"`
+	TCPSocket   *TCPProbe     `yaml:"tcp_socket,omitempty"`
+}
+
+type HTTPProbe struct {
+	Path        string   `yaml:"path,omitempty"`
+	Port        int      `yaml:"port"`
+	HTTPHeaders []Header `yaml:"http_headers,omitempty"`
+}
+
+type Header struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type TCPProbe struct {
+	Port int `yaml:"port"`
 }
 
 type DependsOn struct {
 	Condition string `yaml:"condition,omitempty"`
+	// Restart restarts the dependent service after the `restart` command
+	// restarts this dependency
+	Restart bool `yaml:"restart,omitempty"`
 }
 
 type Network struct {
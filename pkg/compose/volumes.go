@@ -0,0 +1,119 @@
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BindOptions are the long form settings of a bind mount
+type BindOptions struct {
+	// Propagation is shared, slave, private, rshared, rslave or rprivate
+	Propagation string `yaml:"propagation,omitempty"`
+	// SELinux relabels the source for this container only (Z) or for all
+	// containers sharing it (z)
+	SELinux string `yaml:"selinux,omitempty"`
+}
+
+// VolumeMount is a service volume, given either in the short
+// "[source:]target[:mode]" form or as a long form mapping
+type VolumeMount struct {
+	VolumeConfig
+	// Spec is the short form the volume was given in, empty for the long
+	// form
+	Spec string
+}
+
+var (
+	volumeTypes         = []string{"bind", "volume", "tmpfs"}
+	bindPropagations    = []string{"shared", "slave", "private", "rshared", "rslave", "rprivate"}
+	bindSELinuxLabels   = []string{"z", "Z"}
+	volumeConsistencies = []string{"consistent", "cached", "delegated"}
+)
+
+// ParseVolumeMount parses a short form volume
+func ParseVolumeMount(spec string) (VolumeMount, error) {
+	volume, err := ParseVolume(spec)
+	if err != nil {
+		return VolumeMount{}, err
+	}
+	return VolumeMount{VolumeConfig: volume, Spec: spec}, nil
+}
+
+// UnmarshalYAML accepts both `- ./data:/data:ro` and the long form
+// `- {type: bind, source: ./data, target: /data, bind: {propagation: rshared}}`
+func (v *VolumeMount) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		volume, err := ParseVolumeMount(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*v = volume
+		return nil
+	case yaml.MappingNode:
+		var volume VolumeConfig
+		if err := node.Decode(&volume); err != nil {
+			return err
+		}
+		if err := volume.validate(); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*v = VolumeMount{VolumeConfig: volume}
+		return nil
+	default:
+		return fmt.Errorf("line %d: a volume must be a string or a map", node.Line)
+	}
+}
+
+// MarshalYAML writes the volume back in the form it was given in
+func (v VolumeMount) MarshalYAML() (interface{}, error) {
+	if v.Spec != "" {
+		return v.Spec, nil
+	}
+	return v.VolumeConfig, nil
+}
+
+// String returns the short form of the volume. Long form settings without
+// a short form equivalent, such as bind propagation, are left out.
+func (v VolumeMount) String() string {
+	if v.Spec != "" {
+		return v.Spec
+	}
+	if v.Source == "" {
+		return v.Target
+	}
+	if v.ReadOnly {
+		return v.Source + ":" + v.Target + ":ro"
+	}
+	return v.Source + ":" + v.Target
+}
+
+func (v VolumeConfig) validate() error {
+	if !slices.Contains(volumeTypes, v.Type) {
+		return fmt.Errorf("invalid volume type %q: must be %s", v.Type, strings.Join(volumeTypes, ", "))
+	}
+	if v.Target == "" {
+		return fmt.Errorf("volume of type %s is missing a target", v.Type)
+	}
+	if v.Type == "bind" && v.Source == "" {
+		return fmt.Errorf("bind mount of %s is missing a source", v.Target)
+	}
+	if v.Bind != nil {
+		if v.Type != "bind" {
+			return fmt.Errorf("volume of type %s cannot have bind options", v.Type)
+		}
+		if v.Bind.Propagation != "" && !slices.Contains(bindPropagations, v.Bind.Propagation) {
+			return fmt.Errorf("invalid bind propagation %q: must be %s", v.Bind.Propagation, strings.Join(bindPropagations, ", "))
+		}
+		if v.Bind.SELinux != "" && !slices.Contains(bindSELinuxLabels, v.Bind.SELinux) {
+			return fmt.Errorf("invalid bind selinux %q: must be z or Z", v.Bind.SELinux)
+		}
+	}
+	if v.Consistency != "" && !slices.Contains(volumeConsistencies, v.Consistency) {
+		return fmt.Errorf("invalid volume consistency %q: must be %s", v.Consistency, strings.Join(volumeConsistencies, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VolumesFromRef is a parsed volumes_from entry: "<service>" or
+// "container:<name>", optionally followed by ":ro" or ":rw"
+type VolumesFromRef struct {
+	Service   string
+	Container string
+	Mode      string
+}
+
+// ParseVolumesFrom parses a volumes_from entry
+func ParseVolumesFrom(entry string) (VolumesFromRef, error) {
+	var ref VolumesFromRef
+	if name, ok := strings.CutPrefix(entry, "container:"); ok {
+		ref.Container, ref.Mode, _ = strings.Cut(name, ":")
+	} else {
+		ref.Service, ref.Mode, _ = strings.Cut(entry, ":")
+	}
+
+	switch {
+	case ref.Service == "" && ref.Container == "":
+		return VolumesFromRef{}, fmt.Errorf("invalid volumes_from %q: requires a name", entry)
+	case ref.Mode != "" && ref.Mode != "ro" && ref.Mode != "rw":
+		return VolumesFromRef{}, fmt.Errorf("invalid volumes_from %q: mode must be ro or rw", entry)
+	}
+	return ref, nil
+}
+
+// VolumesFromServices returns the services whose volumes volumes_from
+// mounts, in order. Invalid entries are skipped.
+func (s *Service) VolumesFromServices() []string {
+	var services []string
+	for _, entry := range s.VolumesFrom {
+		if ref, err := ParseVolumesFrom(entry); err == nil && ref.Service != "" {
+			services = append(services, ref.Service)
+		}
+	}
+	return services
+}
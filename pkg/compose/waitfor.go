@@ -0,0 +1,26 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WaitCondition interprets the post container's wait_for value. It is either
+// a duration to sleep ("10s") or "<service>:healthy" to block until the named
+// service is healthy. An empty value means no wait.
+func (p *PostContainer) WaitCondition() (time.Duration, string, error) {
+	if p.WaitFor == "" {
+		return 0, "", nil
+	}
+
+	if delay, err := time.ParseDuration(p.WaitFor); err == nil {
+		return delay, "", nil
+	}
+
+	if service, ok := strings.CutSuffix(p.WaitFor, ":healthy"); ok && service != "" {
+		return 0, service, nil
+	}
+
+	return 0, "", fmt.Errorf("invalid wait_for %q: expected a duration or <service>:healthy", p.WaitFor)
+}
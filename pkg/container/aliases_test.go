@@ -0,0 +1,75 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestDockerCreateServiceNetworkSettings(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fake.handle("GET", "/images/nginx/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"Id": "sha256:nginx"})
+	})
+	var created struct {
+		NetworkingConfig network.NetworkingConfig
+	}
+	fake.handle("POST", "/containers/create", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&created)
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, map[string]interface{}{"Id": "c0ffee000001", "Warnings": []string{}})
+	})
+	var connected struct {
+		Container      string
+		EndpointConfig *network.EndpointSettings
+	}
+	fake.handle("POST", "/networks/demo_frontend/connect", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&connected)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Network names are already resolved to their Docker names by the
+	// executor; demo_backend sorts first and is attached at creation
+	service := &compose.Service{
+		Image: "nginx",
+		Networks: compose.ServiceNetworks{
+			"demo_backend":  {Aliases: []string{"api", "api.internal"}, IPv4Address: "172.28.0.10"},
+			"demo_frontend": {Aliases: []string{"web"}},
+		},
+	}
+	if _, err := dm.CreateService(context.Background(), "api", service, 1); err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+
+	backend := created.NetworkingConfig.EndpointsConfig["demo_backend"]
+	if backend == nil {
+		t.Fatalf("created with networks %v, want demo_backend", created.NetworkingConfig.EndpointsConfig)
+	}
+	if len(backend.Aliases) != 2 || backend.Aliases[0] != "api" || backend.Aliases[1] != "api.internal" {
+		t.Errorf("demo_backend aliases = %v, want api and api.internal", backend.Aliases)
+	}
+	if backend.IPAMConfig == nil || backend.IPAMConfig.IPv4Address != "172.28.0.10" {
+		t.Errorf("demo_backend IPAM config = %+v, want 172.28.0.10", backend.IPAMConfig)
+	}
+
+	if connected.Container != "c0ffee000001" || connected.EndpointConfig == nil {
+		t.Fatalf("connect request = %+v, want the container joining demo_frontend", connected)
+	}
+	if aliases := connected.EndpointConfig.Aliases; len(aliases) != 1 || aliases[0] != "web" {
+		t.Errorf("demo_frontend aliases = %v, want web", aliases)
+	}
+	if connected.EndpointConfig.IPAMConfig != nil {
+		t.Errorf("demo_frontend IPAM config = %+v, want none", connected.EndpointConfig.IPAMConfig)
+	}
+}
+
+func TestEndpointSettingsShortForm(t *testing.T) {
+	endpoint := endpointSettings(nil)
+	if endpoint == nil || len(endpoint.Aliases) != 0 || endpoint.IPAMConfig != nil {
+		t.Errorf("endpointSettings(nil) = %+v, want empty settings", endpoint)
+	}
+}
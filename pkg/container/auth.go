@@ -0,0 +1,91 @@
+package container
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// dockerHubAuthKey is the key Docker Hub credentials are stored under in
+// the Docker config file
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// RegistryHost returns the registry an image reference points to. As in
+// Docker, the first path component names a registry only when it contains
+// a dot or a port, or is localhost; otherwise the image is on Docker Hub.
+func RegistryHost(ref string) string {
+	first, _, found := strings.Cut(ref, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first
+	}
+	return "docker.io"
+}
+
+// LoadRegistryAuth looks up the credentials for the registry of ref in the
+// Docker config file ($DOCKER_CONFIG/config.json, or ~/.docker/config.json).
+// Only credentials stored inline under "auths" are read; credential helpers
+// are not supported. A missing config file or entry yields empty
+// credentials, which is enough for registries that allow anonymous pushes.
+func LoadRegistryAuth(ref string) (types.AuthConfig, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return types.AuthConfig{}, fmt.Errorf("failed to locate Docker config: %w", err)
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return types.AuthConfig{}, nil
+	}
+	if err != nil {
+		return types.AuthConfig{}, fmt.Errorf("failed to read Docker config: %w", err)
+	}
+
+	var config struct {
+		Auths map[string]types.AuthConfig `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return types.AuthConfig{}, fmt.Errorf("failed to parse Docker config: %w", err)
+	}
+
+	host := RegistryHost(ref)
+	keys := []string{host, "https://" + host, "http://" + host}
+	if host == "docker.io" {
+		keys = []string{dockerHubAuthKey, "docker.io", "index.docker.io"}
+	}
+	for _, key := range keys {
+		auth, ok := config.Auths[key]
+		if !ok {
+			continue
+		}
+		if auth.Auth != "" && auth.Username == "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return types.AuthConfig{}, fmt.Errorf("invalid credentials for %s: %w", host, err)
+			}
+			auth.Username, auth.Password, _ = strings.Cut(string(decoded), ":")
+			auth.Auth = ""
+		}
+		auth.ServerAddress = key
+		return auth, nil
+	}
+	return types.AuthConfig{}, nil
+}
+
+// encodeAuth encodes credentials for the X-Registry-Auth header
+func encodeAuth(auth types.AuthConfig) (string, error) {
+	data, err := json.Marshal(auth)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
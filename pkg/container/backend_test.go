@@ -0,0 +1,103 @@
+package container
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// unreachableHost is a daemon address nothing listens on
+const unreachableHost = "tcp://127.0.0.1:1"
+
+// fakeDaemonHost starts a fake daemon answering pings and returns its
+// address
+func fakeDaemonHost(t *testing.T) string {
+	t.Helper()
+	for _, name := range []string{"DOCKER_HOST", "DOCKER_API_VERSION", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY"} {
+		t.Setenv(name, "")
+	}
+	server := httptest.NewServer(&fakeDocker{})
+	t.Cleanup(server.Close)
+	return "tcp://" + strings.TrimPrefix(server.URL, "http://")
+}
+
+func newBackendManager(t *testing.T, opts Options) (*Manager, error) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	m, err := NewManagerWithOptions(logger, opts)
+	if err == nil {
+		t.Cleanup(func() { m.Close() })
+	}
+	return m, err
+}
+
+func TestNewManagerBackend(t *testing.T) {
+	t.Setenv(BackendEnvVar, "")
+	daemon := fakeDaemonHost(t)
+	tests := []struct {
+		name    string
+		backend Backend
+		host    string
+		docker  bool
+	}{
+		{"stub ignores a reachable daemon", BackendStub, daemon, false},
+		{"docker", BackendDocker, daemon, true},
+		{"auto with a daemon", BackendAuto, daemon, true},
+		{"auto without a daemon", BackendAuto, unreachableHost, false},
+		{"default without a daemon", "", unreachableHost, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newBackendManager(t, Options{Backend: tt.backend, Host: tt.host})
+			if err != nil {
+				t.Fatalf("NewManagerWithOptions: %v", err)
+			}
+			if _, docker := m.impl.(*DockerManager); docker != tt.docker {
+				t.Errorf("implementation = %T, want Docker %v", m.impl, tt.docker)
+			}
+		})
+	}
+}
+
+func TestNewManagerRequireDocker(t *testing.T) {
+	t.Setenv(BackendEnvVar, "")
+	_, err := newBackendManager(t, Options{Backend: BackendDocker, Host: unreachableHost})
+	if err == nil || !strings.Contains(err.Error(), "docker backend required") {
+		t.Errorf("NewManagerWithOptions = %v, want a docker backend required error", err)
+	}
+}
+
+func TestNewManagerBackendEnvVar(t *testing.T) {
+	t.Setenv(BackendEnvVar, "stub")
+	m, err := newBackendManager(t, Options{Host: fakeDaemonHost(t)})
+	if err != nil {
+		t.Fatalf("NewManagerWithOptions: %v", err)
+	}
+	if _, stub := m.impl.(*StubManager); !stub {
+		t.Errorf("implementation = %T, want the stub selected by %s", m.impl, BackendEnvVar)
+	}
+
+	// An explicit backend wins over the environment
+	t.Setenv(BackendEnvVar, "docker")
+	if m, err = newBackendManager(t, Options{Backend: BackendStub, Host: unreachableHost}); err != nil {
+		t.Fatalf("NewManagerWithOptions: %v", err)
+	}
+	if _, stub := m.impl.(*StubManager); !stub {
+		t.Errorf("implementation = %T, want the explicit stub", m.impl)
+	}
+
+	t.Setenv(BackendEnvVar, "podman")
+	if _, err := newBackendManager(t, Options{}); err == nil || !strings.Contains(err.Error(), BackendEnvVar) {
+		t.Errorf("NewManagerWithOptions = %v, want an invalid %s error", err, BackendEnvVar)
+	}
+}
+
+func TestNewManagerInvalidBackend(t *testing.T) {
+	if _, err := newBackendManager(t, Options{Backend: "podman"}); err == nil || !strings.Contains(err.Error(), `invalid backend "podman"`) {
+		t.Errorf("NewManagerWithOptions = %v, want an invalid backend error", err)
+	}
+}
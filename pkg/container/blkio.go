@@ -0,0 +1,56 @@
+package container
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types/blkiodev"
+	"github.com/docker/docker/api/types/container"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// applyBlkio sets a service's block I/O weights and throttles on its host
+// configuration. They rely on the blkio cgroup, which only Linux daemons
+// have; elsewhere, as with Windows containers, they are left out with a
+// warning.
+func (dm *DockerManager) applyBlkio(ctx context.Context, serviceName string, service *compose.Service, hostConfig *container.HostConfig) error {
+	if !service.HasBlkio() {
+		return nil
+	}
+	if version, err := dm.client.ServerVersion(ctx); err == nil && version.Os != "linux" {
+		dm.logger.Warnf("Service %s: block I/O limits are ignored, the %s daemon has no blkio cgroup", serviceName, version.Os)
+		return nil
+	}
+
+	hostConfig.BlkioWeight = uint16(service.BlkioWeight)
+	for _, device := range service.BlkioWeightDevice {
+		hostConfig.BlkioWeightDevice = append(hostConfig.BlkioWeightDevice, &blkiodev.WeightDevice{Path: device.Path, Weight: uint16(device.Weight)})
+	}
+
+	var err error
+	if hostConfig.BlkioDeviceReadBps, err = throttleDevices(service.DeviceReadBps, compose.ThrottleDevice.BytesPerSecond); err != nil {
+		return err
+	}
+	if hostConfig.BlkioDeviceWriteBps, err = throttleDevices(service.DeviceWriteBps, compose.ThrottleDevice.BytesPerSecond); err != nil {
+		return err
+	}
+	if hostConfig.BlkioDeviceReadIOps, err = throttleDevices(service.DeviceReadIOps, compose.ThrottleDevice.OpsPerSecond); err != nil {
+		return err
+	}
+	if hostConfig.BlkioDeviceWriteIOps, err = throttleDevices(service.DeviceWriteIOps, compose.ThrottleDevice.OpsPerSecond); err != nil {
+		return err
+	}
+	return nil
+}
+
+// throttleDevices converts device throttles, parsing their rates with rate
+func throttleDevices(devices []compose.ThrottleDevice, rate func(compose.ThrottleDevice) (uint64, error)) ([]*blkiodev.ThrottleDevice, error) {
+	var throttles []*blkiodev.ThrottleDevice
+	for _, device := range devices {
+		value, err := rate(device)
+		if err != nil {
+			return nil, err
+		}
+		throttles = append(throttles, &blkiodev.ThrottleDevice{Path: device.Path, Rate: value})
+	}
+	return throttles, nil
+}
@@ -0,0 +1,357 @@
+package container
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// BuildOptions controls how BuildService builds a service image
+type BuildOptions struct {
+	// Tag names the built image
+	Tag string
+	// CacheFrom replaces the build's cache_from images when set
+	CacheFrom []string
+	// Progress receives one line per build step event, numbered like the
+	// docker CLI's plain progress output. Nil discards progress.
+	Progress io.Writer
+}
+
+// Aux message IDs of a BuildKit build stream
+const (
+	buildKitTraceID = "moby.buildkit.trace"
+	buildImageID    = "moby.image.id"
+)
+
+// BuildService builds a service's image with BuildKit, caching from the
+// build's cache_from images, and returns the image ID. cache_to supports
+// only inline cache, the one exporter the Engine API offers; registry and
+// local cache exports need buildx.
+func (dm *DockerManager) BuildService(ctx context.Context, serviceName string, service *compose.Service, opts BuildOptions) (string, error) {
+	build := service.Build
+	if build == nil {
+		return "", fmt.Errorf("service %s has no build configuration", serviceName)
+	}
+
+	buildOpts, err := imageBuildOptions(build, opts)
+	if err != nil {
+		return "", err
+	}
+
+	contextDir := build.Context
+	if contextDir == "" {
+		contextDir = "."
+	}
+	buildContext, err := contextArchive(contextDir, build.Dockerfile)
+	if err != nil {
+		return "", err
+	}
+	defer buildContext.Close()
+
+	dm.logger.Infof("Building image %s for service %s", opts.Tag, serviceName)
+	resp, err := dm.client.ImageBuild(ctx, buildContext, buildOpts)
+	if err != nil {
+		return "", fmt.Errorf("failed to build %s: %w", serviceName, err)
+	}
+	defer resp.Body.Close()
+
+	imageID, err := writeBuildProgress(resp.Body, opts.Progress)
+	if err != nil {
+		return "", fmt.Errorf("failed to build %s: %w", serviceName, err)
+	}
+	return imageID, nil
+}
+
+// imageBuildOptions translates a service's build configuration into a
+// BuildKit build request
+func imageBuildOptions(build *compose.BuildConfig, opts BuildOptions) (types.ImageBuildOptions, error) {
+	buildArgs := make(map[string]*string, len(build.Args)+1)
+	for k, v := range build.Args {
+		value := v
+		buildArgs[k] = &value
+	}
+
+	switch build.CacheTo {
+	case "":
+	case "inline", "type=inline":
+		inline := "1"
+		buildArgs["BUILDKIT_INLINE_CACHE"] = &inline
+	default:
+		return types.ImageBuildOptions{}, fmt.Errorf("unsupported cache_to %q: only inline cache can be exported", build.CacheTo)
+	}
+
+	cacheFrom := build.CacheFrom
+	if len(opts.CacheFrom) > 0 {
+		cacheFrom = opts.CacheFrom
+	}
+
+	var tags []string
+	if opts.Tag != "" {
+		tags = []string{opts.Tag}
+	}
+	return types.ImageBuildOptions{
+		Tags:        tags,
+		Dockerfile:  build.Dockerfile,
+		BuildArgs:   buildArgs,
+		Target:      build.Target,
+		CacheFrom:   cacheFrom,
+		Remove:      true,
+		ForceRemove: true,
+		Version:     types.BuilderBuildKit,
+	}, nil
+}
+
+// contextArchive streams dir as a tar archive, leaving out the paths
+// excluded by its .dockerignore. The Dockerfile and .dockerignore are
+// always sent, as Docker needs them.
+func contextArchive(dir, dockerfile string) (io.ReadCloser, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid build context: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("invalid build context %s: not a directory", dir)
+	}
+	patterns, err := readDockerignore(dir)
+	if err != nil {
+		return nil, err
+	}
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	dockerfile = filepath.ToSlash(filepath.Clean(dockerfile))
+	// Excluded directories are skipped unless something in them may be
+	// included again
+	skipDirs := !reincludes(patterns) && !strings.Contains(dockerfile, "/")
+	if len(patterns) > 0 {
+		patterns = append(patterns, "!"+dockerfile, "!.dockerignore")
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(writeContext(dir, patterns, skipDirs, w))
+	}()
+	return r, nil
+}
+
+func writeContext(dir string, patterns []string, skipDirs bool, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if ignored(rel, patterns) {
+			if info.IsDir() && skipDirs {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive build context: %w", err)
+	}
+	return tw.Close()
+}
+
+// readDockerignore returns the patterns of dir/.dockerignore, if any
+func readDockerignore(dir string) ([]string, error) {
+	file, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negate := strings.HasPrefix(line, "!")
+		line = strings.Trim(filepath.ToSlash(filepath.Clean(strings.TrimPrefix(line, "!"))), "/")
+		if negate {
+			line = "!" + line
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// ignored reports whether a context path is excluded by the .dockerignore
+// patterns. As in Docker, the last matching pattern wins, a "!" pattern
+// re-includes paths, and a pattern matching a directory covers everything
+// in it.
+func ignored(rel string, patterns []string) bool {
+	excluded := false
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if matchesPath(pattern, rel) {
+			excluded = !negate
+		}
+	}
+	return excluded
+}
+
+// reincludes reports whether any pattern re-includes paths
+func reincludes(patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPath matches rel or any of its parent directories against a
+// filepath.Match pattern
+func matchesPath(pattern, rel string) bool {
+	for path := rel; path != "."; path = filepath.ToSlash(filepath.Dir(path)) {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBuildProgress decodes the JSON message stream of a build and
+// renders BuildKit's trace to w: a "#N name" line when a step starts, its
+// transfers at every quarter, its output, and a DONE, CACHED or ERROR line
+// when it ends. Legacy builder output is copied as it is. It returns the
+// ID of the built image.
+func writeBuildProgress(r io.Reader, w io.Writer) (string, error) {
+	if w == nil {
+		w = io.Discard
+	}
+
+	imageID := ""
+	steps := make(map[string]int)
+	started := make(map[string]bool)
+	finished := make(map[string]bool)
+	transfers := make(map[string]int)
+	step := func(digest string) int {
+		if _, ok := steps[digest]; !ok {
+			steps[digest] = len(steps) + 1
+		}
+		return steps[digest]
+	}
+
+	decoder := json.NewDecoder(r)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("failed to read build output: %w", err)
+		}
+		if msg.Error != nil {
+			return "", errors.New(msg.Error.Message)
+		}
+
+		switch {
+		case msg.ID == buildImageID && msg.Aux != nil:
+			var result types.BuildResult
+			if err := json.Unmarshal(*msg.Aux, &result); err == nil {
+				imageID = result.ID
+			}
+		case msg.ID == buildKitTraceID && msg.Aux != nil:
+			var data []byte
+			if err := json.Unmarshal(*msg.Aux, &data); err != nil {
+				return "", fmt.Errorf("invalid build trace: %w", err)
+			}
+			status, err := decodeBuildStatus(data)
+			if err != nil {
+				return "", fmt.Errorf("invalid build trace: %w", err)
+			}
+
+			for _, v := range status.Vertexes {
+				n := step(v.Digest)
+				if !v.Started.IsZero() && !started[v.Digest] {
+					started[v.Digest] = true
+					fmt.Fprintf(w, "#%d %s\n", n, v.Name)
+				}
+				if finished[v.Digest] {
+					continue
+				}
+				switch {
+				case v.Error != "":
+					finished[v.Digest] = true
+					fmt.Fprintf(w, "#%d ERROR: %s\n", n, v.Error)
+				case v.Cached:
+					finished[v.Digest] = true
+					fmt.Fprintf(w, "#%d CACHED\n", n)
+				case !v.Completed.IsZero():
+					finished[v.Digest] = true
+					fmt.Fprintf(w, "#%d DONE %.1fs\n", n, v.Completed.Sub(v.Started).Seconds())
+				}
+			}
+			for _, s := range status.Statuses {
+				n := step(s.Vertex)
+				if s.Total > 0 {
+					quarter := int(s.Current * progressSteps / s.Total)
+					if quarter <= transfers[s.ID] {
+						continue
+					}
+					transfers[s.ID] = quarter
+					fmt.Fprintf(w, "#%d %s %s\n", n, s.ID, progressBar(s.Current, s.Total))
+				} else if !s.Completed.IsZero() {
+					fmt.Fprintf(w, "#%d %s done\n", n, s.ID)
+				}
+			}
+			for _, l := range status.Logs {
+				n := step(l.Vertex)
+				for _, line := range strings.Split(strings.TrimRight(string(l.Msg), "\n"), "\n") {
+					fmt.Fprintf(w, "#%d %s\n", n, line)
+				}
+			}
+		case msg.Stream != "":
+			fmt.Fprint(w, msg.Stream)
+		}
+	}
+	return imageID, nil
+}
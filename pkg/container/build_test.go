@@ -0,0 +1,145 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// protoBytes encodes a length-delimited protobuf field
+func protoBytes(field int, data []byte) []byte {
+	b := binary.AppendUvarint(nil, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// protoVarint encodes a varint protobuf field
+func protoVarint(field int, value uint64) []byte {
+	return binary.AppendUvarint(binary.AppendUvarint(nil, uint64(field)<<3), value)
+}
+
+func protoTimestamp(field int, t time.Time) []byte {
+	ts := append(protoVarint(1, uint64(t.Unix())), protoVarint(2, uint64(t.Nanosecond()))...)
+	return protoBytes(field, ts)
+}
+
+// traceMessage wraps an encoded StatusResponse in the aux message a
+// BuildKit build stream carries it in
+func traceMessage(t *testing.T, status ...[]byte) string {
+	t.Helper()
+	aux, err := json.Marshal(bytes.Join(status, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return `{"id":"moby.buildkit.trace","aux":` + string(aux) + "}\n"
+}
+
+// buildStream is a BuildKit build of one step that logs a line and
+// produces sha256:built
+func buildStream(t *testing.T) string {
+	t.Helper()
+	started := time.Unix(1700000000, 0)
+	vertex := func(fields ...[]byte) []byte {
+		return protoBytes(1, bytes.Join(append([][]byte{protoBytes(1, []byte("sha256:step1")), protoBytes(3, []byte("[1/1] FROM alpine"))}, fields...), nil))
+	}
+	log := protoBytes(3, append(protoBytes(1, []byte("sha256:step1")), protoBytes(4, []byte("hello\n"))...))
+
+	var b strings.Builder
+	b.WriteString(traceMessage(t, vertex(protoTimestamp(5, started))))
+	b.WriteString(traceMessage(t, log))
+	b.WriteString(traceMessage(t, vertex(protoTimestamp(5, started), protoTimestamp(6, started.Add(1500*time.Millisecond)))))
+	b.WriteString(`{"id":"moby.image.id","aux":{"ID":"sha256:built"}}` + "\n")
+	return b.String()
+}
+
+func buildContextDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestDockerBuildServiceBuildKit(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	stream := buildStream(t)
+	var query map[string][]string
+	fake.handle("POST", "/build", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		query = r.URL.Query()
+		io.WriteString(w, stream)
+	})
+
+	service := &compose.Service{Build: &compose.BuildConfig{
+		Context:   buildContextDir(t),
+		CacheFrom: []string{"app:cache"},
+		CacheTo:   "inline",
+	}}
+	var progress bytes.Buffer
+	imageID, err := dm.BuildService(context.Background(), "app", service, BuildOptions{Tag: "app:latest", Progress: &progress})
+	if err != nil {
+		t.Fatalf("BuildService: %v", err)
+	}
+	if imageID != "sha256:built" {
+		t.Errorf("image ID = %q, want sha256:built", imageID)
+	}
+
+	if got := query["version"]; len(got) != 1 || got[0] != string(types.BuilderBuildKit) {
+		t.Errorf("builder version = %v, want BuildKit (%s)", got, types.BuilderBuildKit)
+	}
+	if got := query["cachefrom"]; len(got) != 1 || got[0] != `["app:cache"]` {
+		t.Errorf("cachefrom = %v, want [\"app:cache\"]", got)
+	}
+	if got := query["buildargs"]; len(got) != 1 || !strings.Contains(got[0], `"BUILDKIT_INLINE_CACHE":"1"`) {
+		t.Errorf("buildargs = %v, want BUILDKIT_INLINE_CACHE for cache_to inline", got)
+	}
+
+	want := "#1 [1/1] FROM alpine\n#1 hello\n#1 DONE 1.5s\n"
+	if progress.String() != want {
+		t.Errorf("progress = %q, want %q", progress.String(), want)
+	}
+}
+
+func TestImageBuildOptionsCache(t *testing.T) {
+	build := &compose.BuildConfig{CacheFrom: []string{"app:cache"}}
+
+	opts, err := imageBuildOptions(build, BuildOptions{CacheFrom: []string{"app:ci"}})
+	if err != nil {
+		t.Fatalf("imageBuildOptions: %v", err)
+	}
+	if len(opts.CacheFrom) != 1 || opts.CacheFrom[0] != "app:ci" {
+		t.Errorf("CacheFrom = %v, want the --cache-from override", opts.CacheFrom)
+	}
+	if opts.Version != types.BuilderBuildKit {
+		t.Errorf("Version = %q, want BuildKit", opts.Version)
+	}
+	if _, inline := opts.BuildArgs["BUILDKIT_INLINE_CACHE"]; inline {
+		t.Error("inline cache exported without cache_to")
+	}
+
+	for _, cacheTo := range []string{"type=registry,ref=app:cache", "type=local,dest=/tmp/cache"} {
+		build := &compose.BuildConfig{CacheTo: cacheTo}
+		if _, err := imageBuildOptions(build, BuildOptions{}); err == nil || !strings.Contains(err.Error(), "only inline cache") {
+			t.Errorf("cache_to %q = %v, want an unsupported error", cacheTo, err)
+		}
+	}
+}
+
+func TestWriteBuildProgressError(t *testing.T) {
+	stream := `{"errorDetail":{"message":"failed to solve: exit code 1"},"error":"failed to solve: exit code 1"}` + "\n"
+	if _, err := writeBuildProgress(strings.NewReader(stream), nil); err == nil || err.Error() != "failed to solve: exit code 1" {
+		t.Errorf("writeBuildProgress = %v, want the stream's error", err)
+	}
+}
@@ -0,0 +1,173 @@
+package container
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// BuildKit reports build progress as protobuf-encoded moby.buildkit.v1
+// StatusResponse messages. Only the fields rendered by writeBuildProgress
+// are decoded here, which spares a dependency on the BuildKit module.
+
+type buildStatus struct {
+	Vertexes []buildVertex
+	Statuses []buildVertexStatus
+	Logs     []buildVertexLog
+}
+
+// buildVertex is a build step
+type buildVertex struct {
+	Digest    string
+	Name      string
+	Cached    bool
+	Started   time.Time
+	Completed time.Time
+	Error     string
+}
+
+// buildVertexStatus is the progress of a transfer within a step, such as a
+// layer download
+type buildVertexStatus struct {
+	ID        string
+	Vertex    string
+	Current   int64
+	Total     int64
+	Completed time.Time
+}
+
+// buildVertexLog is output of a step
+type buildVertexLog struct {
+	Vertex string
+	Msg    []byte
+}
+
+func decodeBuildStatus(data []byte) (*buildStatus, error) {
+	var status buildStatus
+	err := protoFields(data, func(field int, value uint64, bytes []byte) error {
+		switch field {
+		case 1:
+			var v buildVertex
+			err := protoFields(bytes, func(field int, value uint64, bytes []byte) error {
+				switch field {
+				case 1:
+					v.Digest = string(bytes)
+				case 3:
+					v.Name = string(bytes)
+				case 4:
+					v.Cached = value != 0
+				case 5:
+					return decodeTimestamp(bytes, &v.Started)
+				case 6:
+					return decodeTimestamp(bytes, &v.Completed)
+				case 7:
+					v.Error = string(bytes)
+				}
+				return nil
+			})
+			status.Vertexes = append(status.Vertexes, v)
+			return err
+		case 2:
+			var s buildVertexStatus
+			err := protoFields(bytes, func(field int, value uint64, bytes []byte) error {
+				switch field {
+				case 1:
+					s.ID = string(bytes)
+				case 2:
+					s.Vertex = string(bytes)
+				case 4:
+					s.Current = int64(value)
+				case 5:
+					s.Total = int64(value)
+				case 8:
+					return decodeTimestamp(bytes, &s.Completed)
+				}
+				return nil
+			})
+			status.Statuses = append(status.Statuses, s)
+			return err
+		case 3:
+			var l buildVertexLog
+			err := protoFields(bytes, func(field int, value uint64, bytes []byte) error {
+				switch field {
+				case 1:
+					l.Vertex = string(bytes)
+				case 4:
+					l.Msg = bytes
+				}
+				return nil
+			})
+			status.Logs = append(status.Logs, l)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// decodeTimestamp decodes a google.protobuf.Timestamp
+func decodeTimestamp(data []byte, t *time.Time) error {
+	var seconds, nanos int64
+	err := protoFields(data, func(field int, value uint64, _ []byte) error {
+		switch field {
+		case 1:
+			seconds = int64(value)
+		case 2:
+			nanos = int64(value)
+		}
+		return nil
+	})
+	*t = time.Unix(seconds, nanos)
+	return err
+}
+
+// protoFields calls fn for every field of a protobuf message with its
+// number and its value: the integer of varint and fixed-size fields, the
+// bytes of length-delimited ones
+func protoFields(data []byte, fn func(field int, value uint64, bytes []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("invalid protobuf field key")
+		}
+		data = data[n:]
+		field := int(key >> 3)
+
+		var value uint64
+		var bytes []byte
+		switch key & 7 {
+		case 0:
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid protobuf varint in field %d", field)
+			}
+			data = data[n:]
+		case 1:
+			if len(data) < 8 {
+				return fmt.Errorf("truncated protobuf field %d", field)
+			}
+			value, data = binary.LittleEndian.Uint64(data), data[8:]
+		case 2:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return fmt.Errorf("truncated protobuf field %d", field)
+			}
+			bytes, data = data[n:n+int(length)], data[n+int(length):]
+		case 5:
+			if len(data) < 4 {
+				return fmt.Errorf("truncated protobuf field %d", field)
+			}
+			value, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d in field %d", key&7, field)
+		}
+
+		if err := fn(field, value, bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+// TLSOptions configures a TLS connection to the Docker daemon, like the
+// docker CLI's --tlscacert, --tlscert, --tlskey and --tlsverify flags
+type TLSOptions struct {
+	CACert string
+	Cert   string
+	Key    string
+	// Verify checks the daemon's certificate against CACert; without it
+	// the connection is encrypted but the daemon is not authenticated
+	Verify bool
+}
+
+// sshPlaceholderHost addresses requests sent over an SSH connection, which
+// ignores the host part of the URL
+const sshPlaceholderHost = "http://docker.example.com"
+
+// clientOptions returns the Docker client options for opts, along with the
+// daemon address to report in errors. DOCKER_HOST and the other variables
+// read by client.FromEnv apply unless opts overrides them. ssh:// hosts are
+// reached through `docker system dial-stdio` on the remote machine, as the
+// docker CLI does.
+func clientOptions(opts Options) ([]client.Opt, string, error) {
+	clientOpts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+
+	host := opts.Host
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+
+	if opts.TLS != nil {
+		httpClient, err := tlsHTTPClient(*opts.TLS)
+		if err != nil {
+			return nil, "", err
+		}
+		clientOpts = append(clientOpts, client.WithHTTPClient(httpClient))
+		if host == "" {
+			// The new transport still needs configuring for the default host
+			host = client.DefaultDockerHost
+		}
+	}
+
+	if strings.HasPrefix(host, "ssh://") {
+		if opts.TLS != nil {
+			return nil, "", fmt.Errorf("TLS options cannot be used with an ssh:// host")
+		}
+		dial, err := sshDialer(host)
+		if err != nil {
+			return nil, "", err
+		}
+		clientOpts = append(clientOpts,
+			client.WithHTTPClient(&http.Client{Transport: &http.Transport{}}),
+			client.WithHost(sshPlaceholderHost),
+			client.WithDialContext(dial),
+		)
+		return clientOpts, host, nil
+	}
+
+	if host != "" {
+		clientOpts = append(clientOpts, client.WithHost(host))
+	}
+	return clientOpts, host, nil
+}
+
+func tlsHTTPClient(opts TLSOptions) (*http.Client, error) {
+	config, err := tlsconfig.Client(tlsconfig.Options{
+		CAFile:             opts.CACert,
+		CertFile:           opts.Cert,
+		KeyFile:            opts.Key,
+		InsecureSkipVerify: !opts.Verify,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS configuration: %w", err)
+	}
+	return &http.Client{
+		Transport:     &http.Transport{TLSClientConfig: config},
+		CheckRedirect: client.CheckRedirect,
+	}, nil
+}
+
+// sshDialer returns a dialer that runs `docker system dial-stdio` on the
+// host of an ssh://[user@]host[:port] URL and talks to the daemon over the
+// command's stdin and stdout
+func sshDialer(host string) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh host %q: %w", host, err)
+	}
+	if u.Hostname() == "" || (u.Path != "" && u.Path != "/") {
+		return nil, fmt.Errorf("invalid ssh host %q: expected ssh://[user@]host[:port]", host)
+	}
+
+	var args []string
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if port := u.Port(); port != "" {
+		args = append(args, "-p", port)
+	}
+	args = append(args, "--", u.Hostname(), "docker", "system", "dial-stdio")
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return newCommandConn(exec.Command("ssh", args...))
+	}, nil
+}
+
+// commandConn is a net.Conn over the stdin and stdout of a command
+type commandConn struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	closeOnce sync.Once
+}
+
+func newCommandConn(cmd *exec.Cmd) (*commandConn, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", cmd.Path, err)
+	}
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+func (c *commandConn) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *commandConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.cmd.Process.Kill()
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr  { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr { return commandAddr{} }
+
+// Deadlines are not supported by pipes to a command; the HTTP client's own
+// timeouts apply instead
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+func (commandAddr) String() string  { return "command" }
@@ -0,0 +1,190 @@
+package container
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// clearDockerEnv stops the environment read by client.FromEnv leaking
+// into a test
+func clearDockerEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"DOCKER_HOST", "DOCKER_API_VERSION", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY"} {
+		t.Setenv(name, "")
+	}
+}
+
+// newOptionsClient builds a client from the options clientOptions returns
+// for opts, so the tests can check what each option configured
+func newOptionsClient(t *testing.T, opts Options) (*client.Client, string) {
+	t.Helper()
+	clientOpts, host, err := clientOptions(opts)
+	if err != nil {
+		t.Fatalf("clientOptions: %v", err)
+	}
+	cli, err := client.NewClientWithOpts(clientOpts...)
+	if err != nil {
+		t.Fatalf("NewClientWithOpts: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli, host
+}
+
+// writeTLSFiles writes a self-signed certificate and its key to dir and
+// returns TLS options using the certificate as the CA as well
+func writeTLSFiles(t *testing.T, dir string) TLSOptions {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "docker.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return TLSOptions{CACert: certFile, Cert: certFile, Key: keyFile, Verify: true}
+}
+
+func transportOf(t *testing.T, cli *client.Client) *http.Transport {
+	t.Helper()
+	transport, ok := cli.HTTPClient().Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport = %T, want *http.Transport", cli.HTTPClient().Transport)
+	}
+	return transport
+}
+
+func TestClientOptionsHost(t *testing.T) {
+	clearDockerEnv(t)
+	cli, host := newOptionsClient(t, Options{Host: "tcp://10.0.0.1:2375"})
+	if host != "tcp://10.0.0.1:2375" {
+		t.Errorf("host = %q, want tcp://10.0.0.1:2375", host)
+	}
+	if cli.DaemonHost() != "tcp://10.0.0.1:2375" {
+		t.Errorf("DaemonHost = %q, want tcp://10.0.0.1:2375", cli.DaemonHost())
+	}
+}
+
+func TestClientOptionsHostOverridesEnv(t *testing.T) {
+	clearDockerEnv(t)
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.2:2375")
+
+	cli, host := newOptionsClient(t, Options{})
+	if host != "tcp://10.0.0.2:2375" || cli.DaemonHost() != "tcp://10.0.0.2:2375" {
+		t.Errorf("without a host: host = %q, DaemonHost = %q, want DOCKER_HOST", host, cli.DaemonHost())
+	}
+
+	cli, host = newOptionsClient(t, Options{Host: "tcp://10.0.0.1:2375"})
+	if host != "tcp://10.0.0.1:2375" || cli.DaemonHost() != "tcp://10.0.0.1:2375" {
+		t.Errorf("with a host: host = %q, DaemonHost = %q, want the host option", host, cli.DaemonHost())
+	}
+}
+
+func TestClientOptionsTLS(t *testing.T) {
+	clearDockerEnv(t)
+	tlsOpts := writeTLSFiles(t, t.TempDir())
+
+	cli, _ := newOptionsClient(t, Options{Host: "tcp://10.0.0.1:2376", TLS: &tlsOpts})
+	if cli.DaemonHost() != "tcp://10.0.0.1:2376" {
+		t.Errorf("DaemonHost = %q, want tcp://10.0.0.1:2376", cli.DaemonHost())
+	}
+	config := transportOf(t, cli).TLSClientConfig
+	if config == nil {
+		t.Fatal("TLSClientConfig is nil")
+	}
+	if config.InsecureSkipVerify {
+		t.Error("InsecureSkipVerify with Verify set")
+	}
+	if config.RootCAs == nil {
+		t.Error("RootCAs not loaded from the CA certificate")
+	}
+	if len(config.Certificates) != 1 {
+		t.Errorf("client certificates = %d, want 1", len(config.Certificates))
+	}
+}
+
+func TestClientOptionsTLSWithoutVerify(t *testing.T) {
+	clearDockerEnv(t)
+	tlsOpts := writeTLSFiles(t, t.TempDir())
+	tlsOpts.Verify = false
+
+	cli, host := newOptionsClient(t, Options{TLS: &tlsOpts})
+	if host != client.DefaultDockerHost {
+		t.Errorf("host = %q, want the default %q", host, client.DefaultDockerHost)
+	}
+	if config := transportOf(t, cli).TLSClientConfig; config == nil || !config.InsecureSkipVerify {
+		t.Errorf("TLSClientConfig = %+v, want InsecureSkipVerify", config)
+	}
+}
+
+func TestClientOptionsErrors(t *testing.T) {
+	clearDockerEnv(t)
+	tlsOpts := writeTLSFiles(t, t.TempDir())
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"missing TLS files", Options{TLS: &TLSOptions{CACert: filepath.Join(t.TempDir(), "ca.pem"), Verify: true}}, "failed to load TLS configuration"},
+		{"TLS over ssh", Options{Host: "ssh://user@remote", TLS: &tlsOpts}, "cannot be used with an ssh:// host"},
+		{"ssh without a host", Options{Host: "ssh://"}, "invalid ssh host"},
+		{"ssh with a path", Options{Host: "ssh://remote/var/run"}, "invalid ssh host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := clientOptions(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("clientOptions = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientOptionsSSH(t *testing.T) {
+	clearDockerEnv(t)
+	cli, host := newOptionsClient(t, Options{Host: "ssh://user@remote:2222"})
+	if host != "ssh://user@remote:2222" {
+		t.Errorf("host = %q, want the ssh URL for error messages", host)
+	}
+	if cli.DaemonHost() != sshPlaceholderHost {
+		t.Errorf("DaemonHost = %q, want %q", cli.DaemonHost(), sshPlaceholderHost)
+	}
+}
@@ -1,34 +1,61 @@
 package container
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/sirupsen/logrus"
 	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/template"
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
+	"github.com/neomody77/fake-compose/pkg/term"
 )
 
 // DockerManager implements the Manager interface using the Docker API
 type DockerManager struct {
-	client *client.Client
-	logger *logrus.Logger
+	client      *client.Client
+	logger      *logrus.Logger
+	project     string
+	pullTimeout time.Duration
+	initLogs    io.Writer
+	postLogs    io.Writer
+	configFiles []string
+	// secretsBase is where secrets are copied to be mounted, see
+	// stageSecrets
+	secretsBase string
 }
 
 // NewDockerManager creates a new Docker-based container manager
-func NewDockerManager(logger *logrus.Logger) (*DockerManager, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+func NewDockerManager(logger *logrus.Logger, opts Options) (*DockerManager, error) {
+	clientOpts, host, err := clientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cli, err := client.NewClientWithOpts(clientOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
+	if host == "" {
+		host = cli.DaemonHost()
+	}
 
 	// Test connection to Docker daemon
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -36,31 +63,130 @@ func NewDockerManager(logger *logrus.Logger) (*DockerManager, error) {
 	
 	_, err = cli.Ping(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to Docker daemon: %w", err)
+		cli.Close()
+		return nil, fmt.Errorf("failed to connect to Docker daemon at %s: %w", host, err)
 	}
 
 	logger.Info("Successfully connected to Docker daemon")
 
+	pullTimeout := opts.PullTimeout
+	if pullTimeout == 0 {
+		pullTimeout = DefaultPullTimeout
+	}
+
 	return &DockerManager{
-		client: cli,
-		logger: logger,
+		client:      cli,
+		logger:      logger,
+		project:     opts.Project,
+		configFiles: opts.ConfigFiles,
+		secretsBase: tmpfsDir(),
+		pullTimeout: pullTimeout,
+		initLogs:    opts.InitLogs,
+		postLogs:    opts.PostLogs,
 	}, nil
 }
 
-// CreateService creates and configures a container for a service
-func (dm *DockerManager) CreateService(ctx context.Context, serviceName string, service *compose.Service) (string, error) {
+// CreateService creates and configures the container for replica number of a
+// service
+func (dm *DockerManager) CreateService(ctx context.Context, serviceName string, service *compose.Service, number int) (string, error) {
 	dm.logger.Infof("Creating container for service: %s", serviceName)
 
 	// Pull image if needed
 	if err := dm.ensureImage(ctx, service.Image); err != nil {
 		return "", fmt.Errorf("failed to ensure image %s: %w", service.Image, err)
 	}
+	imageID, err := dm.ImageID(ctx, service.Image)
+	if err != nil {
+		return "", err
+	}
+
+	config, hostConfig := dm.serviceConfig(service, containerLabels(dm.project, dm.configFiles, serviceName, number, service, imageID))
+	if err := dm.applyBlkio(ctx, serviceName, service, hostConfig); err != nil {
+		return "", fmt.Errorf("invalid block I/O settings for %s: %w", serviceName, err)
+	}
+
+	// The API accepts a single network at creation; the others are
+	// connected afterwards
+	var networks []string
+	for name := range service.Networks {
+		networks = append(networks, name)
+	}
+	sort.Strings(networks)
+
+	networkConfig := &network.NetworkingConfig{}
+	if len(networks) > 0 {
+		networkConfig.EndpointsConfig = map[string]*network.EndpointSettings{
+			networks[0]: endpointSettings(service.Networks[networks[0]]),
+		}
+	}
+
+	secretsDir, err := dm.mountSecrets(service, config, hostConfig)
+	if err != nil {
+		return "", err
+	}
 
-	// Prepare container configuration
+	containerName := ContainerName(dm.project, serviceName, number)
+	
+	// Create the container
+	resp, err := dm.client.ContainerCreate(ctx, config, hostConfig, networkConfig, nil, containerName)
+	if err != nil {
+		removeSecretsDir(secretsDir)
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+
+	for _, name := range networks[min(1, len(networks)):] {
+		if err := dm.client.NetworkConnect(ctx, name, resp.ID, endpointSettings(service.Networks[name])); err != nil {
+			dm.client.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
+			removeSecretsDir(secretsDir)
+			return "", fmt.Errorf("failed to connect container to network %s: %w", name, err)
+		}
+	}
+
+	dm.logger.Infof("Created container %s with ID: %s", containerName, resp.ID[:12])
+	return resp.ID, nil
+}
+
+// endpointSettings converts a service's settings on a network to the
+// endpoint configuration Docker expects
+func endpointSettings(settings *compose.ServiceNetwork) *network.EndpointSettings {
+	endpoint := &network.EndpointSettings{}
+	if settings == nil {
+		return endpoint
+	}
+	endpoint.Aliases = settings.Aliases
+	if settings.IPv4Address != "" {
+		endpoint.IPAMConfig = &network.EndpointIPAMConfig{IPv4Address: settings.IPv4Address}
+	}
+	return endpoint
+}
+
+// serviceConfig builds the container and host configuration shared by
+// service containers and one-off run containers
+func (dm *DockerManager) serviceConfig(service *compose.Service, labels map[string]string) (*container.Config, *container.HostConfig) {
 	config := &container.Config{
-		Image: service.Image,
-		Env:   dm.prepareEnv(service.Environment),
-		Cmd:   service.Command,
+		Image:      service.Image,
+		Env:        dm.prepareEnv(service.Environment),
+		Cmd:        service.Command,
+		Entrypoint: service.Entrypoint,
+		WorkingDir: service.WorkingDir,
+		User:       service.User,
+		StopSignal: service.StopSignal,
+		Labels:     labels,
+	}
+
+	if hc := service.HealthCheck; hc != nil {
+		config.Healthcheck = &container.HealthConfig{
+			Test:        hc.TestCommand(),
+			Interval:    hc.Interval,
+			Timeout:     hc.Timeout,
+			Retries:     hc.Retries,
+			StartPeriod: hc.StartPeriod,
+		}
+	}
+
+	// A custom init binary wraps the entrypoint; it has to exist in the image
+	if service.InitProcess != "" {
+		config.Entrypoint = append([]string{service.InitProcess, "--"}, service.Entrypoint...)
 	}
 
 	// Configure exposed ports
@@ -85,32 +211,135 @@ func (dm *DockerManager) CreateService(ctx context.Context, serviceName string,
 	// Host configuration
 	hostConfig := &container.HostConfig{
 		PortBindings: portBindings,
-		RestartPolicy: container.RestartPolicy{
-			Name: service.Restart,
-		},
+		RestartPolicy: parseRestartPolicy(service.Restart),
+		Init:          service.Init,
+		NetworkMode:   container.NetworkMode(service.NetworkMode),
+		VolumesFrom:   service.VolumesFrom,
+	}
+	if service.MemSwappiness != nil {
+		swappiness := int64(*service.MemSwappiness)
+		hostConfig.MemorySwappiness = &swappiness
+	}
+	hostConfig.OomKillDisable = service.OomKillDisable
+	hostConfig.CPURealtimeRuntime = service.CPURtRuntime
+	hostConfig.CPURealtimePeriod = service.CPURtPeriod
+	// Already validated by the parser
+	if service.CPUs != "" {
+		hostConfig.NanoCPUs, _ = compose.ParseCPUs(service.CPUs)
+	}
+	if service.MemLimit != "" {
+		hostConfig.Memory, _ = compose.ParseMemory(service.MemLimit)
+	}
+	if service.MemReservation != "" {
+		hostConfig.MemoryReservation, _ = compose.ParseMemory(service.MemReservation)
+	}
+	if len(service.ExtraHosts) > 0 {
+		hostConfig.ExtraHosts = dm.extraHosts(service.ExtraHosts)
 	}
 
 	// Configure volumes
 	for _, volume := range service.Volumes {
-		if hostConfig.Binds == nil {
-			hostConfig.Binds = make([]string, 0)
+		if bind, ok := volumeBind(volume); ok {
+			hostConfig.Binds = append(hostConfig.Binds, bind)
+		} else {
+			hostConfig.Mounts = append(hostConfig.Mounts, volumeMount(volume.VolumeConfig))
 		}
-		hostConfig.Binds = append(hostConfig.Binds, volume)
 	}
 
-	// Network configuration
-	networkConfig := &network.NetworkingConfig{}
+	return config, hostConfig
+}
 
-	containerName := fmt.Sprintf("%s_1", serviceName)
-	
-	// Create the container
-	resp, err := dm.client.ContainerCreate(ctx, config, hostConfig, networkConfig, nil, containerName)
+// volumeBind returns the bind string for a volume that needs one: short
+// form volumes, and long form bind mounts with an SELinux label, which the
+// mounts API cannot express
+func volumeBind(volume compose.VolumeMount) (string, bool) {
+	if volume.Spec != "" {
+		return volume.Spec, true
+	}
+	if volume.Type != "bind" || volume.Bind == nil || volume.Bind.SELinux == "" {
+		return "", false
+	}
+
+	var options []string
+	if volume.ReadOnly {
+		options = append(options, "ro")
+	}
+	options = append(options, volume.Bind.SELinux)
+	if volume.Bind.Propagation != "" {
+		options = append(options, volume.Bind.Propagation)
+	}
+	if volume.Consistency != "" {
+		options = append(options, volume.Consistency)
+	}
+	return volume.Source + ":" + volume.Target + ":" + strings.Join(options, ","), true
+}
+
+// volumeMount converts a long form volume to a mount
+func volumeMount(volume compose.VolumeConfig) mount.Mount {
+	m := mount.Mount{
+		Type:        mount.Type(volume.Type),
+		Source:      volume.Source,
+		Target:      volume.Target,
+		ReadOnly:    volume.ReadOnly,
+		Consistency: mount.Consistency(volume.Consistency),
+	}
+	if volume.Bind != nil && volume.Bind.Propagation != "" {
+		m.BindOptions = &mount.BindOptions{Propagation: mount.Propagation(volume.Bind.Propagation)}
+	}
+	return m
+}
+
+// RunOneOff runs a one-off container for a service. Unless detached it waits
+// for the container to exit, copies its output to opts.Output and returns
+// the exit code.
+func (dm *DockerManager) RunOneOff(ctx context.Context, serviceName string, service *compose.Service, opts OneOffOptions) (int, error) {
+	if err := dm.ensureImage(ctx, service.Image); err != nil {
+		return -1, fmt.Errorf("failed to ensure image %s: %w", service.Image, err)
+	}
+
+	labels := MergeLabels(service.Labels, serviceLabels(dm.project, serviceName, 1))
+	labels[LabelOneOff] = "True"
+	config, hostConfig := dm.serviceConfig(service, labels)
+	// One-off containers are never restarted by the daemon
+	hostConfig.RestartPolicy = container.RestartPolicy{}
+
+	secretsDir, err := dm.mountSecrets(service, config, hostConfig)
 	if err != nil {
-		return "", fmt.Errorf("failed to create container: %w", err)
+		return -1, err
 	}
 
-	dm.logger.Infof("Created container %s with ID: %s", containerName, resp.ID[:12])
-	return resp.ID, nil
+	containerName := fmt.Sprintf("%s_%s_run_%d", dm.project, serviceName, time.Now().Unix())
+	resp, err := dm.client.ContainerCreate(ctx, config, hostConfig, nil, nil, containerName)
+	if err != nil {
+		removeSecretsDir(secretsDir)
+		return -1, fmt.Errorf("failed to create run container: %w", err)
+	}
+
+	if err := dm.client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		dm.client.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
+		removeSecretsDir(secretsDir)
+		return -1, fmt.Errorf("failed to start run container: %w", err)
+	}
+
+	if opts.Detach {
+		dm.logger.Infof("Started run container %s", containerName)
+		return 0, nil
+	}
+
+	exitCode, err := dm.Wait(ctx, resp.ID)
+
+	if err == nil && opts.Output != nil {
+		if reader, logErr := dm.client.ContainerLogs(ctx, resp.ID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true}); logErr == nil {
+			stdcopy.StdCopy(opts.Output, opts.Output, reader)
+			reader.Close()
+		}
+	}
+
+	if opts.Remove {
+		dm.client.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
+		removeSecretsDir(secretsDir)
+	}
+	return int(exitCode), err
 }
 
 // StartContainer starts a container
@@ -126,35 +355,130 @@ func (dm *DockerManager) StartContainer(ctx context.Context, containerID string)
 	return nil
 }
 
-// StopContainer stops a container with timeout
+// StopContainer sends the container its stop signal, SIGTERM unless its
+// service sets stop_signal, and SIGKILL if it is still running after
+// timeoutSecs
 func (dm *DockerManager) StopContainer(ctx context.Context, containerID string, timeoutSecs int) error {
 	dm.logger.Infof("Stopping container: %s", containerID[:12])
 
-	timeout := time.Duration(timeoutSecs) * time.Second
-	err := dm.client.ContainerStop(ctx, containerID, &timeout)
+	inspect, err := dm.client.ContainerInspect(ctx, containerID)
 	if err != nil {
 		return fmt.Errorf("failed to stop container: %w", err)
 	}
+	if inspect.State == nil || !inspect.State.Running {
+		return nil
+	}
+	signal := "SIGTERM"
+	if inspect.Config != nil && inspect.Config.StopSignal != "" {
+		signal = inspect.Config.StopSignal
+	}
+
+	if err := dm.client.ContainerKill(ctx, containerID, signal); err != nil {
+		return fmt.Errorf("failed to send %s to container: %w", signal, err)
+	}
+
+	timeout := time.Duration(timeoutSecs) * time.Second
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if _, err := dm.Wait(waitCtx, containerID); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to stop container: %w", ctx.Err())
+		}
+		dm.logger.Warnf("Container %s did not stop within %s after %s, sending SIGKILL", containerID[:12], timeout, signal)
+		if err := dm.client.ContainerKill(ctx, containerID, "SIGKILL"); err != nil {
+			return fmt.Errorf("failed to kill container: %w", err)
+		}
+		if _, err := dm.Wait(ctx, containerID); err != nil {
+			return fmt.Errorf("failed to stop container: %w", err)
+		}
+	}
 
 	dm.logger.Infof("Container %s stopped successfully", containerID[:12])
 	return nil
 }
 
-// RemoveContainer removes a container
-func (dm *DockerManager) RemoveContainer(ctx context.Context, containerID string) error {
+// RemoveContainer removes a container, optionally along with its anonymous volumes
+func (dm *DockerManager) RemoveContainer(ctx context.Context, containerID string, removeVolumes bool) error {
 	dm.logger.Infof("Removing container: %s", containerID[:12])
 
+	var secretsDir string
+	if info, err := dm.client.ContainerInspect(ctx, containerID); err == nil && info.Config != nil {
+		secretsDir = info.Config.Labels[LabelSecretsDir]
+	}
+
 	err := dm.client.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{
-		Force: true,
+		Force:         true,
+		RemoveVolumes: removeVolumes,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to remove container: %w", err)
 	}
+	removeSecretsDir(secretsDir)
 
 	dm.logger.Infof("Container %s removed successfully", containerID[:12])
 	return nil
 }
 
+// RenameContainer gives a container a new name, as recreate does to keep
+// the old container aside until its replacement runs
+func (dm *DockerManager) RenameContainer(ctx context.Context, containerID, newName string) error {
+	dm.logger.Debugf("Renaming container %s to %s", containerID[:12], newName)
+	if err := dm.client.ContainerRename(ctx, containerID, newName); err != nil {
+		return fmt.Errorf("failed to rename container: %w", err)
+	}
+	return nil
+}
+
+// ListServiceContainers lists the project's containers, including stopped
+// ones, that match the given filters
+func (dm *DockerManager) ListServiceContainers(ctx context.Context, f filters.Args) ([]ContainerInfo, error) {
+	f = f.Clone()
+	f.Add("label", LabelProject+"="+dm.project)
+	return dm.listContainers(ctx, f)
+}
+
+// ListProjectContainers lists the containers of every compose project
+func (dm *DockerManager) ListProjectContainers(ctx context.Context) ([]ContainerInfo, error) {
+	return dm.listContainers(ctx, filters.NewArgs(filters.Arg("label", LabelProject)))
+}
+
+func (dm *DockerManager) listContainers(ctx context.Context, f filters.Args) ([]ContainerInfo, error) {
+	containers, err := dm.client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: f,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	infos := make([]ContainerInfo, 0, len(containers))
+	for _, c := range containers {
+		info := ContainerInfo{
+			ID:      c.ID,
+			Service: c.Labels[LabelService],
+			Image:   c.Image,
+			State:   c.State,
+			Status:  c.Status,
+			Labels:  c.Labels,
+		}
+		if len(c.Names) > 0 {
+			info.Name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		info.Number, _ = strconv.Atoi(c.Labels[LabelContainerNumber])
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// entrypoint returns nil for an empty override so the image's default is
+// kept; Docker treats an empty slice as "no entrypoint"
+func entrypoint(override []string) []string {
+	if len(override) == 0 {
+		return nil
+	}
+	return override
+}
+
 // RunInitContainer runs an init container and waits for completion
 func (dm *DockerManager) RunInitContainer(ctx context.Context, serviceName string, initContainer *compose.InitContainer) error {
 	dm.logger.Infof("Running init container: %s for service %s", initContainer.Name, serviceName)
@@ -166,9 +490,11 @@ func (dm *DockerManager) RunInitContainer(ctx context.Context, serviceName strin
 
 	// Container configuration
 	config := &container.Config{
-		Image: initContainer.Image,
-		Cmd:   initContainer.Command,
-		Env:   dm.prepareEnv(initContainer.Environment),
+		Image:      initContainer.Image,
+		Cmd:        initContainer.Command,
+		Entrypoint: entrypoint(initContainer.Entrypoint),
+		WorkingDir: initContainer.WorkingDir,
+		Env:        dm.prepareEnv(initContainer.Environment),
 	}
 
 	// Host configuration
@@ -196,6 +522,8 @@ func (dm *DockerManager) RunInitContainer(ctx context.Context, serviceName strin
 		return fmt.Errorf("failed to start init container: %w", err)
 	}
 
+	streamed := dm.streamOutput(ctx, resp.ID, "init", initContainer.Name, dm.initLogs)
+
 	// Wait for completion
 	statusCh, errCh := dm.client.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
 	select {
@@ -205,11 +533,12 @@ func (dm *DockerManager) RunInitContainer(ctx context.Context, serviceName strin
 			return fmt.Errorf("error waiting for init container: %w", err)
 		}
 	case status := <-statusCh:
+		<-streamed
 		if status.StatusCode != 0 {
 			// Get logs for debugging
 			logs, _ := dm.getContainerLogs(ctx, resp.ID)
 			dm.client.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
-			return fmt.Errorf("init container exited with code %d: %s", status.StatusCode, logs)
+			return &cerrors.ContainerRunError{Name: initContainer.Name, ExitCode: int(status.StatusCode), Logs: logs}
 		}
 	}
 
@@ -220,16 +549,39 @@ func (dm *DockerManager) RunInitContainer(ctx context.Context, serviceName strin
 	return nil
 }
 
+// streamOutput copies the output of an init or post container to w while it
+// runs, each line prefixed with [<kind>:<name>] in yellow. The returned
+// channel is closed once the output ends, which follows the container's
+// exit; it is closed immediately when w is nil.
+func (dm *DockerManager) streamOutput(ctx context.Context, containerID, kind, name string, w io.Writer) <-chan struct{} {
+	done := make(chan struct{})
+	if w == nil {
+		close(done)
+		return done
+	}
+
+	prefix := term.Color(term.Yellow, fmt.Sprintf("[%s:%s]", kind, name))
+	go func() {
+		defer close(done)
+		err := dm.FollowLogs(ctx, containerID, name, func(line LogLine) {
+			fmt.Fprintf(w, "%s %s\n", prefix, line.Text)
+		})
+		if err != nil {
+			dm.logger.Warnf("Failed to stream output of %s container %s: %v", kind, name, err)
+		}
+	}()
+	return done
+}
+
 // RunPostContainer runs a post container and waits for completion
-func (dm *DockerManager) RunPostContainer(ctx context.Context, serviceName string, postContainer *compose.PostContainer) error {
+func (dm *DockerManager) RunPostContainer(ctx context.Context, serviceName string, postContainer *compose.PostContainer, data template.Data) error {
 	dm.logger.Infof("Running post container: %s for service %s", postContainer.Name, serviceName)
 
-	// Wait for specified duration if configured
-	if postContainer.WaitFor != "" {
-		if duration, err := time.ParseDuration(postContainer.WaitFor); err == nil {
-			dm.logger.Infof("Waiting %s before running post container", postContainer.WaitFor)
-			time.Sleep(duration)
-		}
+	// Wait for specified duration if configured; service health conditions
+	// are resolved by the caller, which knows the service containers
+	if duration, _, err := postContainer.WaitCondition(); err == nil && duration > 0 {
+		dm.logger.Infof("Waiting %s before running post container", postContainer.WaitFor)
+		time.Sleep(duration)
 	}
 
 	// Ensure image exists
@@ -237,11 +589,18 @@ func (dm *DockerManager) RunPostContainer(ctx context.Context, serviceName strin
 		return fmt.Errorf("failed to ensure post container image %s: %w", postContainer.Image, err)
 	}
 
+	command, err := template.RenderAll(postContainer.Command, data)
+	if err != nil {
+		return fmt.Errorf("post container %s: %w", postContainer.Name, err)
+	}
+
 	// Container configuration
 	config := &container.Config{
-		Image: postContainer.Image,
-		Cmd:   postContainer.Command,
-		Env:   dm.prepareEnv(postContainer.Environment),
+		Image:      postContainer.Image,
+		Cmd:        command,
+		Entrypoint: entrypoint(postContainer.Entrypoint),
+		WorkingDir: postContainer.WorkingDir,
+		Env:        dm.prepareEnv(postContainer.Environment),
 	}
 
 	// Host configuration
@@ -269,6 +628,8 @@ func (dm *DockerManager) RunPostContainer(ctx context.Context, serviceName strin
 		return fmt.Errorf("failed to start post container: %w", err)
 	}
 
+	streamed := dm.streamOutput(ctx, resp.ID, "post", postContainer.Name, dm.postLogs)
+
 	// Wait for completion
 	statusCh, errCh := dm.client.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
 	select {
@@ -278,11 +639,12 @@ func (dm *DockerManager) RunPostContainer(ctx context.Context, serviceName strin
 			return fmt.Errorf("error waiting for post container: %w", err)
 		}
 	case status := <-statusCh:
+		<-streamed
 		if status.StatusCode != 0 {
 			// Get logs for debugging
 			logs, _ := dm.getContainerLogs(ctx, resp.ID)
 			dm.client.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{Force: true})
-			return fmt.Errorf("post container exited with code %d: %s", status.StatusCode, logs)
+			return &cerrors.ContainerRunError{Name: postContainer.Name, ExitCode: int(status.StatusCode), Logs: logs}
 		}
 	}
 
@@ -293,46 +655,518 @@ func (dm *DockerManager) RunPostContainer(ctx context.Context, serviceName strin
 	return nil
 }
 
-// Close closes the Docker client
-func (dm *DockerManager) Close() error {
-	dm.logger.Info("Closing Docker client connection")
-	return dm.client.Close()
+// IsHealthy reports whether a container passes its healthcheck. Containers
+// without a healthcheck are considered healthy once running.
+func (dm *DockerManager) IsHealthy(ctx context.Context, containerID string) (bool, error) {
+	info, err := dm.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	if info.State == nil {
+		return false, nil
+	}
+	if info.State.Health == nil {
+		if !info.State.Running && info.State.Status == "exited" {
+			return false, fmt.Errorf("container exited with code %d", info.State.ExitCode)
+		}
+		return info.State.Running, nil
+	}
+
+	switch info.State.Health.Status {
+	case types.Healthy:
+		return true, nil
+	case types.Unhealthy:
+		return false, fmt.Errorf("container is unhealthy")
+	default:
+		return false, nil
+	}
 }
 
-// Helper methods
+// ServiceLogs returns the last tail lines of a service container's output.
+// A tail of zero returns the full log.
+func (dm *DockerManager) ServiceLogs(ctx context.Context, serviceName string, number, tail int) ([]LogLine, error) {
+	tailOpt := "all"
+	if tail > 0 {
+		tailOpt = strconv.Itoa(tail)
+	}
 
-func (dm *DockerManager) ensureImage(ctx context.Context, imageName string) error {
-	// Check if image exists locally
-	images, err := dm.client.ImageList(ctx, types.ImageListOptions{})
+	reader, err := dm.client.ContainerLogs(ctx, ContainerName(dm.project, serviceName, number), types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Tail:       tailOpt,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to list images: %w", err)
+		return nil, fmt.Errorf("failed to read logs for service %s: %w", serviceName, err)
+	}
+	defer reader.Close()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, reader); err != nil {
+		return nil, fmt.Errorf("failed to demultiplex logs for service %s: %w", serviceName, err)
+	}
+
+	var lines []LogLine
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		line := parseLogLine(serviceName, scanner.Text())
+		line.Number = number
+		lines = append(lines, line)
 	}
+	return lines, scanner.Err()
+}
 
-	for _, img := range images {
-		for _, tag := range img.RepoTags {
-			if tag == imageName {
-				return nil // Image exists
+// ResolveDigest asks the registry for the manifest digest of an image
+func (dm *DockerManager) ResolveDigest(ctx context.Context, image string) (string, error) {
+	inspect, err := dm.client.DistributionInspect(ctx, image, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect %s in registry: %w", image, err)
+	}
+	return inspect.Descriptor.Digest.String(), nil
+}
+
+// EnsureVolume creates a named volume with the given labels unless it
+// already exists, reporting whether it was created
+func (dm *DockerManager) EnsureVolume(ctx context.Context, name string, volume *compose.Volume, labels map[string]string) (bool, error) {
+	if _, err := dm.client.VolumeInspect(ctx, name); err == nil {
+		return false, nil
+	} else if !client.IsErrNotFound(err) {
+		return false, fmt.Errorf("failed to inspect volume %s: %w", name, err)
+	}
+
+	body := volumetypes.VolumeCreateBody{Name: name, Labels: labels}
+	if volume != nil {
+		body.Driver = volume.Driver
+		body.DriverOpts = volume.DriverOpts
+	}
+	if _, err := dm.client.VolumeCreate(ctx, body); err != nil {
+		return false, fmt.Errorf("failed to create volume %s: %w", name, err)
+	}
+
+	dm.logger.Infof("Created volume %s", name)
+	return true, nil
+}
+
+// EnsureNetwork creates a network with the given labels unless one with
+// that name already exists, reporting whether it was created
+func (dm *DockerManager) EnsureNetwork(ctx context.Context, name string, net *compose.Network, labels map[string]string) (bool, error) {
+	f := filters.NewArgs()
+	f.Add("name", name)
+	networks, err := dm.client.NetworkList(ctx, types.NetworkListOptions{Filters: f})
+	if err != nil {
+		return false, fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, n := range networks {
+		// The name filter matches substrings
+		if n.Name == name {
+			return false, nil
+		}
+	}
+
+	create := types.NetworkCreate{CheckDuplicate: true, Labels: labels}
+	if net != nil {
+		create.Driver = net.Driver
+		create.Options = net.DriverOpts
+	}
+	if _, err := dm.client.NetworkCreate(ctx, name, create); err != nil {
+		return false, fmt.Errorf("failed to create network %s: %w", name, err)
+	}
+
+	dm.logger.Infof("Created network %s", name)
+	return true, nil
+}
+
+// ListVolumes returns the names of the volumes matching the given filters
+func (dm *DockerManager) ListVolumes(ctx context.Context, f filters.Args) ([]string, error) {
+	resp, err := dm.client.VolumeList(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+
+	names := make([]string, 0, len(resp.Volumes))
+	for _, v := range resp.Volumes {
+		names = append(names, v.Name)
+	}
+	return names, nil
+}
+
+// RemoveVolume removes a named volume
+func (dm *DockerManager) RemoveVolume(ctx context.Context, name string) error {
+	if err := dm.client.VolumeRemove(ctx, name, false); err != nil {
+		return fmt.Errorf("failed to remove volume %s: %w", name, err)
+	}
+	dm.logger.Infof("Removed volume %s", name)
+	return nil
+}
+
+// SaveImage writes an image as a tar archive, as `docker save` does
+func (dm *DockerManager) SaveImage(ctx context.Context, image string, w io.Writer) error {
+	reader, err := dm.client.ImageSave(ctx, []string{image})
+	if err != nil {
+		return fmt.Errorf("failed to save image %s: %w", image, err)
+	}
+	defer reader.Close()
+
+	if _, err := io.Copy(w, reader); err != nil {
+		return fmt.Errorf("failed to write image %s: %w", image, err)
+	}
+	return nil
+}
+
+// LoadImage loads images from a tar archive, which may be gzip compressed
+func (dm *DockerManager) LoadImage(ctx context.Context, r io.Reader) error {
+	resp, err := dm.client.ImageLoad(ctx, r, true)
+	if err != nil {
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+// InspectContainer returns Docker's detailed view of a container
+func (dm *DockerManager) InspectContainer(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	info, err := dm.client.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return types.ContainerJSON{}, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return info, nil
+}
+
+// FollowLogs streams a container's output line by line to fn until the
+// container stops or ctx is cancelled
+func (dm *DockerManager) FollowLogs(ctx context.Context, containerID, serviceName string, fn func(LogLine)) error {
+	reader, err := dm.client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Follow:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to follow logs for service %s: %w", serviceName, err)
+	}
+	defer reader.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, reader)
+		pw.CloseWithError(err)
+	}()
+
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		fn(parseLogLine(serviceName, scanner.Text()))
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+// parseLogLine splits the RFC3339 timestamp Docker prefixes to each line
+func parseLogLine(serviceName, text string) LogLine {
+	line := LogLine{Service: serviceName, Text: text}
+	if ts, rest, ok := strings.Cut(text, " "); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			line.Timestamp = parsed
+			line.Text = rest
+		}
+	}
+	return line
+}
+
+// Wait blocks until the container is no longer running and returns its exit
+// code
+func (dm *DockerManager) Wait(ctx context.Context, containerID string) (int64, error) {
+	statusCh, errCh := dm.client.ContainerWait(ctx, containerID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return -1, fmt.Errorf("error waiting for container %s: %w", containerID, err)
+	case status := <-statusCh:
+		if status.Error != nil {
+			return -1, fmt.Errorf("error waiting for container %s: %s", containerID, status.Error.Message)
+		}
+		return status.StatusCode, nil
+	}
+}
+
+// Events streams the project's container events. The daemon closes the
+// stream at opts.Until; any other end is reported as an error.
+func (dm *DockerManager) Events(ctx context.Context, opts EventsOptions, fn func(Event)) error {
+	f := filters.NewArgs()
+	kinds := opts.Types
+	if len(kinds) == 0 {
+		kinds = []string{"container"}
+	}
+	for _, kind := range kinds {
+		f.Add("type", kind)
+	}
+	for _, action := range opts.Actions {
+		f.Add("event", action)
+	}
+	f.Add("label", LabelProject+"="+dm.project)
+
+	eventOpts := types.EventsOptions{Filters: f}
+	if !opts.Since.IsZero() {
+		eventOpts.Since = eventTimestamp(opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		eventOpts.Until = eventTimestamp(opts.Until)
+	}
+
+	messages, errs := dm.client.Events(ctx, eventOpts)
+	for {
+		select {
+		case msg := <-messages:
+			event := Event{
+				Time:        time.Unix(0, msg.TimeNano),
+				Type:        msg.Type,
+				Action:      msg.Action,
+				Service:     msg.Actor.Attributes[LabelService],
+				ContainerID: msg.Actor.ID,
+			}
+			if opts.Matches(event) {
+				fn(event)
+			}
+		case err := <-errs:
+			if ctx.Err() != nil || (err == io.EOF && !opts.Until.IsZero() && !time.Now().Before(opts.Until)) {
+				return nil
+			}
+			if err == io.EOF {
+				return fmt.Errorf("event stream closed")
+			}
+			return fmt.Errorf("event stream failed: %w", err)
+		}
+	}
+}
+
+// eventTimestamp formats t the way the events API expects, as seconds with
+// a nanosecond fraction
+func eventTimestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// ListServiceImages inspects every image the compose file references.
+// Images missing locally are reported as not pulled.
+func (dm *DockerManager) ListServiceImages(ctx context.Context, composeFile *compose.ComposeFile) ([]ImageInfo, error) {
+	var images []ImageInfo
+	for _, ref := range imageReferences(composeFile) {
+		repository, tag, digest := splitImageReference(ref)
+		info := ImageInfo{Reference: ref, Repository: repository, Tag: tag, Digest: digest}
+
+		inspect, _, err := dm.client.ImageInspectWithRaw(ctx, ref)
+		if client.IsErrNotFound(err) {
+			images = append(images, info)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+		}
+
+		info.Pulled = true
+		info.ID = inspect.ID
+		info.Size = inspect.Size
+		if created, err := time.Parse(time.RFC3339Nano, inspect.Created); err == nil {
+			info.Created = &created
+		}
+		if info.Digest == "" {
+			for _, repoDigest := range inspect.RepoDigests {
+				if name, d, found := strings.Cut(repoDigest, "@"); found && name == repository {
+					info.Digest = d
+					break
+				}
 			}
 		}
+		images = append(images, info)
+	}
+	return images, nil
+}
+
+// InspectNetwork returns Docker's detailed view of a network
+func (dm *DockerManager) InspectNetwork(ctx context.Context, name string) (types.NetworkResource, error) {
+	info, err := dm.client.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	if err != nil {
+		return types.NetworkResource{}, fmt.Errorf("failed to inspect network %s: %w", name, err)
+	}
+	return info, nil
+}
+
+// volumeHelperImage backs the throwaway container CopyVolume mounts a
+// volume into
+const volumeHelperImage = "busybox:latest"
+
+// CopyVolume writes the contents of a named volume to w as a tar archive.
+// The volume is mounted read-only into a container that is created but
+// never started, and copied out of it as `docker cp` does.
+func (dm *DockerManager) CopyVolume(ctx context.Context, name string, w io.Writer) error {
+	if err := dm.ensureImage(ctx, volumeHelperImage); err != nil {
+		return err
+	}
+
+	resp, err := dm.client.ContainerCreate(ctx,
+		&container.Config{Image: volumeHelperImage},
+		&container.HostConfig{
+			Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: name, Target: "/volume", ReadOnly: true}},
+		},
+		nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create helper container for volume %s: %w", name, err)
+	}
+	defer dm.client.ContainerRemove(context.Background(), resp.ID, types.ContainerRemoveOptions{Force: true})
+
+	reader, _, err := dm.client.CopyFromContainer(ctx, resp.ID, "/volume/.")
+	if err != nil {
+		return fmt.Errorf("failed to copy volume %s: %w", name, err)
+	}
+	defer reader.Close()
+
+	if _, err := io.Copy(w, reader); err != nil {
+		return fmt.Errorf("failed to write volume %s: %w", name, err)
+	}
+	return nil
+}
+
+// PullImage fetches an image according to opts.Policy, reporting whether
+// the registry was contacted
+func (dm *DockerManager) PullImage(ctx context.Context, ref string, opts PullOptions) (bool, error) {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = dm.pullTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if opts.Policy == PullMissing {
+		if _, _, err := dm.client.ImageInspectWithRaw(ctx, ref); err == nil {
+			return false, nil
+		} else if !client.IsErrNotFound(err) {
+			return false, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+		}
 	}
 
-	// Pull the image
-	dm.logger.Infof("Pulling image: %s", imageName)
-	reader, err := dm.client.ImagePull(ctx, imageName, types.ImagePullOptions{})
+	dm.logger.Infof("Pulling image: %s", ref)
+	start := time.Now()
+	reader, err := dm.client.ImagePull(ctx, ref, types.ImagePullOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to pull image: %w", err)
+		return false, pullError(ctx, ref, start, err)
 	}
 	defer reader.Close()
 
-	// Copy pull output to stdout (shows pull progress)
-	_, err = io.Copy(os.Stdout, reader)
+	if err := writeProgress(ref, reader, opts.Progress, opts.Quiet); err != nil {
+		return false, pullError(ctx, ref, start, err)
+	}
+	return true, nil
+}
+
+// PushImage uploads an image to its registry
+func (dm *DockerManager) PushImage(ctx context.Context, ref string, opts PushOptions) error {
+	registryAuth, err := encodeAuth(opts.Auth)
 	if err != nil {
-		return fmt.Errorf("error reading pull output: %w", err)
+		return fmt.Errorf("failed to encode credentials for %s: %w", ref, err)
 	}
 
+	dm.logger.Infof("Pushing image: %s", ref)
+	reader, err := dm.client.ImagePush(ctx, ref, types.ImagePushOptions{RegistryAuth: registryAuth})
+	if err != nil {
+		return fmt.Errorf("failed to push image %s: %w", ref, err)
+	}
+	defer reader.Close()
+
+	if err := writeProgress(ref, reader, opts.Progress, false); err != nil {
+		return fmt.Errorf("failed to push image %s: %w", ref, err)
+	}
 	return nil
 }
 
+// ImageID returns the ID of a local image
+func (dm *DockerManager) ImageID(ctx context.Context, image string) (string, error) {
+	inspect, _, err := dm.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	return inspect.ID, nil
+}
+
+// Top lists the processes running in a container, as `ps -ef` does
+func (dm *DockerManager) Top(ctx context.Context, containerID string) (container.ContainerTopOKBody, error) {
+	top, err := dm.client.ContainerTop(ctx, containerID, nil)
+	if err != nil {
+		return container.ContainerTopOKBody{}, fmt.Errorf("failed to list processes: %w", err)
+	}
+	return top, nil
+}
+
+// Exec runs a command in a running container and returns its exit code.
+// A detached command's exit code is not waited for and reported as 0.
+func (dm *DockerManager) Exec(ctx context.Context, containerID string, command []string, opts ExecOptions) (int, error) {
+	created, err := dm.client.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		User:         opts.User,
+		Cmd:          command,
+		Detach:       opts.Detach,
+		AttachStdout: !opts.Detach,
+		AttachStderr: !opts.Detach,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	if opts.Detach {
+		if err := dm.client.ContainerExecStart(ctx, created.ID, types.ExecStartCheck{Detach: true}); err != nil {
+			return -1, fmt.Errorf("failed to start exec: %w", err)
+		}
+		return 0, nil
+	}
+
+	resp, err := dm.client.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{})
+	if err != nil {
+		return -1, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer resp.Close()
+
+	output := opts.Output
+	if output == nil {
+		output = io.Discard
+	}
+	if _, err := stdcopy.StdCopy(output, output, resp.Reader); err != nil {
+		return -1, fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	inspect, err := dm.client.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return -1, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	return inspect.ExitCode, nil
+}
+
+// Close closes the Docker client
+func (dm *DockerManager) Close() error {
+	dm.logger.Info("Closing Docker client connection")
+	return dm.client.Close()
+}
+
+// Helper methods
+
+func (dm *DockerManager) ensureImage(ctx context.Context, imageName string) error {
+	_, err := dm.PullImage(ctx, imageName, PullOptions{Policy: PullMissing, Progress: os.Stdout})
+	return err
+}
+
+// parseRestartPolicy converts a compose restart value such as
+// "on-failure:3" into a Docker restart policy
+func parseRestartPolicy(restart string) container.RestartPolicy {
+	name, retries, found := strings.Cut(restart, ":")
+	policy := container.RestartPolicy{Name: name}
+	if found && name == "on-failure" {
+		policy.MaximumRetryCount, _ = strconv.Atoi(retries)
+	}
+	return policy
+}
+
 func (dm *DockerManager) prepareEnv(envMap map[string]string) []string {
 	var env []string
 	for key, value := range envMap {
@@ -351,10 +1185,11 @@ func (dm *DockerManager) getContainerLogs(ctx context.Context, containerID strin
 	}
 	defer reader.Close()
 
-	logs, err := io.ReadAll(reader)
-	if err != nil {
+	// The stream multiplexes stdout and stderr behind frame headers
+	var logs bytes.Buffer
+	if _, err := stdcopy.StdCopy(&logs, &logs, reader); err != nil {
 		return "", err
 	}
 
-	return string(logs), nil
+	return logs.String(), nil
 }
\ No newline at end of file
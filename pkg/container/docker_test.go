@@ -0,0 +1,88 @@
+package container
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// versionPrefix matches the API version the client prefixes paths with
+var versionPrefix = regexp.MustCompile(`^/v[0-9.]+`)
+
+// fakeDocker is a Docker daemon answering the requests registered with
+// handle, keyed by method and unversioned path, and recording every request
+type fakeDocker struct {
+	mu       sync.Mutex
+	routes   map[string]http.HandlerFunc
+	requests []string
+}
+
+func (f *fakeDocker) handle(method, path string, h http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[method+" "+path] = h
+}
+
+// requested returns the requests made so far as "METHOD path?query"
+func (f *fakeDocker) requested() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func (f *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := versionPrefix.ReplaceAllString(r.URL.Path, "")
+	w.Header().Set("API-Version", "1.41")
+
+	f.mu.Lock()
+	request := r.Method + " " + path
+	if r.URL.RawQuery != "" {
+		request += "?" + r.URL.RawQuery
+	}
+	f.requests = append(f.requests, request)
+	h, exists := f.routes[r.Method+" "+path]
+	f.mu.Unlock()
+
+	switch {
+	case exists:
+		h(w, r)
+	case path == "/_ping":
+		io.WriteString(w, "OK")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		writeJSON(w, map[string]string{"message": "no route for " + r.Method + " " + path})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// newFakeDocker starts a fake daemon and connects a DockerManager to it
+func newFakeDocker(t *testing.T) (*fakeDocker, *DockerManager) {
+	t.Helper()
+	for _, name := range []string{"DOCKER_HOST", "DOCKER_API_VERSION", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY"} {
+		t.Setenv(name, "")
+	}
+
+	fake := &fakeDocker{routes: make(map[string]http.HandlerFunc)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	dm, err := NewDockerManager(logger, Options{Host: "tcp://" + strings.TrimPrefix(server.URL, "http://"), Project: "demo"})
+	if err != nil {
+		t.Fatalf("NewDockerManager: %v", err)
+	}
+	t.Cleanup(func() { dm.Close() })
+	return fake, dm
+}
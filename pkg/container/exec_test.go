@@ -0,0 +1,100 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/sirupsen/logrus"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares got with testdata/<name>.golden, rewriting the
+// file instead when run with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func newTestStub() *StubManager {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewStubManager(logger, "demo")
+}
+
+// runningStub returns a stub with a running container for service web
+func runningStub(t *testing.T) (*StubManager, string) {
+	t.Helper()
+	s := newTestStub()
+	ctx := context.Background()
+	containerID, err := s.CreateService(ctx, "web", &compose.Service{Image: "node:20"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartContainer(ctx, containerID); err != nil {
+		t.Fatal(err)
+	}
+	return s, containerID
+}
+
+func TestStubExecGolden(t *testing.T) {
+	s, containerID := runningStub(t)
+
+	tests := []struct {
+		golden  string
+		command []string
+	}{
+		{"exec_cat_package_json", []string{"cat", "package.json"}},
+		{"exec_curl", []string{"curl", "-s", "http://localhost:3000/health"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			var out bytes.Buffer
+			code, err := s.Exec(context.Background(), containerID, tt.command, ExecOptions{Output: &out})
+			if err != nil {
+				t.Fatalf("Exec: %v", err)
+			}
+			if code != 0 {
+				t.Errorf("exit code = %d, want 0", code)
+			}
+			if bytes.Contains(out.Bytes(), []byte(`\n`)) || bytes.Contains(out.Bytes(), []byte(`\"`)) {
+				t.Errorf("output has literal escapes: %q", out.String())
+			}
+			assertGolden(t, tt.golden, out.Bytes())
+		})
+	}
+}
+
+func TestStubExecCatMissingFile(t *testing.T) {
+	s, containerID := runningStub(t)
+
+	var out bytes.Buffer
+	code, err := s.Exec(context.Background(), containerID, []string{"cat", "missing.txt"}, ExecOptions{Output: &out})
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	if want := "cat: missing.txt: No such file or directory\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
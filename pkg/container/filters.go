@@ -0,0 +1,91 @@
+package container
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/filters"
+)
+
+// containerStates are the values a status filter accepts
+var containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+
+// ParseContainerFilters turns `--filter` expressions into container list
+// filters. status=<state> selects containers in that state, service=<name>
+// those of a service and label=<key>[=<value>] those carrying a label.
+// Filters of different kinds must all match, as must repeated labels;
+// repeated status filters match any of the states.
+func ParseContainerFilters(expressions []string) (filters.Args, error) {
+	f := filters.NewArgs()
+	for _, expression := range expressions {
+		key, value, found := strings.Cut(expression, "=")
+		if !found || value == "" {
+			return filters.Args{}, fmt.Errorf("invalid filter %q: expected KEY=VALUE", expression)
+		}
+
+		switch key {
+		case "status":
+			if !slices.Contains(containerStates, value) {
+				return filters.Args{}, fmt.Errorf("invalid filter %q: status must be one of %s", expression, strings.Join(containerStates, ", "))
+			}
+			f.Add("status", value)
+		case "service":
+			f.Add("label", LabelService+"="+value)
+		case "label":
+			f.Add("label", value)
+		default:
+			return filters.Args{}, fmt.Errorf("invalid filter %q: unknown filter %s, expected status, service or label", expression, key)
+		}
+	}
+	return f, nil
+}
+
+// eventTypes are the values an events type filter accepts
+var eventTypes = []string{"container", "image", "network", "volume"}
+
+// ParseEventFilters adds `--filter` expressions for the events command to
+// opts. event=<action> selects events with that action, such as start or
+// health_status, and type=<type> events about that kind of object.
+// Repeated filters of one kind match any of their values.
+func ParseEventFilters(expressions []string, opts *EventsOptions) error {
+	for _, expression := range expressions {
+		key, value, found := strings.Cut(expression, "=")
+		if !found || value == "" {
+			return fmt.Errorf("invalid filter %q: expected KEY=VALUE", expression)
+		}
+
+		switch key {
+		case "event":
+			opts.Actions = append(opts.Actions, value)
+		case "type":
+			if !slices.Contains(eventTypes, value) {
+				return fmt.Errorf("invalid filter %q: type must be one of %s", expression, strings.Join(eventTypes, ", "))
+			}
+			opts.Types = append(opts.Types, value)
+		default:
+			return fmt.Errorf("invalid filter %q: unknown filter %s, expected event or type", expression, key)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether an event passes the service, action and type
+// filters. Actions with a status, such as "health_status: healthy", match
+// on the part before the colon as well. Events about networks, volumes and
+// images belong to no service and pass the service filter.
+func (o EventsOptions) Matches(event Event) bool {
+	if len(o.Services) > 0 && event.Service != "" && !slices.Contains(o.Services, event.Service) {
+		return false
+	}
+	if len(o.Types) > 0 && !slices.Contains(o.Types, event.Type) {
+		return false
+	}
+	if len(o.Actions) > 0 {
+		action, _, _ := strings.Cut(event.Action, ":")
+		if !slices.Contains(o.Actions, event.Action) && !slices.Contains(o.Actions, action) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package container
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseEventFilters(t *testing.T) {
+	var opts EventsOptions
+	err := ParseEventFilters([]string{"event=start", "type=container", "event=health_status", "type=network"}, &opts)
+	if err != nil {
+		t.Fatalf("ParseEventFilters: %v", err)
+	}
+	if !slices.Equal(opts.Actions, []string{"start", "health_status"}) {
+		t.Errorf("Actions = %v, want [start health_status]", opts.Actions)
+	}
+	if !slices.Equal(opts.Types, []string{"container", "network"}) {
+		t.Errorf("Types = %v, want [container network]", opts.Types)
+	}
+}
+
+func TestParseEventFiltersErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"event", "expected KEY=VALUE"},
+		{"event=", "expected KEY=VALUE"},
+		{"type=plugin", "type must be one of container, image, network, volume"},
+		{"status=running", "unknown filter status, expected event or type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			var opts EventsOptions
+			err := ParseEventFilters([]string{tt.expression}, &opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseEventFilters(%q) = %v, want an error containing %q", tt.expression, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventsOptionsMatches(t *testing.T) {
+	start := Event{Type: "container", Action: "start", Service: "web"}
+	healthy := Event{Type: "container", Action: "health_status: healthy", Service: "web"}
+	network := Event{Type: "network", Action: "connect"}
+
+	tests := []struct {
+		name  string
+		opts  EventsOptions
+		event Event
+		want  bool
+	}{
+		{"no filters", EventsOptions{}, start, true},
+		{"service", EventsOptions{Services: []string{"web"}}, start, true},
+		{"other service", EventsOptions{Services: []string{"db"}}, start, false},
+		{"event without a service", EventsOptions{Services: []string{"db"}}, network, true},
+		{"type", EventsOptions{Types: []string{"container"}}, start, true},
+		{"other type", EventsOptions{Types: []string{"network"}}, start, false},
+		{"any of the types", EventsOptions{Types: []string{"network", "container"}}, start, true},
+		{"action", EventsOptions{Actions: []string{"start"}}, start, true},
+		{"other action", EventsOptions{Actions: []string{"stop"}}, start, false},
+		{"action before the status", EventsOptions{Actions: []string{"health_status"}}, healthy, true},
+		{"action with the status", EventsOptions{Actions: []string{"health_status: healthy"}}, healthy, true},
+		{"type and action", EventsOptions{Types: []string{"network"}, Actions: []string{"start"}}, start, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches(%+v) = %v, want %v", tt.event, got, tt.want)
+			}
+		})
+	}
+}
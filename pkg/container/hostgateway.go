@@ -0,0 +1,115 @@
+package container
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// HostGateway is the extra_hosts value standing for the host's IP address
+const HostGateway = "host-gateway"
+
+// defaultBridgeGateway is the gateway of Docker's default bridge network,
+// used when the host's gateway cannot be detected
+const defaultBridgeGateway = "172.17.0.1"
+
+var (
+	hostGatewayOnce sync.Once
+	hostGatewayIP   string
+	hostGatewayErr  error
+)
+
+// resolveHostGateway returns the host's default gateway address. The result
+// is detected once per process.
+func resolveHostGateway() (string, error) {
+	hostGatewayOnce.Do(func() {
+		hostGatewayIP, hostGatewayErr = detectHostGateway()
+		if hostGatewayErr != nil || hostGatewayIP == "" {
+			hostGatewayIP = defaultBridgeGateway
+		}
+	})
+	return hostGatewayIP, hostGatewayErr
+}
+
+func detectHostGateway() (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		f, err := os.Open("/proc/net/route")
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		return parseProcNetRoute(f)
+	case "darwin":
+		out, err := exec.Command("route", "-n", "get", "default").Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to get default route: %w", err)
+		}
+		return parseRouteGet(string(out))
+	default:
+		return "", fmt.Errorf("host gateway detection is not supported on %s", runtime.GOOS)
+	}
+}
+
+// parseProcNetRoute finds the default route's gateway in the contents of
+// /proc/net/route, where addresses are little-endian hex
+func parseProcNetRoute(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			return "", fmt.Errorf("invalid gateway %q in route table", fields[2])
+		}
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+		return ip.String(), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no default route found")
+}
+
+// parseRouteGet extracts the gateway from `route -n get default` output
+func parseRouteGet(output string) (string, error) {
+	for _, line := range strings.Split(output, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "gateway:"); ok {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("no gateway in default route")
+}
+
+// extraHosts converts extra_hosts entries ("host:ip" or "host=ip") to the
+// "host:ip" form Docker expects, resolving host-gateway to the host's IP
+func (dm *DockerManager) extraHosts(entries []string) []string {
+	hosts := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		host, ip, found := strings.Cut(entry, "=")
+		if !found {
+			host, ip, _ = strings.Cut(entry, ":")
+		}
+		if ip == HostGateway {
+			gateway, err := resolveHostGateway()
+			if err != nil {
+				dm.logger.Warnf("Failed to detect host gateway, using %s: %v", gateway, err)
+			}
+			ip = gateway
+		}
+		hosts = append(hosts, host+":"+ip)
+	}
+	return hosts
+}
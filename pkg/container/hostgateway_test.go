@@ -0,0 +1,70 @@
+package container
+
+import (
+	"strings"
+	"testing"
+)
+
+const procNetRoute = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+docker0	000011AC	00000000	0001	0	0	0	0000FFFF	0	0	0
+eth0	0001A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+eth0	00000000	0101A8C0	0003	0	0	100	00000000	0	0	0
+`
+
+func TestParseProcNetRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		want    string
+		wantErr string
+	}{
+		{name: "default route", table: procNetRoute, want: "192.168.1.1"},
+		{name: "docker bridge", table: "Iface\tDestination\tGateway\neth0\t00000000\t010011AC\t0003\n", want: "172.17.0.1"},
+		{name: "no default route", table: strings.Join(strings.Split(procNetRoute, "\n")[:3], "\n"), wantErr: "no default route"},
+		{name: "empty", table: "", wantErr: "no default route"},
+		{name: "bad gateway", table: "Iface\tDestination\tGateway\neth0\t00000000\tZZ01A8C0\t0003\n", wantErr: "invalid gateway"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProcNetRoute(strings.NewReader(tt.table))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseProcNetRoute = %q, %v, want an error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseProcNetRoute = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRouteGet(t *testing.T) {
+	output := `   route to: default
+destination: default
+       mask: default
+    gateway: 192.168.0.254
+  interface: en0
+`
+	if got, err := parseRouteGet(output); err != nil || got != "192.168.0.254" {
+		t.Errorf("parseRouteGet = %q, %v, want 192.168.0.254", got, err)
+	}
+	if _, err := parseRouteGet("route to: default\n"); err == nil {
+		t.Error("parseRouteGet without a gateway line succeeded")
+	}
+}
+
+func TestExtraHostsResolvesHostGateway(t *testing.T) {
+	_, dm := newFakeDocker(t)
+	gateway, _ := resolveHostGateway()
+
+	got := dm.extraHosts([]string{"db:10.0.0.5", "cache=10.0.0.6", "host.docker.internal:host-gateway"})
+	want := []string{"db:10.0.0.5", "cache:10.0.0.6", "host.docker.internal:" + gateway}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("extraHosts = %v, want %v", got, want)
+	}
+	if gateway == "" {
+		t.Error("host-gateway resolved to an empty address")
+	}
+}
@@ -0,0 +1,61 @@
+package container
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// ImageInfo describes an image referenced by the compose file. Images that
+// are not available locally have Pulled unset and only the reference fields.
+type ImageInfo struct {
+	Reference  string    `json:"reference" yaml:"reference"`
+	Repository string    `json:"repository" yaml:"repository"`
+	Tag        string    `json:"tag" yaml:"tag"`
+	Digest     string    `json:"digest,omitempty" yaml:"digest,omitempty"`
+	ID         string    `json:"id,omitempty" yaml:"id,omitempty"`
+	Size       int64     `json:"size,omitempty" yaml:"size,omitempty"`
+	Created    *time.Time `json:"created,omitempty" yaml:"created,omitempty"`
+	Pulled     bool      `json:"pulled" yaml:"pulled"`
+}
+
+// imageReferences returns the unique image references used by the compose
+// file's services and their init and post containers, sorted
+func imageReferences(composeFile *compose.ComposeFile) []string {
+	seen := make(map[string]bool)
+	for _, service := range composeFile.Services {
+		seen[service.Image] = true
+		for _, init := range service.InitContainers {
+			seen[init.Image] = true
+		}
+		for _, post := range service.PostContainers {
+			seen[post.Image] = true
+		}
+	}
+	delete(seen, "")
+
+	refs := make([]string, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// splitImageReference splits "repo:tag" or "repo@sha256:..." into its
+// repository, tag and digest. A missing tag means "latest".
+func splitImageReference(ref string) (repository, tag, digest string) {
+	repository = ref
+	if name, d, found := strings.Cut(ref, "@"); found {
+		repository, digest = name, d
+	}
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+	if tag == "" && digest == "" {
+		tag = "latest"
+	}
+	return repository, tag, digest
+}
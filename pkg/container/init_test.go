@@ -0,0 +1,99 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
+)
+
+// flakyInit is a stub whose init containers fail a number of times before
+// they succeed, each failure with its own logs
+type flakyInit struct {
+	*StubManager
+	failures int
+	calls    []time.Time
+}
+
+func (f *flakyInit) RunInitContainer(ctx context.Context, serviceName string, initContainer *compose.InitContainer) error {
+	f.calls = append(f.calls, time.Now())
+	if len(f.calls) <= f.failures {
+		return &cerrors.ContainerRunError{
+			Name:     serviceName + "_init_" + initContainer.Name,
+			ExitCode: 1,
+			Logs:     fmt.Sprintf("connecting to db\nattempt %d failed\n", len(f.calls)),
+		}
+	}
+	return nil
+}
+
+func newFlakyInit(failures int) (*Manager, *flakyInit) {
+	stub := newTestStub()
+	fake := &flakyInit{StubManager: stub, failures: failures}
+	return NewManagerWithImplementation(stub.logger, fake), fake
+}
+
+func TestRunInitContainerRetriesUntilSuccess(t *testing.T) {
+	m, fake := newFlakyInit(2)
+	init := &compose.InitContainer{Name: "migrate", Image: "migrate", Retries: 3, RetryDelay: "50ms"}
+
+	if err := m.RunInitContainer(context.Background(), "web", init); err != nil {
+		t.Fatalf("RunInitContainer: %v", err)
+	}
+	if len(fake.calls) != 3 {
+		t.Fatalf("init container ran %d times, want 3", len(fake.calls))
+	}
+	for i := 1; i < len(fake.calls); i++ {
+		if delay := fake.calls[i].Sub(fake.calls[i-1]); delay < 50*time.Millisecond {
+			t.Errorf("retry %d came after %s, want the 50ms retry_delay", i, delay)
+		}
+	}
+}
+
+func TestRunInitContainerReturnsLastFailure(t *testing.T) {
+	m, fake := newFlakyInit(5)
+	init := &compose.InitContainer{Name: "migrate", Image: "migrate", Retries: 2}
+
+	err := m.RunInitContainer(context.Background(), "web", init)
+	if len(fake.calls) != 3 {
+		t.Errorf("init container ran %d times, want 3", len(fake.calls))
+	}
+	var runErr *cerrors.ContainerRunError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("RunInitContainer error = %v, want a ContainerRunError", err)
+	}
+	if !strings.Contains(runErr.Logs, "attempt 3 failed") {
+		t.Errorf("logs = %q, want the last attempt's", runErr.Logs)
+	}
+}
+
+func TestRunInitContainerWithoutRetries(t *testing.T) {
+	m, fake := newFlakyInit(1)
+
+	if err := m.RunInitContainer(context.Background(), "web", &compose.InitContainer{Name: "migrate"}); err == nil {
+		t.Error("RunInitContainer succeeded, want the first failure")
+	}
+	if len(fake.calls) != 1 {
+		t.Errorf("init container ran %d times, want 1", len(fake.calls))
+	}
+}
+
+func TestRunInitContainerCancelledDuringDelay(t *testing.T) {
+	m, fake := newFlakyInit(5)
+	init := &compose.InitContainer{Name: "migrate", Retries: 3, RetryDelay: "10s"}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := m.RunInitContainer(ctx, "web", init)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunInitContainer error = %v, want the context's", err)
+	}
+	if len(fake.calls) != 1 {
+		t.Errorf("init container ran %d times, want 1", len(fake.calls))
+	}
+}
@@ -0,0 +1,100 @@
+package container
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// Labels applied to every container created for a project. They follow the
+// Docker Compose conventions so containers are discoverable by either tool.
+const (
+	LabelProject         = "com.docker.compose.project"
+	LabelService         = "com.docker.compose.service"
+	LabelContainerNumber = "com.docker.compose.container-number"
+	LabelOneOff          = "com.docker.compose.oneoff"
+	LabelVolume          = "com.docker.compose.volume"
+	LabelNetwork         = "com.docker.compose.network"
+	LabelVersion         = "com.docker.compose.version"
+	LabelConfigHash      = "com.docker.compose.config-hash"
+	LabelImage           = "com.docker.compose.image"
+	LabelConfigFiles     = "com.docker.compose.project.config_files"
+
+	// LabelCascadeProject marks volumes created by `up --cascade-volumes`,
+	// which `down --cascade-volumes` removes again
+	LabelCascadeProject = "fake-compose.project"
+	// LabelSecretsDir records where a container's secrets were copied, so
+	// removing the container removes the copies too
+	LabelSecretsDir = "fake-compose.secrets-dir"
+)
+
+// ContainerInfo summarizes a container belonging to the project
+type ContainerInfo struct {
+	ID      string
+	Name    string
+	Service string
+	Number  int
+	Image   string
+	State   string
+	Status  string
+	Labels  map[string]string
+}
+
+// composeVersion is the Compose version recorded in LabelVersion
+const composeVersion = "2.23.0"
+
+func serviceLabels(project, serviceName string, number int) map[string]string {
+	return map[string]string{
+		LabelProject:         project,
+		LabelService:         serviceName,
+		LabelContainerNumber: strconv.Itoa(number),
+		LabelVersion:         composeVersion,
+	}
+}
+
+// containerLabels returns the labels of a service replica: the service's
+// own, the standard ones, the project's compose files, and the
+// configuration hash and image ID that tell whether the container is out of
+// date
+func containerLabels(project string, configFiles []string, serviceName string, number int, service *compose.Service, imageID string) map[string]string {
+	labels := MergeLabels(service.Labels, serviceLabels(project, serviceName, number))
+	if len(configFiles) > 0 {
+		labels[LabelConfigFiles] = strings.Join(configFiles, ",")
+	}
+	labels[LabelConfigHash] = ConfigHash(service)
+	labels[LabelImage] = imageID
+	return labels
+}
+
+// ConfigHash identifies a service's configuration, so containers created
+// from an earlier version of it can be told apart
+func ConfigHash(service *compose.Service) string {
+	data, _ := json.Marshal(service)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// MergeLabels combines user-defined labels with the standard ones. The
+// standard labels win so containers and resources stay discoverable by
+// project.
+func MergeLabels(user, standard map[string]string) map[string]string {
+	merged := make(map[string]string, len(user)+len(standard))
+	for key, value := range user {
+		merged[key] = value
+	}
+	for key, value := range standard {
+		merged[key] = value
+	}
+	return merged
+}
+
+// ContainerName returns the name of a service replica, following the
+// <project>_<service>_<number> convention
+func ContainerName(project, serviceName string, number int) string {
+	return fmt.Sprintf("%s_%s_%d", project, serviceName, number)
+}
@@ -0,0 +1,45 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestServiceConfigMemorySettings(t *testing.T) {
+	_, dm := newFakeDocker(t)
+	zero, off := 0, false
+	sixty, on := 60, true
+
+	_, hostConfig := dm.serviceConfig(&compose.Service{Image: "nginx"}, nil)
+	if hostConfig.MemorySwappiness != nil || hostConfig.OomKillDisable != nil {
+		t.Errorf("unset settings sent as swappiness %v, oom kill disable %v, want the daemon defaults (nil)", hostConfig.MemorySwappiness, hostConfig.OomKillDisable)
+	}
+
+	_, hostConfig = dm.serviceConfig(&compose.Service{Image: "nginx", MemSwappiness: &zero, OomKillDisable: &off}, nil)
+	if hostConfig.MemorySwappiness == nil || *hostConfig.MemorySwappiness != 0 {
+		t.Errorf("swappiness 0 sent as %v, want a pointer to 0", hostConfig.MemorySwappiness)
+	}
+	if hostConfig.OomKillDisable == nil || *hostConfig.OomKillDisable {
+		t.Errorf("oom_kill_disable false sent as %v, want a pointer to false", hostConfig.OomKillDisable)
+	}
+
+	_, hostConfig = dm.serviceConfig(&compose.Service{Image: "nginx", MemSwappiness: &sixty, OomKillDisable: &on}, nil)
+	if hostConfig.MemorySwappiness == nil || *hostConfig.MemorySwappiness != 60 || hostConfig.OomKillDisable == nil || !*hostConfig.OomKillDisable {
+		t.Errorf("settings sent as swappiness %v, oom kill disable %v, want 60 and true", hostConfig.MemorySwappiness, hostConfig.OomKillDisable)
+	}
+}
+
+func TestServiceConfigResourceLimits(t *testing.T) {
+	_, dm := newFakeDocker(t)
+	_, hostConfig := dm.serviceConfig(&compose.Service{Image: "nginx", CPUs: "0.5", MemLimit: "512m", MemReservation: "256Mi"}, nil)
+	if hostConfig.NanoCPUs != 5e8 {
+		t.Errorf("NanoCPUs = %d, want 5e8", hostConfig.NanoCPUs)
+	}
+	if hostConfig.Memory != 512<<20 {
+		t.Errorf("Memory = %d, want 512MiB", hostConfig.Memory)
+	}
+	if hostConfig.MemoryReservation != 256<<20 {
+		t.Errorf("MemoryReservation = %d, want 256MiB", hostConfig.MemoryReservation)
+	}
+}
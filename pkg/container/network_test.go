@@ -0,0 +1,59 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestDockerInspectNetwork(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fake.handle("GET", "/networks/demo_backend", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, types.NetworkResource{
+			Name:   "demo_backend",
+			ID:     "net1",
+			Driver: "bridge",
+			Labels: map[string]string{LabelProject: "demo", LabelNetwork: "backend"},
+		})
+	})
+
+	info, err := dm.InspectNetwork(context.Background(), "demo_backend")
+	if err != nil {
+		t.Fatalf("InspectNetwork: %v", err)
+	}
+	if info.Name != "demo_backend" || info.Driver != "bridge" || info.Labels[LabelNetwork] != "backend" {
+		t.Errorf("InspectNetwork = %+v", info)
+	}
+}
+
+func TestDockerInspectNetworkNotFound(t *testing.T) {
+	_, dm := newFakeDocker(t)
+
+	_, err := dm.InspectNetwork(context.Background(), "demo_missing")
+	if err == nil || !strings.Contains(err.Error(), "failed to inspect network demo_missing") {
+		t.Errorf("InspectNetwork error = %v", err)
+	}
+}
+
+func TestServiceConfigNetworkMode(t *testing.T) {
+	_, dm := newFakeDocker(t)
+	for _, mode := range []string{"host", "none", "container:abc123"} {
+		_, hostConfig := dm.serviceConfig(&compose.Service{Image: "nginx", NetworkMode: mode}, nil)
+		if got := string(hostConfig.NetworkMode); got != mode {
+			t.Errorf("host config network mode = %q, want %q", got, mode)
+		}
+	}
+}
+
+func TestServiceConfigVolumesFrom(t *testing.T) {
+	_, dm := newFakeDocker(t)
+	volumesFrom := []string{"abc123:ro", "legacy"}
+	_, hostConfig := dm.serviceConfig(&compose.Service{Image: "restic", VolumesFrom: volumesFrom}, nil)
+	if len(hostConfig.VolumesFrom) != 2 || hostConfig.VolumesFrom[0] != "abc123:ro" || hostConfig.VolumesFrom[1] != "legacy" {
+		t.Errorf("host config volumes from = %v, want %v", hostConfig.VolumesFrom, volumesFrom)
+	}
+}
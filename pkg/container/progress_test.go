@@ -0,0 +1,70 @@
+package container
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordedPull opens a pull event stream recorded from the daemon
+func recordedPull(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "pull_nginx.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestWriteProgress(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeProgress("nginx:1.25", recordedPull(t), &out, false); err != nil {
+		t.Fatalf("writeProgress: %v", err)
+	}
+	assertGolden(t, "pull_nginx", out.Bytes())
+}
+
+func TestWriteProgressQuiet(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeProgress("nginx:1.25", recordedPull(t), &out, true); err != nil {
+		t.Fatalf("writeProgress: %v", err)
+	}
+	want := "nginx:1.25: Downloaded newer image for nginx:1.25 (2 layers, 4.194MB transferred in 0s)\n"
+	if out.String() != want {
+		t.Errorf("quiet progress = %q, want the summary line only %q", out.String(), want)
+	}
+}
+
+func TestWriteProgressError(t *testing.T) {
+	stream := `{"status":"Pulling from library/nginx","id":"latest"}
+{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}
+`
+	var out bytes.Buffer
+	err := writeProgress("nginx", strings.NewReader(stream), &out, false)
+	if err == nil || err.Error() != "manifest unknown" {
+		t.Errorf("writeProgress = %v, want the stream's error", err)
+	}
+	if strings.Contains(out.String(), "transferred") {
+		t.Errorf("summary written for a failed pull:\n%s", out.String())
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		current, total int64
+		want           string
+	}{
+		{0, 100, "[>                   ]   0% 0B/100B"},
+		{50, 100, "[==========>         ]  50% 50B/100B"},
+		{100, 100, "[====================] 100% 100B/100B"},
+		{150, 100, "[====================] 100% 100B/100B"},
+	}
+	for _, tt := range tests {
+		if got := progressBar(tt.current, tt.total); got != tt.want {
+			t.Errorf("progressBar(%d, %d) = %q, want %q", tt.current, tt.total, got, tt.want)
+		}
+	}
+}
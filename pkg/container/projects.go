@@ -0,0 +1,68 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProjectInfo summarizes a compose project found through the labels of its
+// containers, as listed by `ls`
+type ProjectInfo struct {
+	Name string
+	// Status counts the project's containers per state, such as
+	// "exited(1), running(2)"
+	Status string
+	// ConfigFiles are the compose files the containers were created from,
+	// comma separated
+	ConfigFiles string
+}
+
+// ListProjects groups the containers of every compose project by their
+// project label, sorted by name. Unless all is set, projects without a
+// running container are left out.
+func (m *Manager) ListProjects(ctx context.Context, all bool) ([]ProjectInfo, error) {
+	containers, err := m.impl.ListProjectContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]map[string]int)
+	configFiles := make(map[string]string)
+	for _, c := range containers {
+		project := c.Labels[LabelProject]
+		if states[project] == nil {
+			states[project] = make(map[string]int)
+		}
+		states[project][c.State]++
+		if files := c.Labels[LabelConfigFiles]; files != "" {
+			configFiles[project] = files
+		}
+	}
+
+	projects := make([]ProjectInfo, 0, len(states))
+	for project, counts := range states {
+		if !all && counts["running"] == 0 {
+			continue
+		}
+		projects = append(projects, ProjectInfo{Name: project, Status: stateCounts(counts), ConfigFiles: configFiles[project]})
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, nil
+}
+
+// stateCounts formats container counts per state like docker compose ls
+func stateCounts(counts map[string]int) string {
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+
+	parts := make([]string, len(states))
+	for i, state := range states {
+		parts[i] = fmt.Sprintf("%s(%d)", state, counts[state])
+	}
+	return strings.Join(parts, ", ")
+}
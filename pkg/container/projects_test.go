@@ -0,0 +1,101 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+// projectsFake lists a fixed set of containers as the project containers
+type projectsFake struct {
+	*StubManager
+	containers []ContainerInfo
+}
+
+func (f *projectsFake) ListProjectContainers(ctx context.Context) ([]ContainerInfo, error) {
+	return f.containers, nil
+}
+
+func projectContainer(project, state, configFiles string) ContainerInfo {
+	return ContainerInfo{
+		State:  state,
+		Labels: map[string]string{LabelProject: project, LabelConfigFiles: configFiles},
+	}
+}
+
+func TestListProjects(t *testing.T) {
+	stub := newTestStub()
+	m := NewManagerWithImplementation(stub.logger, &projectsFake{
+		StubManager: stub,
+		containers: []ContainerInfo{
+			projectContainer("shop", "running", "/srv/shop/compose.yaml"),
+			projectContainer("shop", "running", "/srv/shop/compose.yaml"),
+			projectContainer("shop", "exited", "/srv/shop/compose.yaml"),
+			projectContainer("blog", "exited", "/srv/blog/compose.yaml,/srv/blog/compose.override.yaml"),
+		},
+	})
+
+	tests := []struct {
+		name string
+		all  bool
+		want []ProjectInfo
+	}{
+		{
+			name: "running only",
+			want: []ProjectInfo{
+				{Name: "shop", Status: "exited(1), running(2)", ConfigFiles: "/srv/shop/compose.yaml"},
+			},
+		},
+		{
+			name: "all",
+			all:  true,
+			want: []ProjectInfo{
+				{Name: "blog", Status: "exited(1)", ConfigFiles: "/srv/blog/compose.yaml,/srv/blog/compose.override.yaml"},
+				{Name: "shop", Status: "exited(1), running(2)", ConfigFiles: "/srv/shop/compose.yaml"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.ListProjects(context.Background(), tt.all)
+			if err != nil {
+				t.Fatalf("ListProjects: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListProjects = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDockerListProjects(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	var query string
+	fake.handle("GET", "/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("filters")
+		writeJSON(w, []types.Container{
+			{ID: "a", State: "running", Labels: map[string]string{LabelProject: "shop", LabelConfigFiles: "/srv/shop/compose.yaml"}},
+			{ID: "b", State: "exited", Labels: map[string]string{LabelProject: "blog", LabelConfigFiles: "/srv/blog/compose.yaml"}},
+		})
+	})
+	m := NewManagerWithImplementation(dm.logger, dm)
+
+	projects, err := m.ListProjects(context.Background(), true)
+	if err != nil {
+		t.Fatalf("ListProjects: %v", err)
+	}
+	want := []ProjectInfo{
+		{Name: "blog", Status: "exited(1)", ConfigFiles: "/srv/blog/compose.yaml"},
+		{Name: "shop", Status: "running(1)", ConfigFiles: "/srv/shop/compose.yaml"},
+	}
+	if !reflect.DeepEqual(projects, want) {
+		t.Errorf("ListProjects = %+v, want %+v", projects, want)
+	}
+	if !strings.Contains(query, `"label":{"`+LabelProject+`":true}`) {
+		t.Errorf("containers listed with filters %s, want the project label", query)
+	}
+}
@@ -0,0 +1,165 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+	units "github.com/docker/go-units"
+)
+
+// PullPolicy decides whether PullImage contacts the registry
+type PullPolicy string
+
+const (
+	// PullAlways pulls the image even when it is present locally
+	PullAlways PullPolicy = "always"
+	// PullMissing pulls the image only when it is not present locally
+	PullMissing PullPolicy = "missing"
+)
+
+// ParsePullPolicy validates a --policy value
+func ParsePullPolicy(value string) (PullPolicy, error) {
+	switch policy := PullPolicy(value); policy {
+	case PullAlways, PullMissing:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid pull policy %q: must be always or missing", value)
+	}
+}
+
+// DefaultPullTimeout bounds a single image pull unless Options.PullTimeout
+// says otherwise, so a hung registry cannot block up forever
+const DefaultPullTimeout = 10 * time.Minute
+
+// PullOptions controls how PullImage fetches an image
+type PullOptions struct {
+	Policy PullPolicy
+	// Timeout bounds the pull. Zero means the manager's Options.PullTimeout.
+	Timeout time.Duration
+	// Quiet writes only a summary line per image to Progress
+	Quiet bool
+	// Progress receives one line per layer status change and transfer
+	// quarter, prefixed with the image reference so concurrent pulls stay
+	// readable, then a summary line. Nil discards progress.
+	Progress io.Writer
+}
+
+// PushOptions controls how PushImage uploads an image
+type PushOptions struct {
+	// Auth holds the registry credentials, see LoadRegistryAuth
+	Auth types.AuthConfig
+	// Progress receives the push progress, rendered as for pulls
+	Progress io.Writer
+}
+
+// progressSteps is how many bar updates a layer transfer prints: at 25%,
+// 50%, 75% and 100%
+const progressSteps = 4
+
+// progressBarWidth is the width of a layer's progress bar in characters
+const progressBarWidth = 20
+
+// writeProgress decodes the JSON message stream of an image pull or push
+// and renders it to w: one line per layer status change, a progress bar
+// at every quarter of each transfer, and a summary line once the stream
+// ends. With quiet set only the summary is written. An error reported in
+// the stream is returned.
+func writeProgress(ref string, r io.Reader, w io.Writer, quiet bool) error {
+	if w == nil {
+		w = io.Discard
+	}
+
+	start := time.Now()
+	steps := make(map[string]int)
+	sizes := make(map[string]int64)
+	layers := 0
+	result := "Done"
+
+	decoder := json.NewDecoder(r)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read progress: %w", err)
+		}
+
+		if msg.Error != nil {
+			return errors.New(msg.Error.Message)
+		}
+
+		if msg.ID == "" {
+			// The final "Status: ..." line ends up in the summary
+			if status, ok := strings.CutPrefix(msg.Status, "Status: "); ok {
+				result = status
+			} else if msg.Status != "" && !quiet {
+				fmt.Fprintf(w, "%s: %s\n", ref, msg.Status)
+			}
+			continue
+		}
+
+		if p := msg.Progress; p != nil && p.Total > 0 {
+			if msg.Status == "Downloading" || msg.Status == "Pushing" {
+				sizes[msg.ID] = p.Total
+			}
+			key := msg.ID + " " + msg.Status
+			step := int(p.Current * progressSteps / p.Total)
+			if quiet || step <= steps[key] {
+				continue
+			}
+			steps[key] = step
+			fmt.Fprintf(w, "%s: %s: %-11s %s\n", ref, msg.ID, msg.Status, progressBar(p.Current, p.Total))
+			continue
+		}
+
+		switch msg.Status {
+		case "Pull complete", "Already exists", "Pushed", "Layer already exists":
+			layers++
+		}
+		if !quiet {
+			fmt.Fprintf(w, "%s: %s: %s\n", ref, msg.ID, msg.Status)
+		}
+	}
+
+	var transferred int64
+	for _, size := range sizes {
+		transferred += size
+	}
+	noun := "layers"
+	if layers == 1 {
+		noun = "layer"
+	}
+	fmt.Fprintf(w, "%s: %s (%d %s, %s transferred in %s)\n",
+		ref, result, layers, noun, units.HumanSize(float64(transferred)), time.Since(start).Round(100*time.Millisecond))
+	return nil
+}
+
+// progressBar renders a transfer as [=====>    ]  50% 5MB/10MB
+func progressBar(current, total int64) string {
+	if current > total {
+		current = total
+	}
+	filled := int(current * progressBarWidth / total)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	return fmt.Sprintf("[%s] %3d%% %s/%s", bar, current*100/total,
+		units.HumanSize(float64(current)), units.HumanSize(float64(total)))
+}
+
+// pullError reports a failed pull, naming the image and how long it ran
+// when the pull's deadline was the cause
+func pullError(ctx context.Context, ref string, start time.Time, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("pull of image %s timed out after %s: %w", ref, time.Since(start).Round(time.Second), context.DeadlineExceeded)
+	}
+	return fmt.Errorf("failed to pull image %s: %w", ref, err)
+}
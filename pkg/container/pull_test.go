@@ -0,0 +1,101 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDockerPullImagePolicy(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fake.handle("GET", "/images/nginx/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"Id": "sha256:nginx"})
+	})
+	fake.handle("GET", "/images/redis/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		writeJSON(w, map[string]string{"message": "No such image: redis"})
+	})
+	fake.handle("POST", "/images/create", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"status": "Pull complete"})
+	})
+	ctx := context.Background()
+	count := func(prefix string) int {
+		n := 0
+		for _, request := range fake.requested() {
+			if strings.HasPrefix(request, prefix) {
+				n++
+			}
+		}
+		return n
+	}
+	pulls := func() int { return count("POST /images/create") }
+
+	if pulled, err := dm.PullImage(ctx, "nginx", PullOptions{Policy: PullMissing}); err != nil || pulled {
+		t.Errorf("missing policy on a present image = %v, %v, want skipped", pulled, err)
+	}
+	if n := pulls(); n != 0 {
+		t.Errorf("missing policy pulled a present image %d times", n)
+	}
+
+	if pulled, err := dm.PullImage(ctx, "redis", PullOptions{Policy: PullMissing}); err != nil || !pulled {
+		t.Errorf("missing policy on an absent image = %v, %v, want pulled", pulled, err)
+	}
+	if pulled, err := dm.PullImage(ctx, "nginx", PullOptions{Policy: PullAlways}); err != nil || !pulled {
+		t.Errorf("always policy on a present image = %v, %v, want pulled", pulled, err)
+	}
+	if n := pulls(); n != 2 {
+		t.Errorf("pulled %d times, want 2", n)
+	}
+	if n := count("GET /images/nginx/json"); n != 1 {
+		t.Errorf("nginx was inspected %d times, want only by the missing policy", n)
+	}
+}
+
+func TestParsePullPolicy(t *testing.T) {
+	for _, value := range []string{"always", "missing"} {
+		if policy, err := ParsePullPolicy(value); err != nil || string(policy) != value {
+			t.Errorf("ParsePullPolicy(%q) = %q, %v", value, policy, err)
+		}
+	}
+	if _, err := ParsePullPolicy("never"); err == nil {
+		t.Error("ParsePullPolicy(never) succeeded")
+	}
+}
+
+// slowPull answers an image pull with a first progress message and then
+// stalls until the client gives up
+func slowPull(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{"status": "Pulling from library/nginx", "id": "latest"})
+	w.(http.Flusher).Flush()
+	<-r.Context().Done()
+}
+
+func TestDockerPullImageTimeout(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fake.handle("POST", "/images/create", slowPull)
+
+	start := time.Now()
+	_, err := dm.PullImage(context.Background(), "nginx", PullOptions{Policy: PullAlways, Timeout: 200 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("PullImage = %v, want a deadline exceeded error", err)
+	}
+	if !strings.Contains(err.Error(), "pull of image nginx timed out after") {
+		t.Errorf("error %q does not name the image and elapsed time", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("pull aborted after %s, want shortly after the 200ms timeout", elapsed)
+	}
+}
+
+func TestDockerPullImageManagerTimeout(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fake.handle("POST", "/images/create", slowPull)
+	dm.pullTimeout = 200 * time.Millisecond
+
+	if _, err := dm.PullImage(context.Background(), "nginx", PullOptions{Policy: PullAlways}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("PullImage = %v, want the manager's pull timeout to apply", err)
+	}
+}
@@ -0,0 +1,40 @@
+package container
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestDockerPushImageSendsAuth(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	var header string
+	fake.handle("POST", "/images/registry.example.com/shop/app/push", func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Registry-Auth")
+		if tag := r.URL.Query().Get("tag"); tag != "1.0" {
+			t.Errorf("pushed tag %q, want 1.0", tag)
+		}
+		writeJSON(w, map[string]string{"status": "Pushed"})
+	})
+
+	auth := types.AuthConfig{Username: "deployer", Password: "s3cret", ServerAddress: "registry.example.com"}
+	if err := dm.PushImage(context.Background(), "registry.example.com/shop/app:1.0", PushOptions{Auth: auth}); err != nil {
+		t.Fatalf("PushImage: %v", err)
+	}
+
+	data, err := base64.URLEncoding.DecodeString(header)
+	if err != nil {
+		t.Fatalf("X-Registry-Auth %q is not base64: %v", header, err)
+	}
+	var sent types.AuthConfig
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Username != "deployer" || sent.Password != "s3cret" || sent.ServerAddress != "registry.example.com" {
+		t.Errorf("sent auth %+v, want %+v", sent, auth)
+	}
+}
@@ -0,0 +1,118 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+// fakeRegistry serves the manifests of app:1.0 publicly and of
+// private/app:1.0 to holders of a token issued for user:secret
+func fakeRegistry(t *testing.T) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "user" || password != "secret" || r.URL.Query().Get("scope") != "repository:private/app:pull" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, map[string]string{"token": "pull-token"})
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		repository, tag, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/manifests/")
+		if strings.HasPrefix(repository, "private/") && r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="fake"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if tag == "1.0" && (repository == "app" || repository == "private/app") {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestCheckManifest(t *testing.T) {
+	registry := fakeRegistry(t)
+	credentials := types.AuthConfig{Username: "user", Password: "secret"}
+	tests := []struct {
+		name     string
+		ref      string
+		auth     types.AuthConfig
+		notFound bool
+	}{
+		{"found", registry + "/app:1.0", types.AuthConfig{}, false},
+		{"missing tag", registry + "/app:2.0", types.AuthConfig{}, true},
+		{"missing repository", registry + "/other:1.0", types.AuthConfig{}, true},
+		{"private found", registry + "/private/app:1.0", credentials, false},
+		{"private missing tag", registry + "/private/app:2.0", credentials, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckManifest(context.Background(), tt.ref, tt.auth)
+			if tt.notFound {
+				if !errors.Is(err, ErrManifestNotFound) {
+					t.Errorf("CheckManifest = %v, want ErrManifestNotFound", err)
+				}
+			} else if err != nil {
+				t.Errorf("CheckManifest = %v, want found", err)
+			}
+		})
+	}
+}
+
+func TestCheckManifestErrors(t *testing.T) {
+	registry := fakeRegistry(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	tests := []struct {
+		name string
+		ref  string
+		auth types.AuthConfig
+		want string
+	}{
+		{"wrong credentials", registry + "/private/app:1.0", types.AuthConfig{Username: "user", Password: "wrong"}, "failed to authenticate"},
+		{"no credentials", registry + "/private/app:1.0", types.AuthConfig{}, "failed to authenticate"},
+		{"unreachable registry", strings.TrimPrefix(closed.URL, "http://") + "/app:1.0", types.AuthConfig{}, "connection refused"},
+		{"invalid reference", "App:Latest", types.AuthConfig{}, "invalid image reference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckManifest(context.Background(), tt.ref, tt.auth)
+			if err == nil || errors.Is(err, ErrManifestNotFound) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("CheckManifest = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryScheme(t *testing.T) {
+	tests := map[string]string{
+		"localhost:5000": "http",
+		"127.0.0.1:5000": "http",
+		"[::1]:5000":     "http",
+		"registry.local": "https",
+		"ghcr.io":        "https",
+		"10.0.0.1:5000":  "https",
+	}
+	for host, want := range tests {
+		if got := registryScheme(host); got != want {
+			t.Errorf("registryScheme(%q) = %s, want %s", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestRunOverridesReachContainerConfig(t *testing.T) {
+	_, dm := newFakeDocker(t)
+	service := &compose.Service{Image: "nginx", Environment: map[string]string{"MODE": "prod"}}
+
+	config, hostConfig := dm.serviceConfig(service.WithRunOverrides(compose.RunOverrides{
+		Environment: map[string]string{"MODE": "debug"},
+		Ports:       []string{"8080:80"},
+	}), nil)
+
+	found := false
+	for _, env := range config.Env {
+		if env == "MODE=prod" {
+			t.Errorf("env %v kept the service value", config.Env)
+		}
+		found = found || env == "MODE=debug"
+	}
+	if !found {
+		t.Errorf("env %v lacks MODE=debug", config.Env)
+	}
+	port := nat.Port("80/tcp")
+	if _, ok := config.ExposedPorts[port]; !ok {
+		t.Errorf("port 80 is not exposed: %v", config.ExposedPorts)
+	}
+	if bindings := hostConfig.PortBindings[port]; len(bindings) != 1 || bindings[0].HostPort != "8080" {
+		t.Errorf("port 80 bindings = %v, want host port 8080", bindings)
+	}
+	if service.Environment["MODE"] != "prod" || len(service.Ports) != 0 {
+		t.Errorf("service changed to env %v, ports %v", service.Environment, service.Ports)
+	}
+}
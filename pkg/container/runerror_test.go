@@ -0,0 +1,122 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/neomody77/fake-compose/pkg/compose"
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
+	"github.com/neomody77/fake-compose/pkg/template"
+)
+
+const failingContainerID = "fa11edc0ffee0001"
+
+// fakeFailingContainer serves a container that exits with exitCode after
+// printing stdout and stderr
+func fakeFailingContainer(fake *fakeDocker, exitCode int, stdout, stderr string) {
+	fake.handle("GET", "/images/busybox/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"Id": "sha256:busybox"})
+	})
+	fake.handle("POST", "/containers/create", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, map[string]interface{}{"Id": failingContainerID, "Warnings": []string{}})
+	})
+	fake.handle("POST", "/containers/"+failingContainerID+"/start", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	fake.handle("POST", "/containers/"+failingContainerID+"/wait", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"StatusCode": exitCode})
+	})
+	fake.handle("GET", "/containers/"+failingContainerID+"/logs", func(w http.ResponseWriter, r *http.Request) {
+		var stream bytes.Buffer
+		stdcopy.NewStdWriter(&stream, stdcopy.Stdout).Write([]byte(stdout))
+		stdcopy.NewStdWriter(&stream, stdcopy.Stderr).Write([]byte(stderr))
+		w.Write(stream.Bytes())
+	})
+	fake.handle("DELETE", "/containers/"+failingContainerID, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func TestDockerRunInitContainerFailure(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fakeFailingContainer(fake, 2, "applying 0001_init\n", "relation \"users\" already exists\n")
+
+	err := dm.RunInitContainer(context.Background(), "web", &compose.InitContainer{Name: "migrate", Image: "busybox"})
+
+	var runErr *cerrors.ContainerRunError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("RunInitContainer error = %v, want a ContainerRunError", err)
+	}
+	if runErr.Name != "migrate" || runErr.ExitCode != 2 {
+		t.Errorf("error = %+v, want migrate with exit code 2", runErr)
+	}
+	if want := "applying 0001_init\nrelation \"users\" already exists\n"; runErr.Logs != want {
+		t.Errorf("logs = %q, want %q", runErr.Logs, want)
+	}
+	if err.Error() != "container migrate exited with code 2" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestDockerRunPostContainerFailure(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fakeFailingContainer(fake, 7, "", "curl: (7) Failed to connect\n")
+
+	post := &compose.PostContainer{Name: "warmup", Image: "busybox", Command: []string{"curl", "http://web"}}
+	err := dm.RunPostContainer(context.Background(), "web", post, template.Data{})
+
+	var runErr *cerrors.ContainerRunError
+	if !errors.As(err, &runErr) {
+		t.Fatalf("RunPostContainer error = %v, want a ContainerRunError", err)
+	}
+	if runErr.Name != "warmup" || runErr.ExitCode != 7 || runErr.Logs != "curl: (7) Failed to connect\n" {
+		t.Errorf("error = %+v", runErr)
+	}
+}
+
+func TestDockerRunPostContainerRendersCommand(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fakeFailingContainer(fake, 0, "", "")
+	var cmd []string
+	fake.handle("POST", "/containers/create", func(w http.ResponseWriter, r *http.Request) {
+		var config struct{ Cmd []string }
+		json.NewDecoder(r.Body).Decode(&config)
+		cmd = config.Cmd
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, map[string]interface{}{"Id": failingContainerID, "Warnings": []string{}})
+	})
+
+	post := &compose.PostContainer{Name: "report", Image: "busybox", Command: []string{"report", "{{.Service.Name}}", "{{.ExitCode}}"}}
+	data := template.NewData("shop", "post-stop", "web", nil)
+	data.ExitCode = 137
+	if err := dm.RunPostContainer(context.Background(), "web", post, data); err != nil {
+		t.Fatalf("RunPostContainer: %v", err)
+	}
+	if want := []string{"report", "web", "137"}; !slices.Equal(cmd, want) {
+		t.Errorf("container command = %q, want %q", cmd, want)
+	}
+}
+
+func TestDockerRunPostContainerBadTemplate(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fakeFailingContainer(fake, 0, "", "")
+
+	post := &compose.PostContainer{Name: "report", Image: "busybox", Command: []string{"report", "{{.Service.Name"}}
+	err := dm.RunPostContainer(context.Background(), "web", post, template.NewData("shop", "post-stop", "web", nil))
+	if err == nil || !strings.Contains(err.Error(), "post container report: invalid template") {
+		t.Fatalf("RunPostContainer = %v, want an invalid template error", err)
+	}
+	for _, request := range fake.requested() {
+		if strings.HasPrefix(request, "POST /containers/create") {
+			t.Errorf("created a container despite the bad template")
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package container
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// tmpfsDir is the tmpfs secrets are copied to before being bind mounted,
+// falling back to the temporary directory where /dev/shm does not exist
+func tmpfsDir() string {
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		return "/dev/shm"
+	}
+	return os.TempDir()
+}
+
+// stageSecrets copies a container's secret files into a new directory under
+// base and returns it, with a read-only bind mount of each copy at the
+// secret's target. Mounting copies rather than the files themselves keeps
+// the secrets in memory and the container isolated from later edits. The
+// directory is empty when the container has no secrets.
+func stageSecrets(base string, secrets []compose.SecretRef) (string, []mount.Mount, error) {
+	if len(secrets) == 0 {
+		return "", nil, nil
+	}
+
+	dir, err := os.MkdirTemp(base, "fake-compose-secrets-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+
+	mounts := make([]mount.Mount, 0, len(secrets))
+	for i, secret := range secrets {
+		// Two references may share a source, so copies are numbered
+		copied := filepath.Join(dir, strconv.Itoa(i)+"_"+secret.Source)
+		if err := copySecret(secret.File, copied); err != nil {
+			os.RemoveAll(dir)
+			return "", nil, fmt.Errorf("failed to copy secret %s: %w", secret.Source, err)
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   copied,
+			Target:   secret.TargetPath(),
+			ReadOnly: true,
+		})
+	}
+	return dir, mounts, nil
+}
+
+// copySecret copies a secret file, readable by any user as the container
+// may not run as the file's owner
+func copySecret(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0444)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// mountSecrets stages a service's secrets for a new container and adds
+// their mounts to its configuration. The copies' directory is recorded in
+// LabelSecretsDir for RemoveContainer, and returned so a failed creation
+// can remove it.
+func (dm *DockerManager) mountSecrets(service *compose.Service, config *container.Config, hostConfig *container.HostConfig) (string, error) {
+	dir, mounts, err := stageSecrets(dm.secretsBase, service.Secrets)
+	if err != nil || dir == "" {
+		return "", err
+	}
+	hostConfig.Mounts = append(hostConfig.Mounts, mounts...)
+	config.Labels[LabelSecretsDir] = dir
+	return dir, nil
+}
+
+// removeSecretsDir removes the secret copies of a removed container
+func removeSecretsDir(dir string) {
+	if dir != "" {
+		os.RemoveAll(dir)
+	}
+}
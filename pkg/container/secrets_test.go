@@ -0,0 +1,117 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStageSecretsCopiesFiles(t *testing.T) {
+	file := writeSecret(t, "hunter2")
+	base := t.TempDir()
+
+	dir, mounts, err := stageSecrets(base, []compose.SecretRef{
+		{Source: "db_password", File: file},
+		{Source: "db_password", Target: "/etc/db/password", File: file},
+	})
+	if err != nil {
+		t.Fatalf("stageSecrets: %v", err)
+	}
+	if filepath.Dir(dir) != base {
+		t.Errorf("secrets directory %s is not under %s", dir, base)
+	}
+
+	targets := []string{"/run/secrets/db_password", "/etc/db/password"}
+	if len(mounts) != len(targets) {
+		t.Fatalf("got %d mounts, want %d", len(mounts), len(targets))
+	}
+	for i, m := range mounts {
+		if m.Type != mount.TypeBind || !m.ReadOnly || m.Target != targets[i] {
+			t.Errorf("mount %d = %+v, want a read-only bind at %s", i, m, targets[i])
+		}
+		if filepath.Dir(m.Source) != dir {
+			t.Errorf("mount %d source %s is not a copy in %s", i, m.Source, dir)
+		}
+		content, err := os.ReadFile(m.Source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "hunter2" {
+			t.Errorf("copy %s holds %q", m.Source, content)
+		}
+	}
+}
+
+func TestStageSecretsWithoutSecrets(t *testing.T) {
+	base := t.TempDir()
+	dir, mounts, err := stageSecrets(base, nil)
+	if err != nil || dir != "" || mounts != nil {
+		t.Errorf("stageSecrets(nil) = %q, %v, %v", dir, mounts, err)
+	}
+	if entries, _ := os.ReadDir(base); len(entries) != 0 {
+		t.Errorf("stageSecrets(nil) created %d entries", len(entries))
+	}
+}
+
+func TestStageSecretsMissingFile(t *testing.T) {
+	base := t.TempDir()
+	_, _, err := stageSecrets(base, []compose.SecretRef{{Source: "gone", File: filepath.Join(base, "gone.txt")}})
+	if err == nil {
+		t.Fatal("stageSecrets succeeded with a missing file")
+	}
+	if entries, _ := os.ReadDir(base); len(entries) != 0 {
+		t.Errorf("the secrets directory was left behind")
+	}
+}
+
+func TestMountSecretsLabelsAndRemoveCleansUp(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	dm.secretsBase = t.TempDir()
+
+	service := &compose.Service{Image: "postgres", Secrets: []compose.SecretRef{{Source: "db_password", File: writeSecret(t, "hunter2")}}}
+	config, hostConfig := dm.serviceConfig(service, map[string]string{})
+	dir, err := dm.mountSecrets(service, config, hostConfig)
+	if err != nil {
+		t.Fatalf("mountSecrets: %v", err)
+	}
+	if config.Labels[LabelSecretsDir] != dir {
+		t.Errorf("label %s = %q, want %q", LabelSecretsDir, config.Labels[LabelSecretsDir], dir)
+	}
+	if len(hostConfig.Mounts) != 1 || hostConfig.Mounts[0].Target != "/run/secrets/db_password" {
+		t.Errorf("mounts = %+v", hostConfig.Mounts)
+	}
+
+	const containerID = "0123456789abcdef"
+	fake.handle("GET", "/containers/"+containerID+"/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: containerID},
+			Config:            &container.Config{Labels: config.Labels},
+		})
+	})
+	fake.handle("DELETE", "/containers/"+containerID, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := dm.RemoveContainer(context.Background(), containerID, false); err != nil {
+		t.Fatalf("RemoveContainer: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("secrets directory %s still exists after removal", dir)
+	}
+}
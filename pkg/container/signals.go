@@ -0,0 +1,71 @@
+package container
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// signals maps the signal names accepted by kill and stop_signal to their
+// Linux numbers
+var signals = map[string]int{
+	"SIGHUP":    1,
+	"SIGINT":    2,
+	"SIGQUIT":   3,
+	"SIGILL":    4,
+	"SIGTRAP":   5,
+	"SIGABRT":   6,
+	"SIGBUS":    7,
+	"SIGFPE":    8,
+	"SIGKILL":   9,
+	"SIGUSR1":   10,
+	"SIGSEGV":   11,
+	"SIGUSR2":   12,
+	"SIGPIPE":   13,
+	"SIGALRM":   14,
+	"SIGTERM":   15,
+	"SIGSTKFLT": 16,
+	"SIGCHLD":   17,
+	"SIGCONT":   18,
+	"SIGSTOP":   19,
+	"SIGTSTP":   20,
+	"SIGTTIN":   21,
+	"SIGTTOU":   22,
+	"SIGURG":    23,
+	"SIGXCPU":   24,
+	"SIGXFSZ":   25,
+	"SIGVTALRM": 26,
+	"SIGPROF":   27,
+	"SIGWINCH":  28,
+	"SIGIO":     29,
+	"SIGPWR":    30,
+	"SIGSYS":    31,
+}
+
+// maxSignal is the highest real-time signal number on Linux
+const maxSignal = 64
+
+// ParseSignal validates a signal given by name ("SIGTERM", "term") or number
+// ("15") and returns the canonical form passed to the container runtime
+func ParseSignal(value string) (string, error) {
+	if number, err := strconv.Atoi(value); err == nil {
+		if number < 1 || number > maxSignal {
+			return "", fmt.Errorf("invalid signal %q: number must be between 1 and %d", value, maxSignal)
+		}
+		for name, n := range signals {
+			if n == number {
+				return name, nil
+			}
+		}
+		return value, nil
+	}
+
+	name := strings.ToUpper(value)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	if _, ok := signals[name]; !ok {
+		return "", fmt.Errorf("invalid signal %q", value)
+	}
+	return name, nil
+}
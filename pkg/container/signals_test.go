@@ -0,0 +1,37 @@
+package container
+
+import "testing"
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "SIGTERM", want: "SIGTERM"},
+		{value: "sigkill", want: "SIGKILL"},
+		{value: "hup", want: "SIGHUP"},
+		{value: "USR1", want: "SIGUSR1"},
+		{value: "9", want: "SIGKILL"},
+		{value: "15", want: "SIGTERM"},
+		// Real-time signals have no name and pass through as numbers
+		{value: "40", want: "40"},
+		{value: "SIGBOGUS", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "65", wantErr: true},
+		{value: "-9", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSignal(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSignal(%q) = %q, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSignal(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
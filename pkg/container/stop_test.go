@@ -0,0 +1,107 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+const stopContainerID = "0123456789abcdef"
+
+// fakeStoppable serves a running container with the given stop signal that
+// exits on its first signal, or only on SIGKILL with ignoreSignal set, and
+// returns the signals it was sent
+func fakeStoppable(fake *fakeDocker, stopSignal string, ignoreSignal bool) func() []string {
+	var mu sync.Mutex
+	var sent []string
+	killed := make(chan struct{})
+
+	fake.handle("GET", "/containers/"+stopContainerID+"/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: stopContainerID, State: &types.ContainerState{Running: true}},
+			Config:            &container.Config{StopSignal: stopSignal},
+		})
+	})
+	fake.handle("POST", "/containers/"+stopContainerID+"/kill", func(w http.ResponseWriter, r *http.Request) {
+		signal := r.URL.Query().Get("signal")
+		mu.Lock()
+		sent = append(sent, signal)
+		mu.Unlock()
+		if !ignoreSignal || signal == "SIGKILL" {
+			close(killed)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	fake.handle("POST", "/containers/"+stopContainerID+"/wait", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-killed:
+			writeJSON(w, map[string]interface{}{"StatusCode": 0})
+		case <-r.Context().Done():
+		}
+	})
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), sent...)
+	}
+}
+
+func TestDockerStopContainerSendsStopSignal(t *testing.T) {
+	tests := []struct {
+		name       string
+		stopSignal string
+		want       []string
+	}{
+		{"default", "", []string{"SIGTERM"}},
+		{"stop_signal", "SIGINT", []string{"SIGINT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, dm := newFakeDocker(t)
+			sent := fakeStoppable(fake, tt.stopSignal, false)
+
+			if err := dm.StopContainer(context.Background(), stopContainerID, 5); err != nil {
+				t.Fatalf("StopContainer: %v", err)
+			}
+			if got := sent(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("signals sent = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDockerStopContainerKillsAfterGracePeriod(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	sent := fakeStoppable(fake, "SIGUSR1", true)
+
+	if err := dm.StopContainer(context.Background(), stopContainerID, 1); err != nil {
+		t.Fatalf("StopContainer: %v", err)
+	}
+	if got, want := sent(), []string{"SIGUSR1", "SIGKILL"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("signals sent = %v, want %v", got, want)
+	}
+}
+
+func TestDockerStopContainerSkipsStoppedContainer(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fake.handle("GET", "/containers/"+stopContainerID+"/json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{ID: stopContainerID, State: &types.ContainerState{Running: false}},
+		})
+	})
+
+	if err := dm.StopContainer(context.Background(), stopContainerID, 1); err != nil {
+		t.Fatalf("StopContainer: %v", err)
+	}
+	for _, request := range fake.requested() {
+		if request == "POST /containers/"+stopContainerID+"/kill" {
+			t.Errorf("a stopped container was sent a signal")
+		}
+	}
+}
@@ -1,48 +1,228 @@
 package container
 
 import (
+	"archive/tar"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/docker/docker/api/types"
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/sirupsen/logrus"
 	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/template"
 )
 
 type Manager struct {
-	impl ContainerImplementation
+	impl   ContainerImplementation
+	logger *logrus.Logger
 }
 
 // ContainerImplementation defines the interface for container operations
 type ContainerImplementation interface {
-	CreateService(ctx context.Context, serviceName string, service *compose.Service) (string, error)
+	CreateService(ctx context.Context, serviceName string, service *compose.Service, number int) (string, error)
 	StartContainer(ctx context.Context, containerID string) error
 	StopContainer(ctx context.Context, containerID string, timeout int) error
-	RemoveContainer(ctx context.Context, containerID string) error
+	RemoveContainer(ctx context.Context, containerID string, removeVolumes bool) error
+	RenameContainer(ctx context.Context, containerID, newName string) error
+	ListServiceContainers(ctx context.Context, f filters.Args) ([]ContainerInfo, error)
+	ListProjectContainers(ctx context.Context) ([]ContainerInfo, error)
 	RunInitContainer(ctx context.Context, serviceName string, initContainer *compose.InitContainer) error
-	RunPostContainer(ctx context.Context, serviceName string, postContainer *compose.PostContainer) error
+	RunPostContainer(ctx context.Context, serviceName string, postContainer *compose.PostContainer, data template.Data) error
+	IsHealthy(ctx context.Context, containerID string) (bool, error)
+	ServiceLogs(ctx context.Context, serviceName string, number, tail int) ([]LogLine, error)
+	FollowLogs(ctx context.Context, containerID, serviceName string, fn func(LogLine)) error
+	RunOneOff(ctx context.Context, serviceName string, service *compose.Service, opts OneOffOptions) (int, error)
+	ResolveDigest(ctx context.Context, image string) (string, error)
+	EnsureNetwork(ctx context.Context, name string, network *compose.Network, labels map[string]string) (bool, error)
+	EnsureVolume(ctx context.Context, name string, volume *compose.Volume, labels map[string]string) (bool, error)
+	ListVolumes(ctx context.Context, f filters.Args) ([]string, error)
+	RemoveVolume(ctx context.Context, name string) error
+	SaveImage(ctx context.Context, image string, w io.Writer) error
+	LoadImage(ctx context.Context, r io.Reader) error
+	InspectContainer(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	Wait(ctx context.Context, containerID string) (int64, error)
+	Events(ctx context.Context, opts EventsOptions, fn func(Event)) error
+	ListServiceImages(ctx context.Context, composeFile *compose.ComposeFile) ([]ImageInfo, error)
+	InspectNetwork(ctx context.Context, name string) (types.NetworkResource, error)
+	CopyVolume(ctx context.Context, name string, w io.Writer) error
+	PullImage(ctx context.Context, ref string, opts PullOptions) (bool, error)
+	BuildService(ctx context.Context, serviceName string, service *compose.Service, opts BuildOptions) (string, error)
+	PushImage(ctx context.Context, ref string, opts PushOptions) error
+	Top(ctx context.Context, containerID string) (dockercontainer.ContainerTopOKBody, error)
+	Exec(ctx context.Context, containerID string, command []string, opts ExecOptions) (int, error)
+	ImageID(ctx context.Context, image string) (string, error)
 	Close() error
 }
 
+// OneOffOptions controls how a one-off `run` container is executed
+type OneOffOptions struct {
+	// Detach returns as soon as the container has started
+	Detach bool
+	// Remove deletes the container once it has exited
+	Remove bool
+	// Output receives the container's output when attached
+	Output io.Writer
+}
+
+// ExecOptions controls a command run in a service container
+type ExecOptions struct {
+	// User runs the command as this user instead of the container's
+	User string
+	// Detach returns as soon as the command has started
+	Detach bool
+	// Output receives the command's output when attached
+	Output io.Writer
+}
+
+// LogLine is a single timestamped line of container output. Number is the
+// replica the line comes from.
+type LogLine struct {
+	Service   string
+	Timestamp time.Time
+	Text      string
+	Number    int
+}
+
+// Event is a runtime event for one of the project's containers
+type Event struct {
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	Action      string    `json:"action,omitempty"`
+	Service     string    `json:"service,omitempty"`
+	ContainerID string    `json:"id,omitempty"`
+}
+
+// EventsOptions bounds an event stream. Zero times leave the stream open at
+// that end.
+type EventsOptions struct {
+	Since time.Time
+	Until time.Time
+	// Services restricts events to these services; empty means all
+	Services []string
+	// Actions and Types restrict events to these actions and object types;
+	// empty means all actions and container events
+	Actions []string
+	Types   []string
+}
+
+// Options configures how a Manager connects to its container backend
+type Options struct {
+	// Host overrides the Docker daemon address (DOCKER_HOST). ssh://
+	// addresses connect through the remote docker CLI.
+	Host string
+	// TLS overrides the TLS material taken from DOCKER_CERT_PATH and
+	// DOCKER_TLS_VERIFY
+	TLS *TLSOptions
+	// Project labels created containers and scopes container queries
+	Project string
+	// ConfigFiles are the project's compose files, recorded on created
+	// containers for `ls`
+	ConfigFiles []string
+	// InitLogs receives the output of init containers while they run, each
+	// line prefixed with [init:<name>]. Nil keeps only the output of failed
+	// ones, for their error.
+	InitLogs io.Writer
+	// PostLogs does the same for post containers, prefixed [post:<name>]
+	PostLogs io.Writer
+	// PullTimeout bounds each image pull that does not set its own
+	// timeout. Zero means DefaultPullTimeout; a negative value disables it.
+	PullTimeout time.Duration
+	// Backend selects the implementation. Empty means the value of
+	// FAKE_COMPOSE_BACKEND, and BackendAuto when that is unset too.
+	Backend Backend
+}
+
+// Backend names a container backend implementation
+type Backend string
+
+const (
+	// BackendAuto uses Docker when the daemon is reachable and falls back
+	// to the stub otherwise
+	BackendAuto Backend = "auto"
+	// BackendDocker requires Docker, failing when the daemon is unreachable
+	BackendDocker Backend = "docker"
+	// BackendStub always uses the in-memory stub
+	BackendStub Backend = "stub"
+)
+
+// BackendEnvVar is the environment variable consulted when
+// Options.Backend is empty
+const BackendEnvVar = "FAKE_COMPOSE_BACKEND"
+
+// ParseBackend validates a backend name
+func ParseBackend(value string) (Backend, error) {
+	switch backend := Backend(value); backend {
+	case BackendAuto, BackendDocker, BackendStub:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("invalid backend %q: must be auto, docker or stub", value)
+	}
+}
+
 func NewManager(logger *logrus.Logger) (*Manager, error) {
-	// Try to create Docker manager first
-	dockerManager, err := NewDockerManager(logger)
+	return NewManagerWithOptions(logger, Options{})
+}
+
+func NewManagerWithOptions(logger *logrus.Logger, opts Options) (*Manager, error) {
+	backend := opts.Backend
+	if backend == "" {
+		backend = BackendAuto
+		if value := os.Getenv(BackendEnvVar); value != "" {
+			var err error
+			if backend, err = ParseBackend(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", BackendEnvVar, err)
+			}
+		}
+	} else if _, err := ParseBackend(string(backend)); err != nil {
+		return nil, err
+	}
+
+	if backend == BackendStub {
+		logger.Info("Using stub container manager")
+		return &Manager{
+			impl:   newStubManager(logger, opts),
+			logger: logger,
+		}, nil
+	}
+
+	dockerManager, err := NewDockerManager(logger, opts)
 	if err != nil {
+		if backend == BackendDocker {
+			return nil, fmt.Errorf("docker backend required: %w", err)
+		}
 		logger.Warnf("Failed to create Docker manager, using stub: %v", err)
 		return &Manager{
-			impl: &StubManager{logger: logger},
+			impl:   newStubManager(logger, opts),
+			logger: logger,
 		}, nil
 	}
 
 	logger.Info("Using Docker container manager")
 	return &Manager{
-		impl: dockerManager,
+		impl:   dockerManager,
+		logger: logger,
 	}, nil
 }
 
+// NewManagerWithImplementation wraps an implementation of its own, such as
+// a fake recording the calls made to it
+func NewManagerWithImplementation(logger *logrus.Logger, impl ContainerImplementation) *Manager {
+	return &Manager{impl: impl, logger: logger}
+}
+
 // Manager methods delegate to the implementation
-func (m *Manager) CreateService(ctx context.Context, serviceName string, service *compose.Service) (string, error) {
-	return m.impl.CreateService(ctx, serviceName, service)
+
+// CreateService creates the container for replica number of a service
+func (m *Manager) CreateService(ctx context.Context, serviceName string, service *compose.Service, number int) (string, error) {
+	return m.impl.CreateService(ctx, serviceName, service, number)
 }
 
 func (m *Manager) StartContainer(ctx context.Context, containerID string) error {
@@ -53,33 +233,232 @@ func (m *Manager) StopContainer(ctx context.Context, containerID string, timeout
 	return m.impl.StopContainer(ctx, containerID, timeout)
 }
 
-func (m *Manager) RemoveContainer(ctx context.Context, containerID string) error {
-	return m.impl.RemoveContainer(ctx, containerID)
+func (m *Manager) RemoveContainer(ctx context.Context, containerID string, removeVolumes bool) error {
+	return m.impl.RemoveContainer(ctx, containerID, removeVolumes)
+}
+
+// RenameContainer gives a container a new name
+func (m *Manager) RenameContainer(ctx context.Context, containerID, newName string) error {
+	return m.impl.RenameContainer(ctx, containerID, newName)
 }
 
+// ListServiceContainers lists the project's containers, including stopped
+// ones, that match the given filters
+func (m *Manager) ListServiceContainers(ctx context.Context, f filters.Args) ([]ContainerInfo, error) {
+	return m.impl.ListServiceContainers(ctx, f)
+}
+
+// RunInitContainer runs an init container, retrying up to its configured
+// retry count with the configured delay between attempts. The error from the
+// last attempt is returned.
 func (m *Manager) RunInitContainer(ctx context.Context, serviceName string, initContainer *compose.InitContainer) error {
-	return m.impl.RunInitContainer(ctx, serviceName, initContainer)
+	var delay time.Duration
+	if initContainer.RetryDelay != "" {
+		parsed, err := time.ParseDuration(initContainer.RetryDelay)
+		if err != nil {
+			return fmt.Errorf("invalid retry_delay for init container %s: %w", initContainer.Name, err)
+		}
+		delay = parsed
+	}
+
+	err := m.impl.RunInitContainer(ctx, serviceName, initContainer)
+	for attempt := 1; err != nil && attempt <= initContainer.Retries; attempt++ {
+		m.logger.Warnf("Init container %s failed, retrying in %s (%d/%d): %v", initContainer.Name, delay, attempt, initContainer.Retries, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		err = m.impl.RunInitContainer(ctx, serviceName, initContainer)
+	}
+	return err
+}
+
+func (m *Manager) RunPostContainer(ctx context.Context, serviceName string, postContainer *compose.PostContainer, data template.Data) error {
+	return m.impl.RunPostContainer(ctx, serviceName, postContainer, data)
+}
+
+func (m *Manager) IsHealthy(ctx context.Context, containerID string) (bool, error) {
+	return m.impl.IsHealthy(ctx, containerID)
+}
+
+func (m *Manager) ServiceLogs(ctx context.Context, serviceName string, number, tail int) ([]LogLine, error) {
+	return m.impl.ServiceLogs(ctx, serviceName, number, tail)
+}
+
+// FollowLogs streams a container's output line by line to fn until the
+// container stops or ctx is cancelled
+func (m *Manager) FollowLogs(ctx context.Context, containerID, serviceName string, fn func(LogLine)) error {
+	return m.impl.FollowLogs(ctx, containerID, serviceName, fn)
+}
+
+func (m *Manager) RunOneOff(ctx context.Context, serviceName string, service *compose.Service, opts OneOffOptions) (int, error) {
+	return m.impl.RunOneOff(ctx, serviceName, service, opts)
+}
+
+// ResolveDigest looks up the registry digest an image reference currently
+// points to
+func (m *Manager) ResolveDigest(ctx context.Context, image string) (string, error) {
+	return m.impl.ResolveDigest(ctx, image)
+}
+
+// EnsureNetwork creates a network with the given labels unless it already
+// exists, reporting whether it was created
+func (m *Manager) EnsureNetwork(ctx context.Context, name string, network *compose.Network, labels map[string]string) (bool, error) {
+	return m.impl.EnsureNetwork(ctx, name, network, labels)
+}
+
+// EnsureVolume creates a named volume with the given labels unless it
+// already exists, reporting whether it was created
+func (m *Manager) EnsureVolume(ctx context.Context, name string, volume *compose.Volume, labels map[string]string) (bool, error) {
+	return m.impl.EnsureVolume(ctx, name, volume, labels)
+}
+
+func (m *Manager) ListVolumes(ctx context.Context, f filters.Args) ([]string, error) {
+	return m.impl.ListVolumes(ctx, f)
+}
+
+func (m *Manager) RemoveVolume(ctx context.Context, name string) error {
+	return m.impl.RemoveVolume(ctx, name)
 }
 
-func (m *Manager) RunPostContainer(ctx context.Context, serviceName string, postContainer *compose.PostContainer) error {
-	return m.impl.RunPostContainer(ctx, serviceName, postContainer)
+// SaveImage writes an image as a tar archive, as `docker save` does
+func (m *Manager) SaveImage(ctx context.Context, image string, w io.Writer) error {
+	return m.impl.SaveImage(ctx, image, w)
+}
+
+// LoadImage loads images from a tar archive, which may be gzip compressed
+func (m *Manager) LoadImage(ctx context.Context, r io.Reader) error {
+	return m.impl.LoadImage(ctx, r)
+}
+
+// InspectContainer returns the runtime's detailed view of a container
+func (m *Manager) InspectContainer(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	return m.impl.InspectContainer(ctx, containerID)
+}
+
+// Wait blocks until the container stops and returns its exit code
+func (m *Manager) Wait(ctx context.Context, containerID string) (int64, error) {
+	return m.impl.Wait(ctx, containerID)
+}
+
+// Events passes the project's container events to fn until the stream
+// ends. A nil error means the stream ended at opts.Until or was cancelled.
+func (m *Manager) Events(ctx context.Context, opts EventsOptions, fn func(Event)) error {
+	return m.impl.Events(ctx, opts, fn)
+}
+
+// ListServiceImages describes every image the compose file references
+func (m *Manager) ListServiceImages(ctx context.Context, composeFile *compose.ComposeFile) ([]ImageInfo, error) {
+	return m.impl.ListServiceImages(ctx, composeFile)
+}
+
+// InspectNetwork returns the runtime's detailed view of a network
+func (m *Manager) InspectNetwork(ctx context.Context, name string) (types.NetworkResource, error) {
+	return m.impl.InspectNetwork(ctx, name)
+}
+
+// CopyVolume writes the contents of a named volume to w as a tar archive
+func (m *Manager) CopyVolume(ctx context.Context, name string, w io.Writer) error {
+	return m.impl.CopyVolume(ctx, name, w)
+}
+
+// PullImage fetches an image according to opts.Policy, reporting whether
+// the registry was contacted
+func (m *Manager) PullImage(ctx context.Context, ref string, opts PullOptions) (bool, error) {
+	return m.impl.PullImage(ctx, ref, opts)
+}
+
+// BuildService builds a service's image and returns its ID
+func (m *Manager) BuildService(ctx context.Context, serviceName string, service *compose.Service, opts BuildOptions) (string, error) {
+	return m.impl.BuildService(ctx, serviceName, service, opts)
+}
+
+// PushImage uploads an image to its registry with the given credentials
+func (m *Manager) PushImage(ctx context.Context, ref string, opts PushOptions) error {
+	return m.impl.PushImage(ctx, ref, opts)
+}
+
+// Top lists the processes running in a container, as `ps -ef` does
+func (m *Manager) Top(ctx context.Context, containerID string) (dockercontainer.ContainerTopOKBody, error) {
+	return m.impl.Top(ctx, containerID)
+}
+
+// ImageID returns the ID of a local image
+func (m *Manager) ImageID(ctx context.Context, image string) (string, error) {
+	return m.impl.ImageID(ctx, image)
+}
+
+// Exec runs a command in a running container and returns its exit code
+func (m *Manager) Exec(ctx context.Context, containerID string, command []string, opts ExecOptions) (int, error) {
+	return m.impl.Exec(ctx, containerID, command, opts)
 }
 
 func (m *Manager) Close() error {
 	return m.impl.Close()
 }
 
-// StubManager provides stub implementations for testing/fallback
+// StubManager provides stub implementations for testing/fallback. It keeps
+// an in-memory record of the containers it has created so list queries
+// reflect earlier calls within the same process.
 type StubManager struct {
-	logger *logrus.Logger
+	logger     *logrus.Logger
+	project    string
+	containers map[string]*ContainerInfo
+	volumes    map[string]map[string]string
+	networks   map[string]map[string]string
+	images     map[string]bool
+	mu         sync.Mutex
+	// exitCodes are the codes Wait reports, see SetExitCode
+	exitCodes   map[string]int64
+	configFiles []string
+}
+
+func NewStubManager(logger *logrus.Logger, project string) *StubManager {
+	return &StubManager{
+		logger:     logger,
+		project:    project,
+		containers: make(map[string]*ContainerInfo),
+		volumes:    make(map[string]map[string]string),
+		networks:   make(map[string]map[string]string),
+		images:     make(map[string]bool),
+		exitCodes:  make(map[string]int64),
+	}
+}
+
+func newStubManager(logger *logrus.Logger, opts Options) *StubManager {
+	s := NewStubManager(logger, opts.Project)
+	s.configFiles = opts.ConfigFiles
+	return s
+}
+
+// SetExitCode sets the code Wait returns once the container has stopped, 0
+// by default, to simulate a failing container
+func (s *StubManager) SetExitCode(containerID string, code int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exitCodes[containerID] = code
 }
 
-func (s *StubManager) CreateService(ctx context.Context, serviceName string, service *compose.Service) (string, error) {
-	containerID := fmt.Sprintf("%s_container_%d", serviceName, time.Now().Unix())
+func (s *StubManager) CreateService(ctx context.Context, serviceName string, service *compose.Service, number int) (string, error) {
+	containerID := fmt.Sprintf("%s_%d_container_%d", serviceName, number, time.Now().UnixNano())
 	s.logger.Infof("[STUB] Creating container %s for service %s (image: %s)", containerID, serviceName, service.Image)
 	
 	// Simulate container creation time
 	time.Sleep(100 * time.Millisecond)
+
+	s.mu.Lock()
+	s.containers[containerID] = &ContainerInfo{
+		ID:      containerID,
+		Name:    ContainerName(s.project, serviceName, number),
+		Service: serviceName,
+		Number:  number,
+		Image:   service.Image,
+		State:   "created",
+		Status:  "Created",
+		Labels:  containerLabels(s.project, s.configFiles, serviceName, number, service, stubImageID(service.Image)),
+	}
+	s.mu.Unlock()
 	
 	return containerID, nil
 }
@@ -89,6 +468,8 @@ func (s *StubManager) StartContainer(ctx context.Context, containerID string) er
 	
 	// Simulate container startup time
 	time.Sleep(200 * time.Millisecond)
+
+	s.setState(containerID, "running", "Up Less than a second")
 	
 	return nil
 }
@@ -98,19 +479,73 @@ func (s *StubManager) StopContainer(ctx context.Context, containerID string, tim
 	
 	// Simulate container stop time
 	time.Sleep(100 * time.Millisecond)
+
+	s.setState(containerID, "exited", "Exited (0) Less than a second ago")
 	
 	return nil
 }
 
-func (s *StubManager) RemoveContainer(ctx context.Context, containerID string) error {
-	s.logger.Infof("[STUB] Removing container %s", containerID)
+func (s *StubManager) RemoveContainer(ctx context.Context, containerID string, removeVolumes bool) error {
+	s.logger.Infof("[STUB] Removing container %s (volumes: %v)", containerID, removeVolumes)
 	
 	// Simulate container removal time
 	time.Sleep(50 * time.Millisecond)
+
+	s.mu.Lock()
+	delete(s.containers, containerID)
+	s.mu.Unlock()
 	
 	return nil
 }
 
+func (s *StubManager) RenameContainer(ctx context.Context, containerID, newName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, exists := s.containers[containerID]
+	if !exists {
+		return fmt.Errorf("[STUB] no such container: %s", containerID)
+	}
+	for _, other := range s.containers {
+		if other.Name == newName {
+			return fmt.Errorf("[STUB] container name %s is already in use", newName)
+		}
+	}
+	s.logger.Infof("[STUB] Renaming container %s to %s", info.Name, newName)
+	info.Name = newName
+	return nil
+}
+
+func (s *StubManager) ListServiceContainers(ctx context.Context, f filters.Args) ([]ContainerInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []ContainerInfo
+	for _, info := range s.containers {
+		if f.Contains("status") && !f.ExactMatch("status", info.State) {
+			continue
+		}
+		if !f.MatchKVList("label", info.Labels) {
+			continue
+		}
+		result = append(result, *info)
+	}
+	return result, nil
+}
+
+func (s *StubManager) ListProjectContainers(ctx context.Context) ([]ContainerInfo, error) {
+	return s.ListServiceContainers(ctx, filters.NewArgs(filters.Arg("label", LabelProject)))
+}
+
+func (s *StubManager) setState(containerID, state, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info, exists := s.containers[containerID]; exists {
+		info.State = state
+		info.Status = status
+	}
+}
+
 func (s *StubManager) RunInitContainer(ctx context.Context, serviceName string, initContainer *compose.InitContainer) error {
 	s.logger.Infof("[STUB] Running init container %s for service %s (image: %s)", initContainer.Name, serviceName, initContainer.Image)
 	
@@ -121,15 +556,17 @@ func (s *StubManager) RunInitContainer(ctx context.Context, serviceName string,
 	return nil
 }
 
-func (s *StubManager) RunPostContainer(ctx context.Context, serviceName string, postContainer *compose.PostContainer) error {
-	s.logger.Infof("[STUB] Running post container %s for service %s (image: %s)", postContainer.Name, serviceName, postContainer.Image)
+func (s *StubManager) RunPostContainer(ctx context.Context, serviceName string, postContainer *compose.PostContainer, data template.Data) error {
+	command, err := template.RenderAll(postContainer.Command, data)
+	if err != nil {
+		return fmt.Errorf("post container %s: %w", postContainer.Name, err)
+	}
+	s.logger.Infof("[STUB] Running post container %s for service %s (image: %s, command: %v)", postContainer.Name, serviceName, postContainer.Image, command)
 	
 	// Wait for specified duration if configured
-	if postContainer.WaitFor != "" {
-		if duration, err := time.ParseDuration(postContainer.WaitFor); err == nil {
-			s.logger.Infof("[STUB] Waiting %s before running post container", postContainer.WaitFor)
-			time.Sleep(duration)
-		}
+	if duration, _, err := postContainer.WaitCondition(); err == nil && duration > 0 {
+		s.logger.Infof("[STUB] Waiting %s before running post container", postContainer.WaitFor)
+		time.Sleep(duration)
 	}
 	
 	// Simulate post container execution
@@ -139,6 +576,419 @@ func (s *StubManager) RunPostContainer(ctx context.Context, serviceName string,
 	return nil
 }
 
+func (s *StubManager) IsHealthy(ctx context.Context, containerID string) (bool, error) {
+	s.logger.Debugf("[STUB] Checking health of container %s", containerID)
+	return true, nil
+}
+
+func (s *StubManager) ServiceLogs(ctx context.Context, serviceName string, number, tail int) ([]LogLine, error) {
+	s.logger.Debugf("[STUB] Reading logs for replica %d of service %s (tail: %d)", number, serviceName, tail)
+
+	// Simulate a short log history with a health probe every 30 seconds
+	now := time.Now()
+	lines := []LogLine{
+		{Service: serviceName, Number: number, Timestamp: now.Add(-2 * time.Minute), Text: "Server started successfully"},
+		{Service: serviceName, Number: number, Timestamp: now.Add(-2*time.Minute + 500*time.Millisecond), Text: "Application ready"},
+	}
+	for i := 3; i >= 0; i-- {
+		lines = append(lines, LogLine{
+			Service:   serviceName,
+			Number:    number,
+			Timestamp: now.Add(-time.Duration(i*30+len(serviceName)+number) * time.Second),
+			Text:      "GET /health - 200",
+		})
+	}
+
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	return lines, nil
+}
+
+func (s *StubManager) FollowLogs(ctx context.Context, containerID, serviceName string, fn func(LogLine)) error {
+	s.logger.Debugf("[STUB] Following logs of container %s", containerID)
+
+	fn(LogLine{Service: serviceName, Timestamp: time.Now(), Text: "Server started successfully"})
+	fn(LogLine{Service: serviceName, Timestamp: time.Now(), Text: "Application ready"})
+
+	// Simulate a health probe every few seconds
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			fn(LogLine{Service: serviceName, Timestamp: now, Text: "GET /health - 200"})
+		}
+	}
+}
+
+func (s *StubManager) RunOneOff(ctx context.Context, serviceName string, service *compose.Service, opts OneOffOptions) (int, error) {
+	s.logger.Infof("[STUB] Running one-off container for service %s (image: %s, command: %v, env: %v, ports: %v, volumes: %v, workdir: %q)",
+		serviceName, service.Image, service.Command, service.Environment, service.Ports, service.Volumes, service.WorkingDir)
+
+	// Simulate container execution
+	time.Sleep(200 * time.Millisecond)
+
+	if !opts.Detach && opts.Output != nil {
+		fmt.Fprintf(opts.Output, "[STUB] %s exited\n", serviceName)
+	}
+	return 0, nil
+}
+
+func (s *StubManager) ResolveDigest(ctx context.Context, image string) (string, error) {
+	return "", fmt.Errorf("[STUB] cannot resolve digest for %s without a Docker daemon", image)
+}
+
+func (s *StubManager) EnsureNetwork(ctx context.Context, name string, network *compose.Network, labels map[string]string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.networks[name]; exists {
+		return false, nil
+	}
+	s.logger.Infof("[STUB] Creating network %s", name)
+	s.networks[name] = labels
+	return true, nil
+}
+
+func (s *StubManager) EnsureVolume(ctx context.Context, name string, volume *compose.Volume, labels map[string]string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.volumes[name]; exists {
+		return false, nil
+	}
+	s.logger.Infof("[STUB] Creating volume %s", name)
+	s.volumes[name] = labels
+	return true, nil
+}
+
+func (s *StubManager) ListVolumes(ctx context.Context, f filters.Args) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for name, labels := range s.volumes {
+		if f.MatchKVList("label", labels) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (s *StubManager) RemoveVolume(ctx context.Context, name string) error {
+	s.logger.Infof("[STUB] Removing volume %s", name)
+
+	s.mu.Lock()
+	delete(s.volumes, name)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *StubManager) SaveImage(ctx context.Context, image string, w io.Writer) error {
+	return fmt.Errorf("[STUB] cannot save image %s without a Docker daemon", image)
+}
+
+func (s *StubManager) LoadImage(ctx context.Context, r io.Reader) error {
+	return fmt.Errorf("[STUB] cannot load images without a Docker daemon")
+}
+
+func (s *StubManager) InspectContainer(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	s.mu.Lock()
+	info, exists := s.containers[containerID]
+	exitCode := s.exitCodes[containerID]
+	s.mu.Unlock()
+
+	if !exists {
+		return types.ContainerJSON{}, fmt.Errorf("[STUB] no such container: %s", containerID)
+	}
+	if info.State == "running" {
+		exitCode = 0
+	}
+
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    info.ID,
+			Name:  "/" + info.Name,
+			Image: info.Image,
+			State: &types.ContainerState{
+				Status:   info.State,
+				Running:  info.State == "running",
+				ExitCode: int(exitCode),
+			},
+		},
+		Config: &dockercontainer.Config{
+			Image:  info.Image,
+			Labels: info.Labels,
+		},
+	}, nil
+}
+
+func (s *StubManager) Wait(ctx context.Context, containerID string) (int64, error) {
+	s.logger.Debugf("[STUB] Waiting for container %s", containerID)
+
+	// Stub containers run until they are stopped, then exit with the code
+	// given to SetExitCode
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case <-ticker.C:
+			s.mu.Lock()
+			info, exists := s.containers[containerID]
+			code := s.exitCodes[containerID]
+			s.mu.Unlock()
+			if !exists || info.State != "running" {
+				return code, nil
+			}
+		}
+	}
+}
+
+func (s *StubManager) Events(ctx context.Context, opts EventsOptions, fn func(Event)) error {
+	s.logger.Debugf("[STUB] Streaming events for services %v", opts.Services)
+	if len(opts.Services) == 0 {
+		return nil
+	}
+
+	// Simulate a short burst of events, then let the stream end
+	actions := []string{"create", "start", "health_status: healthy", "exec_create", "exec_start", "resize", "update"}
+	for i := 0; i < 15; i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Duration(800+i*200) * time.Millisecond):
+		}
+
+		now := time.Now()
+		if !opts.Until.IsZero() && now.After(opts.Until) {
+			return nil
+		}
+		serviceName := opts.Services[i%len(opts.Services)]
+		event := Event{
+			Time:        now,
+			Type:        "container",
+			Action:      actions[i%len(actions)],
+			Service:     serviceName,
+			ContainerID: fmt.Sprintf("%s_container_%d", serviceName, now.Unix()),
+		}
+		if opts.Matches(event) {
+			fn(event)
+		}
+	}
+	return nil
+}
+
+func (s *StubManager) ListServiceImages(ctx context.Context, composeFile *compose.ComposeFile) ([]ImageInfo, error) {
+	// Without a daemon no image is available locally
+	var images []ImageInfo
+	for _, ref := range imageReferences(composeFile) {
+		repository, tag, digest := splitImageReference(ref)
+		images = append(images, ImageInfo{Reference: ref, Repository: repository, Tag: tag, Digest: digest})
+	}
+	return images, nil
+}
+
+func (s *StubManager) InspectNetwork(ctx context.Context, name string) (types.NetworkResource, error) {
+	s.mu.Lock()
+	labels, exists := s.networks[name]
+	s.mu.Unlock()
+
+	if !exists {
+		return types.NetworkResource{}, fmt.Errorf("[STUB] no such network: %s", name)
+	}
+	return types.NetworkResource{Name: name, Driver: "bridge", Labels: labels}, nil
+}
+
+func (s *StubManager) CopyVolume(ctx context.Context, name string, w io.Writer) error {
+	s.mu.Lock()
+	_, exists := s.volumes[name]
+	s.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("[STUB] no such volume: %s", name)
+	}
+	s.logger.Infof("[STUB] Copying volume %s", name)
+
+	// Stub volumes hold no data, so the archive is empty
+	return tar.NewWriter(w).Close()
+}
+
+func (s *StubManager) PullImage(ctx context.Context, ref string, opts PullOptions) (bool, error) {
+	s.mu.Lock()
+	present := s.images[ref]
+	s.mu.Unlock()
+
+	if present && opts.Policy == PullMissing {
+		return false, nil
+	}
+
+	s.logger.Infof("[STUB] Pulling image %s", ref)
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-time.After(200 * time.Millisecond):
+	}
+	if opts.Progress != nil {
+		fmt.Fprintf(opts.Progress, "%s: Pull complete\n", ref)
+	}
+
+	s.mu.Lock()
+	s.images[ref] = true
+	s.mu.Unlock()
+	return true, nil
+}
+
+func (s *StubManager) BuildService(ctx context.Context, serviceName string, service *compose.Service, opts BuildOptions) (string, error) {
+	if service.Build == nil {
+		return "", fmt.Errorf("service %s has no build configuration", serviceName)
+	}
+	if _, err := imageBuildOptions(service.Build, opts); err != nil {
+		return "", err
+	}
+
+	s.logger.Infof("[STUB] Building image %s for service %s", opts.Tag, serviceName)
+	w := opts.Progress
+	if w == nil {
+		w = io.Discard
+	}
+	steps := []string{
+		"[internal] load build definition from Dockerfile",
+		"[internal] load .dockerignore",
+		"[internal] load build context",
+		"[1/2] WORKDIR /app",
+		"[2/2] COPY . .",
+		"exporting to image",
+	}
+	for i, step := range steps {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+		fmt.Fprintf(w, "#%d %s\n#%d DONE 0.1s\n", i+1, step, i+1)
+	}
+
+	s.mu.Lock()
+	s.images[opts.Tag] = true
+	s.mu.Unlock()
+	return "sha256:" + strings.Repeat("0", 64), nil
+}
+
+func (s *StubManager) PushImage(ctx context.Context, ref string, opts PushOptions) error {
+	user := opts.Auth.Username
+	if user == "" {
+		user = "anonymous"
+	}
+	s.logger.Infof("[STUB] Pushing image %s to %s as %s", ref, RegistryHost(ref), user)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(200 * time.Millisecond):
+	}
+	if opts.Progress != nil {
+		fmt.Fprintf(opts.Progress, "%s: Pushed\n", ref)
+	}
+	return nil
+}
+
+func (s *StubManager) Top(ctx context.Context, containerID string) (dockercontainer.ContainerTopOKBody, error) {
+	s.mu.Lock()
+	info, exists := s.containers[containerID]
+	s.mu.Unlock()
+
+	if !exists {
+		return dockercontainer.ContainerTopOKBody{}, fmt.Errorf("[STUB] no such container: %s", containerID)
+	}
+	if info.State != "running" {
+		return dockercontainer.ContainerTopOKBody{}, fmt.Errorf("[STUB] container %s is not running", info.Name)
+	}
+
+	// A stub container runs a single simulated process
+	return dockercontainer.ContainerTopOKBody{
+		Titles:    []string{"UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"},
+		Processes: [][]string{{"root", "1", "0", "0", time.Now().Format("15:04"), "?", "00:00:00", "[STUB] " + info.Image}},
+	}, nil
+}
+
+func (s *StubManager) Exec(ctx context.Context, containerID string, command []string, opts ExecOptions) (int, error) {
+	s.mu.Lock()
+	info, exists := s.containers[containerID]
+	s.mu.Unlock()
+
+	if !exists {
+		return -1, fmt.Errorf("[STUB] no such container: %s", containerID)
+	}
+	if info.State != "running" {
+		return -1, fmt.Errorf("[STUB] container %s is not running", info.Name)
+	}
+
+	s.logger.Infof("[STUB] Executing %v in container %s (user: %q)", command, info.Name, opts.User)
+	if opts.Detach || opts.Output == nil {
+		return 0, nil
+	}
+
+	// Simulate the output of common commands
+	w := opts.Output
+	now := time.Now().Format("Jan 2 15:04")
+	switch command[0] {
+	case "ls":
+		fmt.Fprintf(w, "total 24\n")
+		fmt.Fprintf(w, "drwxr-xr-x 1 root root 4096 %s .\n", now)
+		fmt.Fprintf(w, "drwxr-xr-x 1 root root 4096 %s ..\n", now)
+		fmt.Fprintf(w, "-rw-r--r-- 1 root root  234 %s package.json\n", now)
+		fmt.Fprintf(w, "-rw-r--r-- 1 root root 1234 %s server.js\n", now)
+	case "ps":
+		fmt.Fprintf(w, "PID TTY          TIME CMD\n")
+		fmt.Fprintf(w, "  1 ?        00:00:01 %s\n", info.Service)
+	case "hostname":
+		fmt.Fprintln(w, info.Name)
+	case "env":
+		fmt.Fprintf(w, "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin\n")
+		fmt.Fprintf(w, "HOSTNAME=%s\n", info.Name)
+	case "echo":
+		fmt.Fprintln(w, strings.Join(command[1:], " "))
+	case "cat":
+		switch {
+		case len(command) > 1 && command[1] == "/etc/hostname":
+			fmt.Fprintln(w, info.Name)
+		case len(command) > 1 && command[1] == "package.json":
+			fmt.Fprintf(w, `{
+  "name": %q,
+  "version": "1.0.0",
+  "main": "server.js",
+  "scripts": {
+    "start": "node server.js"
+  }
+}
+`, info.Service)
+		default:
+			fmt.Fprintf(w, "cat: %s: No such file or directory\n", strings.Join(command[1:], " "))
+			return 1, nil
+		}
+	case "curl":
+		fmt.Fprintf(w, `{"status":"ok","service":%q}`+"\n", info.Service)
+	default:
+		fmt.Fprintf(w, "[STUB] %s executed in %s\n", strings.Join(command, " "), info.Name)
+	}
+	return 0, nil
+}
+
+func (s *StubManager) ImageID(ctx context.Context, image string) (string, error) {
+	return stubImageID(image), nil
+}
+
+// stubImageID derives a stable image ID from the image name, as stub tags
+// never move
+func stubImageID(image string) string {
+	sum := sha256.Sum256([]byte(image))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
 func (s *StubManager) Close() error {
 	s.logger.Info("[STUB] Closing container manager")
 	return nil
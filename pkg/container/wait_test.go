@@ -0,0 +1,70 @@
+package container
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStubWaitReturnsConfiguredExitCode(t *testing.T) {
+	s, containerID := runningStub(t)
+	s.SetExitCode(containerID, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan int64, 1)
+	go func() {
+		code, err := s.Wait(ctx, containerID)
+		if err != nil {
+			t.Errorf("Wait: %v", err)
+		}
+		done <- code
+	}()
+
+	if err := s.StopContainer(ctx, containerID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if code := <-done; code != 3 {
+		t.Errorf("Wait = %d, want 3", code)
+	}
+}
+
+func TestStubWaitCancelled(t *testing.T) {
+	s, containerID := runningStub(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := s.Wait(ctx, containerID); err == nil {
+		t.Error("Wait on a running container returned without error after cancel")
+	}
+}
+
+func TestDockerWaitPropagatesStatusCode(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fake.handle("POST", "/containers/abc/wait", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("condition"); got != "not-running" {
+			t.Errorf("condition = %q, want not-running", got)
+		}
+		writeJSON(w, map[string]interface{}{"StatusCode": 137})
+	})
+
+	code, err := dm.Wait(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if code != 137 {
+		t.Errorf("Wait = %d, want 137", code)
+	}
+}
+
+func TestDockerWaitReportsWaitError(t *testing.T) {
+	fake, dm := newFakeDocker(t)
+	fake.handle("POST", "/containers/abc/wait", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"StatusCode": 0, "Error": map[string]string{"Message": "container vanished"}})
+	})
+
+	if _, err := dm.Wait(context.Background(), "abc"); err == nil {
+		t.Error("Wait succeeded, want the daemon's wait error")
+	}
+}
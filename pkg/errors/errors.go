@@ -0,0 +1,191 @@
+// Package errors defines typed errors returned by fake-compose so callers can
+// inspect failure reasons with errors.Is and errors.As instead of matching
+// message strings.
+//
+// The Is methods treat empty identifying fields on the target as wildcards,
+// so errors.Is(err, &ServiceStartError{}) matches any service start failure
+// while errors.Is(err, &ServiceStartError{Service: "web"}) only matches web.
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServiceStartError reports that a service failed to start
+type ServiceStartError struct {
+	Service string
+	Cause   error
+}
+
+func (e *ServiceStartError) Error() string {
+	return fmt.Sprintf("failed to start service %s: %v", e.Service, e.Cause)
+}
+
+func (e *ServiceStartError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *ServiceStartError) Is(target error) bool {
+	t, ok := target.(*ServiceStartError)
+	return ok && matches(t.Service, e.Service)
+}
+
+// InitContainerError reports that an init container failed. ExitCode is -1
+// when the container never ran to completion.
+type InitContainerError struct {
+	Service   string
+	Container string
+	ExitCode  int
+	Logs      string
+	Cause     error
+}
+
+func (e *InitContainerError) Error() string {
+	if e.ExitCode < 0 {
+		return fmt.Sprintf("init container %s for service %s failed: %v", e.Container, e.Service, e.Cause)
+	}
+	msg := fmt.Sprintf("init container %s for service %s exited with code %d", e.Container, e.Service, e.ExitCode)
+	if tail := LogTail(e.Logs, logTailLines); tail != "" {
+		msg += ", last output:\n" + tail
+	}
+	return msg
+}
+
+func (e *InitContainerError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *InitContainerError) Is(target error) bool {
+	t, ok := target.(*InitContainerError)
+	return ok && matches(t.Service, e.Service) && matches(t.Container, e.Container)
+}
+
+// ContainerRunError reports that a run-to-completion container such as an
+// init or post container exited with a non-zero code
+type ContainerRunError struct {
+	Name     string
+	ExitCode int
+	Logs     string
+}
+
+func (e *ContainerRunError) Error() string {
+	return fmt.Sprintf("container %s exited with code %d", e.Name, e.ExitCode)
+}
+
+func (e *ContainerRunError) Unwrap() error {
+	return nil
+}
+
+func (e *ContainerRunError) Is(target error) bool {
+	t, ok := target.(*ContainerRunError)
+	return ok && matches(t.Name, e.Name)
+}
+
+// HookError reports that a lifecycle hook failed
+type HookError struct {
+	Service string
+	Hook    string
+	Phase   string
+	Cause   error
+}
+
+func (e *HookError) Error() string {
+	if e.Service == "" {
+		return fmt.Sprintf("hook %s failed: %v", e.Hook, e.Cause)
+	}
+	return fmt.Sprintf("%s hook %s for service %s failed: %v", e.Phase, e.Hook, e.Service, e.Cause)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *HookError) Is(target error) bool {
+	t, ok := target.(*HookError)
+	return ok && matches(t.Service, e.Service) && matches(t.Hook, e.Hook) && matches(t.Phase, e.Phase)
+}
+
+// DependencyError reports that a service's dependency was not satisfied
+type DependencyError struct {
+	Service    string
+	Dependency string
+	Reason     string
+	Cause      error
+}
+
+func (e *DependencyError) Error() string {
+	msg := fmt.Sprintf("service %s: dependency %s %s", e.Service, e.Dependency, e.Reason)
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+func (e *DependencyError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *DependencyError) Is(target error) bool {
+	t, ok := target.(*DependencyError)
+	return ok && matches(t.Service, e.Service) && matches(t.Dependency, e.Dependency)
+}
+
+// ServiceExitError reports that a service container exited while `up` was
+// attached with --abort-on-container-exit. The command exits with ExitCode.
+type ServiceExitError struct {
+	Service  string
+	ExitCode int
+}
+
+func (e *ServiceExitError) Error() string {
+	return fmt.Sprintf("service %s exited with code %d", e.Service, e.ExitCode)
+}
+
+func (e *ServiceExitError) Unwrap() error {
+	return nil
+}
+
+func (e *ServiceExitError) Is(target error) bool {
+	t, ok := target.(*ServiceExitError)
+	return ok && matches(t.Service, e.Service)
+}
+
+// ValidationError reports an invalid compose file field. Field is a dotted
+// path such as services.web.image.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return nil
+}
+
+func (e *ValidationError) Is(target error) bool {
+	t, ok := target.(*ValidationError)
+	return ok && matches(t.Field, e.Field)
+}
+
+// logTailLines is how many lines of container output error messages include
+const logTailLines = 10
+
+// LogTail returns the last n non-empty lines of logs
+func LogTail(logs string, n int) string {
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func matches(want, got string) bool {
+	return want == "" || want == got
+}
@@ -0,0 +1,112 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorChainIsNavigable(t *testing.T) {
+	hookErr := &HookError{Service: "web", Hook: "migrate", Phase: "pre-start", Cause: context.DeadlineExceeded}
+	depErr := &DependencyError{Service: "web", Dependency: "db", Reason: "is not healthy", Cause: hookErr}
+	err := fmt.Errorf("up failed: %w", &ServiceStartError{Service: "web", Cause: depErr})
+
+	var start *ServiceStartError
+	if !stderrors.As(err, &start) || start.Service != "web" {
+		t.Errorf("errors.As did not find the ServiceStartError for web in %v", err)
+	}
+	var dep *DependencyError
+	if !stderrors.As(err, &dep) || dep.Dependency != "db" {
+		t.Errorf("errors.As did not find the DependencyError on db in %v", err)
+	}
+	var hook *HookError
+	if !stderrors.As(err, &hook) || hook.Hook != "migrate" || hook.Phase != "pre-start" {
+		t.Errorf("errors.As did not find the HookError in %v", err)
+	}
+	if !stderrors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("errors.Is did not reach the root cause of %v", err)
+	}
+	var validation *ValidationError
+	if stderrors.As(err, &validation) {
+		t.Errorf("errors.As found a ValidationError in %v", err)
+	}
+}
+
+func TestIsMatchesEmptyFieldsAsWildcards(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &ServiceStartError{
+		Service: "web",
+		Cause: &InitContainerError{
+			Service:   "web",
+			Container: "migrate",
+			ExitCode:  1,
+			Cause:     &ContainerRunError{Name: "demo_web_init_migrate", ExitCode: 1},
+		},
+	})
+
+	tests := []struct {
+		name   string
+		target error
+		want   bool
+	}{
+		{"any service start", &ServiceStartError{}, true},
+		{"service start of web", &ServiceStartError{Service: "web"}, true},
+		{"service start of db", &ServiceStartError{Service: "db"}, false},
+		{"any init container", &InitContainerError{}, true},
+		{"init container migrate", &InitContainerError{Service: "web", Container: "migrate"}, true},
+		{"init container seed", &InitContainerError{Container: "seed"}, false},
+		{"any run error", &ContainerRunError{}, true},
+		{"run error by name", &ContainerRunError{Name: "demo_web_init_migrate"}, true},
+		{"any hook", &HookError{}, false},
+		{"any validation", &ValidationError{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stderrors.Is(err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%T) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorIs(t *testing.T) {
+	err := fmt.Errorf("validation failed: %w", &ValidationError{Field: "services.web.image", Message: "image or build is required"})
+
+	if !stderrors.Is(err, &ValidationError{}) || !stderrors.Is(err, &ValidationError{Field: "services.web.image"}) {
+		t.Errorf("errors.Is did not match %v", err)
+	}
+	if stderrors.Is(err, &ValidationError{Field: "services.db.image"}) {
+		t.Errorf("errors.Is matched another field in %v", err)
+	}
+	if want := "validation failed: services.web.image: image or build is required"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&ServiceStartError{Service: "web", Cause: stderrors.New("boom")}, "failed to start service web: boom"},
+		{&HookError{Hook: "notify", Cause: stderrors.New("boom")}, "hook notify failed: boom"},
+		{&HookError{Service: "web", Hook: "migrate", Phase: "pre-start", Cause: stderrors.New("boom")}, "pre-start hook migrate for service web failed: boom"},
+		{&DependencyError{Service: "web", Dependency: "db", Reason: "is not healthy"}, "service web: dependency db is not healthy"},
+		{&ServiceExitError{Service: "web", ExitCode: 3}, "service web exited with code 3"},
+		{&ValidationError{Message: "no services"}, "no services"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("%T.Error() = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestLogTail(t *testing.T) {
+	if got := LogTail("a\nb\nc\nd\n", 2); got != "c\nd" {
+		t.Errorf("LogTail = %q, want c, d", got)
+	}
+	if got := LogTail("only\n", 5); got != "only" {
+		t.Errorf("LogTail = %q, want only", got)
+	}
+}
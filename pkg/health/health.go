@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// ErrTimeout is returned by WaitHealthy when the timeout elapses before the
+// check reports healthy
+var ErrTimeout = errors.New("timed out waiting for healthy state")
+
+// maxInterval caps the exponential backoff between checks
+const maxInterval = 30 * time.Second
+
+// CheckFunc reports whether the target is healthy. A non-nil error aborts
+// the wait immediately.
+type CheckFunc func() (bool, error)
+
+// WaitHealthy polls checkFn until it reports healthy, the timeout elapses or
+// ctx is cancelled. The delay between checks starts at interval and doubles
+// after every unhealthy result, capped at 30s. A zero timeout waits until ctx
+// is done.
+func WaitHealthy(ctx context.Context, checkFn CheckFunc, interval, timeout time.Duration) error {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	delay := interval
+	for {
+		healthy, err := checkFn()
+		if err != nil {
+			return err
+		}
+		if healthy {
+			return nil
+		}
+
+		wait := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			wait.Stop()
+			return ctx.Err()
+		case <-deadline:
+			wait.Stop()
+			return ErrTimeout
+		case <-wait.C:
+		}
+
+		delay *= 2
+		if delay > maxInterval {
+			delay = maxInterval
+		}
+		if delay < interval {
+			delay = interval
+		}
+	}
+}
+
+// TCPCheck returns a check that reports healthy once a TCP connection to
+// address (host:port) succeeds
+func TCPCheck(address string) CheckFunc {
+	return func() (bool, error) {
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err != nil {
+			return false, nil
+		}
+		conn.Close()
+		return true, nil
+	}
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// countingCheck reports healthy from the given call on, recording when each
+// call was made
+type countingCheck struct {
+	healthyFrom int
+	calls       []time.Time
+}
+
+func (c *countingCheck) check() (bool, error) {
+	c.calls = append(c.calls, time.Now())
+	return len(c.calls) >= c.healthyFrom, nil
+}
+
+func TestWaitHealthySuccess(t *testing.T) {
+	c := &countingCheck{healthyFrom: 3}
+	if err := WaitHealthy(context.Background(), c.check, 10*time.Millisecond, time.Second); err != nil {
+		t.Fatalf("WaitHealthy: %v", err)
+	}
+	if len(c.calls) != 3 {
+		t.Errorf("checked %d times, want 3", len(c.calls))
+	}
+}
+
+func TestWaitHealthyBacksOff(t *testing.T) {
+	c := &countingCheck{healthyFrom: 4}
+	interval := 20 * time.Millisecond
+	if err := WaitHealthy(context.Background(), c.check, interval, 5*time.Second); err != nil {
+		t.Fatalf("WaitHealthy: %v", err)
+	}
+	// The delays are 20ms, 40ms and 80ms
+	for i, want := range []time.Duration{interval, 2 * interval, 4 * interval} {
+		if got := c.calls[i+1].Sub(c.calls[i]); got < want {
+			t.Errorf("delay %d = %s, want at least %s", i, got, want)
+		}
+	}
+}
+
+func TestWaitHealthyTimeout(t *testing.T) {
+	c := &countingCheck{healthyFrom: 1 << 30}
+	start := time.Now()
+	err := WaitHealthy(context.Background(), c.check, 10*time.Millisecond, 100*time.Millisecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("WaitHealthy error = %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("timed out after %s, want about 100ms", elapsed)
+	}
+}
+
+func TestWaitHealthyCancel(t *testing.T) {
+	c := &countingCheck{healthyFrom: 1 << 30}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	err := WaitHealthy(ctx, c.check, 10*time.Millisecond, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitHealthy error = %v, want context.Canceled", err)
+	}
+}
+
+func TestWaitHealthyCheckError(t *testing.T) {
+	checkErr := errors.New("container exited")
+	calls := 0
+	err := WaitHealthy(context.Background(), func() (bool, error) {
+		calls++
+		return false, checkErr
+	}, 10*time.Millisecond, time.Second)
+	if !errors.Is(err, checkErr) {
+		t.Errorf("WaitHealthy error = %v, want the check's error", err)
+	}
+	if calls != 1 {
+		t.Errorf("checked %d times after an error, want 1", calls)
+	}
+}
+
+func TestTCPCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+
+	if healthy, err := TCPCheck(address)(); err != nil || !healthy {
+		t.Errorf("TCPCheck on a listening port = %v, %v", healthy, err)
+	}
+	listener.Close()
+	if healthy, err := TCPCheck(address)(); err != nil || healthy {
+		t.Errorf("TCPCheck on a closed port = %v, %v", healthy, err)
+	}
+}
@@ -0,0 +1,59 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
+	"github.com/neomody77/fake-compose/pkg/template"
+)
+
+func TestHookErrorCarriesServiceAndPhase(t *testing.T) {
+	failing := shellHook("migrate", "exit 3")
+	retried := shellHook("flaky", "exit 1")
+	retried.Retries = 1
+	retried.RetryWhen = "{{.Bogus"
+	badTemplate := shellHook("broken", "echo {{.Service.Name")
+	service := template.NewData("shop", "pre-start", "web", &compose.Service{})
+
+	tests := []struct {
+		name  string
+		run   func() error
+		hook  string
+		svc   string
+		phase string
+	}{
+		{"failed hook", func() error {
+			return newTestExecutor().ExecuteHooks(context.Background(), []compose.Hook{failing}, 0, service)
+		}, "migrate", "web", "pre-start"},
+		{"bad retry_when", func() error {
+			return newTestExecutor().ExecuteHooks(context.Background(), []compose.Hook{retried}, 0, service)
+		}, "flaky", "web", "pre-start"},
+		{"bad template", func() error {
+			return newTestExecutor().ExecuteHooks(context.Background(), []compose.Hook{badTemplate}, 0, service)
+		}, "broken", "web", "pre-start"},
+		{"single hook", func() error {
+			return newTestExecutor().ExecuteHook(context.Background(), &failing, template.NewData("shop", "post-stop", "db", nil))
+		}, "migrate", "db", "post-stop"},
+		{"deploy hook", func() error {
+			return newTestExecutor().ExecuteHooks(context.Background(), []compose.Hook{failing}, 0, template.NewData("shop", "pre-deploy", "", nil))
+		}, "migrate", "", "pre-deploy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			var hookErr *cerrors.HookError
+			if !errors.As(err, &hookErr) {
+				t.Fatalf("error = %v, want a HookError", err)
+			}
+			if hookErr.Hook != tt.hook || hookErr.Service != tt.svc || hookErr.Phase != tt.phase {
+				t.Errorf("HookError = %+v, want hook %s, service %q and phase %s", hookErr, tt.hook, tt.svc, tt.phase)
+			}
+			if hookErr.Cause == nil {
+				t.Error("HookError has no cause")
+			}
+		})
+	}
+}
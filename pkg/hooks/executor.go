@@ -3,15 +3,21 @@ package hooks
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
+	"sort"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/neomody77/fake-compose/pkg/compose"
+	cerrors "github.com/neomody77/fake-compose/pkg/errors"
+	"github.com/neomody77/fake-compose/pkg/template"
+	"golang.org/x/sync/errgroup"
 )
 
 type Executor struct {
@@ -28,68 +34,219 @@ func NewExecutor(logger *logrus.Logger) *Executor {
 	}
 }
 
-func (e *Executor) ExecuteHooks(ctx context.Context, hooks []compose.Hook) error {
-	for _, hook := range hooks {
-		if err := e.ExecuteHook(ctx, &hook); err != nil {
-			if hook.Retries > 0 {
-				for i := 0; i < hook.Retries; i++ {
-					e.logger.Warnf("Hook %s failed, retrying (%d/%d): %v", hook.Name, i+1, hook.Retries, err)
-					time.Sleep(time.Second * time.Duration(i+1))
-					if err = e.ExecuteHook(ctx, &hook); err == nil {
-						break
-					}
+// ExecuteHooks runs hooks in order. Consecutive hooks marked parallel are
+// run concurrently as a group; the first failure in a group cancels the rest.
+// After a failure only hooks whose when condition allows it are run, and the
+// first error is returned once the list is exhausted.
+//
+// A listTimeout above zero bounds the whole list, retries and their delays
+// included, while each hook's own timeout bounds a single attempt. Once the
+// list times out no further hooks are run.
+//
+// Templates in each hook's command, script and http body are rendered
+// against data before the hook runs.
+func (e *Executor) ExecuteHooks(ctx context.Context, hooks []compose.Hook, listTimeout time.Duration, data template.Data) error {
+	if listTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, listTimeout)
+		defer cancel()
+	}
+
+	return e.executeGroups(ctx, hooks, data, make([]HookResult, len(hooks)), func(err error) error {
+		if listTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			if err == nil {
+				err = ctx.Err()
+			}
+			return fmt.Errorf("hook list timed out after %s: %w", listTimeout, err)
+		}
+		return nil
+	})
+}
+
+// executeGroups renders and runs hooks in order, running consecutive
+// parallel hooks concurrently as a group, and records the outcome of each
+// in the matching entry of results. After each group stop is given the
+// group's error; a non-nil return ends the list with that error.
+// Otherwise the first failure is returned once the list is exhausted.
+func (e *Executor) executeGroups(ctx context.Context, hooks []compose.Hook, data template.Data, results []HookResult, stop func(error) error) error {
+	var firstErr error
+	for i := 0; i < len(hooks); {
+		j := i + 1
+		if hooks[i].Parallel {
+			for j < len(hooks) && hooks[j].Parallel {
+				j++
+			}
+		}
+
+		g, groupCtx := errgroup.WithContext(ctx)
+		for k := i; k < j; k++ {
+			hook, result := &hooks[k], &results[k]
+			result.HookName = hook.Name
+			result.StartTime = time.Now()
+			if !shouldRun(hook, firstErr != nil) {
+				e.logger.Debugf("Skipping hook %s (when: %s)", hook.Name, hook.When)
+				result.EndTime = result.StartTime
+				result.Skipped = true
+				continue
+			}
+			g.Go(func() error {
+				rendered, err := renderHook(hook, data)
+				if err == nil {
+					err = e.executeWithRetries(groupCtx, rendered, result)
 				}
+				if err != nil {
+					err = hookError(hook, data, err)
+				}
+				result.EndTime = time.Now()
+				result.Success = err == nil
+				result.Error = err
+				return err
+			})
+		}
+		err := g.Wait()
+		if stopErr := stop(err); stopErr != nil {
+			return stopErr
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		i = j
+	}
+	return firstErr
+}
+
+// shouldRun evaluates a hook's when condition against whether an earlier
+// hook in the same list has failed. An empty condition means on-success.
+func shouldRun(hook *compose.Hook, failed bool) bool {
+	switch hook.When {
+	case "always":
+		return true
+	case "on-failure":
+		return failed
+	default:
+		return !failed
+	}
+}
+
+// executeWithRetries runs a hook, retrying a failure up to hook.Retries
+// times while its retry_when condition, if any, holds for the failed
+// attempt. result describes the last attempt.
+func (e *Executor) executeWithRetries(ctx context.Context, hook *compose.Hook, result *HookResult) error {
+	err := e.executeHook(ctx, hook, result)
+	for i := 0; err != nil && i < hook.Retries; i++ {
+		if hook.RetryWhen != "" {
+			retry, evalErr := evaluateRetryCondition(hook.RetryWhen, *result)
+			if evalErr != nil {
+				return evalErr
 			}
-			if err != nil {
-				return fmt.Errorf("hook %s failed: %w", hook.Name, err)
+			if !retry {
+				e.logger.Debugf("Not retrying hook %s: retry_when %s is not true", hook.Name, hook.RetryWhen)
+				break
 			}
 		}
+		e.logger.Warnf("Hook %s failed, retrying (%d/%d): %v", hook.Name, i+1, hook.Retries, err)
+		select {
+		case <-time.After(time.Second * time.Duration(i+1)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		err = e.executeHook(ctx, hook, result)
+	}
+	return err
+}
+
+// hookError reports a failed hook with the service and phase it ran for
+func hookError(hook *compose.Hook, data template.Data, cause error) error {
+	return &cerrors.HookError{Service: data.Service.Name, Hook: hook.Name, Phase: data.Phase, Cause: cause}
+}
+
+func (e *Executor) ExecuteHook(ctx context.Context, hook *compose.Hook, data template.Data) error {
+	rendered, err := renderHook(hook, data)
+	if err == nil {
+		err = e.executeHook(ctx, rendered, &HookResult{HookName: hook.Name})
+	}
+	if err != nil {
+		return hookError(hook, data, err)
 	}
 	return nil
 }
 
-func (e *Executor) ExecuteHook(ctx context.Context, hook *compose.Hook) error {
+// executeHook runs a hook once, recording its outcome in result
+func (e *Executor) executeHook(ctx context.Context, hook *compose.Hook, result *HookResult) error {
+	result.Output = ""
+	result.ExitCode = 0
+	err := e.runHook(ctx, hook, result)
+	result.Success = err == nil
+	result.Error = err
+	return err
+}
+
+func (e *Executor) runHook(ctx context.Context, hook *compose.Hook, result *HookResult) error {
 	e.logger.Infof("Executing hook: %s (type: %s)", hook.Name, hook.Type)
 
+	listDeadline, listBounded := ctx.Deadline()
+	if listBounded {
+		e.logger.Debugf("Hook %s timeout: %s, %s left in hook list", hook.Name, timeoutString(hook.Timeout), time.Until(listDeadline).Round(time.Millisecond))
+	} else {
+		e.logger.Debugf("Hook %s timeout: %s", hook.Name, timeoutString(hook.Timeout))
+	}
+
+	hookCtx := ctx
 	if hook.Timeout > 0 {
 		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		hookCtx, cancel = context.WithTimeout(ctx, hook.Timeout)
 		defer cancel()
 	}
 
+	var err error
 	switch hook.Type {
 	case "command":
-		return e.executeCommandHook(ctx, hook)
+		err = e.executeCommandHook(hookCtx, hook, result)
 	case "script":
-		return e.executeScriptHook(ctx, hook)
+		err = e.executeScriptHook(hookCtx, hook, result)
 	case "http":
-		return e.executeHTTPHook(ctx, hook)
+		err = e.executeHTTPHook(hookCtx, hook, result)
 	case "exec":
-		return e.executeExecHook(ctx, hook)
+		err = e.executeExecHook(hookCtx, hook)
+	case "plugin":
+		err = e.executePluginHook(hookCtx, hook, result)
 	default:
 		return fmt.Errorf("unknown hook type: %s", hook.Type)
 	}
+	// A hook killed by its own timeout reports it; one killed by the list
+	// timeout is reported by ExecuteHooks
+	if err != nil && ctx.Err() == nil && errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("hook timed out after %s: %w", hook.Timeout, err)
+	}
+	return err
+}
+
+// timeoutString describes a hook timeout, where zero means none
+func timeoutString(timeout time.Duration) string {
+	if timeout <= 0 {
+		return "none"
+	}
+	return timeout.String()
 }
 
-func (e *Executor) executeCommandHook(ctx context.Context, hook *compose.Hook) error {
+func (e *Executor) executeCommandHook(ctx context.Context, hook *compose.Hook, result *HookResult) error {
 	if len(hook.Command) == 0 {
 		return fmt.Errorf("command hook requires command")
 	}
 
 	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Env = hookEnv(hook)
 
 	e.logger.Debugf("Executing command: %v", hook.Command)
 
-	if err := cmd.Run(); err != nil {
+	if err := runCommand(cmd, result); err != nil {
 		return fmt.Errorf("command execution failed: %w", err)
 	}
 
 	return nil
 }
 
-func (e *Executor) executeScriptHook(ctx context.Context, hook *compose.Hook) error {
+func (e *Executor) executeScriptHook(ctx context.Context, hook *compose.Hook, result *HookResult) error {
 	if hook.Script == "" {
 		return fmt.Errorf("script hook requires script content")
 	}
@@ -110,19 +267,73 @@ func (e *Executor) executeScriptHook(ctx context.Context, hook *compose.Hook) er
 	}
 
 	cmd := exec.CommandContext(ctx, tmpfile.Name())
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Env = hookEnv(hook)
 
 	e.logger.Debugf("Executing script for hook: %s", hook.Name)
 
-	if err := cmd.Run(); err != nil {
+	if err := runCommand(cmd, result); err != nil {
 		return fmt.Errorf("script execution failed: %w", err)
 	}
 
 	return nil
 }
 
-func (e *Executor) executeHTTPHook(ctx context.Context, hook *compose.Hook) error {
+// runCommand runs a command hook's process with its output going to the
+// terminal as well as into result, along with its exit code
+func runCommand(cmd *exec.Cmd, result *HookResult) error {
+	var output bytes.Buffer
+	cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+	// Children of a cancelled hook, such as a script's sleep, keep its
+	// output open; stop waiting on them shortly after the hook is killed
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	result.Output = output.String()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	}
+	return err
+}
+
+// hookEnv returns the environment of a command or script hook: the
+// process environment plus the hook's env, which takes precedence. Nil
+// means the process environment unchanged.
+func hookEnv(hook *compose.Hook) []string {
+	if len(hook.Env) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(hook.Env))
+	for key := range hook.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := os.Environ()
+	for _, key := range keys {
+		env = append(env, key+"="+hook.Env[key])
+	}
+	return env
+}
+
+// expandHookEnv substitutes ${VAR} and $VAR references to the hook's env in
+// s. References to other variables are kept as they are. Since compose
+// files are interpolated when parsed, the file has to write them as
+// $${VAR}.
+func expandHookEnv(s string, env map[string]string) string {
+	if len(env) == 0 {
+		return s
+	}
+	return os.Expand(s, func(key string) string {
+		if value, ok := env[key]; ok {
+			return value
+		}
+		return "${" + key + "}"
+	})
+}
+
+func (e *Executor) executeHTTPHook(ctx context.Context, hook *compose.Hook, result *HookResult) error {
 	if hook.HTTP == nil || hook.HTTP.URL == "" {
 		return fmt.Errorf("HTTP hook requires URL")
 	}
@@ -132,9 +343,9 @@ func (e *Executor) executeHTTPHook(ctx context.Context, hook *compose.Hook) erro
 		method = "GET"
 	}
 
-	var body *bytes.Buffer
+	var body io.Reader
 	if hook.HTTP.Body != "" {
-		body = bytes.NewBufferString(hook.HTTP.Body)
+		body = bytes.NewBufferString(expandHookEnv(hook.HTTP.Body, hook.Env))
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, hook.HTTP.URL, body)
@@ -143,7 +354,7 @@ func (e *Executor) executeHTTPHook(ctx context.Context, hook *compose.Hook) erro
 	}
 
 	for key, value := range hook.HTTP.Headers {
-		req.Header.Set(key, value)
+		req.Header.Set(key, expandHookEnv(value, hook.Env))
 	}
 
 	e.logger.Debugf("Making HTTP request: %s %s", method, hook.HTTP.URL)
@@ -156,6 +367,7 @@ func (e *Executor) executeHTTPHook(ctx context.Context, hook *compose.Hook) erro
 
 	if resp.StatusCode >= 400 {
 		body, _ := ioutil.ReadAll(resp.Body)
+		result.Output = string(body)
 		return fmt.Errorf("HTTP request returned status %d: %s", resp.StatusCode, string(body))
 	}
 
@@ -175,32 +387,21 @@ func (e *Executor) executeExecHook(ctx context.Context, hook *compose.Hook) erro
 type HookResult struct {
 	HookName  string
 	Success   bool
+	Skipped   bool
 	Error     error
 	StartTime time.Time
 	EndTime   time.Time
-	Output    string
+	// Output is what a command or script hook printed, or the body of an
+	// http hook's error response
+	Output string
+	// ExitCode is the exit status of a command or script hook
+	ExitCode int
 }
 
-func (e *Executor) ExecuteHooksWithResults(ctx context.Context, hooks []compose.Hook) []HookResult {
-	results := make([]HookResult, 0, len(hooks))
-
-	for _, hook := range hooks {
-		result := HookResult{
-			HookName:  hook.Name,
-			StartTime: time.Now(),
-		}
-
-		err := e.ExecuteHook(ctx, &hook)
-		result.EndTime = time.Now()
-		result.Success = err == nil
-		result.Error = err
-
-		results = append(results, result)
-
-		if err != nil && hook.Retries == 0 {
-			break
-		}
-	}
-
+// ExecuteHooksWithResults runs hooks as ExecuteHooks does, without a list
+// timeout, and returns the outcome of each hook in order
+func (e *Executor) ExecuteHooksWithResults(ctx context.Context, hooks []compose.Hook, data template.Data) []HookResult {
+	results := make([]HookResult, len(hooks))
+	e.executeGroups(ctx, hooks, data, results, func(error) error { return nil })
 	return results
-}
\ No newline at end of file
+}
@@ -0,0 +1,121 @@
+package hooks
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/template"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestExecutor() *Executor {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewExecutor(logger)
+}
+
+// shellHook is a command hook running script with sh
+func shellHook(name, script string) compose.Hook {
+	return compose.Hook{Name: name, Type: "command", Command: []string{"sh", "-c", script}}
+}
+
+// recordHook appends its name to the file at path
+func recordHook(name, path string) compose.Hook {
+	return shellHook(name, "echo "+name+" >> "+path)
+}
+
+// recorded returns the names the hooks wrote to path, in order
+func recorded(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Fields(string(data))
+}
+
+func runHooks(hooks []compose.Hook) error {
+	return newTestExecutor().ExecuteHooks(context.Background(), hooks, 0, template.Data{})
+}
+
+func TestExecuteHooksRunsParallelHooksConcurrently(t *testing.T) {
+	var hooks []compose.Hook
+	for _, name := range []string{"a", "b", "c", "d"} {
+		hook := shellHook(name, "sleep 0.5")
+		hook.Parallel = true
+		hooks = append(hooks, hook)
+	}
+
+	start := time.Now()
+	if err := runHooks(hooks); err != nil {
+		t.Fatalf("ExecuteHooks: %v", err)
+	}
+	// Run one after the other the four hooks would take two seconds
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("parallel hooks took %s, want them to overlap", elapsed)
+	}
+}
+
+func TestExecuteHooksKeepsSequentialOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order")
+	hooks := []compose.Hook{
+		// The first hook is slowest, so running out of order would show
+		shellHook("first", "sleep 0.3; echo first >> "+path),
+		shellHook("second", "sleep 0.1; echo second >> "+path),
+		recordHook("third", path),
+	}
+
+	if err := runHooks(hooks); err != nil {
+		t.Fatalf("ExecuteHooks: %v", err)
+	}
+	if got := strings.Join(recorded(t, path), " "); got != "first second third" {
+		t.Errorf("hooks ran as %q, want first second third", got)
+	}
+}
+
+func TestExecuteHooksWaitsForParallelGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order")
+	slow := shellHook("slow", "sleep 0.3; echo slow >> "+path)
+	slow.Parallel = true
+	fast := recordHook("fast", path)
+	fast.Parallel = true
+	hooks := []compose.Hook{recordHook("before", path), slow, fast, recordHook("after", path)}
+
+	if err := runHooks(hooks); err != nil {
+		t.Fatalf("ExecuteHooks: %v", err)
+	}
+	if got := strings.Join(recorded(t, path), " "); got != "before fast slow after" {
+		t.Errorf("hooks ran as %q, want before fast slow after", got)
+	}
+}
+
+func TestExecuteHooksParallelFailureCancelsGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order")
+	failing := shellHook("failing", "exit 1")
+	failing.Parallel = true
+	slow := shellHook("slow", "sleep 5; echo slow >> "+path)
+	slow.Parallel = true
+
+	start := time.Now()
+	err := runHooks([]compose.Hook{failing, slow, recordHook("after", path)})
+	if err == nil {
+		t.Fatal("ExecuteHooks succeeded, want the failing hook's error")
+	}
+	if !strings.Contains(err.Error(), "failing") {
+		t.Errorf("error %q does not name the failing hook", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("the slow hook was not cancelled, ExecuteHooks took %s", elapsed)
+	}
+	if got := recorded(t, path); len(got) != 0 {
+		t.Errorf("hooks %v ran after the failure", got)
+	}
+}
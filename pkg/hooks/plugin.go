@@ -0,0 +1,231 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// Plugin is an installed hook plugin binary
+type Plugin struct {
+	Name string
+	Path string
+	// Err is why the plugin cannot be run, nil when it can
+	Err error
+}
+
+// pluginResponse is what a plugin prints on stdout for `<name> execute`
+type pluginResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// PluginDir returns the directory plugins are installed in,
+// ~/.fake-compose/plugins
+func PluginDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate plugin directory: %w", err)
+	}
+	return filepath.Join(home, ".fake-compose", "plugins"), nil
+}
+
+// LookupPlugin returns the path of the named plugin, looking in the plugin
+// directory first and then in PATH
+func LookupPlugin(name string) (string, error) {
+	dir, err := PluginDir()
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, name)
+	if _, err := os.Stat(file); err != nil {
+		if file, err = exec.LookPath(name); err != nil {
+			return "", fmt.Errorf("plugin %s not found in %s or PATH", name, dir)
+		}
+	}
+	if err := checkPluginPermissions(file); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// checkPluginPermissions makes sure a plugin is a regular executable file
+// that only its owner can modify, as compose files run it with the user's
+// privileges
+func checkPluginPermissions(file string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	mode := info.Mode()
+	switch {
+	case !mode.IsRegular():
+		return fmt.Errorf("plugin %s is not a regular file", file)
+	case mode.Perm()&0111 == 0:
+		return fmt.Errorf("plugin %s is not executable", file)
+	case mode.Perm()&0022 != 0:
+		return fmt.Errorf("plugin %s is writable by group or others (mode %s)", file, mode.Perm())
+	}
+	return nil
+}
+
+// ListPlugins returns the plugins in the plugin directory, sorted by name
+func ListPlugins() ([]Plugin, error) {
+	dir, err := PluginDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory: %w", err)
+	}
+
+	plugins := make([]Plugin, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file := filepath.Join(dir, entry.Name())
+		plugins = append(plugins, Plugin{Name: entry.Name(), Path: file, Err: checkPluginPermissions(file)})
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, nil
+}
+
+// InstallPlugin downloads a plugin binary from an http or https URL into the
+// plugin directory, named after the URL's last path element, and makes it
+// executable by its owner only. An existing plugin of that name is
+// replaced.
+func InstallPlugin(ctx context.Context, rawURL string) (Plugin, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Plugin{}, fmt.Errorf("invalid plugin URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Plugin{}, fmt.Errorf("invalid plugin URL %s: must be http or https", rawURL)
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return Plugin{}, fmt.Errorf("invalid plugin URL %s: no file name", rawURL)
+	}
+
+	dir, err := PluginDir()
+	if err != nil {
+		return Plugin{}, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Plugin{}, fmt.Errorf("failed to create plugin directory: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return Plugin{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Plugin{}, fmt.Errorf("failed to download plugin: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Plugin{}, fmt.Errorf("failed to download plugin: %s returned status %d", rawURL, resp.StatusCode)
+	}
+
+	// Download next to the target so the rename replacing it is atomic
+	tmp, err := os.CreateTemp(dir, "."+name+"-*")
+	if err != nil {
+		return Plugin{}, fmt.Errorf("failed to install plugin: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return Plugin{}, fmt.Errorf("failed to download plugin: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return Plugin{}, fmt.Errorf("failed to install plugin: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0700); err != nil {
+		return Plugin{}, fmt.Errorf("failed to install plugin: %w", err)
+	}
+	file := filepath.Join(dir, name)
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return Plugin{}, fmt.Errorf("failed to install plugin: %w", err)
+	}
+	return Plugin{Name: name, Path: file}, nil
+}
+
+// executePluginHook runs `<name> execute` with the hook's config as JSON on
+// stdin. The plugin answers on stdout with {"success": true, "message": ...}
+// or {"success": false, "error": ...}; what it writes to stderr is shown
+// as it is.
+func (e *Executor) executePluginHook(ctx context.Context, hook *compose.Hook, result *HookResult) error {
+	if hook.Plugin == nil || hook.Plugin.Name == "" {
+		return fmt.Errorf("plugin hook requires plugin name")
+	}
+
+	file, err := LookupPlugin(hook.Plugin.Name)
+	if err != nil {
+		return err
+	}
+	config := hook.Plugin.Config
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	input, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("invalid config for plugin %s: %w", hook.Plugin.Name, err)
+	}
+
+	cmd := exec.CommandContext(ctx, file, "execute")
+	cmd.Env = hookEnv(hook)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	e.logger.Debugf("Executing plugin %s for hook %s", file, hook.Name)
+
+	runErr := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	}
+
+	var resp pluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		result.Output = stdout.String()
+		if runErr != nil {
+			return fmt.Errorf("plugin %s failed: %w", hook.Plugin.Name, runErr)
+		}
+		return fmt.Errorf("plugin %s returned an invalid response: %w", hook.Plugin.Name, err)
+	}
+	if !resp.Success {
+		result.Output = resp.Error
+		if resp.Error == "" {
+			return fmt.Errorf("plugin %s failed", hook.Plugin.Name)
+		}
+		return fmt.Errorf("plugin %s failed: %s", hook.Plugin.Name, resp.Error)
+	}
+	result.Output = resp.Message
+	if runErr != nil {
+		return fmt.Errorf("plugin %s failed: %w", hook.Plugin.Name, runErr)
+	}
+	if resp.Message != "" {
+		e.logger.Infof("Plugin %s: %s", hook.Plugin.Name, resp.Message)
+	}
+	return nil
+}
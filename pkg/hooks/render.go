@@ -0,0 +1,27 @@
+package hooks
+
+import (
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/template"
+)
+
+// renderHook returns a copy of hook with the templates in its command,
+// script and http body rendered against data
+func renderHook(hook *compose.Hook, data template.Data) (*compose.Hook, error) {
+	rendered := *hook
+	var err error
+	if rendered.Command, err = template.RenderAll(hook.Command, data); err != nil {
+		return nil, err
+	}
+	if rendered.Script, err = template.RenderTemplate(hook.Script, data); err != nil {
+		return nil, err
+	}
+	if hook.HTTP != nil {
+		http := *hook.HTTP
+		if http.Body, err = template.RenderTemplate(hook.HTTP.Body, data); err != nil {
+			return nil, err
+		}
+		rendered.HTTP = &http
+	}
+	return &rendered, nil
+}
@@ -0,0 +1,75 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/template"
+)
+
+func TestExecuteHookRendersCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	hook := shellHook("announce", "echo {{.Service.Name}} {{.Phase}} {{.Project}} > "+path)
+	data := template.NewData("shop", "post-start", "web", &compose.Service{Image: "nginx"})
+
+	if err := newTestExecutor().ExecuteHook(context.Background(), &hook, data); err != nil {
+		t.Fatalf("ExecuteHook: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(got)) != "web post-start shop" {
+		t.Errorf("hook wrote %q, want %q", got, "web post-start shop")
+	}
+	if hook.Command[2] != "echo {{.Service.Name}} {{.Phase}} {{.Project}} > "+path {
+		t.Errorf("hook command changed to %q, want the template kept", hook.Command[2])
+	}
+}
+
+func TestExecuteHookBadTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order")
+	hook := recordHook("broken", path)
+	hook.Command[2] += " {{.Service.Name"
+
+	err := newTestExecutor().ExecuteHook(context.Background(), &hook, template.NewData("shop", "post-start", "web", nil))
+	if err == nil || !strings.Contains(err.Error(), "invalid template") {
+		t.Fatalf("ExecuteHook = %v, want an invalid template error", err)
+	}
+	if got := recorded(t, path); len(got) != 0 {
+		t.Errorf("hook ran as %v despite the bad template", got)
+	}
+}
+
+func TestRenderHook(t *testing.T) {
+	hook := &compose.Hook{
+		Name:   "notify",
+		Type:   "http",
+		Script: "echo {{.Service.Image}}",
+		HTTP:   &compose.HTTPHook{URL: "http://hooks.example.com", Body: `{"service":"{{.Service.Name}}"}`},
+	}
+	data := template.NewData("shop", "post-start", "web", &compose.Service{Image: "nginx"})
+
+	rendered, err := renderHook(hook, data)
+	if err != nil {
+		t.Fatalf("renderHook: %v", err)
+	}
+	if rendered.Script != "echo nginx" {
+		t.Errorf("script = %q, want %q", rendered.Script, "echo nginx")
+	}
+	if rendered.HTTP.Body != `{"service":"web"}` {
+		t.Errorf("body = %q, want the service name", rendered.HTTP.Body)
+	}
+	if hook.HTTP.Body != `{"service":"{{.Service.Name}}"}` {
+		t.Errorf("original body changed to %q", hook.HTTP.Body)
+	}
+
+	hook.HTTP.Body = "{{.ExitCode"
+	if _, err := renderHook(hook, data); err == nil || !strings.Contains(err.Error(), "invalid template") {
+		t.Errorf("renderHook with a bad body = %v, want an invalid template error", err)
+	}
+}
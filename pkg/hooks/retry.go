@@ -0,0 +1,45 @@
+package hooks
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// retryFuncs are the functions available to retry_when expressions, in
+// addition to text/template's builtins such as eq and not
+var retryFuncs = template.FuncMap{
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"hasSuffix": strings.HasSuffix,
+	"match": func(pattern, s string) (bool, error) {
+		return regexp.MatchString(pattern, s)
+	},
+}
+
+// ParseRetryCondition checks that a retry_when expression is a valid
+// template
+func ParseRetryCondition(when string) (*template.Template, error) {
+	tmpl, err := template.New("retry_when").Funcs(retryFuncs).Option("missingkey=error").Parse(when)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry_when expression: %w", err)
+	}
+	return tmpl, nil
+}
+
+// evaluateRetryCondition executes a retry_when template against the result
+// of a failed attempt, such as `{{eq .ExitCode 1}}` or
+// `{{contains .Output "RETRY_NEEDED"}}`. The hook is retried only when the
+// template renders "true".
+func evaluateRetryCondition(when string, result HookResult) (bool, error) {
+	tmpl, err := ParseRetryCondition(when)
+	if err != nil {
+		return false, err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, result); err != nil {
+		return false, fmt.Errorf("failed to evaluate retry_when expression: %w", err)
+	}
+	return strings.TrimSpace(out.String()) == "true", nil
+}
@@ -0,0 +1,94 @@
+package hooks
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/template"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// sleepHook is a command hook that sleeps far longer than any test waits
+func sleepHook(name string, timeout time.Duration, retries int) compose.Hook {
+	hook := shellHook(name, "exec sleep 30")
+	hook.Timeout = timeout
+	hook.Retries = retries
+	return hook
+}
+
+func TestExecuteHooksListTimeout(t *testing.T) {
+	// Each attempt times out on its own, and the retry delays count
+	// against the list timeout
+	hooks := []compose.Hook{sleepHook("sleepy", 200*time.Millisecond, 10)}
+
+	start := time.Now()
+	err := newTestExecutor().ExecuteHooks(context.Background(), hooks, time.Second, template.Data{})
+	elapsed := time.Since(start)
+	if err == nil || !strings.Contains(err.Error(), "hook list timed out after 1s") {
+		t.Fatalf("ExecuteHooks = %v, want a list timeout", err)
+	}
+	if elapsed < time.Second || elapsed > 3*time.Second {
+		t.Errorf("ExecuteHooks returned after %s, want soon after the 1s list timeout", elapsed)
+	}
+}
+
+func TestExecuteHooksListTimeoutStopsList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order")
+	hooks := []compose.Hook{sleepHook("sleepy", 0, 0), recordHook("after", path)}
+
+	err := newTestExecutor().ExecuteHooks(context.Background(), hooks, 300*time.Millisecond, template.Data{})
+	if err == nil || !strings.Contains(err.Error(), "hook list timed out") {
+		t.Fatalf("ExecuteHooks = %v, want a list timeout", err)
+	}
+	if got := recorded(t, path); len(got) != 0 {
+		t.Errorf("hooks %v ran after the list timed out", got)
+	}
+}
+
+func TestExecuteHooksHookTimeout(t *testing.T) {
+	hooks := []compose.Hook{sleepHook("sleepy", 200*time.Millisecond, 0)}
+
+	err := newTestExecutor().ExecuteHooks(context.Background(), hooks, 10*time.Second, template.Data{})
+	if err == nil || !strings.Contains(err.Error(), "hook timed out after 200ms") {
+		t.Fatalf("ExecuteHooks = %v, want the hook's own timeout", err)
+	}
+	if strings.Contains(err.Error(), "list timed out") {
+		t.Errorf("hook timeout %q reported as a list timeout", err)
+	}
+}
+
+func TestExecuteHooksLogsRemainingTime(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	e := NewExecutor(logger)
+	hooks := []compose.Hook{shellHook("quick", "true")}
+	hooks[0].Timeout = 5 * time.Second
+
+	if err := e.ExecuteHooks(context.Background(), hooks, time.Minute, template.Data{}); err != nil {
+		t.Fatalf("ExecuteHooks: %v", err)
+	}
+	if err := e.ExecuteHooks(context.Background(), hooks, 0, template.Data{}); err != nil {
+		t.Fatalf("ExecuteHooks: %v", err)
+	}
+
+	var bounded, unbounded bool
+	for _, entry := range hook.AllEntries() {
+		if strings.HasPrefix(entry.Message, "Hook quick timeout: 5s, ") && strings.HasSuffix(entry.Message, " left in hook list") {
+			bounded = true
+		}
+		if entry.Message == "Hook quick timeout: 5s" {
+			unbounded = true
+		}
+	}
+	if !bounded {
+		t.Error("time left in the hook list was not logged")
+	}
+	if !unbounded {
+		t.Error("hook timeout without a list timeout was not logged")
+	}
+}
@@ -0,0 +1,131 @@
+package hooks
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/template"
+)
+
+func TestExecuteHooksWithResultsWhenAfterFailure(t *testing.T) {
+	tests := []struct {
+		when    string
+		wantRun bool
+	}{
+		{"", false},
+		{"on-success", false},
+		{"on-failure", true},
+		{"always", true},
+	}
+	for _, tt := range tests {
+		t.Run("when="+tt.when, func(t *testing.T) {
+			hook := shellHook("next", "true")
+			hook.When = tt.when
+			results := newTestExecutor().ExecuteHooksWithResults(context.Background(), []compose.Hook{shellHook("failing", "exit 1"), hook}, template.Data{})
+
+			if len(results) != 2 {
+				t.Fatalf("got %d results, want 2", len(results))
+			}
+			if results[0].Success || results[0].Error == nil || results[0].ExitCode != 1 {
+				t.Errorf("failing hook result = %+v", results[0])
+			}
+			next := results[1]
+			if next.Skipped == tt.wantRun {
+				t.Errorf("skipped = %v, want %v", next.Skipped, !tt.wantRun)
+			}
+			if next.Success != tt.wantRun {
+				t.Errorf("success = %v, want %v", next.Success, tt.wantRun)
+			}
+		})
+	}
+}
+
+func TestExecuteHooksWithResultsWhenWithoutFailure(t *testing.T) {
+	tests := []struct {
+		when    string
+		wantRun bool
+	}{
+		{"", true},
+		{"on-success", true},
+		{"on-failure", false},
+		{"always", true},
+	}
+	for _, tt := range tests {
+		t.Run("when="+tt.when, func(t *testing.T) {
+			hook := shellHook("next", "true")
+			hook.When = tt.when
+			results := newTestExecutor().ExecuteHooksWithResults(context.Background(), []compose.Hook{shellHook("passing", "true"), hook}, template.Data{})
+
+			if results[1].Skipped == tt.wantRun {
+				t.Errorf("skipped = %v, want %v", results[1].Skipped, !tt.wantRun)
+			}
+		})
+	}
+}
+
+func TestExecuteHooksWhenAfterFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order")
+	cleanup := recordHook("cleanup", path)
+	cleanup.When = "on-failure"
+	report := recordHook("report", path)
+	report.When = "always"
+	hooks := []compose.Hook{
+		shellHook("failing", "exit 1"),
+		recordHook("deploy", path),
+		cleanup,
+		report,
+	}
+
+	err := runHooks(hooks)
+	if err == nil || !strings.Contains(err.Error(), "failing") {
+		t.Errorf("ExecuteHooks error = %v, want the first failure", err)
+	}
+	if got := strings.Join(recorded(t, path), " "); got != "cleanup report" {
+		t.Errorf("hooks ran as %q, want cleanup report", got)
+	}
+}
+
+func TestExecuteHooksWithResultsRendersTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order")
+	hooks := []compose.Hook{recordHook("first", path), shellHook("greet", "echo {{.Service.Name}}-{{.Phase}} >> "+path)}
+
+	results := newTestExecutor().ExecuteHooksWithResults(context.Background(), hooks, template.NewData("shop", "post-start", "web", nil))
+	for _, result := range results {
+		if !result.Success {
+			t.Errorf("hook %s failed: %v", result.HookName, result.Error)
+		}
+	}
+	if got := strings.Join(recorded(t, path), " "); got != "first web-post-start" {
+		t.Errorf("hooks wrote %q, want first web-post-start", got)
+	}
+
+	bad := []compose.Hook{shellHook("broken", "echo {{.Service.Name")}
+	results = newTestExecutor().ExecuteHooksWithResults(context.Background(), bad, template.NewData("shop", "post-start", "web", nil))
+	if results[0].Success || results[0].Error == nil || !strings.Contains(results[0].Error.Error(), "invalid template") {
+		t.Errorf("bad template result = %+v, want an invalid template error", results[0])
+	}
+}
+
+func TestExecuteHooksWithResultsRunsParallelGroups(t *testing.T) {
+	var hooks []compose.Hook
+	for _, name := range []string{"a", "b", "c", "d"} {
+		hook := shellHook(name, "sleep 0.5")
+		hook.Parallel = true
+		hooks = append(hooks, hook)
+	}
+
+	start := time.Now()
+	results := newTestExecutor().ExecuteHooksWithResults(context.Background(), hooks, template.Data{})
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("parallel hooks took %s, want them to overlap", elapsed)
+	}
+	for i, result := range results {
+		if result.HookName != hooks[i].Name || !result.Success || result.EndTime.Before(result.StartTime) {
+			t.Errorf("result %d = %+v, want hook %s to succeed", i, result, hooks[i].Name)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Package inspect combines a service's compose configuration, lifecycle
+// state and container runtime state into a single view.
+package inspect
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"github.com/neomody77/fake-compose/pkg/container"
+	"github.com/neomody77/fake-compose/pkg/lifecycle"
+)
+
+// Backend provides the runtime information the inspector combines
+type Backend interface {
+	Containers(ctx context.Context, services []string, all bool) ([]container.ContainerInfo, error)
+	Inspect(ctx context.Context, containers []container.ContainerInfo) ([]types.ContainerJSON, error)
+	ServiceState(serviceName string) (*lifecycle.ServiceState, bool)
+}
+
+// ServiceInspect is the full view of a service. The embedded container
+// inspect output is that of the service's first container and is empty when
+// the service has no container; its Mounts and NetworkSettings describe the
+// volume mounts and network endpoints.
+type ServiceInspect struct {
+	types.ContainerJSON
+	Service       string
+	ServiceConfig *compose.Service
+	Lifecycle     *LifecycleState `json:",omitempty"`
+	// Health is the container's health status, or "none" without a
+	// healthcheck
+	Health string `json:",omitempty"`
+}
+
+// LifecycleState is the lifecycle manager's view of a service
+type LifecycleState struct {
+	Phase         lifecycle.Phase
+	Status        string
+	Error         string `json:",omitempty"`
+	StartTime     time.Time
+	StopTime      time.Time
+	InitCompleted bool
+	PostCompleted bool
+}
+
+// Inspector builds ServiceInspect views for the services of a compose file
+type Inspector struct {
+	compose *compose.ComposeFile
+	backend Backend
+}
+
+func New(composeFile *compose.ComposeFile, backend Backend) *Inspector {
+	return &Inspector{compose: composeFile, backend: backend}
+}
+
+// InspectService returns the combined view of a service
+func (i *Inspector) InspectService(ctx context.Context, serviceName string) (*ServiceInspect, error) {
+	service, exists := i.compose.Services[serviceName]
+	if !exists {
+		return nil, fmt.Errorf("no such service: %s", serviceName)
+	}
+
+	result := &ServiceInspect{Service: serviceName, ServiceConfig: service}
+
+	if state, ok := i.backend.ServiceState(serviceName); ok {
+		result.Lifecycle = &LifecycleState{
+			Phase:         state.Phase,
+			Status:        state.Status,
+			StartTime:     state.StartTime,
+			StopTime:      state.StopTime,
+			InitCompleted: state.InitCompleted,
+			PostCompleted: state.PostCompleted,
+		}
+		if state.Error != nil {
+			result.Lifecycle.Error = state.Error.Error()
+		}
+	}
+
+	containers, err := i.backend.Containers(ctx, []string{serviceName}, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return result, nil
+	}
+
+	infos, err := i.backend.Inspect(ctx, containers[:1])
+	if err != nil {
+		return nil, err
+	}
+	result.ContainerJSON = infos[0]
+
+	result.Health = "none"
+	if base := result.ContainerJSONBase; base != nil && base.State != nil && base.State.Health != nil {
+		result.Health = base.State.Health.Status
+	}
+	return result, nil
+}
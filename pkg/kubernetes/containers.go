@@ -0,0 +1,225 @@
+package kubernetes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"gopkg.in/yaml.v3"
+)
+
+// lifecycleComment is written above every lifecycle a post container was
+// converted into
+const lifecycleComment = "Converted from post_containers. Kubernetes has no post containers, so their\n" +
+	"commands run in this container instead of their own images, and wait_for is\n" +
+	"ignored: on_success ones run on postStart, on_failure ones on preStop."
+
+type Volume struct {
+	Name                  string                 `yaml:"name"`
+	PersistentVolumeClaim *PersistentVolumeClaim `yaml:"persistentVolumeClaim,omitempty"`
+	HostPath              *HostPath              `yaml:"hostPath,omitempty"`
+	EmptyDir              *struct{}              `yaml:"emptyDir,omitempty"`
+}
+
+type PersistentVolumeClaim struct {
+	ClaimName string `yaml:"claimName"`
+}
+
+type HostPath struct {
+	Path string `yaml:"path"`
+}
+
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+type Lifecycle struct {
+	PostStart *LifecycleHandler `yaml:"postStart,omitempty"`
+	PreStop   *LifecycleHandler `yaml:"preStop,omitempty"`
+}
+
+type LifecycleHandler struct {
+	Exec ExecAction `yaml:"exec"`
+}
+
+type ExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+// sharedVolumes maps the volumes of a service and its init and post
+// containers onto pod volumes, so containers mounting the same source share
+// one volume: named volumes become claims of the same name, bind mounts
+// hostPath volumes, and anonymous volumes an emptyDir each
+type sharedVolumes struct {
+	volumes []Volume
+	names   map[string]string
+}
+
+func newSharedVolumes() *sharedVolumes {
+	return &sharedVolumes{names: make(map[string]string)}
+}
+
+// mounts returns the volume mounts for a container's compose volumes,
+// adding pod volumes for sources not seen before
+func (s *sharedVolumes) mounts(specs []string) ([]VolumeMount, error) {
+	volumes := make([]compose.VolumeConfig, 0, len(specs))
+	for _, spec := range specs {
+		volume, err := compose.ParseVolume(spec)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, volume)
+	}
+	return s.volumeMounts(volumes), nil
+}
+
+// serviceMounts returns the volume mounts for a service's volumes
+func (s *sharedVolumes) serviceMounts(serviceVolumes []compose.VolumeMount) []VolumeMount {
+	volumes := make([]compose.VolumeConfig, 0, len(serviceVolumes))
+	for _, volume := range serviceVolumes {
+		volumes = append(volumes, volume.VolumeConfig)
+	}
+	return s.volumeMounts(volumes)
+}
+
+func (s *sharedVolumes) volumeMounts(volumes []compose.VolumeConfig) []VolumeMount {
+	var mounts []VolumeMount
+	for _, volume := range volumes {
+		key := volume.Type + ":" + volume.Source
+		name, seen := s.names[key]
+		if !seen || volume.Source == "" {
+			podVolume := Volume{}
+			switch {
+			case volume.Source == "":
+				name = fmt.Sprintf("empty-dir-%d", len(s.volumes))
+				podVolume.EmptyDir = &struct{}{}
+			case volume.Type == "bind":
+				name = fmt.Sprintf("host-path-%d", len(s.volumes))
+				podVolume.HostPath = &HostPath{Path: volume.Source}
+			default:
+				name = resourceName(volume.Source)
+				podVolume.PersistentVolumeClaim = &PersistentVolumeClaim{ClaimName: name}
+			}
+			podVolume.Name = name
+			s.volumes = append(s.volumes, podVolume)
+			s.names[key] = name
+		}
+
+		mounts = append(mounts, VolumeMount{Name: name, MountPath: volume.Target, ReadOnly: volume.ReadOnly})
+	}
+	return mounts
+}
+
+// convertInitContainers maps a service's init containers to Kubernetes init
+// containers, which likewise run to completion in order before the service
+func convertInitContainers(service *compose.Service, volumes *sharedVolumes) ([]Container, error) {
+	var containers []Container
+	for _, init := range service.InitContainers {
+		mounts, err := volumes.mounts(init.Volumes)
+		if err != nil {
+			return nil, fmt.Errorf("init container %s: %w", init.Name, err)
+		}
+		c := Container{
+			Name:         resourceName(init.Name),
+			Image:        init.Image,
+			Command:      init.Entrypoint,
+			Args:         init.Command,
+			WorkingDir:   init.WorkingDir,
+			Env:          envVars(init.Environment),
+			VolumeMounts: mounts,
+		}
+		if init.Resources != nil {
+			c.Resources = &ResourceRequirements{
+				Limits:   resourceList(init.Resources.Limits),
+				Requests: resourceList(init.Resources.Requests),
+			}
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
+
+// postContainerLifecycle turns a service's post containers into lifecycle
+// hooks of its container: on_success ones into postStart and on_failure
+// ones into preStop. Their volumes are mounted into the service container
+// so the commands find the same files. Post containers without a command
+// have nothing to run there and are skipped.
+func postContainerLifecycle(service *compose.Service, volumes *sharedVolumes) (*Lifecycle, []VolumeMount, error) {
+	var onSuccess, onFailure [][]string
+	var mounts []VolumeMount
+	for _, post := range service.PostContainers {
+		command := append(append([]string(nil), post.Entrypoint...), post.Command...)
+		if len(command) == 0 || !(post.OnSuccess || post.OnFailure) {
+			continue
+		}
+		postMounts, err := volumes.mounts(post.Volumes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("post container %s: %w", post.Name, err)
+		}
+		mounts = append(mounts, postMounts...)
+		if post.OnSuccess {
+			onSuccess = append(onSuccess, command)
+		}
+		if post.OnFailure {
+			onFailure = append(onFailure, command)
+		}
+	}
+
+	if len(onSuccess) == 0 && len(onFailure) == 0 {
+		return nil, nil, nil
+	}
+	return &Lifecycle{PostStart: lifecycleHandler(onSuccess), PreStop: lifecycleHandler(onFailure)}, mounts, nil
+}
+
+// lifecycleHandler runs commands in order, through sh when there is more
+// than one since a handler takes a single command
+func lifecycleHandler(commands [][]string) *LifecycleHandler {
+	switch len(commands) {
+	case 0:
+		return nil
+	case 1:
+		return &LifecycleHandler{Exec: ExecAction{Command: commands[0]}}
+	}
+
+	script := make([]string, 0, len(commands))
+	for _, command := range commands {
+		words := make([]string, 0, len(command))
+		for _, word := range command {
+			words = append(words, compose.ShellQuote(word))
+		}
+		script = append(script, strings.Join(words, " "))
+	}
+	return &LifecycleHandler{Exec: ExecAction{Command: []string{"sh", "-c", strings.Join(script, " && ")}}}
+}
+
+// envVars converts an environment map to env entries in name order
+func envVars(environment map[string]string) []EnvVar {
+	names := make([]string, 0, len(environment))
+	for k := range environment {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var env []EnvVar
+	for _, k := range names {
+		env = append(env, EnvVar{Name: k, Value: environment[k]})
+	}
+	return env
+}
+
+// commentLifecycles explains the post container limitation above every
+// lifecycle key in an encoded manifest
+func commentLifecycles(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "lifecycle" && node.Content[i+1].Kind == yaml.MappingNode {
+				node.Content[i].HeadComment = lifecycleComment
+			}
+		}
+	}
+	for _, child := range node.Content {
+		commentLifecycles(child)
+	}
+}
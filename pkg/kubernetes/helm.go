@@ -0,0 +1,158 @@
+package kubernetes
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"gopkg.in/yaml.v3"
+)
+
+// chartVersion is the version of every generated chart
+const chartVersion = "0.1.0"
+
+//go:embed helm/*.yaml
+var helmTemplates embed.FS
+
+// Chart.yaml of a generated chart
+type chartMetadata struct {
+	APIVersion   string            `yaml:"apiVersion"`
+	Name         string            `yaml:"name"`
+	Version      string            `yaml:"version"`
+	Description  string            `yaml:"description"`
+	Type         string            `yaml:"type"`
+	Dependencies []chartDependency `yaml:"dependencies,omitempty"`
+}
+
+type chartDependency struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version,omitempty"`
+	Repository string `yaml:"repository"`
+}
+
+// serviceValues are the generated values of one service, which the chart
+// templates render
+type serviceValues struct {
+	Image     string                `yaml:"image"`
+	Replicas  int                   `yaml:"replicas"`
+	Command   []string              `yaml:"command,omitempty"`
+	Args      []string              `yaml:"args,omitempty"`
+	Env       map[string]string     `yaml:"env,omitempty"`
+	Ports     []ServicePort         `yaml:"ports,omitempty"`
+	Resources *ResourceRequirements `yaml:"resources,omitempty"`
+}
+
+// HelmChart generates a Helm chart for the project and returns its files
+// keyed by path within the chart directory: Chart.yaml, values.yaml and
+// templates rendering a Deployment per service and a Service per service
+// with ports. Each service's values live under services.<name>, with its
+// cloud_native.helm.values merged over the generated ones. Services with
+// a cloud_native.helm.repository add their chart as a dependency.
+func HelmChart(composeFile *compose.ComposeFile, project string) (map[string][]byte, error) {
+	names := make([]string, 0, len(composeFile.Services))
+	for name := range composeFile.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	chart := chartMetadata{
+		APIVersion:  "v2",
+		Name:        resourceName(project),
+		Version:     chartVersion,
+		Description: fmt.Sprintf("Helm chart for the %s compose project", project),
+		Type:        "application",
+	}
+	services := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		service := composeFile.Services[name]
+
+		var k8s compose.KubernetesConfig
+		var helm *compose.HelmConfig
+		if service.CloudNative != nil {
+			if service.CloudNative.Kubernetes != nil {
+				k8s = *service.CloudNative.Kubernetes
+			}
+			helm = service.CloudNative.Helm
+		}
+
+		_, ports, err := convertPorts(service.Ports)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		values, err := toValues(serviceValues{
+			Image:     service.Image,
+			Replicas:  serviceReplicas(service),
+			Command:   service.Entrypoint,
+			Args:      service.Command,
+			Env:       service.Environment,
+			Ports:     ports,
+			Resources: serviceResources(service, k8s),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+
+		if helm != nil {
+			mergeValues(values, helm.Values)
+			if helm.Repository != "" {
+				chart.Dependencies = append(chart.Dependencies, chartDependency{
+					Name:       helm.Chart,
+					Version:    helm.Version,
+					Repository: helm.Repository,
+				})
+			}
+		}
+		services[resourceName(name)] = values
+	}
+
+	files := make(map[string][]byte)
+	var err error
+	if files["Chart.yaml"], err = yaml.Marshal(chart); err != nil {
+		return nil, fmt.Errorf("failed to marshal Chart.yaml: %w", err)
+	}
+	if files["values.yaml"], err = yaml.Marshal(map[string]interface{}{"services": services}); err != nil {
+		return nil, fmt.Errorf("failed to marshal values.yaml: %w", err)
+	}
+
+	templates, err := helmTemplates.ReadDir("helm")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range templates {
+		data, err := helmTemplates.ReadFile(path.Join("helm", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[path.Join("templates", entry.Name())] = data
+	}
+	return files, nil
+}
+
+// toValues converts v into the generic form values are merged in
+func toValues(v interface{}) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// mergeValues merges src into dst. Nested maps are merged key by key; any
+// other value in src replaces the one in dst.
+func mergeValues(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
@@ -0,0 +1,59 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"gopkg.in/yaml.v3"
+)
+
+func TestHelmChart(t *testing.T) {
+	composeFile := loadStack(t)
+	composeFile.Services["api"].CloudNative = &compose.CloudNativeConfig{
+		Helm: &compose.HelmConfig{
+			Chart:      "postgresql",
+			Repository: "https://charts.example.com",
+			Version:    "12.1.0",
+			Values:     map[string]interface{}{"replicas": 3, "env": map[string]interface{}{"LOG": "debug"}},
+		},
+	}
+
+	files, err := HelmChart(composeFile, "demo")
+	if err != nil {
+		t.Fatalf("HelmChart: %v", err)
+	}
+	for _, name := range []string{"Chart.yaml", "values.yaml", "templates/deployment.yaml", "templates/service.yaml"} {
+		if len(files[name]) == 0 {
+			t.Errorf("chart has no %s", name)
+		}
+	}
+
+	var chart chartMetadata
+	if err := yaml.Unmarshal(files["Chart.yaml"], &chart); err != nil {
+		t.Fatalf("Chart.yaml: %v", err)
+	}
+	if chart.APIVersion != "v2" {
+		t.Errorf("Chart.yaml apiVersion = %q, want v2", chart.APIVersion)
+	}
+	if chart.Name != "demo" || chart.Version != chartVersion || chart.Description == "" {
+		t.Errorf("Chart.yaml = %+v, want the demo chart at version %s", chart, chartVersion)
+	}
+	want := chartDependency{Name: "postgresql", Version: "12.1.0", Repository: "https://charts.example.com"}
+	if len(chart.Dependencies) != 1 || chart.Dependencies[0] != want {
+		t.Errorf("dependencies = %+v, want %+v", chart.Dependencies, want)
+	}
+
+	var values struct {
+		Services map[string]serviceValues
+	}
+	if err := yaml.Unmarshal(files["values.yaml"], &values); err != nil {
+		t.Fatalf("values.yaml: %v", err)
+	}
+	api, web := values.Services["api"], values.Services["web"]
+	if api.Image != "example/api:2.0" || api.Replicas != 3 || api.Env["LOG"] != "debug" {
+		t.Errorf("api values = %+v, want the helm values merged over the image", api)
+	}
+	if web.Image != "nginx:1.25" || web.Replicas != 1 || web.Resources == nil || web.Resources.Limits["memory"] != "256Mi" {
+		t.Errorf("web values = %+v, want its image, one replica and its limits", web)
+	}
+}
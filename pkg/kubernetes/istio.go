@@ -0,0 +1,60 @@
+package kubernetes
+
+import (
+	"sort"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// istioAPIVersion is the Istio networking API version emitted. v1beta1 is
+// served by every Istio release since 1.8.
+const istioAPIVersion = "networking.istio.io/v1beta1"
+
+// IstioResources generates a VirtualService for every service with a
+// cloud_native.istio.virtual_service and a DestinationRule for every
+// service with a destination_rule, in service name order. The compose
+// field is merged over a default spec addressing the service by name, and
+// the objects go in the service's cloud_native.kubernetes.namespace.
+func IstioResources(composeFile *compose.ComposeFile) []Manifest {
+	names := make([]string, 0, len(composeFile.Services))
+	for name := range composeFile.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var manifests []Manifest
+	for _, name := range names {
+		service := composeFile.Services[name]
+		if service.CloudNative == nil || service.CloudNative.Istio == nil {
+			continue
+		}
+		istio := service.CloudNative.Istio
+
+		metadata := Metadata{Name: resourceName(name)}
+		if k8s := service.CloudNative.Kubernetes; k8s != nil {
+			metadata.Namespace = k8s.Namespace
+		}
+
+		if len(istio.VirtualService) > 0 {
+			spec := map[string]interface{}{"hosts": []interface{}{metadata.Name}}
+			mergeValues(spec, istio.VirtualService)
+			manifests = append(manifests, Manifest{
+				APIVersion: istioAPIVersion,
+				Kind:       "VirtualService",
+				Metadata:   metadata,
+				Spec:       spec,
+			})
+		}
+		if len(istio.DestinationRule) > 0 {
+			spec := map[string]interface{}{"host": metadata.Name}
+			mergeValues(spec, istio.DestinationRule)
+			manifests = append(manifests, Manifest{
+				APIVersion: istioAPIVersion,
+				Kind:       "DestinationRule",
+				Metadata:   metadata,
+				Spec:       spec,
+			})
+		}
+	}
+	return manifests
+}
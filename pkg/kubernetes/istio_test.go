@@ -0,0 +1,27 @@
+package kubernetes
+
+import "testing"
+
+func TestIstioResourcesGolden(t *testing.T) {
+	manifests := IstioResources(loadStack(t))
+
+	kinds := make(map[string]bool)
+	for _, m := range manifests {
+		kinds[m.Kind] = true
+		if m.APIVersion != istioAPIVersion {
+			t.Errorf("%s apiVersion = %q, want %q", m.Kind, m.APIVersion, istioAPIVersion)
+		}
+		if m.Metadata.Namespace != "shop" {
+			t.Errorf("%s namespace = %q, want shop", m.Kind, m.Metadata.Namespace)
+		}
+	}
+	if len(manifests) != 2 || !kinds["VirtualService"] || !kinds["DestinationRule"] {
+		t.Errorf("got kinds %v, want a VirtualService and a DestinationRule", kinds)
+	}
+
+	output, err := Marshal(manifests)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	assertGolden(t, "istio", output)
+}
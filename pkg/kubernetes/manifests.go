@@ -0,0 +1,335 @@
+// Package kubernetes converts a compose file into Kubernetes manifests,
+// honoring each service's cloud_native.kubernetes settings.
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultVersion is the cluster version manifests target unless told
+// otherwise
+const DefaultVersion = "1.30"
+
+// apiVersions pins the API group version of every kind emitted, per
+// supported cluster version. The kinds generated today have been stable
+// since before 1.24, so the versions agree; kinds added later must be
+// checked against each cluster version.
+var apiVersions = map[string]map[string]string{
+	"1.24": {"Namespace": "v1", "Deployment": "apps/v1", "Service": "v1"},
+	"1.27": {"Namespace": "v1", "Deployment": "apps/v1", "Service": "v1"},
+	"1.30": {"Namespace": "v1", "Deployment": "apps/v1", "Service": "v1"},
+}
+
+// SupportedVersions lists the cluster versions accepted by Convert, sorted
+func SupportedVersions() []string {
+	versions := make([]string, 0, len(apiVersions))
+	for version := range apiVersions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Manifest is a single Kubernetes object
+type Manifest struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   Metadata    `yaml:"metadata"`
+	Spec       interface{} `yaml:"spec,omitempty"`
+}
+
+type Metadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type DeploymentSpec struct {
+	Replicas int             `yaml:"replicas"`
+	Selector LabelSelector   `yaml:"selector"`
+	Template PodTemplateSpec `yaml:"template"`
+}
+
+type LabelSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+type PodTemplateSpec struct {
+	Metadata Metadata `yaml:"metadata"`
+	Spec     PodSpec  `yaml:"spec"`
+}
+
+type PodSpec struct {
+	InitContainers []Container `yaml:"initContainers,omitempty"`
+	Containers     []Container `yaml:"containers"`
+	Volumes        []Volume    `yaml:"volumes,omitempty"`
+}
+
+type Container struct {
+	Name         string                `yaml:"name"`
+	Image        string                `yaml:"image"`
+	Command      []string              `yaml:"command,omitempty"`
+	Args         []string              `yaml:"args,omitempty"`
+	WorkingDir   string                `yaml:"workingDir,omitempty"`
+	Env          []EnvVar              `yaml:"env,omitempty"`
+	Ports        []ContainerPort       `yaml:"ports,omitempty"`
+	Resources    *ResourceRequirements `yaml:"resources,omitempty"`
+	VolumeMounts []VolumeMount         `yaml:"volumeMounts,omitempty"`
+	Lifecycle    *Lifecycle            `yaml:"lifecycle,omitempty"`
+}
+
+type EnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type ContainerPort struct {
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol"`
+}
+
+type ResourceRequirements struct {
+	Limits   map[string]string `yaml:"limits,omitempty"`
+	Requests map[string]string `yaml:"requests,omitempty"`
+}
+
+type ServiceSpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Ports    []ServicePort     `yaml:"ports"`
+}
+
+type ServicePort struct {
+	Name       string `yaml:"name"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+	Protocol   string `yaml:"protocol"`
+}
+
+// Convert generates a Namespace for every namespace the services use, then
+// a Deployment and, for services with ports, a Service per service in name
+// order, followed by the services' Istio resources. cloud_native.kubernetes labels and annotations are added to the
+// object metadata, and its resources take precedence over
+// deploy.resources. Init containers become the pod's init containers and
+// post containers lifecycle hooks of the service container.
+func Convert(composeFile *compose.ComposeFile, project, version string) ([]Manifest, error) {
+	versions, ok := apiVersions[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported Kubernetes version %q: must be one of %s", version, strings.Join(SupportedVersions(), ", "))
+	}
+
+	names := make([]string, 0, len(composeFile.Services))
+	for name := range composeFile.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var namespaces []string
+	var objects []Manifest
+	for _, name := range names {
+		service := composeFile.Services[name]
+
+		var k8s compose.KubernetesConfig
+		if service.CloudNative != nil && service.CloudNative.Kubernetes != nil {
+			k8s = *service.CloudNative.Kubernetes
+		}
+		if k8s.Namespace != "" && !slices.Contains(namespaces, k8s.Namespace) {
+			namespaces = append(namespaces, k8s.Namespace)
+		}
+
+		deployment, svc, err := convertService(project, name, service, k8s, versions)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		objects = append(objects, deployment)
+		if svc != nil {
+			objects = append(objects, *svc)
+		}
+	}
+
+	sort.Strings(namespaces)
+	manifests := make([]Manifest, 0, len(namespaces)+len(objects))
+	for _, namespace := range namespaces {
+		manifests = append(manifests, Manifest{
+			APIVersion: versions["Namespace"],
+			Kind:       "Namespace",
+			Metadata:   Metadata{Name: namespace},
+		})
+	}
+	manifests = append(manifests, objects...)
+	return append(manifests, IstioResources(composeFile)...), nil
+}
+
+func convertService(project, name string, service *compose.Service, k8s compose.KubernetesConfig, versions map[string]string) (Manifest, *Manifest, error) {
+	objectName := resourceName(name)
+	selector := map[string]string{
+		"app.kubernetes.io/name":    objectName,
+		"app.kubernetes.io/part-of": project,
+	}
+	labels := make(map[string]string, len(selector)+len(k8s.Labels))
+	for k, v := range k8s.Labels {
+		labels[k] = v
+	}
+	for k, v := range selector {
+		labels[k] = v
+	}
+	metadata := Metadata{Name: objectName, Namespace: k8s.Namespace, Labels: labels, Annotations: k8s.Annotations}
+
+	c := Container{
+		Name:       objectName,
+		Image:      service.Image,
+		Command:    service.Entrypoint,
+		Args:       service.Command,
+		WorkingDir: service.WorkingDir,
+		Env:        envVars(service.Environment),
+		Resources:  serviceResources(service, k8s),
+	}
+
+	volumes := newSharedVolumes()
+	mounts := volumes.serviceMounts(service.Volumes)
+	initContainers, err := convertInitContainers(service, volumes)
+	if err != nil {
+		return Manifest{}, nil, err
+	}
+	lifecycle, postMounts, err := postContainerLifecycle(service, volumes)
+	if err != nil {
+		return Manifest{}, nil, err
+	}
+	c.Lifecycle = lifecycle
+	for _, mount := range postMounts {
+		if !slices.ContainsFunc(mounts, func(m VolumeMount) bool { return m.MountPath == mount.MountPath }) {
+			mounts = append(mounts, mount)
+		}
+	}
+	c.VolumeMounts = mounts
+
+	containerPorts, servicePorts, err := convertPorts(service.Ports)
+	if err != nil {
+		return Manifest{}, nil, err
+	}
+	c.Ports = containerPorts
+
+	deployment := Manifest{
+		APIVersion: versions["Deployment"],
+		Kind:       "Deployment",
+		Metadata:   metadata,
+		Spec: DeploymentSpec{
+			Replicas: serviceReplicas(service),
+			Selector: LabelSelector{MatchLabels: selector},
+			Template: PodTemplateSpec{
+				Metadata: Metadata{Name: objectName, Labels: labels, Annotations: k8s.Annotations},
+				Spec:     PodSpec{InitContainers: initContainers, Containers: []Container{c}, Volumes: volumes.volumes},
+			},
+		},
+	}
+	if len(servicePorts) == 0 {
+		return deployment, nil, nil
+	}
+
+	return deployment, &Manifest{
+		APIVersion: versions["Service"],
+		Kind:       "Service",
+		Metadata:   metadata,
+		Spec:       ServiceSpec{Selector: selector, Ports: servicePorts},
+	}, nil
+}
+
+// serviceResources returns the container resources of a service:
+// cloud_native.kubernetes.resources, else deploy.resources
+func serviceResources(service *compose.Service, k8s compose.KubernetesConfig) *ResourceRequirements {
+	resources := k8s.Resources
+	if resources == nil && service.Deploy != nil {
+		resources = service.Deploy.Resources
+	}
+	if resources == nil {
+		return nil
+	}
+	return &ResourceRequirements{
+		Limits:   resourceList(resources.Limits),
+		Requests: resourceList(resources.Requests),
+	}
+}
+
+func serviceReplicas(service *compose.Service) int {
+	if service.Deploy != nil && service.Deploy.Replicas > 0 {
+		return service.Deploy.Replicas
+	}
+	return 1
+}
+
+// convertPorts maps compose ports to container ports and the matching
+// Service ports
+func convertPorts(specs []string) ([]ContainerPort, []ServicePort, error) {
+	var containerPorts []ContainerPort
+	var servicePorts []ServicePort
+	for _, spec := range specs {
+		port, err := compose.ParsePort(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		protocol := strings.ToUpper(port.Protocol)
+		containerPorts = append(containerPorts, ContainerPort{ContainerPort: port.Target, Protocol: protocol})
+
+		// Ranges and unpublished ports are exposed on the container port
+		published, err := strconv.Atoi(port.Published)
+		if err != nil {
+			published = port.Target
+		}
+		servicePorts = append(servicePorts, ServicePort{
+			Name:       fmt.Sprintf("%s-%d", strings.ToLower(protocol), published),
+			Port:       published,
+			TargetPort: port.Target,
+			Protocol:   protocol,
+		})
+	}
+	return containerPorts, servicePorts, nil
+}
+
+func resourceList(spec compose.ResourceSpec) map[string]string {
+	list := make(map[string]string)
+	if spec.CPU != "" {
+		list["cpu"] = spec.CPU
+	}
+	if spec.Memory != "" {
+		list["memory"] = spec.Memory
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+// resourceName turns a compose service name into a valid Kubernetes
+// object name
+func resourceName(name string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(name))
+}
+
+// Marshal renders manifests as a multi-document YAML stream
+func Marshal(manifests []Manifest) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, manifest := range manifests {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		var node yaml.Node
+		if err := node.Encode(manifest); err != nil {
+			return nil, fmt.Errorf("failed to marshal %s %s: %w", manifest.Kind, manifest.Metadata.Name, err)
+		}
+		commentLifecycles(&node)
+		data, err := yaml.Marshal(&node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s %s: %w", manifest.Kind, manifest.Metadata.Name, err)
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"gopkg.in/yaml.v3"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares got with testdata/<name>.golden, rewriting the
+// file instead when run with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// loadStack reads testdata/stack.yml
+func loadStack(t *testing.T) *compose.ComposeFile {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "stack.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cf compose.ComposeFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		t.Fatal(err)
+	}
+	return &cf
+}
+
+func TestConvertGolden(t *testing.T) {
+	for _, version := range SupportedVersions() {
+		t.Run(version, func(t *testing.T) {
+			manifests, err := Convert(loadStack(t), "demo", version)
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			output, err := Marshal(manifests)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			assertGolden(t, "kubernetes-"+version, output)
+		})
+	}
+}
+
+func TestConvertUnsupportedVersion(t *testing.T) {
+	if _, err := Convert(loadStack(t), "demo", "1.20"); err == nil {
+		t.Error("Convert for 1.20 succeeded, want an unsupported version error")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/neomody77/fake-compose/pkg/compose"
 	"github.com/neomody77/fake-compose/pkg/hooks"
+	"github.com/neomody77/fake-compose/pkg/template"
 )
 
 type Phase string
@@ -24,6 +25,14 @@ const (
 	PhaseStopped    Phase = "stopped"
 )
 
+// PhaseEvent reports a service entering a lifecycle phase. Err is set when
+// the service failed in that phase.
+type PhaseEvent struct {
+	Service string
+	Phase   Phase
+	Err     error
+}
+
 type ServiceState struct {
 	Name          string
 	Phase         Phase
@@ -37,19 +46,71 @@ type ServiceState struct {
 
 type Manager struct {
 	services     map[string]*ServiceState
+	loggers      map[string]*logrus.Entry
 	hookExecutor *hooks.Executor
 	mu           sync.RWMutex
 	logger       *logrus.Logger
+	events       chan<- PhaseEvent
+	// project is passed to hook templates as {{.Project}}
+	project      string
 }
 
-func NewManager(logger *logrus.Logger) *Manager {
+func NewManager(logger *logrus.Logger, project string) *Manager {
 	return &Manager{
+		project:      project,
 		services:     make(map[string]*ServiceState),
+		loggers:      make(map[string]*logrus.Entry),
 		hookExecutor: hooks.NewExecutor(logger),
 		logger:       logger,
 	}
 }
 
+// serviceLogger returns a logger tagged with the service name. When the
+// service sets log_level it gets its own level, otherwise the global logger's
+// level applies. Loggers are cached per service.
+func (m *Manager) serviceLogger(serviceName string, service *compose.Service) *logrus.Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, exists := m.loggers[serviceName]; exists {
+		return entry
+	}
+
+	logger := m.logger
+	if service != nil && service.LogLevel != "" {
+		if level, err := logrus.ParseLevel(service.LogLevel); err == nil {
+			logger = &logrus.Logger{
+				Out:          m.logger.Out,
+				Hooks:        m.logger.Hooks,
+				Formatter:    m.logger.Formatter,
+				ReportCaller: m.logger.ReportCaller,
+				Level:        level,
+				ExitFunc:     m.logger.ExitFunc,
+			}
+		}
+	}
+
+	entry := logger.WithField("service", serviceName)
+	m.loggers[serviceName] = entry
+	return entry
+}
+
+// Notify sends every phase change to events until it is called again with
+// nil. The caller must keep receiving from events meanwhile, as services
+// block until their event is taken.
+func (m *Manager) Notify(events chan<- PhaseEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = events
+}
+
+// emit sends a phase event to the Notify channel, if any. m.mu must be held.
+func (m *Manager) emit(serviceName string, phase Phase, err error) {
+	if m.events != nil {
+		m.events <- PhaseEvent{Service: serviceName, Phase: phase, Err: err}
+	}
+}
+
 func (m *Manager) StartService(ctx context.Context, serviceName string, service *compose.Service) error {
 	m.mu.Lock()
 	state := &ServiceState{
@@ -59,15 +120,18 @@ func (m *Manager) StartService(ctx context.Context, serviceName string, service
 		StartTime: time.Now(),
 	}
 	m.services[serviceName] = state
+	m.emit(serviceName, PhasePreStart, nil)
 	m.mu.Unlock()
 
+	log := m.serviceLogger(serviceName, service)
+
 	if err := m.runInitContainers(ctx, serviceName, service); err != nil {
 		return m.setError(serviceName, err)
 	}
 
 	if service.Hooks != nil && len(service.Hooks.PreStart) > 0 {
-		m.logger.Infof("Running pre-start hooks for service %s", serviceName)
-		if err := m.hookExecutor.ExecuteHooks(ctx, service.Hooks.PreStart); err != nil {
+		log.Infof("Running pre-start hooks for service %s", serviceName)
+		if err := m.hookExecutor.ExecuteHooks(ctx, service.Hooks.PreStart, service.Hooks.ListTimeout, m.templateData(serviceName, service, PhasePreStart)); err != nil {
 			return m.setError(serviceName, fmt.Errorf("pre-start hooks failed: %w", err))
 		}
 	}
@@ -77,14 +141,14 @@ func (m *Manager) StartService(ctx context.Context, serviceName string, service
 	m.updatePhase(serviceName, PhasePostStart)
 
 	if service.Hooks != nil && len(service.Hooks.PostStart) > 0 {
-		m.logger.Infof("Running post-start hooks for service %s", serviceName)
-		if err := m.hookExecutor.ExecuteHooks(ctx, service.Hooks.PostStart); err != nil {
+		log.Infof("Running post-start hooks for service %s", serviceName)
+		if err := m.hookExecutor.ExecuteHooks(ctx, service.Hooks.PostStart, service.Hooks.ListTimeout, m.templateData(serviceName, service, PhasePostStart)); err != nil {
 			return m.setError(serviceName, fmt.Errorf("post-start hooks failed: %w", err))
 		}
 	}
 
 	if err := m.runPostContainers(ctx, serviceName, service, true); err != nil {
-		m.logger.Warnf("Post containers failed for service %s: %v", serviceName, err)
+		log.Warnf("Post containers failed for service %s: %v", serviceName, err)
 	}
 
 	m.updatePhase(serviceName, PhaseRunning)
@@ -94,24 +158,28 @@ func (m *Manager) StartService(ctx context.Context, serviceName string, service
 }
 
 func (m *Manager) StopService(ctx context.Context, serviceName string, service *compose.Service) error {
-	m.mu.RLock()
+	// A service started by another process, as for `down`, is tracked
+	// from here on
+	m.mu.Lock()
 	state, exists := m.services[serviceName]
-	m.mu.RUnlock()
-
 	if !exists {
-		return fmt.Errorf("service %s not found", serviceName)
+		state = &ServiceState{Name: serviceName, Phase: PhaseRunning, Status: "Running"}
+		m.services[serviceName] = state
 	}
+	m.mu.Unlock()
 
 	if state.Phase == PhaseStopped {
 		return nil
 	}
 
+	log := m.serviceLogger(serviceName, service)
+
 	m.updatePhase(serviceName, PhasePreStop)
 
 	if service.Hooks != nil && len(service.Hooks.PreStop) > 0 {
-		m.logger.Infof("Running pre-stop hooks for service %s", serviceName)
-		if err := m.hookExecutor.ExecuteHooks(ctx, service.Hooks.PreStop); err != nil {
-			m.logger.Warnf("Pre-stop hooks failed for service %s: %v", serviceName, err)
+		log.Infof("Running pre-stop hooks for service %s", serviceName)
+		if err := m.hookExecutor.ExecuteHooks(ctx, service.Hooks.PreStop, service.Hooks.ListTimeout, m.templateData(serviceName, service, PhasePreStop)); err != nil {
+			log.Warnf("Pre-stop hooks failed for service %s: %v", serviceName, err)
 		}
 	}
 
@@ -120,20 +188,21 @@ func (m *Manager) StopService(ctx context.Context, serviceName string, service *
 	m.updatePhase(serviceName, PhasePostStop)
 
 	if service.Hooks != nil && len(service.Hooks.PostStop) > 0 {
-		m.logger.Infof("Running post-stop hooks for service %s", serviceName)
-		if err := m.hookExecutor.ExecuteHooks(ctx, service.Hooks.PostStop); err != nil {
-			m.logger.Warnf("Post-stop hooks failed for service %s: %v", serviceName, err)
+		log.Infof("Running post-stop hooks for service %s", serviceName)
+		if err := m.hookExecutor.ExecuteHooks(ctx, service.Hooks.PostStop, service.Hooks.ListTimeout, m.templateData(serviceName, service, PhasePostStop)); err != nil {
+			log.Warnf("Post-stop hooks failed for service %s: %v", serviceName, err)
 		}
 	}
 
 	if err := m.runPostContainers(ctx, serviceName, service, false); err != nil {
-		m.logger.Warnf("Post containers (on failure) failed for service %s: %v", serviceName, err)
+		log.Warnf("Post containers (on failure) failed for service %s: %v", serviceName, err)
 	}
 
 	m.mu.Lock()
 	state.Phase = PhaseStopped
 	state.Status = "Stopped"
 	state.StopTime = time.Now()
+	m.emit(serviceName, PhaseStopped, nil)
 	m.mu.Unlock()
 
 	return nil
@@ -144,16 +213,17 @@ func (m *Manager) runInitContainers(ctx context.Context, serviceName string, ser
 		return nil
 	}
 
-	m.logger.Infof("Running init containers for service %s", serviceName)
+	log := m.serviceLogger(serviceName, service)
+	log.Infof("Running init containers for service %s", serviceName)
 
 	for _, initContainer := range service.InitContainers {
-		m.logger.Infof("Starting init container %s for service %s", initContainer.Name, serviceName)
+		log.Infof("Starting init container %s for service %s", initContainer.Name, serviceName)
 		
 		if err := m.executeInitContainer(ctx, serviceName, &initContainer); err != nil {
 			return fmt.Errorf("init container %s failed: %w", initContainer.Name, err)
 		}
 		
-		m.logger.Infof("Init container %s completed successfully", initContainer.Name)
+		log.Infof("Init container %s completed successfully", initContainer.Name)
 	}
 
 	m.mu.Lock()
@@ -163,12 +233,18 @@ func (m *Manager) runInitContainers(ctx context.Context, serviceName string, ser
 	return nil
 }
 
+// templateData describes a phase of a service to hook templates
+func (m *Manager) templateData(serviceName string, service *compose.Service, phase Phase) template.Data {
+	return template.NewData(m.project, string(phase), serviceName, service)
+}
+
 func (m *Manager) runPostContainers(ctx context.Context, serviceName string, service *compose.Service, onSuccess bool) error {
 	if len(service.PostContainers) == 0 {
 		return nil
 	}
 
-	m.logger.Infof("Running post containers for service %s (onSuccess=%v)", serviceName, onSuccess)
+	log := m.serviceLogger(serviceName, service)
+	log.Infof("Running post containers for service %s (onSuccess=%v)", serviceName, onSuccess)
 
 	for _, postContainer := range service.PostContainers {
 		shouldRun := (onSuccess && postContainer.OnSuccess) || (!onSuccess && postContainer.OnFailure)
@@ -176,13 +252,13 @@ func (m *Manager) runPostContainers(ctx context.Context, serviceName string, ser
 			continue
 		}
 
-		m.logger.Infof("Starting post container %s for service %s", postContainer.Name, serviceName)
+		log.Infof("Starting post container %s for service %s", postContainer.Name, serviceName)
 		
 		if err := m.executePostContainer(ctx, serviceName, &postContainer); err != nil {
 			return fmt.Errorf("post container %s failed: %w", postContainer.Name, err)
 		}
 		
-		m.logger.Infof("Post container %s completed successfully", postContainer.Name)
+		log.Infof("Post container %s completed successfully", postContainer.Name)
 	}
 
 	m.mu.Lock()
@@ -197,15 +273,13 @@ func (m *Manager) executeInitContainer(ctx context.Context, serviceName string,
 }
 
 func (m *Manager) executePostContainer(ctx context.Context, serviceName string, container *compose.PostContainer) error {
-	if container.WaitFor != "" {
-		waitDuration, err := time.ParseDuration(container.WaitFor)
-		if err == nil {
-			m.logger.Infof("Waiting %s before starting post container %s", waitDuration, container.Name)
-			select {
-			case <-time.After(waitDuration):
-			case <-ctx.Done():
-				return ctx.Err()
-			}
+	waitDuration, _, err := container.WaitCondition()
+	if err == nil && waitDuration > 0 {
+		m.serviceLogger(serviceName, nil).Infof("Waiting %s before starting post container %s", waitDuration, container.Name)
+		select {
+		case <-time.After(waitDuration):
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 
@@ -236,6 +310,7 @@ func (m *Manager) updatePhase(serviceName string, phase Phase) {
 	if state, exists := m.services[serviceName]; exists {
 		state.Phase = phase
 		m.logger.Debugf("Service %s transitioned to phase %s", serviceName, phase)
+		m.emit(serviceName, phase, nil)
 	}
 }
 
@@ -253,6 +328,7 @@ func (m *Manager) setError(serviceName string, err error) error {
 	if state, exists := m.services[serviceName]; exists {
 		state.Error = err
 		state.Status = "Error"
+		m.emit(serviceName, state.Phase, err)
 	}
 	return err
 }
\ No newline at end of file
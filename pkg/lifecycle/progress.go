@@ -0,0 +1,104 @@
+package lifecycle
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/neomody77/fake-compose/pkg/term"
+)
+
+// Progress renders phase events like docker compose's live summary: a
+// "[+] Running 2/3" header counting the services done and a status line per
+// service, such as "Container web  Started", redrawn in place on a terminal
+type Progress struct {
+	out      io.Writer
+	services []string
+	lines    map[string]*progressLine
+	drawn    int
+}
+
+type progressLine struct {
+	status  string
+	done    bool
+	failed  bool
+	started time.Time
+	elapsed time.Duration
+}
+
+func NewProgress(out io.Writer) *Progress {
+	return &Progress{out: out, lines: make(map[string]*progressLine)}
+}
+
+// Render draws every event received until events is closed
+func (p *Progress) Render(events <-chan PhaseEvent) {
+	for event := range events {
+		p.update(event)
+		p.draw()
+	}
+}
+
+func (p *Progress) update(event PhaseEvent) {
+	line, exists := p.lines[event.Service]
+	if !exists {
+		line = &progressLine{started: time.Now()}
+		p.lines[event.Service] = line
+		p.services = append(p.services, event.Service)
+	}
+	line.status, line.done = phaseStatus(event.Phase)
+	line.failed = event.Err != nil
+	if line.failed {
+		line.status, line.done = "Error", true
+	}
+	line.elapsed = time.Since(line.started)
+}
+
+// phaseStatus returns the status docker compose shows for a phase, and
+// whether the service is done
+func phaseStatus(phase Phase) (string, bool) {
+	switch phase {
+	case PhaseRunning:
+		return "Started", true
+	case PhasePreStop, PhaseStop, PhasePostStop:
+		return "Stopping", false
+	case PhaseStopped:
+		return "Stopped", true
+	default:
+		return "Starting", false
+	}
+}
+
+// draw moves the cursor back over the previous drawing and writes the
+// header and service lines again
+func (p *Progress) draw() {
+	if p.drawn > 0 {
+		fmt.Fprintf(p.out, "\033[%dA", p.drawn)
+	}
+
+	done := 0
+	width := 0
+	for _, service := range p.services {
+		if p.lines[service].done {
+			done++
+		}
+		width = max(width, len(service))
+	}
+	fmt.Fprintf(p.out, "\033[2K[+] Running %d/%d\n", done, len(p.services))
+
+	for _, service := range p.services {
+		line := p.lines[service]
+		mark := " "
+		switch {
+		case line.failed:
+			mark = term.Color(term.Red, "✘")
+		case line.done:
+			mark = term.Color(term.Green, "✔")
+		}
+		status := line.status
+		if line.done && !line.failed {
+			status = term.Color(term.Green, status)
+		}
+		fmt.Fprintf(p.out, "\033[2K %s Container %-*s  %s  %.1fs\n", mark, width, service, status, line.elapsed.Seconds())
+	}
+	p.drawn = len(p.services) + 1
+}
@@ -0,0 +1,93 @@
+package lifecycle
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/term"
+)
+
+// cursorCodes matches the escape sequences Progress redraws with
+var cursorCodes = regexp.MustCompile(`\x1b\[[0-9]*[AK]`)
+
+// elapsedTime matches the time shown at the end of a service line
+var elapsedTime = regexp.MustCompile(`\s+[0-9.]+s$`)
+
+// renderEvents renders events and returns the last frame drawn, one line
+// per entry, with the elapsed times dropped
+func renderEvents(t *testing.T, events ...PhaseEvent) []string {
+	t.Helper()
+	term.SetMode(term.ModeNever)
+	t.Cleanup(func() { term.SetMode(term.ModeAuto) })
+
+	ch := make(chan PhaseEvent, len(events))
+	for _, event := range events {
+		ch <- event
+	}
+	close(ch)
+
+	var out bytes.Buffer
+	p := NewProgress(&out)
+	p.Render(ch)
+
+	lines := strings.Split(strings.TrimSuffix(cursorCodes.ReplaceAllString(out.String(), ""), "\n"), "\n")
+	frame := lines[len(lines)-p.drawn:]
+	for i, line := range frame {
+		frame[i] = elapsedTime.ReplaceAllString(line, "")
+	}
+	return frame
+}
+
+func TestProgressStartedPerService(t *testing.T) {
+	frame := renderEvents(t,
+		PhaseEvent{Service: "db", Phase: PhasePreStart},
+		PhaseEvent{Service: "db", Phase: PhaseStart},
+		PhaseEvent{Service: "web", Phase: PhasePreStart},
+		PhaseEvent{Service: "db", Phase: PhaseRunning},
+		PhaseEvent{Service: "web", Phase: PhasePostStart},
+		PhaseEvent{Service: "web", Phase: PhaseRunning},
+	)
+	want := []string{
+		"[+] Running 2/2",
+		" ✔ Container db   Started",
+		" ✔ Container web  Started",
+	}
+	if strings.Join(frame, "\n") != strings.Join(want, "\n") {
+		t.Errorf("final frame:\n%s\nwant:\n%s", strings.Join(frame, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestProgressInFlight(t *testing.T) {
+	frame := renderEvents(t,
+		PhaseEvent{Service: "db", Phase: PhaseRunning},
+		PhaseEvent{Service: "web", Phase: PhasePreStart},
+	)
+	want := []string{
+		"[+] Running 1/2",
+		" ✔ Container db   Started",
+		"   Container web  Starting",
+	}
+	if strings.Join(frame, "\n") != strings.Join(want, "\n") {
+		t.Errorf("final frame:\n%s\nwant:\n%s", strings.Join(frame, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestProgressStopAndError(t *testing.T) {
+	frame := renderEvents(t,
+		PhaseEvent{Service: "web", Phase: PhasePreStop},
+		PhaseEvent{Service: "web", Phase: PhaseStopped},
+		PhaseEvent{Service: "db", Phase: PhasePreStart},
+		PhaseEvent{Service: "db", Phase: PhaseStart, Err: errors.New("boom")},
+	)
+	want := []string{
+		"[+] Running 2/2",
+		" ✔ Container web  Stopped",
+		" ✘ Container db   Error",
+	}
+	if strings.Join(frame, "\n") != strings.Join(want, "\n") {
+		t.Errorf("final frame:\n%s\nwant:\n%s", strings.Join(frame, "\n"), strings.Join(want, "\n"))
+	}
+}
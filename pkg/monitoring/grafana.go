@@ -0,0 +1,102 @@
+package monitoring
+
+import (
+	"fmt"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// Dashboard is a Grafana dashboard in the JSON model Grafana imports
+type Dashboard struct {
+	Title         string     `json:"title"`
+	UID           string     `json:"uid"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Time          TimeRange  `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Templating struct {
+	List []TemplateVariable `json:"list"`
+}
+
+type TemplateVariable struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+type Panel struct {
+	ID         int        `json:"id"`
+	Type       string     `json:"type"`
+	Title      string     `json:"title"`
+	GridPos    GridPos    `json:"gridPos"`
+	Datasource Datasource `json:"datasource"`
+	Targets    []Target   `json:"targets"`
+}
+
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type Target struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+// GenerateGrafanaDashboard builds a dashboard with one panel per scraped
+// service, two panels to a row, plotting the target's `up` series. Panels
+// are titled with the service's "app" label, or its name when unset. The
+// Prometheus data source is chosen through a dashboard variable.
+func GenerateGrafanaDashboard(composeFile *compose.ComposeFile, project string, config *PrometheusConfig) *Dashboard {
+	dashboard := &Dashboard{
+		Title:         project,
+		UID:           "fake-compose-" + project,
+		SchemaVersion: 39,
+		Time:          TimeRange{From: "now-6h", To: "now"},
+		Templating: Templating{List: []TemplateVariable{
+			{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+		}},
+		Panels: []Panel{},
+	}
+
+	for _, job := range config.ScrapeConfigs {
+		for _, static := range job.StaticConfigs {
+			name := static.Labels[serviceLabel]
+			title := name
+			if app := composeFile.Services[name].Labels["app"]; app != "" {
+				title = app
+			}
+
+			i := len(dashboard.Panels)
+			dashboard.Panels = append(dashboard.Panels, Panel{
+				ID:         i + 1,
+				Type:       "timeseries",
+				Title:      title,
+				GridPos:    GridPos{H: 8, W: 12, X: (i % 2) * 12, Y: (i / 2) * 8},
+				Datasource: Datasource{Type: "prometheus", UID: "${datasource}"},
+				Targets: []Target{{
+					Expr:         fmt.Sprintf(`up{job=%q,%s=%q}`, job.JobName, serviceLabel, name),
+					LegendFormat: "{{instance}}",
+					RefID:        "A",
+				}},
+			})
+		}
+	}
+	return dashboard
+}
@@ -0,0 +1,66 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestGenerateGrafanaDashboard(t *testing.T) {
+	project := monitoredProject()
+	project.Services["worker"].Labels = map[string]string{"app": "Queue worker"}
+	config, _, err := GeneratePrometheusConfig(project, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dashboard := GenerateGrafanaDashboard(project, "shop", config)
+	if dashboard.Title != "shop" || dashboard.UID != "fake-compose-shop" {
+		t.Errorf("dashboard = %q (%s), want shop (fake-compose-shop)", dashboard.Title, dashboard.UID)
+	}
+
+	tests := []struct {
+		title string
+		x, y  int
+		expr  string
+	}{
+		{"web", 0, 0, `up{job="web",compose_service="web"}`},
+		{"api", 12, 0, `up{job="shop-9090",compose_service="api"}`},
+		{"Queue worker", 0, 8, `up{job="shop-9090",compose_service="worker"}`},
+	}
+	if len(dashboard.Panels) != len(tests) {
+		t.Fatalf("panels = %+v, want %d", dashboard.Panels, len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			panel := dashboard.Panels[i]
+			if panel.ID != i+1 || panel.Title != tt.title {
+				t.Errorf("panel %d = %d %q, want %d %q", i, panel.ID, panel.Title, i+1, tt.title)
+			}
+			if panel.GridPos != (GridPos{H: 8, W: 12, X: tt.x, Y: tt.y}) {
+				t.Errorf("grid position = %+v, want x %d y %d", panel.GridPos, tt.x, tt.y)
+			}
+			if len(panel.Targets) != 1 || panel.Targets[0].Expr != tt.expr {
+				t.Errorf("targets = %+v, want %s", panel.Targets, tt.expr)
+			}
+			if panel.Datasource.UID != "${datasource}" {
+				t.Errorf("data source = %+v, want the dashboard variable", panel.Datasource)
+			}
+		})
+	}
+}
+
+func TestGrafanaDashboardJSON(t *testing.T) {
+	dashboard := GenerateGrafanaDashboard(&compose.ComposeFile{}, "shop", &PrometheusConfig{})
+	data, err := json.Marshal(dashboard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"title":"shop"`, `"schemaVersion":39`, `"panels":[]`, `"time":{"from":"now-6h","to":"now"}`, `"type":"datasource"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("dashboard JSON %s lacks %s", data, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package monitoring generates monitoring configuration, a Prometheus
+// scrape configuration and a Grafana dashboard, from the services'
+// cloud_native.prometheus settings.
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// serviceLabel names the label that tells apart the services sharing a job
+const serviceLabel = "compose_service"
+
+// PrometheusConfig is the part of prometheus.yml generated for a project
+type PrometheusConfig struct {
+	ScrapeConfigs []ScrapeConfig `yaml:"scrape_configs"`
+}
+
+type ScrapeConfig struct {
+	JobName        string         `yaml:"job_name"`
+	ScrapeInterval string         `yaml:"scrape_interval,omitempty"`
+	StaticConfigs  []StaticConfig `yaml:"static_configs"`
+}
+
+type StaticConfig struct {
+	Targets []string          `yaml:"targets"`
+	Labels  map[string]string `yaml:"labels,omitempty"`
+}
+
+// ScrapedServices returns the names of the services with Prometheus
+// scraping configured, sorted
+func ScrapedServices(composeFile *compose.ComposeFile) []string {
+	var names []string
+	for name, service := range composeFile.Services {
+		if service.CloudNative != nil && service.CloudNative.Prometheus != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GeneratePrometheusConfig builds a scrape config per scrape port. Services
+// sharing a port are merged into one job named <project>-<port>, with a
+// static config per service; a job with a single service is named after
+// it. Targets are addressed by service name on the project network, and
+// each carries its service's labels plus compose_service. A merged job
+// uses the first scrape_interval set, in service name order; services
+// whose interval differs are reported in the returned warnings.
+func GeneratePrometheusConfig(composeFile *compose.ComposeFile, project string) (*PrometheusConfig, []string, error) {
+	byPort := make(map[int][]string)
+	for _, name := range ScrapedServices(composeFile) {
+		prometheus := composeFile.Services[name].CloudNative.Prometheus
+		if prometheus.ScrapePort < 1 || prometheus.ScrapePort > 65535 {
+			return nil, nil, fmt.Errorf("service %s: cloud_native.prometheus.scrape_port must be between 1 and 65535", name)
+		}
+		byPort[prometheus.ScrapePort] = append(byPort[prometheus.ScrapePort], name)
+	}
+
+	ports := make([]int, 0, len(byPort))
+	for port := range byPort {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	config := &PrometheusConfig{ScrapeConfigs: []ScrapeConfig{}}
+	var warnings []string
+	for _, port := range ports {
+		names := byPort[port]
+		job := ScrapeConfig{JobName: jobName(project, names, port)}
+
+		for _, name := range names {
+			prometheus := composeFile.Services[name].CloudNative.Prometheus
+			if interval := prometheus.ScrapeInterval; interval != "" {
+				if job.ScrapeInterval == "" {
+					job.ScrapeInterval = interval
+				} else if interval != job.ScrapeInterval {
+					warnings = append(warnings, fmt.Sprintf("service %s: scrape_interval %s ignored, job %s scrapes every %s", name, interval, job.JobName, job.ScrapeInterval))
+				}
+			}
+
+			labels := make(map[string]string, len(prometheus.Labels)+1)
+			for k, v := range prometheus.Labels {
+				labels[k] = v
+			}
+			labels[serviceLabel] = name
+			job.StaticConfigs = append(job.StaticConfigs, StaticConfig{
+				Targets: []string{fmt.Sprintf("%s:%d", name, port)},
+				Labels:  labels,
+			})
+		}
+		config.ScrapeConfigs = append(config.ScrapeConfigs, job)
+	}
+	return config, warnings, nil
+}
+
+// jobName returns the job name for the services scraped on port
+func jobName(project string, services []string, port int) string {
+	if len(services) == 1 {
+		return services[0]
+	}
+	return fmt.Sprintf("%s-%d", project, port)
+}
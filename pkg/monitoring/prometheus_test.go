@@ -0,0 +1,137 @@
+package monitoring
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+	"gopkg.in/yaml.v3"
+)
+
+// scraped returns a service scraped on port every interval
+func scraped(port int, interval string, labels map[string]string) *compose.Service {
+	return &compose.Service{
+		Image: "app",
+		CloudNative: &compose.CloudNativeConfig{Prometheus: &compose.PrometheusConfig{
+			ScrapePort:     port,
+			ScrapeInterval: interval,
+			Labels:         labels,
+		}},
+	}
+}
+
+func monitoredProject() *compose.ComposeFile {
+	return &compose.ComposeFile{Services: map[string]*compose.Service{
+		"api":    scraped(9090, "15s", map[string]string{"team": "core"}),
+		"worker": scraped(9090, "30s", nil),
+		"web":    scraped(8080, "", nil),
+		"db":     {Image: "postgres"},
+		"cache":  {Image: "redis", CloudNative: &compose.CloudNativeConfig{}},
+	}}
+}
+
+func TestScrapedServices(t *testing.T) {
+	tests := []struct {
+		name    string
+		project *compose.ComposeFile
+		want    []string
+	}{
+		{"scraped services in name order", monitoredProject(), []string{"api", "web", "worker"}},
+		{"no services scraped", &compose.ComposeFile{Services: map[string]*compose.Service{"db": {Image: "postgres"}}}, nil},
+		{"no services", &compose.ComposeFile{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScrapedServices(tt.project); !slices.Equal(got, tt.want) {
+				t.Errorf("ScrapedServices = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratePrometheusConfig(t *testing.T) {
+	config, warnings, err := GeneratePrometheusConfig(monitoredProject(), "shop")
+	if err != nil {
+		t.Fatalf("GeneratePrometheusConfig: %v", err)
+	}
+
+	tests := []struct {
+		job      string
+		interval string
+		targets  []string
+		services []string
+	}{
+		{"web", "", []string{"web:8080"}, []string{"web"}},
+		{"shop-9090", "15s", []string{"api:9090", "worker:9090"}, []string{"api", "worker"}},
+	}
+	if len(config.ScrapeConfigs) != len(tests) {
+		t.Fatalf("scrape configs = %+v, want %d jobs", config.ScrapeConfigs, len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.job, func(t *testing.T) {
+			job := config.ScrapeConfigs[i]
+			if job.JobName != tt.job || job.ScrapeInterval != tt.interval {
+				t.Errorf("job = %s every %q, want %s every %q", job.JobName, job.ScrapeInterval, tt.job, tt.interval)
+			}
+			var targets, services []string
+			for _, static := range job.StaticConfigs {
+				targets = append(targets, static.Targets...)
+				services = append(services, static.Labels[serviceLabel])
+			}
+			if !slices.Equal(targets, tt.targets) || !slices.Equal(services, tt.services) {
+				t.Errorf("targets %v for services %v, want %v for %v", targets, services, tt.targets, tt.services)
+			}
+		})
+	}
+
+	if api := config.ScrapeConfigs[1].StaticConfigs[0].Labels; api["team"] != "core" {
+		t.Errorf("api labels = %v, want its own labels kept", api)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "service worker: scrape_interval 30s ignored, job shop-9090 scrapes every 15s") {
+		t.Errorf("warnings = %q, want the ignored worker interval", warnings)
+	}
+}
+
+func TestGeneratePrometheusConfigInvalidPort(t *testing.T) {
+	for _, port := range []int{0, -1, 65536} {
+		project := &compose.ComposeFile{Services: map[string]*compose.Service{"api": scraped(port, "", nil)}}
+		_, _, err := GeneratePrometheusConfig(project, "shop")
+		if err == nil || !strings.Contains(err.Error(), "service api: cloud_native.prometheus.scrape_port must be between 1 and 65535") {
+			t.Errorf("port %d: GeneratePrometheusConfig = %v, want a scrape_port error", port, err)
+		}
+	}
+}
+
+func TestPrometheusConfigYAML(t *testing.T) {
+	config, _, err := GeneratePrometheusConfig(&compose.ComposeFile{Services: map[string]*compose.Service{
+		"web": scraped(8080, "10s", nil),
+	}}, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `scrape_configs:
+    - job_name: web
+      scrape_interval: 10s
+      static_configs:
+        - targets:
+            - web:8080
+          labels:
+            compose_service: web
+`
+	if string(data) != want {
+		t.Errorf("prometheus.yml =\n%s\nwant\n%s", data, want)
+	}
+
+	empty, _, err := GeneratePrometheusConfig(&compose.ComposeFile{}, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := yaml.Marshal(empty); string(data) != "scrape_configs: []\n" {
+		t.Errorf("prometheus.yml without scraped services = %q, want an empty list", data)
+	}
+}
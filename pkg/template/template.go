@@ -0,0 +1,71 @@
+// Package template renders the Go template expressions hooks and post
+// containers may use in their commands, such as {{.Service.Name}}
+package template
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+// Service describes the service a hook or post container runs for
+type Service struct {
+	Name   string
+	Image  string
+	Labels map[string]string
+}
+
+// Data is what templates can refer to. ExitCode is the exit code of the
+// service's container, set for post containers run once it has stopped.
+type Data struct {
+	Service  Service
+	Project  string
+	Phase    string
+	ExitCode int
+}
+
+// NewData describes a phase of a service for templates. service may be nil
+// for project wide hooks such as pre_deploy.
+func NewData(project, phase, serviceName string, service *compose.Service) Data {
+	data := Data{Project: project, Phase: phase}
+	data.Service.Name = serviceName
+	if service != nil {
+		data.Service.Image = service.Image
+		data.Service.Labels = service.Labels
+	}
+	return data
+}
+
+// RenderTemplate executes tmpl against data. Strings without {{ are
+// returned as they are, and a reference to a field data lacks is an error.
+func RenderTemplate(tmpl string, data interface{}) (string, error) {
+	if !strings.Contains(tmpl, "{{") {
+		return tmpl, nil
+	}
+	t, err := template.New("").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %q: %w", tmpl, err)
+	}
+	var out strings.Builder
+	if err := t.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render template %q: %w", tmpl, err)
+	}
+	return out.String(), nil
+}
+
+// RenderAll renders each of args, as for a command
+func RenderAll(args []string, data interface{}) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+	rendered := make([]string, len(args))
+	for i, arg := range args {
+		var err error
+		if rendered[i], err = RenderTemplate(arg, data); err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
@@ -0,0 +1,85 @@
+package template
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/neomody77/fake-compose/pkg/compose"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	service := &compose.Service{Image: "nginx:1.25", Labels: map[string]string{"tier": "frontend"}}
+	data := NewData("shop", "post-start", "web", service)
+	data.ExitCode = 3
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"{{.Service.Name}}", "web"},
+		{"deploy {{.Service.Name}} of {{.Project}}", "deploy web of shop"},
+		{"{{.Service.Image}}", "nginx:1.25"},
+		{`{{index .Service.Labels "tier"}}`, "frontend"},
+		{"{{.Phase}} exited {{.ExitCode}}", "post-start exited 3"},
+		{"echo ${HOME} $$", "echo ${HOME} $$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			got, err := RenderTemplate(tt.tmpl, data)
+			if err != nil {
+				t.Fatalf("RenderTemplate: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	data := NewData("shop", "pre-deploy", "", nil)
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"{{.Service.Name", "invalid template"},
+		{"{{end}}", "invalid template"},
+		{"{{.Missing}}", "failed to render template"},
+		{`{{index .Service.Labels "tier"}}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			got, err := RenderTemplate(tt.tmpl, data)
+			if tt.want == "" {
+				// A project wide hook has no labels, which renders empty
+				if err != nil || got != "" {
+					t.Errorf("RenderTemplate = %q, %v, want an empty string", got, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), tt.tmpl) {
+				t.Errorf("RenderTemplate = %q, %v, want an error containing %q and the template", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderAll(t *testing.T) {
+	data := NewData("shop", "post-start", "web", nil)
+
+	got, err := RenderAll([]string{"sh", "-c", "echo {{.Service.Name}}"}, data)
+	if err != nil {
+		t.Fatalf("RenderAll: %v", err)
+	}
+	if want := []string{"sh", "-c", "echo web"}; !slices.Equal(got, want) {
+		t.Errorf("RenderAll = %q, want %q", got, want)
+	}
+
+	if got, err := RenderAll(nil, data); got != nil || err != nil {
+		t.Errorf("RenderAll(nil) = %q, %v, want nil", got, err)
+	}
+	if _, err := RenderAll([]string{"echo", "{{.Service.Name"}, data); err == nil {
+		t.Error("RenderAll with a bad template succeeded")
+	}
+}
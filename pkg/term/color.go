@@ -0,0 +1,85 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// Mode controls whether Color emits ANSI escape sequences
+type Mode int32
+
+const (
+	// ModeAuto colors output only when stdout is a terminal and neither
+	// NO_COLOR nor TERM=dumb is set
+	ModeAuto Mode = iota
+	// ModeNever disables colors
+	ModeNever
+	// ModeAlways enables colors even when output is redirected
+	ModeAlways
+)
+
+// ANSI color codes accepted by Color
+const (
+	Red     = "31"
+	Green   = "32"
+	Yellow  = "33"
+	Blue    = "34"
+	Magenta = "35"
+	Cyan    = "36"
+)
+
+var mode atomic.Int32
+
+// ParseMode parses the value of the --ansi flag
+func ParseMode(value string) (Mode, error) {
+	switch value {
+	case "auto", "":
+		return ModeAuto, nil
+	case "never":
+		return ModeNever, nil
+	case "always":
+		return ModeAlways, nil
+	default:
+		return ModeAuto, fmt.Errorf("invalid ansi mode %q: must be never, always or auto", value)
+	}
+}
+
+// SetMode sets the process-wide color mode
+func SetMode(m Mode) {
+	mode.Store(int32(m))
+}
+
+// Enabled reports whether output should be colored under the current mode
+func Enabled() bool {
+	switch Mode(mode.Load()) {
+	case ModeNever:
+		return false
+	case ModeAlways:
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(os.Stdout)
+}
+
+// IsTerminal reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Color wraps s in the escape sequence for code, or returns s unchanged
+// when colors are disabled
+func Color(code, s string) string {
+	if !Enabled() {
+		return s
+	}
+	return "\033[" + code + "m" + s + "\033[0m"
+}
+
+// Colorf formats according to format and colors the result
+func Colorf(code, format string, args ...any) string {
+	return Color(code, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,45 @@
+package term
+
+// RingBuffer keeps the most recent values pushed to it, up to a fixed
+// capacity. Once full, each push overwrites the oldest value.
+type RingBuffer[T any] struct {
+	items []T
+	start int
+	size  int
+}
+
+// NewRingBuffer creates a ring buffer holding at most capacity values
+func NewRingBuffer[T any](capacity int) *RingBuffer[T] {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &RingBuffer[T]{items: make([]T, capacity)}
+}
+
+// Push adds a value, evicting the oldest one when the buffer is full
+func (r *RingBuffer[T]) Push(value T) {
+	if len(r.items) == 0 {
+		return
+	}
+	if r.size < len(r.items) {
+		r.items[(r.start+r.size)%len(r.items)] = value
+		r.size++
+		return
+	}
+	r.items[r.start] = value
+	r.start = (r.start + 1) % len(r.items)
+}
+
+// Len returns the number of values currently held
+func (r *RingBuffer[T]) Len() int {
+	return r.size
+}
+
+// Items returns the held values from oldest to newest
+func (r *RingBuffer[T]) Items() []T {
+	out := make([]T, r.size)
+	for i := 0; i < r.size; i++ {
+		out[i] = r.items[(r.start+i)%len(r.items)]
+	}
+	return out
+}